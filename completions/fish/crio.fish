@@ -2,7 +2,7 @@
 
 function __fish_crio_no_subcommand --description 'Test if there has been any subcommand yet'
     for i in (commandline -opc)
-        if contains -- $i complete completion help h man markdown md config version wipe status config c containers container cs s info i help h
+        if contains -- $i complete completion help h man markdown md config version wipe status checkpoints checkpoint cp config c containers container cs s debug dbg images image img info i pods pod p pulls pull help h
             return 1
         end
     end
@@ -21,6 +21,8 @@ complete -c crio -n '__fish_crio_no_subcommand' -f -l blockio-config-file -r -d
 complete -c crio -n '__fish_crio_no_subcommand' -f -l blockio-reload -d 'Reload blockio-config-file and rescan blockio devices in the system before applying blockio parameters.'
 complete -c crio -n '__fish_crio_no_subcommand' -f -l cdi-spec-dirs -r -d 'Directories to scan for CDI Spec files.'
 complete -c crio -n '__fish_crio_no_subcommand' -f -l cgroup-manager -r -d 'cgroup manager (cgroupfs or systemd).'
+complete -c crio -n '__fish_crio_no_subcommand' -f -l checkpoint-image-compression -r -d 'Compression of checkpoint images, either \'gzip\' or \'zstd\'.'
+complete -c crio -n '__fish_crio_no_subcommand' -f -l checkpoint-images -d 'Store container checkpoints additionally as OCI images in the local storage. The checkpoint archive is still written to the requested location, if any.'
 complete -c crio -n '__fish_crio_no_subcommand' -l clean-shutdown-file -r -d 'Location for CRI-O to lay down the clean shutdown file. It indicates whether we\'ve had time to sync changes to disk before shutting down. If not found, crio wipe will clear the storage directory.'
 complete -c crio -n '__fish_crio_no_subcommand' -l cni-config-dir -r -d 'CNI configuration files directory.'
 complete -c crio -n '__fish_crio_no_subcommand' -f -l cni-default-network -r -d 'Name of the default CNI network to select. If not set or "", then CRI-O will pick-up the first one found in --cni-config-dir.'
@@ -84,6 +86,11 @@ complete -c crio -n '__fish_crio_no_subcommand' -f -l hooks-dir -r -d 'Set the O
     Kubernetes configuration are considered. Bind mounts that CRI-O
     inserts by default (e.g. \'/dev/shm\') are not considered.'
 complete -c crio -n '__fish_crio_no_subcommand' -f -l hostnetwork-disable-selinux -d 'Determines whether SELinux should be disabled within a pod when it is running in the host network namespace.'
+complete -c crio -n '__fish_crio_no_subcommand' -f -l image-gc-high-threshold-percent -r -d 'Image filesystem usage in percent, which triggers the removal of the least recently used images. The value 0 disables this policy.'
+complete -c crio -n '__fish_crio_no_subcommand' -f -l image-gc-interval -r -d 'Interval of the built-in image garbage collection, which removes images not used by any container according to the image-gc-* policies. Pinned images and the pause image are never removed. An empty value disables the garbage collection.'
+complete -c crio -n '__fish_crio_no_subcommand' -f -l image-gc-low-threshold-percent -r -d 'Image filesystem usage in percent the garbage collection aims for, once the image-gc-high-threshold-percent has been reached.'
+complete -c crio -n '__fish_crio_no_subcommand' -f -l image-gc-max-images -r -d 'Maximum number of images kept in the storage, where the least recently used images are removed first. The value 0 disables this policy.'
+complete -c crio -n '__fish_crio_no_subcommand' -f -l image-gc-max-unused-age -r -d 'Remove images which have not been used by any container for longer than this duration. An empty value disables this policy.'
 complete -c crio -n '__fish_crio_no_subcommand' -f -l image-volumes -r -d 'Image volume handling (\'mkdir\', \'bind\', or \'ignore\')
     1. mkdir: A directory is created inside the container root filesystem for
        the volumes.
@@ -92,6 +99,7 @@ complete -c crio -n '__fish_crio_no_subcommand' -f -l image-volumes -r -d 'Image
 	3. ignore: All volumes are just ignored and no action is taken.'
 complete -c crio -n '__fish_crio_no_subcommand' -l imagestore -r -d 'Store newly pulled images in the specified path, rather than the path provided by --root.'
 complete -c crio -n '__fish_crio_no_subcommand' -f -l included-pod-metrics -r -d 'A list of pod metrics to include. Specify the names of the metrics to include in this list.'
+complete -c crio -n '__fish_crio_no_subcommand' -f -l incremental-checkpoint-chain-length -r -d 'Maximum number of incremental checkpoints of a running container, before a new chain with a full memory dump gets started. Set to 0 to disable incremental checkpoints.'
 complete -c crio -n '__fish_crio_no_subcommand' -f -l infra-ctr-cpuset -r -d 'CPU set to run infra containers, if not specified CRI-O will use all online CPUs to run infra containers.'
 complete -c crio -n '__fish_crio_no_subcommand' -f -l insecure-registry -r -d 'Enable insecure registry communication, i.e., enable un-encrypted and/or untrusted communication.
     1. List of insecure registries can contain an element with CIDR notation to
@@ -115,6 +123,7 @@ complete -c crio -n '__fish_crio_no_subcommand' -f -l log-format -r -d 'Set the
 complete -c crio -n '__fish_crio_no_subcommand' -f -l log-journald -d 'Log to systemd journal (journald) in addition to kubernetes log file.'
 complete -c crio -n '__fish_crio_no_subcommand' -f -l log-level -s l -r -d 'Log messages above specified level: trace, debug, info, warn, error, fatal or panic.'
 complete -c crio -n '__fish_crio_no_subcommand' -f -l log-size-max -r -d 'Maximum log size in bytes for a container. If it is positive, it must be >= 8192 to match/exceed conmon read buffer. This option is deprecated. The Kubelet flag \'--container-log-max-size\' should be used instead.'
+complete -c crio -n '__fish_crio_no_subcommand' -f -l max-concurrent-pulls-per-registry -r -d 'Maximum number of image pulls running in parallel for a single registry. Further pulls are queued, where the pause image and pinned images are pulled first. The value 0 does not limit the number of parallel pulls.'
 complete -c crio -n '__fish_crio_no_subcommand' -f -l metrics-cert -r -d 'Certificate for the secure metrics endpoint.'
 complete -c crio -n '__fish_crio_no_subcommand' -f -l metrics-collectors -r -d 'Enabled metrics collectors.'
 complete -c crio -n '__fish_crio_no_subcommand' -f -l metrics-host -r -d 'Host for the metrics endpoint.'
@@ -123,9 +132,11 @@ complete -c crio -n '__fish_crio_no_subcommand' -f -l metrics-port -r -d 'Port f
 complete -c crio -n '__fish_crio_no_subcommand' -f -l metrics-socket -r -d 'Socket for the metrics endpoint.'
 complete -c crio -n '__fish_crio_no_subcommand' -f -l minimum-mappable-gid -r -d 'Specify the lowest host GID which can be specified in mappings for a pod that will be run as a UID other than 0. This option is deprecated, and will be replaced with Kubernetes user namespace support (KEP-127) in the future.'
 complete -c crio -n '__fish_crio_no_subcommand' -f -l minimum-mappable-uid -r -d 'Specify the lowest host UID which can be specified in mappings for a pod that will be run as a UID other than 0. This option is deprecated, and will be replaced with Kubernetes user namespace support (KEP-127) in the future.'
+complete -c crio -n '__fish_crio_no_subcommand' -l namespaced-registries-dir -r -d 'Path to the root directory for namespaced registries configurations and credentials, containing a <NAMESPACE>/registries.conf and <NAMESPACE>/auth.json per pod namespace. Must be an absolute path.'
 complete -c crio -n '__fish_crio_no_subcommand' -f -l namespaces-dir -r -d 'The directory where the state of the managed namespaces gets tracked. Only used when manage-ns-lifecycle is true.'
 complete -c crio -n '__fish_crio_no_subcommand' -f -l no-pivot -d 'If true, the runtime will not use \'pivot_root\', but instead use \'MS_MOVE\'.'
 complete -c crio -n '__fish_crio_no_subcommand' -f -l nri-disable-connections -r -d 'Disable connections from externally started NRI plugins. (default: false)'
+complete -c crio -n '__fish_crio_no_subcommand' -f -l nri-dry-run -d 'Record and log the container adjustments and updates of NRI plugins without applying them. (default: false)'
 complete -c crio -n '__fish_crio_no_subcommand' -f -l nri-listen -r -d 'Socket to listen on for externally started NRI plugins to connect to. (default: "/var/run/nri/nri.sock")'
 complete -c crio -n '__fish_crio_no_subcommand' -f -l nri-plugin-config-dir -r -d 'Directory to scan for configuration of pre-installed NRI plugins. (default: "/etc/nri/conf.d")'
 complete -c crio -n '__fish_crio_no_subcommand' -f -l nri-plugin-dir -r -d 'Directory to scan for pre-installed NRI plugins to start automatically. (default: "/opt/nri/plugins")'
@@ -137,14 +148,18 @@ complete -c crio -n '__fish_crio_no_subcommand' -l pause-image-auth-file -r -d '
 complete -c crio -n '__fish_crio_no_subcommand' -f -l pids-limit -r -d 'Maximum number of processes allowed in a container. This option is deprecated. The Kubelet flag \'--pod-pids-limit\' should be used instead.'
 complete -c crio -n '__fish_crio_no_subcommand' -f -l pinned-images -r -d 'A list of images that will be excluded from the kubelet\'s garbage collection.'
 complete -c crio -n '__fish_crio_no_subcommand' -l pinns-path -r -d 'The path to find the pinns binary, which is needed to manage namespace lifecycle. Will be searched for in $PATH if empty.'
+complete -c crio -n '__fish_crio_no_subcommand' -f -l pre-pull-pinned-images -d 'Pull the pinned images without glob or keyword pattern and the pause image in the background after startup.'
 complete -c crio -n '__fish_crio_no_subcommand' -f -l profile -d 'Enable pprof remote profiler on localhost:6060.'
 complete -c crio -n '__fish_crio_no_subcommand' -f -l profile-cpu -r -d 'Write a pprof CPU profile to the provided path.'
 complete -c crio -n '__fish_crio_no_subcommand' -f -l profile-mem -r -d 'Write a pprof memory profile to the provided path.'
 complete -c crio -n '__fish_crio_no_subcommand' -f -l profile-port -r -d 'Port for the pprof profiler.'
+complete -c crio -n '__fish_crio_no_subcommand' -f -l pull-progress-timeout -r -d 'Cancel an image pull if it did not make any progress for this duration. An empty value disables the timeout.'
 complete -c crio -n '__fish_crio_no_subcommand' -f -l rdt-config-file -r -d 'Path to the RDT configuration file for configuring the resctrl pseudo-filesystem.'
 complete -c crio -n '__fish_crio_no_subcommand' -f -l read-only -d 'Setup all unprivileged containers to run as read-only. Automatically mounts the containers\' tmpfs on \'/run\', \'/tmp\' and \'/var/tmp\'.'
+complete -c crio -n '__fish_crio_no_subcommand' -f -l resumable-pulls -d 'Keep the downloaded layers of failed or cancelled image pulls in the storage root and reuse or resume them on subsequent pulls of the same image.'
 complete -c crio -n '__fish_crio_no_subcommand' -l root -s r -r -d 'The CRI-O root directory.'
 complete -c crio -n '__fish_crio_no_subcommand' -l runroot -r -d 'The CRI-O state directory.'
+complete -c crio -n '__fish_crio_no_subcommand' -f -l runtime-health-check-interval -r -d 'Interval of the health checks of the runtime handlers. New sandboxes are rejected for unhealthy runtime handlers. Set to an empty value to disable the health checks.'
 complete -c crio -n '__fish_crio_no_subcommand' -f -l runtimes -r -d 'OCI runtimes, format is \'runtime_name:runtime_path:runtime_root:runtime_type:privileged_without_host_devices:runtime_config_path:container_min_memory\'.'
 complete -c crio -n '__fish_crio_no_subcommand' -l seccomp-profile -r -d 'Path to the seccomp.json profile to be used as the runtime\'s default. If not specified, then the internal default seccomp profile will be used.'
 complete -c crio -n '__fish_crio_no_subcommand' -f -l selinux -d 'Enable selinux support. This option is deprecated, and be interpreted from whether SELinux is enabled on the host in the future.'
@@ -211,12 +226,39 @@ complete -c crio -n '__fish_seen_subcommand_from wipe' -f -l force -s f -d 'forc
 complete -c crio -n '__fish_seen_subcommand_from status' -f -l help -s h -d 'show help'
 complete -r -c crio -n '__fish_crio_no_subcommand' -a 'status' -d 'Display status information'
 complete -c crio -n '__fish_seen_subcommand_from status' -l socket -s s -r -d 'absolute path to the unix socket'
+complete -c crio -n '__fish_seen_subcommand_from checkpoints checkpoint cp' -f -l help -s h -d 'show help'
+complete -r -c crio -n '__fish_seen_subcommand_from status' -a 'checkpoints checkpoint cp' -d 'Display information about all checkpoint images or remove one of them.'
+complete -c crio -n '__fish_seen_subcommand_from checkpoints checkpoint cp' -f -l delete -s d -r -d 'remove the checkpoint with the provided ID, ID prefix or name'
 complete -c crio -n '__fish_seen_subcommand_from config c' -f -l help -s h -d 'show help'
 complete -r -c crio -n '__fish_seen_subcommand_from status' -a 'config c' -d 'Show the configuration of CRI-O as a TOML string.'
 complete -c crio -n '__fish_seen_subcommand_from containers container cs s' -f -l help -s h -d 'show help'
-complete -r -c crio -n '__fish_seen_subcommand_from status' -a 'containers container cs s' -d 'Display detailed information about the provided container ID.'
+complete -r -c crio -n '__fish_seen_subcommand_from status' -a 'containers container cs s' -d 'Display detailed information about the provided container ID or list the containers.'
 complete -c crio -n '__fish_seen_subcommand_from containers container cs s' -f -l id -s i -r -d 'the container ID'
+complete -c crio -n '__fish_seen_subcommand_from containers container cs s' -f -l all -s a -d 'show all containers instead of only the running ones'
+complete -c crio -n '__fish_seen_subcommand_from containers container cs s' -f -l state -r -d 'only show the containers in the state, like \'created\', \'running\', \'paused\' or \'stopped\''
+complete -c crio -n '__fish_seen_subcommand_from containers container cs s' -f -l label -s l -r -d 'only show the entries matching the label selector, for example \'app=nginx,tier!=frontend\''
+complete -c crio -n '__fish_seen_subcommand_from containers container cs s' -f -l runtime-handler -s r -r -d 'only show the entries using the runtime handler'
+complete -c crio -n '__fish_seen_subcommand_from debug dbg' -f -l help -s h -d 'show help'
+complete -r -c crio -n '__fish_seen_subcommand_from status' -a 'debug dbg' -d 'Display the debug settings, like the log level, or change them for a limited duration.'
+complete -c crio -n '__fish_seen_subcommand_from debug dbg' -f -l log-level -r -d 'temporarily change the log level, like \'debug\' or \'trace\''
+complete -c crio -n '__fish_seen_subcommand_from debug dbg' -f -l log-filter -r -d 'temporarily change the regular expression to filter the log messages'
+complete -c crio -n '__fish_seen_subcommand_from debug dbg' -f -l tracing-sampling-rate -r -d 'temporarily change the number of samples to collect per million spans, requires enabled tracing'
+complete -c crio -n '__fish_seen_subcommand_from debug dbg' -f -l duration -s d -r -d 'the duration after which the changed settings revert automatically'
+complete -c crio -n '__fish_seen_subcommand_from debug dbg' -f -l reset -d 'revert all changed settings immediately'
+complete -c crio -n '__fish_seen_subcommand_from images image img' -f -l help -s h -d 'show help'
+complete -r -c crio -n '__fish_seen_subcommand_from status' -a 'images image img' -d 'Display information about all images, like their last usage.'
 complete -c crio -n '__fish_seen_subcommand_from info i' -f -l help -s h -d 'show help'
 complete -r -c crio -n '__fish_seen_subcommand_from status' -a 'info i' -d 'Retrieve generic information about CRI-O, such as the cgroup and storage driver.'
+complete -c crio -n '__fish_seen_subcommand_from pods pod p' -f -l help -s h -d 'show help'
+complete -r -c crio -n '__fish_seen_subcommand_from status' -a 'pods pod p' -d 'Display detailed information about the provided pod ID or list all pods, or checkpoint and restore a pod.'
+complete -c crio -n '__fish_seen_subcommand_from pods pod p' -f -l id -s i -r -d 'the pod ID or ID prefix'
+complete -c crio -n '__fish_seen_subcommand_from pods pod p' -f -l state -r -d 'only show the pods in the state, either \'ready\' or \'notready\''
+complete -c crio -n '__fish_seen_subcommand_from pods pod p' -l checkpoint -r -d 'checkpoint the running containers of the pod with the provided ID into a single archive at the path'
+complete -c crio -n '__fish_seen_subcommand_from pods pod p' -f -l leave-running -d 'keep the containers running after checkpointing them'
+complete -c crio -n '__fish_seen_subcommand_from pods pod p' -l restore -r -d 'recreate a pod and its containers from the pod checkpoint archive at the path, the checkpointed pod has to be removed before'
+complete -c crio -n '__fish_seen_subcommand_from pods pod p' -f -l label -s l -r -d 'only show the entries matching the label selector, for example \'app=nginx,tier!=frontend\''
+complete -c crio -n '__fish_seen_subcommand_from pods pod p' -f -l runtime-handler -s r -r -d 'only show the entries using the runtime handler'
+complete -c crio -n '__fish_seen_subcommand_from pulls pull' -f -l help -s h -d 'show help'
+complete -r -c crio -n '__fish_seen_subcommand_from status' -a 'pulls pull' -d 'Display the progress of the image pulls in progress and the pods waiting on them.'
 complete -c crio -n '__fish_seen_subcommand_from help h' -f -l help -s h -d 'show help'
 complete -r -c crio -n '__fish_crio_no_subcommand' -a 'help h' -d 'Shows a list of commands or help for one command'
@@ -6,6 +6,9 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
@@ -22,7 +25,19 @@ const (
 type CrioClient interface {
 	DaemonInfo() (types.CrioInfo, error)
 	ContainerInfo(string) (*types.ContainerInfo, error)
+	ContainersInfo(*types.InspectFilter) ([]types.ContainerInfo, error)
+	PodInfo(string) (*types.PodInfo, error)
+	PodsInfo(*types.InspectFilter) ([]types.PodInfo, error)
+	CheckpointPod(id, path string, leaveRunning bool) (*types.PodCheckpointInfo, error)
+	RestorePod(path string) (*types.PodInfo, error)
 	ConfigInfo() (string, error)
+	ImagesInfo() ([]types.ImageInfo, error)
+	CheckpointsInfo() ([]types.CheckpointInfo, error)
+	DeleteCheckpoint(string) error
+	PullsInfo() ([]types.PullInfo, error)
+	DebugInfo() (types.DebugInfo, error)
+	OverrideDebugSetting(setting, value string, duration time.Duration) error
+	RevertDebugSetting(setting string) error
 }
 
 type crioClientImpl struct {
@@ -58,7 +73,11 @@ func New(crioSocketPath string) (CrioClient, error) {
 }
 
 func (c *crioClientImpl) getRequest(path string) (*http.Request, error) {
-	req, err := http.NewRequest(http.MethodGet, path, http.NoBody)
+	return c.request(http.MethodGet, path)
+}
+
+func (c *crioClientImpl) request(method, path string) (*http.Request, error) {
+	req, err := http.NewRequest(method, path, http.NoBody)
 	if err != nil {
 		return nil, err
 	}
@@ -106,6 +125,156 @@ func (c *crioClientImpl) ContainerInfo(id string) (*types.ContainerInfo, error)
 	return &cInfo, nil
 }
 
+// ContainersInfo returns the information about all containers matching the
+// filter by querying the cri-o containers endpoint.
+func (c *crioClientImpl) ContainersInfo(filter *types.InspectFilter) ([]types.ContainerInfo, error) {
+	req, err := c.getRequest(server.InspectContainersEndpoint + filterQuery(filter))
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp); err != nil {
+		return nil, err
+	}
+	containers := []types.ContainerInfo{}
+	if err := json.NewDecoder(resp.Body).Decode(&containers); err != nil {
+		return nil, err
+	}
+	return containers, nil
+}
+
+// PodInfo returns the information about the pod referenced by its ID or ID
+// prefix by querying the cri-o pods endpoint.
+func (c *crioClientImpl) PodInfo(id string) (*types.PodInfo, error) {
+	req, err := c.getRequest(server.InspectPodsEndpoint + "/" + url.PathEscape(id))
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp); err != nil {
+		return nil, err
+	}
+	pInfo := types.PodInfo{}
+	if err := json.NewDecoder(resp.Body).Decode(&pInfo); err != nil {
+		return nil, err
+	}
+	return &pInfo, nil
+}
+
+// PodsInfo returns the information about all pods matching the filter by
+// querying the cri-o pods endpoint.
+func (c *crioClientImpl) PodsInfo(filter *types.InspectFilter) ([]types.PodInfo, error) {
+	req, err := c.getRequest(server.InspectPodsEndpoint + filterQuery(filter))
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp); err != nil {
+		return nil, err
+	}
+	pods := []types.PodInfo{}
+	if err := json.NewDecoder(resp.Body).Decode(&pods); err != nil {
+		return nil, err
+	}
+	return pods, nil
+}
+
+// CheckpointPod checkpoints all running containers of the pod referenced by
+// its ID or ID prefix into a single archive at the absolute path on the host
+// of the cri-o daemon.
+func (c *crioClientImpl) CheckpointPod(id, path string, leaveRunning bool) (*types.PodCheckpointInfo, error) {
+	query := url.Values{}
+	query.Set(server.InspectCheckpointPath, path)
+	query.Set(server.InspectCheckpointLeaveRunning, strconv.FormatBool(leaveRunning))
+	req, err := c.request(http.MethodPost, server.InspectPodCheckpointEndpoint+"/"+url.PathEscape(id)+"?"+query.Encode())
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp); err != nil {
+		return nil, fmt.Errorf("checkpoint pod %s: %w", id, err)
+	}
+	info := types.PodCheckpointInfo{}
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// RestorePod recreates the pod and its containers from the pod checkpoint
+// archive at the absolute path on the host of the cri-o daemon.
+func (c *crioClientImpl) RestorePod(path string) (*types.PodInfo, error) {
+	query := url.Values{}
+	query.Set(server.InspectCheckpointPath, path)
+	req, err := c.request(http.MethodPost, server.InspectPodRestoreEndpoint+"?"+query.Encode())
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp); err != nil {
+		return nil, fmt.Errorf("restore pod from %s: %w", path, err)
+	}
+	pInfo := types.PodInfo{}
+	if err := json.NewDecoder(resp.Body).Decode(&pInfo); err != nil {
+		return nil, err
+	}
+	return &pInfo, nil
+}
+
+// filterQuery returns the encoded query of the filter including the leading
+// question mark, or an empty string if nothing has to be filtered.
+func filterQuery(filter *types.InspectFilter) string {
+	if filter == nil {
+		return ""
+	}
+	query := url.Values{}
+	if filter.State != "" {
+		query.Set(server.InspectFilterState, filter.State)
+	}
+	if filter.LabelSelector != "" {
+		query.Set(server.InspectFilterLabel, filter.LabelSelector)
+	}
+	if filter.RuntimeHandler != "" {
+		query.Set(server.InspectFilterRuntimeHandler, filter.RuntimeHandler)
+	}
+	if len(query) == 0 {
+		return ""
+	}
+	return "?" + query.Encode()
+}
+
+// checkResponse converts a non successful response into an error.
+func checkResponse(resp *http.Response) error {
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(body)))
+}
+
 // ConfigInfo returns current config as TOML string
 func (c *crioClientImpl) ConfigInfo() (string, error) {
 	req, err := c.getRequest(server.InspectConfigEndpoint)
@@ -123,3 +292,140 @@ func (c *crioClientImpl) ConfigInfo() (string, error) {
 	}
 	return string(body), nil
 }
+
+// ImagesInfo returns the information about all images by querying the cri-o
+// images endpoint.
+func (c *crioClientImpl) ImagesInfo() ([]types.ImageInfo, error) {
+	req, err := c.getRequest(server.InspectImagesEndpoint)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	images := []types.ImageInfo{}
+	if err := json.NewDecoder(resp.Body).Decode(&images); err != nil {
+		return nil, err
+	}
+	return images, nil
+}
+
+// CheckpointsInfo returns the information about all checkpoint images by
+// querying the cri-o checkpoints endpoint.
+func (c *crioClientImpl) CheckpointsInfo() ([]types.CheckpointInfo, error) {
+	req, err := c.getRequest(server.InspectCheckpointsEndpoint)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	checkpoints := []types.CheckpointInfo{}
+	if err := json.NewDecoder(resp.Body).Decode(&checkpoints); err != nil {
+		return nil, err
+	}
+	return checkpoints, nil
+}
+
+// PullsInfo returns the progress of all image pulls in progress by querying
+// the cri-o pulls endpoint.
+func (c *crioClientImpl) PullsInfo() ([]types.PullInfo, error) {
+	req, err := c.getRequest(server.InspectPullsEndpoint)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	pulls := []types.PullInfo{}
+	if err := json.NewDecoder(resp.Body).Decode(&pulls); err != nil {
+		return nil, err
+	}
+	return pulls, nil
+}
+
+// DeleteCheckpoint removes the checkpoint image referenced by its ID, ID
+// prefix or name.
+func (c *crioClientImpl) DeleteCheckpoint(id string) error {
+	req, err := c.request(http.MethodDelete, server.InspectCheckpointsEndpoint+"/"+url.PathEscape(id))
+	if err != nil {
+		return err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("delete checkpoint %s: %s", id, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// DebugInfo returns the current debug settings by querying the cri-o debug
+// endpoint.
+func (c *crioClientImpl) DebugInfo() (types.DebugInfo, error) {
+	info := types.DebugInfo{}
+	req, err := c.getRequest(server.InspectDebugEndpoint)
+	if err != nil {
+		return info, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return info, err
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp); err != nil {
+		return info, err
+	}
+	err = json.NewDecoder(resp.Body).Decode(&info)
+	return info, err
+}
+
+// OverrideDebugSetting changes a debug setting, like the log level, to the
+// value. The server reverts the setting once the duration elapsed.
+func (c *crioClientImpl) OverrideDebugSetting(setting, value string, duration time.Duration) error {
+	query := url.Values{}
+	query.Set(server.InspectDebugValue, value)
+	query.Set(server.InspectDebugDuration, duration.String())
+	req, err := c.request(http.MethodPut, server.InspectDebugEndpoint+"/"+url.PathEscape(setting)+"?"+query.Encode())
+	if err != nil {
+		return err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp); err != nil {
+		return fmt.Errorf("override debug setting %s: %w", setting, err)
+	}
+	return nil
+}
+
+// RevertDebugSetting immediately reverts an overridden debug setting.
+func (c *crioClientImpl) RevertDebugSetting(setting string) error {
+	req, err := c.request(http.MethodDelete, server.InspectDebugEndpoint+"/"+url.PathEscape(setting))
+	if err != nil {
+		return err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp); err != nil {
+		return fmt.Errorf("revert debug setting %s: %w", setting, err)
+	}
+	return nil
+}
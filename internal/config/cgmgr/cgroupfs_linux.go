@@ -97,11 +97,7 @@ func (m *CgroupfsManager) ContainerCgroupStats(sbParent, containerID string) (*C
 	if err != nil {
 		return nil, err
 	}
-	stats, err := cgMgr.GetStats()
-	if err != nil {
-		return nil, err
-	}
-	return libctrStatsToCgroupStats(stats), nil
+	return cgroupStats(cgMgr)
 }
 
 // RemoveContainerCgManager removes the cgroup manager for the container
@@ -161,11 +157,7 @@ func (m *CgroupfsManager) SandboxCgroupStats(sbParent, sbID string) (*CgroupStat
 	if err != nil {
 		return nil, err
 	}
-	stats, err := cgMgr.GetStats()
-	if err != nil {
-		return nil, err
-	}
-	return libctrStatsToCgroupStats(stats), nil
+	return cgroupStats(cgMgr)
 }
 
 // RemoveSandboxCgroupManager removes the cgroup manager for the sandbox
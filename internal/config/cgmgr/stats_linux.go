@@ -1,15 +1,23 @@
 package cgmgr
 
 import (
+	"errors"
+	"fmt"
 	"math"
+	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/cri-o/cri-o/internal/config/node"
 	libctrcgroups "github.com/opencontainers/runc/libcontainer/cgroups"
+	"github.com/opencontainers/runc/libcontainer/cgroups/fscommon"
 	"github.com/opencontainers/runc/libcontainer/cgroups/manager"
 	cgcfgs "github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
 )
 
 // This is a universal stats object to be used across different runtime implementations.
@@ -20,6 +28,9 @@ type CgroupStats struct {
 	Memory     *MemoryStats
 	CPU        *CPUStats
 	Pid        *PidsStats
+	DiskIO     *DiskIOStats
+	Hugetlb    map[string]HugetlbStats
+	TaskStates *TaskStateStats
 	SystemNano int64
 }
 
@@ -41,6 +52,8 @@ type MemoryStats struct {
 	FileMapped uint64
 	// The number of memory usage hits limits. For cgroup v1 only.
 	Failcnt uint64
+	// Memory pressure stall information. For cgroup v2 only.
+	PSI *PSIStats
 }
 
 type CPUStats struct {
@@ -56,6 +69,8 @@ type CPUStats struct {
 	ThrottledPeriods uint64
 	// Aggregate time the container was throttled for in nanoseconds.
 	ThrottledTime uint64
+	// CPU pressure stall information. For cgroup v2 only.
+	PSI *PSIStats
 }
 
 type PidsStats struct {
@@ -63,6 +78,74 @@ type PidsStats struct {
 	Limit   uint64
 }
 
+// HugetlbStats are the statistics of the hugepages of a single page size.
+// CgroupStats contains them by page size, for example "2MB".
+type HugetlbStats struct {
+	// Current usage in bytes.
+	Usage uint64
+	// Maximum recorded usage in bytes. For cgroup v1 only.
+	MaxUsage uint64
+	// Usage limit in bytes, math.MaxUint64 if unlimited.
+	Limit uint64
+	// Number of allocation failures due to the limit.
+	Failcnt uint64
+}
+
+// TaskStateStats are the number of tasks (threads) of the cgroup per state.
+type TaskStateStats struct {
+	Running         uint64
+	Sleeping        uint64
+	Stopped         uint64
+	Uninterruptible uint64
+}
+
+// DiskIOStats are the block I/O statistics of the cgroup, using the layout
+// of the cgroup v1 blkio controller for both cgroup versions.
+type DiskIOStats struct {
+	// Number of bytes transferred to and from the device.
+	ServiceBytes []BlkioEntry
+	// Number of I/O operations completed.
+	Serviced []BlkioEntry
+	// Number of I/O operations merged. For cgroup v1 only.
+	Merged []BlkioEntry
+	// Time spent servicing I/O operations in nanoseconds. For cgroup v1 only.
+	ServiceTime []BlkioEntry
+	// Time spent waiting for service in nanoseconds. For cgroup v1 only.
+	WaitTime []BlkioEntry
+	// Number of I/O operations queued. For cgroup v1 only.
+	Queued []BlkioEntry
+	// Device access time in milliseconds. For cgroup v1 only.
+	Time []BlkioEntry
+	// I/O pressure stall information. For cgroup v2 only.
+	PSI *PSIStats
+}
+
+// BlkioEntry is a single statistic of a block device, optionally for a
+// specific operation like "Read" or "Write".
+type BlkioEntry struct {
+	Major uint64
+	Minor uint64
+	Op    string
+	Value uint64
+}
+
+// PSIStats is the pressure stall information of a resource. Some is the share
+// of time in which at least one task was stalled on the resource, Full the
+// share of time in which all non-idle tasks were stalled simultaneously.
+type PSIStats struct {
+	Some PSIData `json:"some"`
+	Full PSIData `json:"full"`
+}
+
+// PSIData contains the stall averages as percentages over the last 10, 60 and
+// 300 seconds as well as the total stall time in microseconds.
+type PSIData struct {
+	Avg10  float64 `json:"avg10"`
+	Avg60  float64 `json:"avg60"`
+	Avg300 float64 `json:"avg300"`
+	Total  uint64  `json:"total"`
+}
+
 // MemLimitGivenSystem limit returns the memory limit for a given cgroup
 // If the configured memory limit is larger than the total memory on the sys, the
 // physical system memory size is returned
@@ -109,6 +192,24 @@ func libctrManager(cgroup, parent string, systemd bool) (libctrcgroups.Manager,
 	return manager.New(cg)
 }
 
+// cgroupStats returns the statistics of the cgroup managed by cgMgr,
+// including the pressure stall information on cgroup v2.
+func cgroupStats(cgMgr libctrcgroups.Manager) (*CgroupStats, error) {
+	stats, err := cgMgr.GetStats()
+	if err != nil {
+		return nil, err
+	}
+	res := libctrStatsToCgroupStats(stats)
+	setHugetlbLimits(cgMgr, res.Hugetlb)
+	if node.CgroupIsV2() {
+		dir := cgMgr.Path("")
+		res.CPU.PSI = readPSI(filepath.Join(dir, "cpu.pressure"))
+		res.Memory.PSI = readPSI(filepath.Join(dir, "memory.pressure"))
+		res.DiskIO.PSI = readPSI(filepath.Join(dir, "io.pressure"))
+	}
+	return res, nil
+}
+
 func libctrStatsToCgroupStats(stats *libctrcgroups.Stats) *CgroupStats {
 	return &CgroupStats{
 		Memory: cgroupMemStats(&stats.MemoryStats),
@@ -117,6 +218,8 @@ func libctrStatsToCgroupStats(stats *libctrcgroups.Stats) *CgroupStats {
 			Current: stats.PidsStats.Current,
 			Limit:   stats.PidsStats.Limit,
 		},
+		DiskIO:     cgroupDiskIOStats(&stats.BlkioStats),
+		Hugetlb:    cgroupHugetlbStats(stats.HugetlbStats),
 		SystemNano: time.Now().UnixNano(),
 	}
 }
@@ -201,6 +304,186 @@ func cgroupCPUStats(cpuStats *libctrcgroups.CpuStats) *CPUStats {
 	}
 }
 
+func cgroupDiskIOStats(blkioStats *libctrcgroups.BlkioStats) *DiskIOStats {
+	return &DiskIOStats{
+		ServiceBytes: blkioEntries(blkioStats.IoServiceBytesRecursive),
+		Serviced:     blkioEntries(blkioStats.IoServicedRecursive),
+		Merged:       blkioEntries(blkioStats.IoMergedRecursive),
+		ServiceTime:  blkioEntries(blkioStats.IoServiceTimeRecursive),
+		WaitTime:     blkioEntries(blkioStats.IoWaitTimeRecursive),
+		Queued:       blkioEntries(blkioStats.IoQueuedRecursive),
+		Time:         blkioEntries(blkioStats.IoTimeRecursive),
+	}
+}
+
+func blkioEntries(entries []libctrcgroups.BlkioStatEntry) []BlkioEntry {
+	res := make([]BlkioEntry, 0, len(entries))
+	for _, e := range entries {
+		res = append(res, BlkioEntry{
+			Major: e.Major,
+			Minor: e.Minor,
+			Op:    e.Op,
+			Value: e.Value,
+		})
+	}
+	return res
+}
+
+func cgroupHugetlbStats(hugetlbStats map[string]libctrcgroups.HugetlbStats) map[string]HugetlbStats {
+	res := make(map[string]HugetlbStats, len(hugetlbStats))
+	for pageSize, stats := range hugetlbStats {
+		res[pageSize] = HugetlbStats{
+			Usage:    stats.Usage,
+			MaxUsage: stats.MaxUsage,
+			Limit:    math.MaxUint64,
+			Failcnt:  stats.Failcnt,
+		}
+	}
+	return res
+}
+
+// setHugetlbLimits sets the limits of the hugetlb statistics, which are not
+// part of the libcontainer stats. The limit stays unlimited if it cannot be
+// read, for example because the hugetlb controller is not enabled.
+func setHugetlbLimits(cgMgr libctrcgroups.Manager, hugetlbStats map[string]HugetlbStats) {
+	dir, suffix := cgMgr.Path("hugetlb"), "limit_in_bytes"
+	if node.CgroupIsV2() {
+		dir, suffix = cgMgr.Path(""), "max"
+	}
+	if dir == "" {
+		return
+	}
+	for pageSize, stats := range hugetlbStats {
+		limit, err := fscommon.GetCgroupParamUint(dir, "hugetlb."+pageSize+"."+suffix)
+		if err != nil {
+			continue
+		}
+		stats.Limit = limit
+		hugetlbStats[pageSize] = stats
+	}
+}
+
+// CgroupTaskStates counts the tasks of the processes in the cgroup managed by
+// cgMgr by their state. They are not part of the cgroup statistics, because
+// reading the state of every task is only worth it if it gets reported.
+func CgroupTaskStates(cgMgr libctrcgroups.Manager) (*TaskStateStats, error) {
+	pids, err := cgMgr.GetAllPids()
+	if err != nil {
+		return nil, err
+	}
+	return taskStates(pids), nil
+}
+
+// taskStates counts the tasks of the provided processes by their state, as
+// reported in /proc/<pid>/task/<tid>/stat. Processes and tasks which exit
+// while being counted are skipped.
+func taskStates(pids []int) *TaskStateStats {
+	res := &TaskStateStats{}
+	for _, pid := range pids {
+		taskDir := filepath.Join("/proc", strconv.Itoa(pid), "task")
+		tasks, err := os.ReadDir(taskDir)
+		if err != nil {
+			continue
+		}
+		for _, task := range tasks {
+			stat, err := os.ReadFile(filepath.Join(taskDir, task.Name(), "stat"))
+			if err != nil {
+				continue
+			}
+			switch taskState(string(stat)) {
+			case 'R':
+				res.Running++
+			case 'S':
+				res.Sleeping++
+			case 'D':
+				res.Uninterruptible++
+			case 'T', 't':
+				res.Stopped++
+			}
+		}
+	}
+	return res
+}
+
+// taskState returns the state of a task from the content of its stat file,
+// which is the first field after the command name in parentheses.
+func taskState(stat string) byte {
+	i := strings.LastIndexByte(stat, ')')
+	if i < 0 || i+2 >= len(stat) {
+		return 0
+	}
+	return stat[i+2]
+}
+
+// readPSI returns the pressure stall information of the pressure file, or nil
+// if it cannot be read. The remaining statistics are still reported then.
+func readPSI(path string) *PSIStats {
+	psi, err := readPSIFile(path)
+	if err != nil {
+		logrus.Debugf("Unable to get pressure stall information from %s: %v", path, err)
+		return nil
+	}
+	return psi
+}
+
+// readPSIFile parses a cgroup v2 pressure file like cpu.pressure. A nil
+// PSIStats is returned if the file does not exist, which is the case if the
+// kernel does not support PSI or it got disabled.
+func readPSIFile(path string) (*PSIStats, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) || errors.Is(err, unix.EOPNOTSUPP) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read pressure file: %w", err)
+	}
+	return parsePSI(string(content))
+}
+
+// parsePSI parses the content of a pressure file in the format:
+//
+//	some avg10=0.00 avg60=0.00 avg300=0.00 total=0
+//	full avg10=0.00 avg60=0.00 avg300=0.00 total=0
+func parsePSI(content string) (*PSIStats, error) {
+	psi := &PSIStats{}
+	for _, line := range strings.Split(strings.TrimSpace(content), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		var data *PSIData
+		switch fields[0] {
+		case "some":
+			data = &psi.Some
+		case "full":
+			data = &psi.Full
+		default:
+			return nil, fmt.Errorf("invalid pressure line %q", line)
+		}
+		for _, field := range fields[1:] {
+			key, value, ok := strings.Cut(field, "=")
+			if !ok {
+				return nil, fmt.Errorf("invalid pressure field %q", field)
+			}
+			var err error
+			switch key {
+			case "avg10":
+				data.Avg10, err = strconv.ParseFloat(value, 64)
+			case "avg60":
+				data.Avg60, err = strconv.ParseFloat(value, 64)
+			case "avg300":
+				data.Avg300, err = strconv.ParseFloat(value, 64)
+			case "total":
+				data.Total, err = strconv.ParseUint(value, 10, 64)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("parse pressure field %q: %w", field, err)
+			}
+		}
+	}
+	return psi, nil
+}
+
 func isMemoryUnlimited(v uint64) bool {
 	// if the container has unlimited memory, the value of memory.max (in cgroupv2) will be "max"
 	// or the value of memory.limit_in_bytes (in cgroupv1) will be -1
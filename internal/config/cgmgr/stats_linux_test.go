@@ -0,0 +1,243 @@
+package cgmgr
+
+import (
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	libctrcgroups "github.com/opencontainers/runc/libcontainer/cgroups"
+)
+
+var _ = Describe("Stats", func() {
+	Describe("parsePSI", func() {
+		DescribeTable("should parse the pressure file",
+			func(content string, expected *PSIStats) {
+				// When
+				psi, err := parsePSI(content)
+
+				// Then
+				Expect(err).ToNot(HaveOccurred())
+				Expect(psi).To(Equal(expected))
+			},
+			Entry("with some and full lines",
+				"some avg10=1.50 avg60=0.75 avg300=0.25 total=12345\n"+
+					"full avg10=0.50 avg60=0.25 avg300=0.10 total=6789\n",
+				&PSIStats{
+					Some: PSIData{Avg10: 1.5, Avg60: 0.75, Avg300: 0.25, Total: 12345},
+					Full: PSIData{Avg10: 0.5, Avg60: 0.25, Avg300: 0.1, Total: 6789},
+				},
+			),
+			Entry("without full line",
+				"some avg10=2.00 avg60=1.00 avg300=0.50 total=100\n",
+				&PSIStats{
+					Some: PSIData{Avg10: 2, Avg60: 1, Avg300: 0.5, Total: 100},
+				},
+			),
+			Entry("with unknown fields",
+				"some avg10=0.00 avg60=0.00 avg600=1.00 total=1\n",
+				&PSIStats{Some: PSIData{Total: 1}},
+			),
+			Entry("with empty content", "", &PSIStats{}),
+		)
+
+		DescribeTable("should fail on malformed content",
+			func(content string) {
+				// When
+				psi, err := parsePSI(content)
+
+				// Then
+				Expect(err).To(HaveOccurred())
+				Expect(psi).To(BeNil())
+			},
+			Entry("with unknown line", "partial avg10=0.00 avg60=0.00 avg300=0.00 total=0\n"),
+			Entry("with field without value", "some avg10 avg60=0.00 avg300=0.00 total=0\n"),
+			Entry("with invalid average", "some avg10=abc avg60=0.00 avg300=0.00 total=0\n"),
+			Entry("with negative total", "full avg10=0.00 avg60=0.00 avg300=0.00 total=-1\n"),
+		)
+	})
+
+	Describe("readPSI", func() {
+		It("should return nil without pressure file", func() {
+			// Given
+			path := filepath.Join(GinkgoT().TempDir(), "cpu.pressure")
+
+			// When
+			psi := readPSI(path)
+
+			// Then
+			Expect(psi).To(BeNil())
+		})
+
+		It("should return nil if the pressure file cannot be read", func() {
+			// Given
+			// reading a directory fails with EISDIR
+			path := GinkgoT().TempDir()
+
+			// When
+			psi := readPSI(path)
+
+			// Then
+			Expect(psi).To(BeNil())
+		})
+	})
+
+	Describe("cgroupDiskIOStats", func() {
+		It("should convert the recursive blkio statistics", func() {
+			// Given
+			blkio := &libctrcgroups.BlkioStats{
+				IoServiceBytesRecursive: []libctrcgroups.BlkioStatEntry{
+					{Major: 8, Minor: 0, Op: "Read", Value: 4096},
+					{Major: 8, Minor: 0, Op: "Write", Value: 1024},
+				},
+				IoServicedRecursive: []libctrcgroups.BlkioStatEntry{
+					{Major: 8, Minor: 0, Op: "Read", Value: 2},
+				},
+				IoTimeRecursive: []libctrcgroups.BlkioStatEntry{
+					{Major: 8, Minor: 16, Value: 10},
+				},
+			}
+
+			// When
+			stats := cgroupDiskIOStats(blkio)
+
+			// Then
+			Expect(stats.ServiceBytes).To(Equal([]BlkioEntry{
+				{Major: 8, Minor: 0, Op: "Read", Value: 4096},
+				{Major: 8, Minor: 0, Op: "Write", Value: 1024},
+			}))
+			Expect(stats.Serviced).To(Equal([]BlkioEntry{{Major: 8, Minor: 0, Op: "Read", Value: 2}}))
+			Expect(stats.Time).To(Equal([]BlkioEntry{{Major: 8, Minor: 16, Value: 10}}))
+			Expect(stats.Merged).To(BeEmpty())
+			Expect(stats.Queued).To(BeEmpty())
+		})
+	})
+
+	Describe("taskState", func() {
+		DescribeTable("should return the state of the stat file",
+			func(stat string, expected byte) {
+				// When
+				state := taskState(stat)
+
+				// Then
+				Expect(state).To(Equal(expected))
+			},
+			Entry("with running task", "1234 (bash) R 1 1234 1234 0 -1", byte('R')),
+			Entry("with sleeping task", "1 (systemd) S 0 1 1 0 -1", byte('S')),
+			Entry("with parentheses in the command", "42 (a) (b)) D 1 42 42 0 -1", byte('D')),
+			Entry("with spaces in the command", "42 (my cmd) T 1 42 42 0 -1", byte('T')),
+			Entry("without command", "42 R 1", byte(0)),
+			Entry("without state", "42 (cmd)", byte(0)),
+			Entry("with empty stat", "", byte(0)),
+		)
+	})
+
+	Describe("CgroupTaskStates", func() {
+		It("should count the tasks of the cgroup", func() {
+			// Given
+			cgMgr := &fakeCgroupManager{pids: []int{os.Getpid()}}
+
+			// When
+			states, err := CgroupTaskStates(cgMgr)
+
+			// Then
+			Expect(err).ToNot(HaveOccurred())
+			// the test process has at least one task, which is either
+			// running or sleeping
+			Expect(states.Running + states.Sleeping).To(BeNumerically(">=", 1))
+		})
+
+		It("should fail if the processes cannot be listed", func() {
+			// Given
+			cgMgr := &fakeCgroupManager{pidsErr: errors.New("no cgroup")}
+
+			// When
+			states, err := CgroupTaskStates(cgMgr)
+
+			// Then
+			Expect(err).To(HaveOccurred())
+			Expect(states).To(BeNil())
+		})
+	})
+
+	Describe("setHugetlbLimits", func() {
+		var (
+			dir   string
+			cgMgr *fakeCgroupManager
+		)
+
+		BeforeEach(func() {
+			// read the limits from a directory which is not a cgroupfs
+			libctrcgroups.TestMode = true
+			DeferCleanup(func() { libctrcgroups.TestMode = false })
+			dir = GinkgoT().TempDir()
+			cgMgr = &fakeCgroupManager{path: dir}
+			// the limit file names of both cgroup versions
+			for _, file := range []string{"hugetlb.2MB.limit_in_bytes", "hugetlb.2MB.max"} {
+				Expect(os.WriteFile(filepath.Join(dir, file), []byte("4194304\n"), 0o644)).To(Succeed())
+			}
+		})
+
+		It("should set the limits of the page sizes", func() {
+			// Given
+			hugetlbStats := map[string]HugetlbStats{
+				"2MB": {Usage: 2097152, Limit: math.MaxUint64},
+			}
+
+			// When
+			setHugetlbLimits(cgMgr, hugetlbStats)
+
+			// Then
+			Expect(hugetlbStats).To(Equal(map[string]HugetlbStats{
+				"2MB": {Usage: 2097152, Limit: 4194304},
+			}))
+		})
+
+		It("should keep the limit if it cannot be read", func() {
+			// Given
+			hugetlbStats := map[string]HugetlbStats{
+				"1GB": {Usage: 0, Limit: math.MaxUint64},
+			}
+
+			// When
+			setHugetlbLimits(cgMgr, hugetlbStats)
+
+			// Then
+			Expect(hugetlbStats["1GB"].Limit).To(BeEquivalentTo(uint64(math.MaxUint64)))
+		})
+
+		It("should keep the limits without hugetlb cgroup", func() {
+			// Given
+			cgMgr.path = ""
+			hugetlbStats := map[string]HugetlbStats{
+				"2MB": {Usage: 2097152, Limit: math.MaxUint64},
+			}
+
+			// When
+			setHugetlbLimits(cgMgr, hugetlbStats)
+
+			// Then
+			Expect(hugetlbStats["2MB"].Limit).To(BeEquivalentTo(uint64(math.MaxUint64)))
+		})
+	})
+})
+
+// fakeCgroupManager is a cgroup manager with the same path for all
+// controllers, which lists the provided processes.
+type fakeCgroupManager struct {
+	libctrcgroups.Manager
+
+	path    string
+	pids    []int
+	pidsErr error
+}
+
+func (f *fakeCgroupManager) Path(string) string {
+	return f.path
+}
+
+func (f *fakeCgroupManager) GetAllPids() ([]int, error) {
+	return f.pids, f.pidsErr
+}
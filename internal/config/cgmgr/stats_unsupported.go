@@ -11,6 +11,9 @@ type CgroupStats struct {
 	Memory     *MemoryStats
 	CPU        *CPUStats
 	Pid        *PidsStats
+	DiskIO     *DiskIOStats
+	Hugetlb    map[string]HugetlbStats
+	TaskStates *TaskStateStats
 	SystemNano int64
 }
 
@@ -30,6 +33,7 @@ type MemoryStats struct {
 	SwapLimit       uint64
 	FileMapped      uint64
 	Failcnt         uint64
+	PSI             *PSIStats
 }
 
 type CPUStats struct {
@@ -43,6 +47,7 @@ type CPUStats struct {
 	ThrottledPeriods uint64
 	// Aggregate time the container was throttled for in nanoseconds.
 	ThrottledTime uint64
+	PSI           *PSIStats
 }
 
 type PidsStats struct {
@@ -50,6 +55,74 @@ type PidsStats struct {
 	Limit   uint64
 }
 
+// HugetlbStats are the statistics of the hugepages of a single page size.
+// CgroupStats contains them by page size, for example "2MB".
+type HugetlbStats struct {
+	// Current usage in bytes.
+	Usage uint64
+	// Maximum recorded usage in bytes. For cgroup v1 only.
+	MaxUsage uint64
+	// Usage limit in bytes, math.MaxUint64 if unlimited.
+	Limit uint64
+	// Number of allocation failures due to the limit.
+	Failcnt uint64
+}
+
+// TaskStateStats are the number of tasks (threads) of the cgroup per state.
+type TaskStateStats struct {
+	Running         uint64
+	Sleeping        uint64
+	Stopped         uint64
+	Uninterruptible uint64
+}
+
+// DiskIOStats are the block I/O statistics of the cgroup, using the layout
+// of the cgroup v1 blkio controller for both cgroup versions.
+type DiskIOStats struct {
+	// Number of bytes transferred to and from the device.
+	ServiceBytes []BlkioEntry
+	// Number of I/O operations completed.
+	Serviced []BlkioEntry
+	// Number of I/O operations merged. For cgroup v1 only.
+	Merged []BlkioEntry
+	// Time spent servicing I/O operations in nanoseconds. For cgroup v1 only.
+	ServiceTime []BlkioEntry
+	// Time spent waiting for service in nanoseconds. For cgroup v1 only.
+	WaitTime []BlkioEntry
+	// Number of I/O operations queued. For cgroup v1 only.
+	Queued []BlkioEntry
+	// Device access time in milliseconds. For cgroup v1 only.
+	Time []BlkioEntry
+	// I/O pressure stall information. For cgroup v2 only.
+	PSI *PSIStats
+}
+
+// BlkioEntry is a single statistic of a block device, optionally for a
+// specific operation like "Read" or "Write".
+type BlkioEntry struct {
+	Major uint64
+	Minor uint64
+	Op    string
+	Value uint64
+}
+
+// PSIStats is the pressure stall information of a resource. Some is the share
+// of time in which at least one task was stalled on the resource, Full the
+// share of time in which all non-idle tasks were stalled simultaneously.
+type PSIStats struct {
+	Some PSIData `json:"some"`
+	Full PSIData `json:"full"`
+}
+
+// PSIData contains the stall averages as percentages over the last 10, 60 and
+// 300 seconds as well as the total stall time in microseconds.
+type PSIData struct {
+	Avg10  float64 `json:"avg10"`
+	Avg60  float64 `json:"avg60"`
+	Avg300 float64 `json:"avg300"`
+	Total  uint64  `json:"total"`
+}
+
 // MemLimitGivenSystem limit returns the memory limit for a given cgroup
 // If the configured memory limit is larger than the total memory on the sys, the
 // physical system memory size is returned
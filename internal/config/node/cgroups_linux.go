@@ -22,6 +22,7 @@ var (
 	cgroupControllerErr  error
 	cgroupHasHugetlb     bool
 	cgroupHasPid         bool
+	cgroupControllers    []string
 
 	cgroupIsV2Err error
 )
@@ -75,6 +76,12 @@ func CgroupHasPid() bool {
 	return cgroupHasPid
 }
 
+// CgroupControllers returns the available cgroup controllers of the node
+func CgroupControllers() []string {
+	checkRelevantControllers()
+	return cgroupControllers
+}
+
 func checkRelevantControllers() {
 	cgroupControllerOnce.Do(func() {
 		relevantControllers := []struct {
@@ -95,6 +102,7 @@ func checkRelevantControllers() {
 			cgroupControllerErr = err
 			return
 		}
+		cgroupControllers = ctrls
 		for _, toCheck := range relevantControllers {
 			for _, ctrl := range ctrls {
 				if ctrl == toCheck.name {
@@ -17,3 +17,13 @@ func CgroupHasHugetlb() bool {
 func CgroupHasPid() bool {
 	return false
 }
+
+// CgroupControllers returns the available cgroup controllers of the node
+func CgroupControllers() []string {
+	return nil
+}
+
+// CgroupIsV2 returns whether the node uses cgroup v2
+func CgroupIsV2() bool {
+	return false
+}
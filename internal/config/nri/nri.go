@@ -1,6 +1,7 @@
 package nri
 
 import (
+	"fmt"
 	"time"
 
 	nri "github.com/containerd/nri/pkg/adaptation"
@@ -17,7 +18,33 @@ type Config struct {
 	PluginRegistrationTimeout time.Duration `toml:"nri_plugin_registration_timeout"`
 	PluginRequestTimeout      time.Duration `toml:"nri_plugin_request_timeout"`
 	DisableConnections        bool          `toml:"nri_disable_connections"`
-	withTracing               bool
+	// DryRun records and logs the container adjustments and updates of
+	// the plugins without applying them.
+	DryRun bool `toml:"nri_dry_run"`
+	// BuiltinPlugins are the plugins compiled into CRI-O to enable, by
+	// their name.
+	BuiltinPlugins map[string]*BuiltinPlugin `toml:"builtin_plugins"`
+	withTracing    bool
+}
+
+// Positions of a builtin plugin relative to the external plugins.
+const (
+	BuiltinPluginPositionBefore = "before"
+	BuiltinPluginPositionAfter  = "after"
+)
+
+// BuiltinPlugin is the configuration of a plugin compiled into CRI-O.
+type BuiltinPlugin struct {
+	// Index orders the builtin plugins at the same position, like the
+	// index prefix of external plugins.
+	Index int `toml:"index"`
+	// Position is whether the plugin handles the container events
+	// "before" or "after" the external plugins. A later plugin gets the
+	// adjustments of the earlier ones, and must not adjust the same
+	// settings.
+	Position string `toml:"position"`
+	// Config is the plugin specific configuration.
+	Config string `toml:"config"`
 }
 
 // New returns the default CRI-O NRI configuration.
@@ -34,6 +61,19 @@ func New() *Config {
 
 // Validate loads and validates the effective runtime NRI configuration.
 func (c *Config) Validate(onExecution bool) error {
+	for name, plugin := range c.BuiltinPlugins {
+		if plugin == nil {
+			return fmt.Errorf("builtin plugin %q has no configuration", name)
+		}
+		switch plugin.Position {
+		case "":
+			plugin.Position = BuiltinPluginPositionBefore
+		case BuiltinPluginPositionBefore, BuiltinPluginPositionAfter:
+		default:
+			return fmt.Errorf("builtin plugin %q has invalid position %q, must be %q or %q",
+				name, plugin.Position, BuiltinPluginPositionBefore, BuiltinPluginPositionAfter)
+		}
+	}
 	return nil
 }
 
@@ -14,6 +14,7 @@ import (
 	"github.com/containers/image/v5/manifest"
 	"github.com/containers/image/v5/pkg/blobinfocache"
 	"github.com/containers/image/v5/types"
+	"github.com/opencontainers/go-digest"
 
 	"github.com/cri-o/cri-o/internal/log"
 )
@@ -38,6 +39,12 @@ type Artifact struct {
 	// Path is the file representation of the artifact on disk. Can be empty if
 	// the cache is not used.
 	Path string
+
+	// Digest is the digest of the artifact layer.
+	Digest digest.Digest
+
+	// Annotations are the annotations of the artifact layer.
+	Annotations map[string]string
 }
 
 // PullOptions can be used to customize the pull behavior.
@@ -159,8 +166,10 @@ func (o *OCIArtifact) Pull(ctx context.Context, img string, opts *PullOptions) (
 	keyPath := o.tryWriteCache(ctx, useCache, opts, layer, layerBytes)
 
 	return &Artifact{
-		Data: layerBytes,
-		Path: keyPath,
+		Data:        layerBytes,
+		Path:        keyPath,
+		Digest:      layer.BlobInfo.Digest,
+		Annotations: layer.BlobInfo.Annotations,
 	}, nil
 }
 
@@ -219,8 +228,10 @@ func (o *OCIArtifact) tryLookupCache(ctx context.Context, useCache bool, opts *P
 		if err := verifyDigest(layer, value); err == nil {
 			log.Infof(ctx, "Using cached artifact layer for digest %q", layer.BlobInfo.Digest)
 			return &Artifact{
-				Data: value,
-				Path: keyPath,
+				Data:        value,
+				Path:        keyPath,
+				Digest:      layer.BlobInfo.Digest,
+				Annotations: layer.BlobInfo.Annotations,
 			}
 		}
 
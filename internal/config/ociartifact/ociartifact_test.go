@@ -85,7 +85,7 @@ var _ = t.Describe("OCIArtifact", func() {
 				implMock.EXPECT().GetManifest(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, "", nil),
 				implMock.EXPECT().ManifestFromBlob(gomock.Any(), gomock.Any()).Return(nil, nil),
 				implMock.EXPECT().LayerInfos(gomock.Any()).Return([]manifest.LayerInfo{
-					{BlobInfo: types.BlobInfo{Digest: testArtifactDigest}},
+					{BlobInfo: types.BlobInfo{Digest: testArtifactDigest, Annotations: map[string]string{"key": "value"}}},
 				}),
 				implMock.EXPECT().GetBlob(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(io.NopCloser(nil), int64(10), nil),
 				implMock.EXPECT().ReadAll(gomock.Any()).Return(testArtifact, nil),
@@ -98,6 +98,8 @@ var _ = t.Describe("OCIArtifact", func() {
 			Expect(err).NotTo(HaveOccurred())
 			Expect(res).NotTo(BeNil())
 			Expect(res.Data).To(BeEquivalentTo(testArtifact))
+			Expect(res.Digest).To(Equal(testArtifactDigest))
+			Expect(res.Annotations).To(HaveKeyWithValue("key", "value"))
 		})
 
 		It("should succeed with cached artifact", func() {
@@ -83,6 +83,9 @@ func mergeConfig(config *libconfig.Config, ctx *cli.Context) error {
 	if ctx.IsSet("signature-policy-dir") {
 		config.SignaturePolicyDir = ctx.String("signature-policy-dir")
 	}
+	if ctx.IsSet("namespaced-registries-dir") {
+		config.NamespacedRegistriesDir = ctx.String("namespaced-registries-dir")
+	}
 	if ctx.IsSet("root") {
 		config.Root = ctx.String("root")
 	}
@@ -287,6 +290,18 @@ func mergeConfig(config *libconfig.Config, ctx *cli.Context) error {
 	if ctx.IsSet("enable-criu-support") {
 		config.EnableCriuSupport = ctx.Bool("enable-criu-support")
 	}
+	if ctx.IsSet("incremental-checkpoint-chain-length") {
+		config.IncrementalCheckpointChainLength = ctx.Int("incremental-checkpoint-chain-length")
+	}
+	if ctx.IsSet("checkpoint-images") {
+		config.CheckpointImages = ctx.Bool("checkpoint-images")
+	}
+	if ctx.IsSet("checkpoint-image-compression") {
+		config.CheckpointImageCompression = ctx.String("checkpoint-image-compression")
+	}
+	if ctx.IsSet("runtime-health-check-interval") {
+		config.RuntimeHealthCheckInterval = ctx.String("runtime-health-check-interval")
+	}
 	if ctx.IsSet("ctr-stop-timeout") {
 		config.CtrStopTimeout = ctx.Int64("ctr-stop-timeout")
 	}
@@ -395,12 +410,39 @@ func mergeConfig(config *libconfig.Config, ctx *cli.Context) error {
 	if ctx.IsSet("nri-plugin-request-timeout") {
 		config.NRI.PluginRequestTimeout = ctx.Duration("nri-plugin-request-timeout")
 	}
+	if ctx.IsSet("nri-dry-run") {
+		config.NRI.DryRun = ctx.Bool("nri-dry-run")
+	}
 	if ctx.IsSet("big-files-temporary-dir") {
 		config.BigFilesTemporaryDir = ctx.String("big-files-temporary-dir")
 	}
 	if ctx.IsSet("auto-reload-registries") {
 		config.AutoReloadRegistries = ctx.Bool("auto-reload-registries")
 	}
+	if ctx.IsSet("max-concurrent-pulls-per-registry") {
+		config.MaxConcurrentPullsPerRegistry = ctx.Int("max-concurrent-pulls-per-registry")
+	}
+	if ctx.IsSet("pull-progress-timeout") {
+		config.PullProgressTimeout = ctx.String("pull-progress-timeout")
+	}
+	if ctx.IsSet("resumable-pulls") {
+		config.ResumablePulls = ctx.Bool("resumable-pulls")
+	}
+	if ctx.IsSet("image-gc-interval") {
+		config.ImageGCInterval = ctx.String("image-gc-interval")
+	}
+	if ctx.IsSet("image-gc-max-unused-age") {
+		config.ImageGCMaxUnusedAge = ctx.String("image-gc-max-unused-age")
+	}
+	if ctx.IsSet("image-gc-max-images") {
+		config.ImageGCMaxImages = ctx.Int("image-gc-max-images")
+	}
+	if ctx.IsSet("image-gc-high-threshold-percent") {
+		config.ImageGCHighThresholdPercent = ctx.Int("image-gc-high-threshold-percent")
+	}
+	if ctx.IsSet("image-gc-low-threshold-percent") {
+		config.ImageGCLowThresholdPercent = ctx.Int("image-gc-low-threshold-percent")
+	}
 	if ctx.IsSet("separate-pull-cgroup") {
 		config.SeparatePullCgroup = ctx.String("separate-pull-cgroup")
 	}
@@ -428,6 +470,9 @@ func mergeConfig(config *libconfig.Config, ctx *cli.Context) error {
 	if ctx.IsSet("pinned-images") {
 		config.PinnedImages = StringSliceTrySplit(ctx, "pinned-images")
 	}
+	if ctx.IsSet("pre-pull-pinned-images") {
+		config.PrePullPinnedImages = ctx.Bool("pre-pull-pinned-images")
+	}
 	if ctx.IsSet("disable-hostport-mapping") {
 		config.DisableHostPortMapping = ctx.Bool("disable-hostport-mapping")
 	}
@@ -583,6 +628,13 @@ func getCrioFlags(defConf *libconfig.Config) []cli.Flag {
 			EnvVars:   []string{"CONTAINER_SIGNATURE_POLICY_DIR"},
 			TakesFile: true,
 		},
+		&cli.StringFlag{
+			Name:      "namespaced-registries-dir",
+			Usage:     "Path to the root directory for namespaced registries configurations and credentials, containing a <NAMESPACE>/registries.conf and <NAMESPACE>/auth.json per pod namespace. Must be an absolute path.",
+			Value:     defConf.NamespacedRegistriesDir,
+			EnvVars:   []string{"CONTAINER_NAMESPACED_REGISTRIES_DIR"},
+			TakesFile: true,
+		},
 		&cli.StringFlag{
 			Name:      "root",
 			Aliases:   []string{"r"},
@@ -916,6 +968,10 @@ func getCrioFlags(defConf *libconfig.Config) []cli.Flag {
 			Usage: `Timeout for a plugin to handle an NRI request.`,
 			Value: defConf.NRI.PluginRequestTimeout,
 		},
+		&cli.BoolFlag{
+			Name:  "nri-dry-run",
+			Usage: fmt.Sprintf("Record and log the container adjustments and updates of NRI plugins without applying them. (default: %v)", defConf.NRI.DryRun),
+		},
 		&cli.StringFlag{
 			Name:    "big-files-temporary-dir",
 			Usage:   `Path to the temporary directory to use for storing big files, used to store image blobs and data streams related to containers image management.`,
@@ -928,6 +984,54 @@ func getCrioFlags(defConf *libconfig.Config) []cli.Flag {
 			EnvVars: []string{"AUTO_RELOAD_REGISTRIES"},
 			Value:   defConf.AutoReloadRegistries,
 		},
+		&cli.IntFlag{
+			Name:    "max-concurrent-pulls-per-registry",
+			Usage:   "Maximum number of image pulls running in parallel for a single registry. Further pulls are queued, where the pause image and pinned images are pulled first. The value 0 does not limit the number of parallel pulls.",
+			EnvVars: []string{"CONTAINER_MAX_CONCURRENT_PULLS_PER_REGISTRY"},
+			Value:   defConf.MaxConcurrentPullsPerRegistry,
+		},
+		&cli.StringFlag{
+			Name:    "pull-progress-timeout",
+			Usage:   "Cancel an image pull if it did not make any progress for this duration. An empty value disables the timeout.",
+			EnvVars: []string{"CONTAINER_PULL_PROGRESS_TIMEOUT"},
+			Value:   defConf.PullProgressTimeout,
+		},
+		&cli.BoolFlag{
+			Name:    "resumable-pulls",
+			Usage:   "Keep the downloaded layers of failed or cancelled image pulls in the storage root and reuse or resume them on subsequent pulls of the same image.",
+			EnvVars: []string{"CONTAINER_RESUMABLE_PULLS"},
+			Value:   defConf.ResumablePulls,
+		},
+		&cli.StringFlag{
+			Name:    "image-gc-interval",
+			Usage:   "Interval of the built-in image garbage collection, which removes images not used by any container according to the image-gc-* policies. Pinned images and the pause image are never removed. An empty value disables the garbage collection.",
+			EnvVars: []string{"CONTAINER_IMAGE_GC_INTERVAL"},
+			Value:   defConf.ImageGCInterval,
+		},
+		&cli.StringFlag{
+			Name:    "image-gc-max-unused-age",
+			Usage:   "Remove images which have not been used by any container for longer than this duration. An empty value disables this policy.",
+			EnvVars: []string{"CONTAINER_IMAGE_GC_MAX_UNUSED_AGE"},
+			Value:   defConf.ImageGCMaxUnusedAge,
+		},
+		&cli.IntFlag{
+			Name:    "image-gc-max-images",
+			Usage:   "Maximum number of images kept in the storage, where the least recently used images are removed first. The value 0 disables this policy.",
+			EnvVars: []string{"CONTAINER_IMAGE_GC_MAX_IMAGES"},
+			Value:   defConf.ImageGCMaxImages,
+		},
+		&cli.IntFlag{
+			Name:    "image-gc-high-threshold-percent",
+			Usage:   "Image filesystem usage in percent, which triggers the removal of the least recently used images. The value 0 disables this policy.",
+			EnvVars: []string{"CONTAINER_IMAGE_GC_HIGH_THRESHOLD_PERCENT"},
+			Value:   defConf.ImageGCHighThresholdPercent,
+		},
+		&cli.IntFlag{
+			Name:    "image-gc-low-threshold-percent",
+			Usage:   "Image filesystem usage in percent the garbage collection aims for, once the image-gc-high-threshold-percent has been reached.",
+			EnvVars: []string{"CONTAINER_IMAGE_GC_LOW_THRESHOLD_PERCENT"},
+			Value:   defConf.ImageGCLowThresholdPercent,
+		},
 		&cli.BoolFlag{
 			Name:    "read-only",
 			Usage:   "Setup all unprivileged containers to run as read-only. Automatically mounts the containers' tmpfs on '/run', '/tmp' and '/var/tmp'.",
@@ -1180,6 +1284,30 @@ func getCrioFlags(defConf *libconfig.Config) []cli.Flag {
 			EnvVars: []string{"CONTAINER_ENABLE_CRIU_SUPPORT"},
 			Value:   false,
 		},
+		&cli.IntFlag{
+			Name:    "incremental-checkpoint-chain-length",
+			Usage:   "Maximum number of incremental checkpoints of a running container, before a new chain with a full memory dump gets started. Set to 0 to disable incremental checkpoints.",
+			EnvVars: []string{"CONTAINER_INCREMENTAL_CHECKPOINT_CHAIN_LENGTH"},
+			Value:   defConf.IncrementalCheckpointChainLength,
+		},
+		&cli.BoolFlag{
+			Name:    "checkpoint-images",
+			Usage:   "Store container checkpoints additionally as OCI images in the local storage. The checkpoint archive is still written to the requested location, if any.",
+			EnvVars: []string{"CONTAINER_CHECKPOINT_IMAGES"},
+			Value:   defConf.CheckpointImages,
+		},
+		&cli.StringFlag{
+			Name:    "checkpoint-image-compression",
+			Usage:   "Compression of checkpoint images, either 'gzip' or 'zstd'.",
+			EnvVars: []string{"CONTAINER_CHECKPOINT_IMAGE_COMPRESSION"},
+			Value:   defConf.CheckpointImageCompression,
+		},
+		&cli.StringFlag{
+			Name:    "runtime-health-check-interval",
+			Usage:   "Interval of the health checks of the runtime handlers. New sandboxes are rejected for unhealthy runtime handlers. Set to an empty value to disable the health checks.",
+			EnvVars: []string{"CONTAINER_RUNTIME_HEALTH_CHECK_INTERVAL"},
+			Value:   defConf.RuntimeHealthCheckInterval,
+		},
 		&cli.BoolFlag{
 			Name:    "enable-pod-events",
 			Usage:   "If true, CRI-O starts sending the container events to the kubelet",
@@ -1202,6 +1330,12 @@ func getCrioFlags(defConf *libconfig.Config) []cli.Flag {
 			EnvVars: []string{"CONTAINER_PINNED_IMAGES"},
 			Value:   cli.NewStringSlice(defConf.PinnedImages...),
 		},
+		&cli.BoolFlag{
+			Name:    "pre-pull-pinned-images",
+			Usage:   "Pull the pinned images without glob or keyword pattern and the pause image in the background after startup.",
+			EnvVars: []string{"CONTAINER_PRE_PULL_PINNED_IMAGES"},
+			Value:   defConf.PrePullPinnedImages,
+		},
 		&cli.BoolFlag{
 			Name:    "disable-hostport-mapping",
 			Usage:   "If true, CRI-O would disable the hostport mapping.",
@@ -2,19 +2,47 @@ package criocli
 
 import (
 	"fmt"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/cri-o/cri-o/internal/client"
+	"github.com/cri-o/cri-o/pkg/types"
+	"github.com/cri-o/cri-o/server"
 
 	"github.com/urfave/cli/v2"
 )
 
 const (
-	defaultSocket = "/var/run/crio/crio.sock"
-	idArg         = "id"
-	socketArg     = "socket"
+	defaultSocket     = "/var/run/crio/crio.sock"
+	allArg            = "all"
+	checkpointArg     = "checkpoint"
+	deleteArg         = "delete"
+	durationArg       = "duration"
+	idArg             = "id"
+	labelArg          = "label"
+	leaveRunningArg   = "leave-running"
+	resetArg          = "reset"
+	restoreArg        = "restore"
+	runtimeHandlerArg = "runtime-handler"
+	socketArg         = "socket"
+	stateArg          = "state"
 )
 
+// filterFlags are the flags of the subcommands listing pods or containers.
+var filterFlags = []cli.Flag{
+	&cli.StringFlag{
+		Name:    labelArg,
+		Aliases: []string{"l"},
+		Usage:   "only show the entries matching the label selector, for example 'app=nginx,tier!=frontend'",
+	},
+	&cli.StringFlag{
+		Name:    runtimeHandlerArg,
+		Aliases: []string{"r"},
+		Usage:   "only show the entries using the runtime handler",
+	},
+}
+
 var StatusCommand = &cli.Command{
 	Name:  "status",
 	Usage: "Display status information",
@@ -29,6 +57,16 @@ var StatusCommand = &cli.Command{
 	},
 	OnUsageError: func(c *cli.Context, e error, b bool) error { return e },
 	Subcommands: []*cli.Command{{
+		Action:  checkpoints,
+		Aliases: []string{"checkpoint", "cp"},
+		Flags: []cli.Flag{&cli.StringFlag{
+			Name:    deleteArg,
+			Aliases: []string{"d"},
+			Usage:   "remove the checkpoint with the provided ID, ID prefix or name",
+		}},
+		Name:  "checkpoints",
+		Usage: "Display information about all checkpoint images or remove one of them.",
+	}, {
 		Action:  configSubCommand,
 		Aliases: []string{"c"},
 		Name:    "config",
@@ -36,21 +74,141 @@ var StatusCommand = &cli.Command{
 	}, {
 		Action:  containers,
 		Aliases: []string{"container", "cs", "s"},
-		Flags: []cli.Flag{&cli.StringFlag{
-			Name:    idArg,
-			Aliases: []string{"i"},
-			Usage:   "the container ID",
-		}},
+		Flags: append([]cli.Flag{
+			&cli.StringFlag{
+				Name:    idArg,
+				Aliases: []string{"i"},
+				Usage:   "the container ID",
+			},
+			&cli.BoolFlag{
+				Name:    allArg,
+				Aliases: []string{"a"},
+				Usage:   "show all containers instead of only the running ones",
+			},
+			&cli.StringFlag{
+				Name:  stateArg,
+				Usage: "only show the containers in the state, like 'created', 'running', 'paused' or 'stopped'",
+			},
+		}, filterFlags...),
 		Name:  "containers",
-		Usage: "Display detailed information about the provided container ID.",
+		Usage: "Display detailed information about the provided container ID or list the containers.",
+	}, {
+		Action:  debug,
+		Aliases: []string{"dbg"},
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  server.DebugSettingLogLevel,
+				Usage: "temporarily change the log level, like 'debug' or 'trace'",
+			},
+			&cli.StringFlag{
+				Name:  server.DebugSettingLogFilter,
+				Usage: "temporarily change the regular expression to filter the log messages",
+			},
+			&cli.IntFlag{
+				Name:  server.DebugSettingTracingSamplingRate,
+				Usage: "temporarily change the number of samples to collect per million spans, requires enabled tracing",
+			},
+			&cli.DurationFlag{
+				Name:    durationArg,
+				Aliases: []string{"d"},
+				Usage:   "the duration after which the changed settings revert automatically",
+				Value:   server.DefaultDebugOverrideDuration,
+			},
+			&cli.BoolFlag{
+				Name:  resetArg,
+				Usage: "revert all changed settings immediately",
+			},
+		},
+		Name:  "debug",
+		Usage: "Display the debug settings, like the log level, or change them for a limited duration.",
+	}, {
+		Action:  images,
+		Aliases: []string{"image", "img"},
+		Name:    "images",
+		Usage:   "Display information about all images, like their last usage.",
 	}, {
 		Action:  info,
 		Aliases: []string{"i"},
 		Name:    "info",
 		Usage:   "Retrieve generic information about CRI-O, such as the cgroup and storage driver.",
+	}, {
+		Action:  pods,
+		Aliases: []string{"pod", "p"},
+		Flags: append([]cli.Flag{
+			&cli.StringFlag{
+				Name:    idArg,
+				Aliases: []string{"i"},
+				Usage:   "the pod ID or ID prefix",
+			},
+			&cli.StringFlag{
+				Name:  stateArg,
+				Usage: "only show the pods in the state, either 'ready' or 'notready'",
+			},
+			&cli.StringFlag{
+				Name:      checkpointArg,
+				Usage:     "checkpoint the running containers of the pod with the provided ID into a single archive at the path",
+				TakesFile: true,
+			},
+			&cli.BoolFlag{
+				Name:  leaveRunningArg,
+				Usage: "keep the containers running after checkpointing them",
+			},
+			&cli.StringFlag{
+				Name:      restoreArg,
+				Usage:     "recreate a pod and its containers from the pod checkpoint archive at the path, the checkpointed pod has to be removed before",
+				TakesFile: true,
+			},
+		}, filterFlags...),
+		Name:  "pods",
+		Usage: "Display detailed information about the provided pod ID or list all pods, or checkpoint and restore a pod.",
+	}, {
+		Action:  pulls,
+		Aliases: []string{"pull"},
+		Name:    "pulls",
+		Usage:   "Display the progress of the image pulls in progress and the pods waiting on them.",
 	}},
 }
 
+func checkpoints(c *cli.Context) error {
+	crioClient, err := crioClient(c)
+	if err != nil {
+		return err
+	}
+
+	if id := c.String(deleteArg); id != "" {
+		if err := crioClient.DeleteCheckpoint(id); err != nil {
+			return err
+		}
+		fmt.Printf("removed checkpoint: %s\n", id)
+		return nil
+	}
+
+	checkpoints, err := crioClient.CheckpointsInfo()
+	if err != nil {
+		return err
+	}
+
+	for i, checkpoint := range checkpoints {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("id: %s\n", checkpoint.ID)
+		fmt.Printf("repo tags: %s\n", strings.Join(checkpoint.RepoTags, ", "))
+		fmt.Printf("container: %s\n", checkpoint.Container)
+		fmt.Printf("rootfs image: %s\n", checkpoint.RootfsImageName)
+		fmt.Printf("size: %d\n", checkpoint.Size)
+		created := "unknown"
+		if checkpoint.Created != 0 {
+			created = time.Unix(0, checkpoint.Created).Format(time.RFC3339)
+		}
+		fmt.Printf("created: %s\n", created)
+		fmt.Printf("CRI-O version: %s\n", checkpoint.CRIOVersion)
+		fmt.Printf("CRIU version: %s\n", checkpoint.CriuVersion)
+	}
+
+	return nil
+}
+
 func configSubCommand(c *cli.Context) error {
 	crioClient, err := crioClient(c)
 	if err != nil {
@@ -72,17 +230,37 @@ func containers(c *cli.Context) error {
 		return err
 	}
 
-	id := c.String(idArg)
-	if id == "" {
-		return fmt.Errorf("the argument --%s cannot be empty", idArg)
+	if id := c.String(idArg); id != "" {
+		info, err := crioClient.ContainerInfo(id)
+		if err != nil {
+			return err
+		}
+		printContainerInfo(info)
+		return nil
 	}
 
-	info, err := crioClient.ContainerInfo(c.String(idArg))
+	filter := inspectFilter(c)
+	if filter.State == "" && !c.Bool(allArg) {
+		filter.State = "running"
+	}
+	infos, err := crioClient.ContainersInfo(filter)
 	if err != nil {
 		return err
 	}
+	for i := range infos {
+		if i > 0 {
+			fmt.Println()
+		}
+		printContainerInfo(&infos[i])
+	}
+
+	return nil
+}
 
+func printContainerInfo(info *types.ContainerInfo) {
+	fmt.Printf("id: %s\n", info.ID)
 	fmt.Printf("name: %s\n", info.Name)
+	fmt.Printf("state: %s\n", info.State)
 	fmt.Printf("pid: %d\n", info.Pid)
 	fmt.Printf("image: %s\n", info.Image)
 	fmt.Printf("image ref: %s\n", info.ImageRef)
@@ -102,7 +280,103 @@ func containers(c *cli.Context) error {
 	fmt.Printf("log path: %s\n", info.LogPath)
 	fmt.Printf("graph root: %s\n", info.Root)
 	fmt.Printf("sandbox: %s\n", info.Sandbox)
+	fmt.Printf("runtime handler: %s\n", info.RuntimeHandler)
 	fmt.Printf("ips: %s\n", strings.Join(info.IPs, ", "))
+	if len(info.NRIAudit) > 0 {
+		fmt.Printf("NRI audit:\n")
+		for _, entry := range info.NRIAudit {
+			plugin := entry.Plugin
+			if plugin == types.NRIAuditExternalPlugins {
+				plugin += " (all external plugins, NRI does not attribute their changes individually)"
+			}
+			dryRun := ""
+			if entry.DryRun {
+				dryRun = " (dry run)"
+			}
+			fmt.Printf("  %v %s %s%s\n", time.Unix(0, entry.Time).UTC(), plugin, entry.Event, dryRun)
+			for _, change := range entry.Changes {
+				fmt.Printf("    %s: %v -> %v\n", change.Path, change.Old, change.New)
+			}
+		}
+	}
+}
+
+func debug(c *cli.Context) error {
+	crioClient, err := crioClient(c)
+	if err != nil {
+		return err
+	}
+
+	if c.Bool(resetArg) {
+		info, err := crioClient.DebugInfo()
+		if err != nil {
+			return err
+		}
+		for _, override := range info.Overrides {
+			if err := crioClient.RevertDebugSetting(override.Setting); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, setting := range []string{
+		server.DebugSettingLogLevel,
+		server.DebugSettingLogFilter,
+		server.DebugSettingTracingSamplingRate,
+	} {
+		if !c.IsSet(setting) {
+			continue
+		}
+		if err := crioClient.OverrideDebugSetting(setting, c.String(setting), c.Duration(durationArg)); err != nil {
+			return err
+		}
+	}
+
+	info, err := crioClient.DebugInfo()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("log level: %s\n", info.LogLevel)
+	fmt.Printf("log filter: %s\n", info.LogFilter)
+	fmt.Printf("tracing enabled: %v\n", info.TracingEnabled)
+	fmt.Printf("tracing sampling rate per million: %d\n", info.TracingSamplingRatePerMillion)
+	fmt.Printf("overrides:\n")
+	for _, override := range info.Overrides {
+		fmt.Printf("  %s: %q (reverts to %q at %s)\n", override.Setting, override.Value, override.Previous,
+			time.Unix(0, override.Expires).Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+func images(c *cli.Context) error {
+	crioClient, err := crioClient(c)
+	if err != nil {
+		return err
+	}
+
+	images, err := crioClient.ImagesInfo()
+	if err != nil {
+		return err
+	}
+
+	for i, image := range images {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("id: %s\n", image.ID)
+		fmt.Printf("repo tags: %s\n", strings.Join(image.RepoTags, ", "))
+		fmt.Printf("repo digests: %s\n", strings.Join(image.RepoDigests, ", "))
+		fmt.Printf("size: %d\n", image.Size)
+		fmt.Printf("pinned: %v\n", image.Pinned)
+		lastUsed := "never"
+		if image.LastUsed != 0 {
+			lastUsed = time.Unix(0, image.LastUsed).Format(time.RFC3339)
+		}
+		fmt.Printf("last used: %s\n", lastUsed)
+		fmt.Printf("usage count: %d\n", image.UsageCount)
+	}
 
 	return nil
 }
@@ -132,9 +406,147 @@ func info(c *cli.Context) error {
 		fmt.Printf("  %d:%d:%d\n", m.ContainerID, m.HostID, m.Size)
 	}
 
+	fmt.Printf("runtime handlers:\n")
+	for _, h := range info.RuntimeHandlers {
+		fmt.Printf("  %s:\n", h.Name)
+		fmt.Printf("    runtime type: %s\n", h.RuntimeType)
+		if !h.FeaturesReported {
+			fmt.Printf("    features: unknown\n")
+		} else {
+			fmt.Printf("    OCI versions: %s - %s\n", h.OCIVersionMin, h.OCIVersionMax)
+			fmt.Printf("    mount options: %s\n", strings.Join(h.MountOptions, ", "))
+			fmt.Printf("    namespaces: %s\n", strings.Join(h.Namespaces, ", "))
+			fmt.Printf("    seccomp actions: %s\n", strings.Join(h.SeccompActions, ", "))
+			fmt.Printf("    cgroup v2: %v\n", h.CgroupV2)
+			fmt.Printf("    cgroup v2 controllers: %s\n", strings.Join(h.CgroupV2Controllers, ", "))
+			fmt.Printf("    user namespaces: %v\n", h.UserNamespaces)
+			fmt.Printf("    recursive read-only mounts: %v\n", h.RecursiveReadOnlyMounts)
+		}
+		fmt.Printf("    checkpoint/restore: %v\n", h.CheckpointRestore)
+	}
+
+	return nil
+}
+
+func pods(c *cli.Context) error {
+	crioClient, err := crioClient(c)
+	if err != nil {
+		return err
+	}
+
+	if path := c.String(restoreArg); path != "" {
+		path, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		info, err := crioClient.RestorePod(path)
+		if err != nil {
+			return err
+		}
+		printPodInfo(info)
+		return nil
+	}
+
+	if path := c.String(checkpointArg); path != "" {
+		id := c.String(idArg)
+		if id == "" {
+			return fmt.Errorf("--%s requires the pod --%s", checkpointArg, idArg)
+		}
+		path, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		info, err := crioClient.CheckpointPod(id, path, c.Bool(leaveRunningArg))
+		if err != nil {
+			return err
+		}
+		fmt.Printf("checkpointed pod: %s\n", info.ID)
+		fmt.Printf("path: %s\n", info.Path)
+		fmt.Printf("containers: %s\n", strings.Join(info.Containers, ", "))
+		return nil
+	}
+
+	if id := c.String(idArg); id != "" {
+		info, err := crioClient.PodInfo(id)
+		if err != nil {
+			return err
+		}
+		printPodInfo(info)
+		return nil
+	}
+
+	infos, err := crioClient.PodsInfo(inspectFilter(c))
+	if err != nil {
+		return err
+	}
+	for i := range infos {
+		if i > 0 {
+			fmt.Println()
+		}
+		printPodInfo(&infos[i])
+	}
+
 	return nil
 }
 
+func printPodInfo(info *types.PodInfo) {
+	fmt.Printf("id: %s\n", info.ID)
+	fmt.Printf("name: %s\n", info.Name)
+	fmt.Printf("namespace: %s\n", info.Namespace)
+	fmt.Printf("uid: %s\n", info.UID)
+	fmt.Printf("state: %s\n", info.State)
+	fmt.Printf("created: %s\n", time.Unix(0, info.CreatedTime).Format(time.RFC3339))
+	fmt.Printf("labels:\n")
+	for k, v := range info.Labels {
+		fmt.Printf("  %s: %s\n", k, v)
+	}
+	fmt.Printf("annotations:\n")
+	for k, v := range info.Annotations {
+		fmt.Printf("  %s: %s\n", k, v)
+	}
+	fmt.Printf("runtime handler: %s\n", info.RuntimeHandler)
+	fmt.Printf("ips: %s\n", strings.Join(info.IPs, ", "))
+	fmt.Printf("infra container: %s\n", info.InfraContainer)
+	fmt.Printf("containers: %s\n", strings.Join(info.Containers, ", "))
+}
+
+func pulls(c *cli.Context) error {
+	crioClient, err := crioClient(c)
+	if err != nil {
+		return err
+	}
+
+	pulls, err := crioClient.PullsInfo()
+	if err != nil {
+		return err
+	}
+
+	for i, pull := range pulls {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("image: %s\n", pull.Image)
+		fmt.Printf("candidate: %s\n", pull.Candidate)
+		fmt.Printf("started: %s\n", time.Unix(0, pull.StartTime).Format(time.RFC3339))
+		fmt.Printf("downloaded: %d/%d bytes\n", pull.DownloadedBytes, pull.TotalBytes)
+		fmt.Printf("pods: %s\n", strings.Join(pull.Pods, ", "))
+		fmt.Printf("layers:\n")
+		for _, layer := range pull.Layers {
+			fmt.Printf("  %s: %s (%d/%d bytes)\n", layer.Digest, layer.State, layer.DownloadedBytes, layer.Size)
+		}
+	}
+
+	return nil
+}
+
+func inspectFilter(c *cli.Context) *types.InspectFilter {
+	return &types.InspectFilter{
+		State:          c.String(stateArg),
+		LabelSelector:  c.String(labelArg),
+		RuntimeHandler: c.String(runtimeHandlerArg),
+	}
+}
+
 func crioClient(c *cli.Context) (client.CrioClient, error) {
 	return client.New(c.String(socketArg))
 }
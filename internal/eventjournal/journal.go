@@ -0,0 +1,233 @@
+// Package eventjournal provides a bounded, on-disk journal of CRI container
+// events. Every journaled event gets a monotonically increasing sequence
+// number, which allows clients to resume an event stream after a reconnect or
+// a restart of the daemon.
+package eventjournal
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/google/renameio"
+	"github.com/sirupsen/logrus"
+	types "k8s.io/cri-api/pkg/apis/runtime/v1"
+)
+
+// journalFileName is the name of the journal file within the journal
+// directory.
+const journalFileName = "journal"
+
+// ErrInvalidMaxEntries is returned if the journal should be created with a
+// size which is not positive.
+var ErrInvalidMaxEntries = errors.New("maximum number of journal entries has to be positive")
+
+// ErrSequenceNotJournaled is returned if the events following a sequence
+// cannot be replayed, because they have already been dropped from the
+// journal or the sequence has never been assigned.
+var ErrSequenceNotJournaled = errors.New("events after sequence are not journaled")
+
+// Entry is a single journaled container event.
+type Entry struct {
+	// Sequence is the unique and monotonically increasing number of the
+	// event.
+	Sequence uint64 `json:"sequence"`
+
+	// Event is the journaled container event.
+	Event *types.ContainerEventResponse `json:"event"`
+}
+
+// Journal is a bounded container event log backed by a file.
+// Journal is safe for concurrent access.
+type Journal struct {
+	path       string
+	maxEntries int
+
+	mu sync.Mutex
+	// entries contains at most maxEntries events, ordered by sequence.
+	entries []*Entry
+	// sequence is the sequence of the last journaled event.
+	sequence uint64
+	// fileEntries is the number of lines in the journal file, which can
+	// exceed the in-memory entries until the file gets compacted.
+	fileEntries int
+}
+
+// New opens the journal within dir or creates a new one if it does not exist
+// yet. The journal keeps at most maxEntries events.
+func New(dir string, maxEntries int) (*Journal, error) {
+	if maxEntries <= 0 {
+		return nil, ErrInvalidMaxEntries
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("create event journal directory: %w", err)
+	}
+
+	j := &Journal{
+		path:       filepath.Join(dir, journalFileName),
+		maxEntries: maxEntries,
+	}
+	if err := j.load(); err != nil {
+		return nil, fmt.Errorf("load event journal %s: %w", j.path, err)
+	}
+	return j, nil
+}
+
+// load reads the journal file into memory. Lines which cannot be decoded,
+// for example because of an interrupted write, are skipped and the file gets
+// compacted to not append to a truncated line.
+func (j *Journal) load() error {
+	f, err := os.Open(j.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	invalid := false
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 16*1024*1024)
+	for scanner.Scan() {
+		j.fileEntries++
+		entry := &Entry{}
+		if err := json.Unmarshal(scanner.Bytes(), entry); err != nil || entry.Event == nil {
+			logrus.Warnf("Skipping invalid event journal entry in %s: %v", j.path, err)
+			invalid = true
+			continue
+		}
+		if entry.Sequence <= j.sequence {
+			continue
+		}
+		j.sequence = entry.Sequence
+		j.entries = append(j.entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	j.trim()
+	if invalid {
+		return j.compact()
+	}
+	return nil
+}
+
+// Append adds an event to the journal and returns its sequence.
+func (j *Journal) Append(event *types.ContainerEventResponse) (uint64, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	entry := &Entry{Sequence: j.sequence + 1, Event: event}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return 0, fmt.Errorf("marshal event journal entry: %w", err)
+	}
+
+	j.sequence = entry.Sequence
+	j.entries = append(j.entries, entry)
+	j.trim()
+
+	// Rewrite the whole file only once it holds twice as many entries as
+	// required, to keep the cost of the compaction low.
+	if j.fileEntries+1 > 2*j.maxEntries {
+		return entry.Sequence, j.compact()
+	}
+
+	f, err := os.OpenFile(j.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return entry.Sequence, fmt.Errorf("open event journal: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return entry.Sequence, fmt.Errorf("write event journal: %w", err)
+	}
+	j.fileEntries++
+	return entry.Sequence, nil
+}
+
+// compact rewrites the journal file to only contain the in-memory entries.
+func (j *Journal) compact() error {
+	buf := bytes.Buffer{}
+	for _, entry := range j.entries {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return fmt.Errorf("marshal event journal entry: %w", err)
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	if err := renameio.WriteFile(j.path, buf.Bytes(), 0o600); err != nil {
+		return fmt.Errorf("compact event journal: %w", err)
+	}
+	j.fileEntries = len(j.entries)
+	return nil
+}
+
+// trim drops the oldest entries exceeding maxEntries.
+func (j *Journal) trim() {
+	if excess := len(j.entries) - j.maxEntries; excess > 0 {
+		j.entries = append([]*Entry{}, j.entries[excess:]...)
+	}
+}
+
+// Since returns all journaled entries with a sequence greater than the
+// provided one, ordered by sequence.
+func (j *Journal) Since(sequence uint64) []*Entry {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.since(sequence)
+}
+
+// Resume returns all journaled entries with a sequence greater than the
+// provided one, ordered by sequence, like Since. It fails with
+// ErrSequenceNotJournaled if not all of these entries are still part of the
+// journal, in which case the client has to relist the containers.
+func (j *Journal) Resume(sequence uint64) ([]*Entry, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if sequence > j.sequence {
+		return nil, fmt.Errorf("%w: %d is newer than the last sequence %d", ErrSequenceNotJournaled, sequence, j.sequence)
+	}
+	if len(j.entries) > 0 && sequence+1 < j.entries[0].Sequence {
+		return nil, fmt.Errorf("%w: %d is older than the first sequence %d", ErrSequenceNotJournaled, sequence, j.entries[0].Sequence)
+	}
+	return j.since(sequence), nil
+}
+
+func (j *Journal) since(sequence uint64) []*Entry {
+	res := []*Entry{}
+	for _, entry := range j.entries {
+		if entry.Sequence > sequence {
+			res = append(res, entry)
+		}
+	}
+	return res
+}
+
+// LastSequence returns the sequence of the latest journaled event, or zero if
+// no event has been journaled yet.
+func (j *Journal) LastSequence() uint64 {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.sequence
+}
+
+// LastEvents returns the latest journaled event for every container ID still
+// part of the journal.
+func (j *Journal) LastEvents() map[string]*types.ContainerEventResponse {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	res := make(map[string]*types.ContainerEventResponse)
+	for _, entry := range j.entries {
+		res[entry.Event.ContainerId] = entry.Event
+	}
+	return res
+}
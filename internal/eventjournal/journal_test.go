@@ -0,0 +1,170 @@
+package eventjournal_test
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/cri-o/cri-o/internal/eventjournal"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	types "k8s.io/cri-api/pkg/apis/runtime/v1"
+)
+
+// The actual test suite
+var _ = t.Describe("EventJournal", func() {
+	var dir string
+
+	event := func(id string, eventType types.ContainerEventType) *types.ContainerEventResponse {
+		return &types.ContainerEventResponse{ContainerId: id, ContainerEventType: eventType}
+	}
+
+	BeforeEach(func() {
+		dir = t.MustTempDir("eventjournal")
+	})
+
+	It("should fail with invalid maximum entries", func() {
+		// Given
+		// When
+		sut, err := eventjournal.New(dir, 0)
+
+		// Then
+		Expect(err).To(MatchError(eventjournal.ErrInvalidMaxEntries))
+		Expect(sut).To(BeNil())
+	})
+
+	It("should assign increasing sequences", func() {
+		// Given
+		sut, err := eventjournal.New(dir, 10)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(sut.LastSequence()).To(BeZero())
+
+		// When
+		first, err := sut.Append(event("1", types.ContainerEventType_CONTAINER_CREATED_EVENT))
+		Expect(err).ToNot(HaveOccurred())
+		second, err := sut.Append(event("1", types.ContainerEventType_CONTAINER_STARTED_EVENT))
+		Expect(err).ToNot(HaveOccurred())
+
+		// Then
+		Expect(first).To(BeEquivalentTo(1))
+		Expect(second).To(BeEquivalentTo(2))
+		Expect(sut.LastSequence()).To(BeEquivalentTo(2))
+		entries := sut.Since(1)
+		Expect(entries).To(HaveLen(1))
+		Expect(entries[0].Sequence).To(BeEquivalentTo(2))
+		Expect(entries[0].Event.ContainerEventType).To(Equal(types.ContainerEventType_CONTAINER_STARTED_EVENT))
+	})
+
+	It("should keep only the maximum number of entries", func() {
+		// Given
+		sut, err := eventjournal.New(dir, 2)
+		Expect(err).ToNot(HaveOccurred())
+
+		// When
+		for _, id := range []string{"1", "2", "3", "4", "5"} {
+			_, err := sut.Append(event(id, types.ContainerEventType_CONTAINER_CREATED_EVENT))
+			Expect(err).ToNot(HaveOccurred())
+		}
+
+		// Then
+		entries := sut.Since(0)
+		Expect(entries).To(HaveLen(2))
+		Expect(entries[0].Sequence).To(BeEquivalentTo(4))
+		Expect(entries[1].Sequence).To(BeEquivalentTo(5))
+	})
+
+	It("should resume from a journaled sequence", func() {
+		// Given
+		sut, err := eventjournal.New(dir, 2)
+		Expect(err).ToNot(HaveOccurred())
+		for _, id := range []string{"1", "2", "3"} {
+			_, err := sut.Append(event(id, types.ContainerEventType_CONTAINER_CREATED_EVENT))
+			Expect(err).ToNot(HaveOccurred())
+		}
+
+		// When
+		entries, err := sut.Resume(1)
+
+		// Then
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entries).To(HaveLen(2))
+		Expect(entries[0].Event.ContainerId).To(Equal("2"))
+		Expect(entries[1].Event.ContainerId).To(Equal("3"))
+		entries, err = sut.Resume(3)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entries).To(BeEmpty())
+	})
+
+	It("should fail to resume from a dropped sequence", func() {
+		// Given
+		sut, err := eventjournal.New(dir, 2)
+		Expect(err).ToNot(HaveOccurred())
+		for _, id := range []string{"1", "2", "3"} {
+			_, err := sut.Append(event(id, types.ContainerEventType_CONTAINER_CREATED_EVENT))
+			Expect(err).ToNot(HaveOccurred())
+		}
+
+		// When
+		entries, err := sut.Resume(0)
+
+		// Then
+		Expect(err).To(MatchError(eventjournal.ErrSequenceNotJournaled))
+		Expect(entries).To(BeNil())
+	})
+
+	It("should fail to resume from an unknown sequence", func() {
+		// Given
+		sut, err := eventjournal.New(dir, 2)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = sut.Append(event("1", types.ContainerEventType_CONTAINER_CREATED_EVENT))
+		Expect(err).ToNot(HaveOccurred())
+
+		// When
+		entries, err := sut.Resume(2)
+
+		// Then
+		Expect(err).To(MatchError(eventjournal.ErrSequenceNotJournaled))
+		Expect(entries).To(BeNil())
+	})
+
+	It("should restore the entries from disk", func() {
+		// Given
+		sut, err := eventjournal.New(dir, 3)
+		Expect(err).ToNot(HaveOccurred())
+		for _, id := range []string{"1", "2", "3", "4", "5", "6", "7"} {
+			_, err := sut.Append(event(id, types.ContainerEventType_CONTAINER_CREATED_EVENT))
+			Expect(err).ToNot(HaveOccurred())
+		}
+		_, err = sut.Append(event("7", types.ContainerEventType_CONTAINER_STOPPED_EVENT))
+		Expect(err).ToNot(HaveOccurred())
+
+		// When
+		restored, err := eventjournal.New(dir, 3)
+
+		// Then
+		Expect(err).ToNot(HaveOccurred())
+		Expect(restored.LastSequence()).To(BeEquivalentTo(8))
+		Expect(restored.Since(0)).To(Equal(sut.Since(0)))
+		lastEvents := restored.LastEvents()
+		Expect(lastEvents).To(HaveLen(2))
+		Expect(lastEvents["6"].ContainerEventType).To(Equal(types.ContainerEventType_CONTAINER_CREATED_EVENT))
+		Expect(lastEvents["7"].ContainerEventType).To(Equal(types.ContainerEventType_CONTAINER_STOPPED_EVENT))
+	})
+
+	It("should skip invalid entries on restore", func() {
+		// Given
+		Expect(os.WriteFile(filepath.Join(dir, "journal"), []byte(
+			`{"sequence":1,"event":{"container_id":"1"}}`+"\n"+
+				`{"sequence":2,"event":{"cont`,
+		), 0o600)).To(Succeed())
+
+		// When
+		sut, err := eventjournal.New(dir, 10)
+
+		// Then
+		Expect(err).ToNot(HaveOccurred())
+		Expect(sut.LastSequence()).To(BeEquivalentTo(1))
+		_, err = sut.Append(event("2", types.ContainerEventType_CONTAINER_CREATED_EVENT))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(sut.LastSequence()).To(BeEquivalentTo(2))
+	})
+})
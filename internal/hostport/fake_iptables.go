@@ -42,6 +42,7 @@ type fakeIPTables struct {
 	tables        map[string]*fakeTable
 	builtinChains map[string]sets.Set[string]
 	protocol      utiliptables.Protocol
+	present       bool
 }
 
 func newFakeIPTables() *fakeIPTables {
@@ -53,6 +54,7 @@ func newFakeIPTables() *fakeIPTables {
 			string(utiliptables.TableMangle): sets.New("PREROUTING", "INPUT", "FORWARD", "OUTPUT", "POSTROUTING"),
 		},
 		protocol: utiliptables.ProtocolIPv4,
+		present:  true,
 	}
 }
 
@@ -371,5 +373,5 @@ func (f *fakeIPTables) HasRandomFully() bool {
 }
 
 func (f *fakeIPTables) Present() bool {
-	return true
+	return f.present
 }
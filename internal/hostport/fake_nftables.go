@@ -0,0 +1,183 @@
+package hostport
+
+import (
+	"errors"
+	"fmt"
+
+	utilnftables "github.com/cri-o/cri-o/internal/nftables"
+)
+
+type fakeNFTablesChain struct {
+	chain utilnftables.Chain
+	rules []*utilnftables.Rule
+}
+
+type fakeNFTablesTable struct {
+	chains map[string]*fakeNFTablesChain
+}
+
+type fakeNFTables struct {
+	tables  map[string]*fakeNFTablesTable
+	family  utilnftables.Family
+	present bool
+}
+
+func newFakeNFTables() *fakeNFTables {
+	return &fakeNFTables{
+		tables:  make(map[string]*fakeNFTablesTable),
+		family:  utilnftables.FamilyIPv4,
+		present: true,
+	}
+}
+
+func (f *fakeNFTables) Family() utilnftables.Family {
+	return f.family
+}
+
+func (f *fakeNFTables) Present() bool {
+	return f.present
+}
+
+func (f *fakeNFTables) ListChains(table string) ([]string, error) {
+	chains := []string{}
+	if t, ok := f.tables[table]; ok {
+		for name := range t.chains {
+			chains = append(chains, name)
+		}
+	}
+	return chains, nil
+}
+
+// Run applies the transaction to a copy of the tables, which only replaces
+// the current state if all operations succeeded.
+func (f *fakeNFTables) Run(tx *utilnftables.Transaction) error {
+	if tx.Family != f.family {
+		return fmt.Errorf("transaction family %s does not match %s", tx.Family, f.family)
+	}
+	tables := make(map[string]*fakeNFTablesTable, len(f.tables))
+	for name, table := range f.tables {
+		tables[name] = table.copy()
+	}
+	for i := range tx.Operations {
+		if err := applyFakeOperation(tables, tx.Table, &tx.Operations[i]); err != nil {
+			return fmt.Errorf("operation %d (%s %s): %w", i, tx.Operations[i].Verb, tx.Table, err)
+		}
+	}
+	f.tables = tables
+	return nil
+}
+
+func applyFakeOperation(tables map[string]*fakeNFTablesTable, tableName string, op *utilnftables.Operation) error {
+	table, ok := tables[tableName]
+	if op.Rule == nil && op.Chain == nil {
+		switch op.Verb {
+		case utilnftables.VerbAdd:
+			if !ok {
+				tables[tableName] = &fakeNFTablesTable{chains: make(map[string]*fakeNFTablesChain)}
+			}
+			return nil
+		case utilnftables.VerbDelete:
+			if !ok {
+				return errors.New("table does not exist")
+			}
+			delete(tables, tableName)
+			return nil
+		}
+		return fmt.Errorf("unsupported table operation %q", op.Verb)
+	}
+	if !ok {
+		return errors.New("table does not exist")
+	}
+
+	if op.Rule != nil {
+		if op.Verb != utilnftables.VerbAdd {
+			return fmt.Errorf("unsupported rule operation %q", op.Verb)
+		}
+		if err := op.Rule.Validate(); err != nil {
+			return err
+		}
+		chain, ok := table.chains[op.Rule.Chain]
+		if !ok {
+			return fmt.Errorf("chain %s does not exist", op.Rule.Chain)
+		}
+		if op.Rule.Jump != "" {
+			target, ok := table.chains[op.Rule.Jump]
+			if !ok {
+				return fmt.Errorf("jump target %s does not exist", op.Rule.Jump)
+			}
+			if target.chain.IsBaseChain() {
+				return fmt.Errorf("jump target %s is a base chain", op.Rule.Jump)
+			}
+		}
+		rule := *op.Rule
+		chain.rules = append(chain.rules, &rule)
+		return nil
+	}
+
+	chain, ok := table.chains[op.Chain.Name]
+	switch op.Verb {
+	case utilnftables.VerbAdd:
+		if ok {
+			if chain.chain != *op.Chain {
+				return fmt.Errorf("chain %s exists with different hook", op.Chain.Name)
+			}
+			return nil
+		}
+		table.chains[op.Chain.Name] = &fakeNFTablesChain{chain: *op.Chain}
+		return nil
+
+	case utilnftables.VerbFlush:
+		if !ok {
+			return fmt.Errorf("chain %s does not exist", op.Chain.Name)
+		}
+		chain.rules = nil
+		return nil
+
+	case utilnftables.VerbDelete:
+		if !ok {
+			return fmt.Errorf("chain %s does not exist", op.Chain.Name)
+		}
+		if len(chain.rules) > 0 {
+			return fmt.Errorf("chain %s is not empty", op.Chain.Name)
+		}
+		for _, other := range table.chains {
+			for _, rule := range other.rules {
+				if rule.Jump == op.Chain.Name {
+					return fmt.Errorf("chain %s is referenced by chain %s", op.Chain.Name, other.chain.Name)
+				}
+			}
+		}
+		delete(table.chains, op.Chain.Name)
+		return nil
+	}
+	return fmt.Errorf("unsupported chain operation %q", op.Verb)
+}
+
+func (t *fakeNFTablesTable) copy() *fakeNFTablesTable {
+	res := &fakeNFTablesTable{chains: make(map[string]*fakeNFTablesChain, len(t.chains))}
+	for name, chain := range t.chains {
+		res.chains[name] = &fakeNFTablesChain{
+			chain: chain.chain,
+			rules: append([]*utilnftables.Rule{}, chain.rules...),
+		}
+	}
+	return res
+}
+
+// getRules returns the rules of the chain in the syntax of the nft command
+// line tool.
+func (f *fakeNFTables) getRules(table, chain string) ([]string, error) {
+	t, ok := f.tables[table]
+	if !ok {
+		return nil, fmt.Errorf("table %s does not exist", table)
+	}
+	c, ok := t.chains[chain]
+	if !ok {
+		return nil, fmt.Errorf("chain %s/%s does not exist", table, chain)
+	}
+	rules := []string{}
+	for _, rule := range c.rules {
+		rules = append(rules, rule.String())
+	}
+	return rules, nil
+}
@@ -0,0 +1,43 @@
+package hostport
+
+import (
+	"testing"
+
+	utilnftables "github.com/cri-o/cri-o/internal/nftables"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFakeNFTablesRunIsAtomic(t *testing.T) {
+	nftables := newFakeNFTables()
+
+	tx := utilnftables.NewTransaction(utilnftables.FamilyIPv4, "test")
+	tx.AddTable()
+	tx.AddChain(&utilnftables.Chain{Name: "target"})
+	tx.AddChain(&utilnftables.Chain{Name: "base", Hook: utilnftables.HookPrerouting, Priority: utilnftables.PriorityDestinationNAT})
+	tx.AddRule(&utilnftables.Rule{Chain: "base", Jump: "target"})
+	require.NoError(t, nftables.Run(tx))
+
+	// Deleting a referenced chain fails and leaves the table untouched
+	tx = utilnftables.NewTransaction(utilnftables.FamilyIPv4, "test")
+	tx.AddChain(&utilnftables.Chain{Name: "other"})
+	tx.DeleteChain("target")
+	assert.Error(t, nftables.Run(tx))
+
+	chains, err := nftables.ListChains("test")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"base", "target"}, chains)
+
+	// Flushing the referencing chain first allows the deletion
+	tx = utilnftables.NewTransaction(utilnftables.FamilyIPv4, "test")
+	tx.FlushChain("base")
+	tx.DeleteChain("target")
+	require.NoError(t, nftables.Run(tx))
+
+	rules, err := nftables.getRules("test", "base")
+	require.NoError(t, err)
+	assert.Empty(t, rules)
+	chains, err = nftables.ListChains("test")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"base"}, chains)
+}
@@ -287,6 +287,18 @@ func (hm *hostportManager) syncIPTables(lines []byte) error {
 // If encounter any error, clean up and return the error
 // If all ports are opened successfully, return the hostport and socket mapping
 func (hm *hostportManager) openHostports(podPortMapping *PodPortMapping) (map[hostport]closeable, error) {
+	return openHostports(hm.portOpener, podPortMapping, hm.iptables.IsIPv6())
+}
+
+// closeHostports tries to close all the listed host ports
+func (hm *hostportManager) closeHostports(hostportMappings []*PortMapping) error {
+	return closeHostports(hm.hostPortMap, hostportMappings, hm.iptables.IsIPv6())
+}
+
+// openHostports opens all hostports of the IP family using the portOpener.
+// If encounter any error, clean up and return the error
+// If all ports are opened successfully, return the hostport and socket mapping
+func openHostports(portOpener hostportOpener, podPortMapping *PodPortMapping, isIPv6 bool) (map[hostport]closeable, error) {
 	var retErr error
 	ports := make(map[hostport]closeable)
 	for _, pm := range podPortMapping.PortMappings {
@@ -300,12 +312,12 @@ func (hm *hostportManager) openHostports(podPortMapping *PodPortMapping) (map[ho
 		}
 
 		// HostIP IP family is not handled by this port opener
-		if pm.HostIP != "" && utilnet.IsIPv6String(pm.HostIP) != hm.iptables.IsIPv6() {
+		if pm.HostIP != "" && utilnet.IsIPv6String(pm.HostIP) != isIPv6 {
 			continue
 		}
 
-		hp := portMappingToHostport(pm, hm.getIPFamily())
-		socket, err := hm.portOpener(&hp)
+		hp := portMappingToHostport(pm, getIPFamily(isIPv6))
+		socket, err := portOpener(&hp)
 		if err != nil {
 			retErr = fmt.Errorf("cannot open hostport %d for pod %s: %w", pm.HostPort, getPodFullName(podPortMapping), err)
 			break
@@ -325,18 +337,19 @@ func (hm *hostportManager) openHostports(podPortMapping *PodPortMapping) (map[ho
 	return ports, nil
 }
 
-// closeHostports tries to close all the listed host ports
-func (hm *hostportManager) closeHostports(hostportMappings []*PortMapping) error {
+// closeHostports tries to close all the listed host ports and removes them
+// from the hostPortMap
+func closeHostports(hostPortMap map[hostport]closeable, hostportMappings []*PortMapping, isIPv6 bool) error {
 	errList := []error{}
 	for _, pm := range hostportMappings {
-		hp := portMappingToHostport(pm, hm.getIPFamily())
-		if socket, ok := hm.hostPortMap[hp]; ok {
+		hp := portMappingToHostport(pm, getIPFamily(isIPv6))
+		if socket, ok := hostPortMap[hp]; ok {
 			logrus.Infof("Closing host port %s", hp.String())
 			if err := socket.Close(); err != nil {
 				errList = append(errList, fmt.Errorf("failed to close host port %s: %w", hp.String(), err))
 				continue
 			}
-			delete(hm.hostPortMap, hp)
+			delete(hostPortMap, hp)
 		} else {
 			logrus.Infof("Host port %s does not have an open socket", hp.String())
 		}
@@ -344,13 +357,12 @@ func (hm *hostportManager) closeHostports(hostportMappings []*PortMapping) error
 	return utilerrors.NewAggregate(errList)
 }
 
-// getIPFamily returns the hostPortManager IP family
-func (hm *hostportManager) getIPFamily() ipFamily {
-	family := IPv4
-	if hm.iptables.IsIPv6() {
-		family = IPv6
+// getIPFamily returns the IP family of the hostports
+func getIPFamily(isIPv6 bool) ipFamily {
+	if isIPv6 {
+		return IPv6
 	}
-	return family
+	return IPv4
 }
 
 // getHostportChain takes id, hostport and protocol for a pod and returns associated iptables chain.
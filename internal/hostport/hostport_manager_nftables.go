@@ -0,0 +1,319 @@
+package hostport
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	utilnftables "github.com/cri-o/cri-o/internal/nftables"
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	utilnet "k8s.io/utils/net"
+)
+
+const (
+	// the table containing all hostport chains
+	nftablesHostportsTable = "crio-hostports"
+
+	// the base chains hooking into the netfilter nat hooks
+	nftablesPreroutingChain  = "prerouting"
+	nftablesOutputChain      = "output"
+	nftablesPostroutingChain = "postrouting"
+
+	// the chain dispatching to the per mapping hostport chains
+	nftablesHostportsChain = "hostports"
+	// prefix for hostport chains
+	nftablesHostportChainPrefix = "hostport-"
+
+	// the chain dispatching to the per mapping masquerade chains
+	nftablesMasqueradingChain = "masquerading"
+	// prefix for masquerade chains
+	nftablesMasqueradeChainPrefix = "masquerade-"
+)
+
+type nftablesHostportManager struct {
+	hostPortMap map[hostport]closeable
+	nftables    utilnftables.Interface
+	portOpener  hostportOpener
+	mu          sync.Mutex
+}
+
+// NewNFTablesHostportManager creates a new HostPortManager which programs
+// the crio-hostports nftables table instead of using the iptables binaries
+func NewNFTablesHostportManager(nftables utilnftables.Interface) HostPortManager {
+	return &nftablesHostportManager{
+		hostPortMap: make(map[hostport]closeable),
+		nftables:    nftables,
+		portOpener:  openLocalPort,
+	}
+}
+
+func (hm *nftablesHostportManager) isIPv6() bool {
+	return hm.nftables.Family() == utilnftables.FamilyIPv6
+}
+
+func (hm *nftablesHostportManager) Add(id string, podPortMapping *PodPortMapping, natInterfaceName string) (err error) {
+	if podPortMapping == nil || podPortMapping.HostNetwork {
+		return nil
+	}
+	podFullName := getPodFullName(podPortMapping)
+	// IP.To16() returns nil if IP is not a valid IPv4 or IPv6 address
+	if podPortMapping.IP.To16() == nil {
+		return fmt.Errorf("invalid or missing IP of pod %s", podFullName)
+	}
+	isIPv6 := utilnet.IsIPv6(podPortMapping.IP)
+
+	// skip if there is no hostport needed
+	hostportMappings := gatherHostportMappings(podPortMapping, isIPv6)
+	if len(hostportMappings) == 0 {
+		return nil
+	}
+
+	if isIPv6 != hm.isIPv6() {
+		return fmt.Errorf("HostPortManager IP family mismatch: %v, isIPv6 - %v", podPortMapping.IP, isIPv6)
+	}
+
+	// Ensure atomicity for port opening and nftables operations
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+
+	// try to open hostports
+	ports, err := openHostports(hm.portOpener, podPortMapping, isIPv6)
+	if err != nil {
+		return err
+	}
+	for hostport, socket := range ports {
+		hm.hostPortMap[hostport] = socket
+	}
+
+	existingChains, err := hm.nftables.ListChains(nftablesHostportsTable)
+	if err != nil {
+		// clean up opened host port if encounter any error
+		return utilerrors.NewAggregate([]error{err, closeHostports(hm.hostPortMap, hostportMappings, isIPv6)})
+	}
+
+	tx := utilnftables.NewTransaction(hm.nftables.Family(), nftablesHostportsTable)
+	ensureNFTablesHostportChains(tx)
+
+	podIP := hostNetwork(podPortMapping.IP)
+	chains := sets.New(existingChains...)
+	conntrackPortsToRemove := []int{}
+	for _, pm := range hostportMappings {
+		protocol := strings.ToLower(string(pm.Protocol))
+		hpChain := string(getHostportChain(nftablesHostportChainPrefix, id, pm))
+		masqChain := string(getHostportChain(nftablesMasqueradeChainPrefix, id, pm))
+		comment := fmt.Sprintf("%s hostport %d", podFullName, pm.HostPort)
+		if pm.Protocol == v1.ProtocolUDP {
+			conntrackPortsToRemove = append(conntrackPortsToRemove, int(pm.HostPort))
+		}
+
+		// Recreate the chains to not leak any rules of previous Adds
+		for _, chain := range []string{hpChain, masqChain} {
+			tx.AddChain(&utilnftables.Chain{Name: chain})
+			tx.FlushChain(chain)
+			chains.Insert(chain)
+		}
+
+		// DNAT to the podIP:containerPort
+		dnat := &utilnftables.Rule{
+			Chain:           hpChain,
+			Protocol:        protocol,
+			DestinationPort: uint16(pm.HostPort),
+			DNAT: &utilnftables.NAT{
+				Address: podPortMapping.IP,
+				Port:    uint16(pm.ContainerPort),
+			},
+			Comment: comment,
+		}
+		if pm.HostIP != "" && pm.HostIP != "0.0.0.0" && pm.HostIP != "::" {
+			hostIP := net.ParseIP(pm.HostIP)
+			if hostIP == nil {
+				return utilerrors.NewAggregate([]error{
+					fmt.Errorf("invalid host IP %q of pod %s", pm.HostIP, podFullName),
+					closeHostports(hm.hostPortMap, hostportMappings, isIPv6),
+				})
+			}
+			dnat.DestinationAddress = hostNetwork(hostIP)
+		}
+		tx.AddRule(dnat)
+
+		// SNAT hairpin traffic, see the iptables based hostportManager.
+		// Traffic of the pod to itself only passes the host if its
+		// destination got translated, so matching DNATed connections
+		// replaces the original destination port match of iptables.
+		tx.AddRule(&utilnftables.Rule{
+			Chain:              masqChain,
+			DNATed:             true,
+			Protocol:           protocol,
+			SourceAddress:      podIP,
+			DestinationAddress: podIP,
+			DestinationPort:    uint16(pm.ContainerPort),
+			Masquerade:         true,
+			Comment:            comment,
+		})
+
+		if natInterfaceName != "" && natInterfaceName != "lo" {
+			// Need to SNAT traffic from localhost
+			localhost := &net.IPNet{IP: net.IPv4(127, 0, 0, 0), Mask: net.CIDRMask(8, 32)}
+			if isIPv6 {
+				localhost = hostNetwork(net.IPv6loopback)
+			}
+			tx.AddRule(&utilnftables.Rule{
+				Chain:           masqChain,
+				OutputInterface: natInterfaceName,
+				SourceAddress:   localhost,
+				Masquerade:      true,
+				Comment:         "SNAT for localhost access to hostports",
+			})
+		}
+	}
+	syncNFTablesDispatchChains(tx, chains)
+
+	if err := hm.run(tx); err != nil {
+		// clean up opened host port if encounter any error
+		return utilerrors.NewAggregate([]error{err, closeHostports(hm.hostPortMap, hostportMappings, isIPv6)})
+	}
+
+	// Remove conntrack entries just after adding the new rules, see the
+	// iptables based hostportManager for details.
+	logrus.Infof("Starting to delete udp conntrack entries: %v, isIPv6 - %v", conntrackPortsToRemove, isIPv6)
+	// https://www.iana.org/assignments/protocol-numbers/protocol-numbers.xhtml
+	const protocolUDPNumber = 17
+	for _, port := range conntrackPortsToRemove {
+		err = deleteConntrackEntriesForDstPort(uint16(port), protocolUDPNumber, getNetlinkFamily(isIPv6))
+		if err != nil {
+			logrus.Errorf("Failed to clear udp conntrack for port %d, error: %v", port, err)
+		}
+	}
+	return nil
+}
+
+func (hm *nftablesHostportManager) Remove(id string, podPortMapping *PodPortMapping) (err error) {
+	if podPortMapping == nil || podPortMapping.HostNetwork {
+		return nil
+	}
+
+	isIPv6 := hm.isIPv6()
+	hostportMappings := gatherHostportMappings(podPortMapping, isIPv6)
+	if len(hostportMappings) == 0 {
+		return nil
+	}
+
+	// Ensure atomicity for port closing and nftables operations
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+
+	existingChains, err := hm.nftables.ListChains(nftablesHostportsTable)
+	if err != nil {
+		return err
+	}
+	chains := sets.New(existingChains...)
+
+	// gather target hostport chains that exist in the table
+	chainsToRemove := []string{}
+	for _, pm := range hostportMappings {
+		for _, prefix := range []string{nftablesHostportChainPrefix, nftablesMasqueradeChainPrefix} {
+			chain := string(getHostportChain(prefix, id, pm))
+			if chains.Has(chain) {
+				chainsToRemove = append(chainsToRemove, chain)
+				chains.Delete(chain)
+			}
+		}
+	}
+
+	// exit if there is nothing to remove
+	// don´t forget to clean up opened pod host ports
+	if len(chainsToRemove) == 0 {
+		return closeHostports(hm.hostPortMap, hostportMappings, isIPv6)
+	}
+
+	// The chains are not referenced anymore once the dispatch chains got
+	// rebuilt, which allows deleting them within the same transaction.
+	tx := utilnftables.NewTransaction(hm.nftables.Family(), nftablesHostportsTable)
+	syncNFTablesDispatchChains(tx, chains)
+	for _, chain := range chainsToRemove {
+		tx.FlushChain(chain)
+		tx.DeleteChain(chain)
+	}
+
+	if err := hm.run(tx); err != nil {
+		return err
+	}
+
+	// clean up opened pod host ports
+	return closeHostports(hm.hostPortMap, hostportMappings, isIPv6)
+}
+
+// run applies the transaction to nftables
+func (hm *nftablesHostportManager) run(tx *utilnftables.Transaction) error {
+	logrus.Infof("Applying nftables rules: %s", tx)
+	if err := hm.nftables.Run(tx); err != nil {
+		return fmt.Errorf("failed to apply nftables rules: %w", err)
+	}
+	return nil
+}
+
+// ensureNFTablesHostportChains adds the hostports table including its base
+// and dispatch chains to the transaction
+func ensureNFTablesHostportChains(tx *utilnftables.Transaction) {
+	tx.AddTable()
+	baseChains := []*utilnftables.Chain{
+		{Name: nftablesPreroutingChain, Hook: utilnftables.HookPrerouting, Priority: utilnftables.PriorityDestinationNAT},
+		{Name: nftablesOutputChain, Hook: utilnftables.HookOutput, Priority: utilnftables.PriorityDestinationNAT},
+		{Name: nftablesPostroutingChain, Hook: utilnftables.HookPostrouting, Priority: utilnftables.PrioritySourceNAT},
+	}
+	for _, chain := range baseChains {
+		tx.AddChain(chain)
+		tx.FlushChain(chain.Name)
+	}
+	tx.AddChain(&utilnftables.Chain{Name: nftablesHostportsChain})
+	tx.AddChain(&utilnftables.Chain{Name: nftablesMasqueradingChain})
+
+	for _, chain := range []string{nftablesPreroutingChain, nftablesOutputChain} {
+		tx.AddRule(&utilnftables.Rule{
+			Chain:            chain,
+			DestinationLocal: true,
+			Jump:             nftablesHostportsChain,
+			Comment:          "kube hostport portals",
+		})
+	}
+	tx.AddRule(&utilnftables.Rule{
+		Chain:   nftablesPostroutingChain,
+		DNATed:  true,
+		Jump:    nftablesMasqueradingChain,
+		Comment: "kube hostport masquerading",
+	})
+}
+
+// syncNFTablesDispatchChains rebuilds the dispatch chains to jump into all
+// hostport and masquerade chains of the provided set. nftables rules can only
+// be deleted by their handle, which is why the dispatch chains get flushed and
+// refilled instead of removing single jumps.
+func syncNFTablesDispatchChains(tx *utilnftables.Transaction, chains sets.Set[string]) {
+	dispatchChains := []struct {
+		name   string
+		prefix string
+	}{
+		{nftablesHostportsChain, nftablesHostportChainPrefix},
+		{nftablesMasqueradingChain, nftablesMasqueradeChainPrefix},
+	}
+	for _, dispatch := range dispatchChains {
+		tx.FlushChain(dispatch.name)
+		for _, chain := range sets.List(chains) {
+			if strings.HasPrefix(chain, dispatch.prefix) {
+				tx.AddRule(&utilnftables.Rule{Chain: dispatch.name, Jump: chain})
+			}
+		}
+	}
+}
+
+// hostNetwork returns the single address network of the IP
+func hostNetwork(ip net.IP) *net.IPNet {
+	if ip4 := ip.To4(); ip4 != nil {
+		return &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}
+}
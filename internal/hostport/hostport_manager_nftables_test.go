@@ -0,0 +1,304 @@
+package hostport
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	utiliptables "github.com/cri-o/cri-o/internal/iptables"
+	utilnftables "github.com/cri-o/cri-o/internal/nftables"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestNFTablesHostportManager(t *testing.T) {
+	nftables := newFakeNFTables()
+	portOpener := newFakeSocketManager()
+	manager := &nftablesHostportManager{
+		hostPortMap: make(map[hostport]closeable),
+		nftables:    nftables,
+		portOpener:  portOpener.openFakeSocket,
+	}
+	testCases := []struct {
+		id          string
+		mapping     *PodPortMapping
+		expectError bool
+	}{
+		// open HostPorts 8080/TCP, 8081/UDP and 8083/SCTP
+		{
+			id: "id1",
+			mapping: &PodPortMapping{
+				Name:      "pod1",
+				Namespace: "ns1",
+				IP:        net.ParseIP("10.1.1.2"),
+				PortMappings: []*PortMapping{
+					{HostPort: 8080, ContainerPort: 80, Protocol: v1.ProtocolTCP},
+					{HostPort: 8081, ContainerPort: 81, Protocol: v1.ProtocolUDP},
+					{HostPort: 8083, ContainerPort: 83, Protocol: v1.ProtocolSCTP},
+				},
+			},
+		},
+		// fail to open HostPort due to conflict 8081/UDP
+		{
+			id: "id2",
+			mapping: &PodPortMapping{
+				Name:      "pod2",
+				Namespace: "ns1",
+				IP:        net.ParseIP("10.1.1.3"),
+				PortMappings: []*PortMapping{
+					{HostPort: 8082, ContainerPort: 80, Protocol: v1.ProtocolTCP},
+					{HostPort: 8081, ContainerPort: 81, Protocol: v1.ProtocolUDP},
+				},
+			},
+			expectError: true,
+		},
+		// open HostPort 8443 on a specific HostIP
+		{
+			id: "id3",
+			mapping: &PodPortMapping{
+				Name:      "pod3",
+				Namespace: "ns1",
+				IP:        net.ParseIP("10.1.1.4"),
+				PortMappings: []*PortMapping{
+					{HostPort: 8443, ContainerPort: 443, Protocol: v1.ProtocolTCP, HostIP: "192.168.0.1"},
+				},
+			},
+		},
+		// fail on IP family mismatch
+		{
+			id: "id4",
+			mapping: &PodPortMapping{
+				Name:      "pod4",
+				Namespace: "ns1",
+				IP:        net.ParseIP("2001:beef::2"),
+				PortMappings: []*PortMapping{
+					{HostPort: 8444, ContainerPort: 443, Protocol: v1.ProtocolTCP},
+				},
+			},
+			expectError: true,
+		},
+	}
+
+	// Add Hostports
+	for _, tc := range testCases {
+		err := manager.Add(tc.id, tc.mapping, "cbr0")
+		if tc.expectError {
+			assert.Error(t, err)
+			continue
+		}
+		assert.NoError(t, err)
+	}
+
+	// Check port opened
+	expectedPorts := []hostport{
+		{IPv4, "", 8080, "tcp"},
+		{IPv4, "", 8081, "udp"},
+		{IPv4, "192.168.0.1", 8443, "tcp"},
+	}
+	openedPorts := make(map[hostport]bool)
+	for hp, port := range portOpener.mem {
+		if !port.closed {
+			openedPorts[hp] = true
+		}
+	}
+	assert.EqualValues(t, len(openedPorts), len(expectedPorts))
+	for _, hp := range expectedPorts {
+		_, ok := openedPorts[hp]
+		assert.EqualValues(t, true, ok)
+	}
+
+	// Check base chains
+	for _, chain := range []string{nftablesPreroutingChain, nftablesOutputChain} {
+		rules, err := nftables.getRules(nftablesHostportsTable, chain)
+		require.NoError(t, err)
+		assert.Equal(t, []string{`fib daddr type local jump hostports comment "kube hostport portals"`}, rules)
+	}
+	rules, err := nftables.getRules(nftablesHostportsTable, nftablesPostroutingChain)
+	require.NoError(t, err)
+	assert.Equal(t, []string{`ct status dnat jump masquerading comment "kube hostport masquerading"`}, rules)
+
+	// Check per mapping chains, including the SCTP one which has no opened port
+	hostportRules, err := nftables.getRules(nftablesHostportsTable, nftablesHostportsChain)
+	require.NoError(t, err)
+	assert.Len(t, hostportRules, 4)
+	masqueradingRules, err := nftables.getRules(nftablesHostportsTable, nftablesMasqueradingChain)
+	require.NoError(t, err)
+	assert.Len(t, masqueradingRules, 4)
+
+	expectedRules := map[string][]string{
+		string(getHostportChain(nftablesHostportChainPrefix, "id1", &PortMapping{HostPort: 8080, Protocol: v1.ProtocolTCP})): {
+			`meta l4proto tcp th dport 8080 dnat to 10.1.1.2:80 comment "pod1_ns1 hostport 8080"`,
+		},
+		string(getHostportChain(nftablesMasqueradeChainPrefix, "id1", &PortMapping{HostPort: 8080, Protocol: v1.ProtocolTCP})): {
+			`ct status dnat meta l4proto tcp ip saddr 10.1.1.2 ip daddr 10.1.1.2 th dport 80 masquerade comment "pod1_ns1 hostport 8080"`,
+			`oifname "cbr0" ip saddr 127.0.0.0/8 masquerade comment "SNAT for localhost access to hostports"`,
+		},
+		string(getHostportChain(nftablesHostportChainPrefix, "id1", &PortMapping{HostPort: 8081, Protocol: v1.ProtocolUDP})): {
+			`meta l4proto udp th dport 8081 dnat to 10.1.1.2:81 comment "pod1_ns1 hostport 8081"`,
+		},
+		string(getHostportChain(nftablesHostportChainPrefix, "id1", &PortMapping{HostPort: 8083, Protocol: v1.ProtocolSCTP})): {
+			`meta l4proto sctp th dport 8083 dnat to 10.1.1.2:83 comment "pod1_ns1 hostport 8083"`,
+		},
+		string(getHostportChain(nftablesHostportChainPrefix, "id3", &PortMapping{HostPort: 8443, Protocol: v1.ProtocolTCP, HostIP: "192.168.0.1"})): {
+			`meta l4proto tcp ip daddr 192.168.0.1 th dport 8443 dnat to 10.1.1.4:443 comment "pod3_ns1 hostport 8443"`,
+		},
+	}
+	for chain, expected := range expectedRules {
+		rules, err := nftables.getRules(nftablesHostportsTable, chain)
+		require.NoError(t, err, chain)
+		assert.Equal(t, expected, rules, chain)
+		assert.Contains(t, append(hostportRules, masqueradingRules...), "jump "+chain)
+	}
+
+	// Adding a removed pod again restores the same rules
+	assert.NoError(t, manager.Remove("id1", testCases[0].mapping))
+	assert.NoError(t, manager.Add("id1", testCases[0].mapping, "cbr0"))
+	for chain, expected := range expectedRules {
+		rules, err := nftables.getRules(nftablesHostportsTable, chain)
+		require.NoError(t, err, chain)
+		assert.Equal(t, expected, rules, chain)
+	}
+
+	// Remove all added hostports
+	for _, tc := range testCases {
+		if !tc.expectError {
+			assert.NoError(t, manager.Remove(tc.id, tc.mapping))
+		}
+	}
+
+	// Check that only the static chains remain
+	chains, err := nftables.ListChains(nftablesHostportsTable)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{
+		nftablesPreroutingChain,
+		nftablesOutputChain,
+		nftablesPostroutingChain,
+		nftablesHostportsChain,
+		nftablesMasqueradingChain,
+	}, chains)
+	for _, chain := range []string{nftablesHostportsChain, nftablesMasqueradingChain} {
+		rules, err := nftables.getRules(nftablesHostportsTable, chain)
+		require.NoError(t, err)
+		assert.Empty(t, rules)
+	}
+
+	// Check that all ports got closed
+	for _, port := range portOpener.mem {
+		assert.True(t, port.closed)
+	}
+	assert.Empty(t, manager.hostPortMap)
+}
+
+func TestNFTablesHostportManagerIPv6(t *testing.T) {
+	nftables := newFakeNFTables()
+	nftables.family = utilnftables.FamilyIPv6
+	portOpener := newFakeSocketManager()
+	manager := &nftablesHostportManager{
+		hostPortMap: make(map[hostport]closeable),
+		nftables:    nftables,
+		portOpener:  portOpener.openFakeSocket,
+	}
+	mapping := &PodPortMapping{
+		Name:      "pod1",
+		Namespace: "ns1",
+		IP:        net.ParseIP("2001:beef::2"),
+		PortMappings: []*PortMapping{
+			{HostPort: 8080, ContainerPort: 80, Protocol: v1.ProtocolTCP},
+			// skipped because of the IPv4 HostIP
+			{HostPort: 8081, ContainerPort: 81, Protocol: v1.ProtocolTCP, HostIP: "192.168.0.1"},
+		},
+	}
+
+	assert.NoError(t, manager.Add("id1", mapping, "cbr0"))
+	assert.Len(t, manager.hostPortMap, 1)
+
+	pm := &PortMapping{HostPort: 8080, Protocol: v1.ProtocolTCP}
+	rules, err := nftables.getRules(nftablesHostportsTable, string(getHostportChain(nftablesHostportChainPrefix, "id1", pm)))
+	require.NoError(t, err)
+	assert.Equal(t, []string{`meta l4proto tcp th dport 8080 dnat to [2001:beef::2]:80 comment "pod1_ns1 hostport 8080"`}, rules)
+
+	rules, err = nftables.getRules(nftablesHostportsTable, string(getHostportChain(nftablesMasqueradeChainPrefix, "id1", pm)))
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		`ct status dnat meta l4proto tcp ip6 saddr 2001:beef::2 ip6 daddr 2001:beef::2 th dport 80 masquerade comment "pod1_ns1 hostport 8080"`,
+		`oifname "cbr0" ip6 saddr ::1 masquerade comment "SNAT for localhost access to hostports"`,
+	}, rules)
+
+	// Remove works without pod IP
+	assert.NoError(t, manager.Remove("id1", &PodPortMapping{
+		Name:         "pod1",
+		Namespace:    "ns1",
+		PortMappings: mapping.PortMappings,
+	}))
+	assert.Empty(t, manager.hostPortMap)
+	rules, err = nftables.getRules(nftablesHostportsTable, nftablesHostportsChain)
+	require.NoError(t, err)
+	assert.Empty(t, rules)
+}
+
+func TestNFTablesHostportManagerRunError(t *testing.T) {
+	nftables := newFakeNFTables()
+	portOpener := newFakeSocketManager()
+	manager := &nftablesHostportManager{
+		hostPortMap: make(map[hostport]closeable),
+		nftables:    nftables,
+		portOpener:  portOpener.openFakeSocket,
+	}
+	mapping := &PodPortMapping{
+		Name:      "pod1",
+		Namespace: "ns1",
+		IP:        net.ParseIP("10.1.1.2"),
+		PortMappings: []*PortMapping{
+			{HostPort: 8080, ContainerPort: 80, Protocol: v1.ProtocolTCP},
+		},
+	}
+
+	// The transaction fails because of the family mismatch of the fake
+	nftables.family = utilnftables.FamilyIPv6
+	manager.nftables = &fakeNFTablesFamily{fakeNFTables: nftables, family: utilnftables.FamilyIPv4}
+	assert.Error(t, manager.Add("id1", mapping, ""))
+
+	// Opened ports got closed again and nothing got applied
+	assert.Empty(t, manager.hostPortMap)
+	for _, port := range portOpener.mem {
+		assert.True(t, port.closed)
+	}
+	assert.Empty(t, nftables.tables)
+}
+
+// fakeNFTablesFamily overrides the reported family of the fake.
+type fakeNFTablesFamily struct {
+	*fakeNFTables
+	family utilnftables.Family
+}
+
+func (f *fakeNFTablesFamily) Family() utilnftables.Family {
+	return f.family
+}
+
+func TestUseNFTables(t *testing.T) {
+	found := func(string) (string, error) { return "/usr/sbin/iptables", nil }
+	notFound := func(string) (string, error) { return "", errors.New("not found") }
+
+	testCases := []struct {
+		name            string
+		lookPath        func(string) (string, error)
+		iptablesPresent bool
+		nftablesPresent bool
+		expected        bool
+	}{
+		{"iptables usable", found, true, true, false},
+		{"iptables binary missing", notFound, true, true, true},
+		{"iptables backend disabled", found, false, true, true},
+		{"nftables not supported", notFound, false, false, false},
+	}
+	for _, tc := range testCases {
+		iptables := newFakeIPTables()
+		iptables.protocol = utiliptables.ProtocolIPv4
+		iptables.present = tc.iptablesPresent
+		nftables := newFakeNFTables()
+		nftables.present = tc.nftablesPresent
+		assert.Equal(t, tc.expected, useNFTables(tc.lookPath, iptables, nftables), tc.name)
+	}
+}
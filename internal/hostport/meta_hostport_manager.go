@@ -5,6 +5,8 @@ import (
 	"strings"
 
 	utiliptables "github.com/cri-o/cri-o/internal/iptables"
+	utilnftables "github.com/cri-o/cri-o/internal/nftables"
+	"github.com/sirupsen/logrus"
 	utilexec "k8s.io/utils/exec"
 	utilnet "k8s.io/utils/net"
 )
@@ -14,23 +16,44 @@ type metaHostportManager struct {
 	ipv6HostportManager HostPortManager
 }
 
-// NewMetaHostportManager creates a new HostPortManager
+// NewMetaHostportManager creates a new HostPortManager. It uses nftables
+// directly if the iptables binaries are not available or the kernel lacks
+// support for the iptables backend.
 func NewMetaHostportManager() HostPortManager {
 	exec := utilexec.New()
-	// Create IPv4 handler
-	iptInterface := utiliptables.New(exec, utiliptables.ProtocolIPv4)
-	hostportManagerv4 := NewHostportManager(iptInterface)
-	// Create IPv6 handler
-	ip6tInterface := utiliptables.New(exec, utiliptables.ProtocolIPv6)
-	hostportManagerv6 := NewHostportManager(ip6tInterface)
-
 	h := &metaHostportManager{
-		ipv4HostportManager: hostportManagerv4,
-		ipv6HostportManager: hostportManagerv6,
+		ipv4HostportManager: newHostportManagerForFamily(exec, utiliptables.ProtocolIPv4, utilnftables.FamilyIPv4),
+		ipv6HostportManager: newHostportManagerForFamily(exec, utiliptables.ProtocolIPv6, utilnftables.FamilyIPv6),
 	}
 	return h
 }
 
+// newHostportManagerForFamily creates the iptables or nftables based
+// HostPortManager for a single IP family.
+func newHostportManagerForFamily(exec utilexec.Interface, protocol utiliptables.Protocol, family utilnftables.Family) HostPortManager {
+	iptInterface := utiliptables.New(exec, protocol)
+	nftInterface := utilnftables.New(family)
+	if useNFTables(exec.LookPath, iptInterface, nftInterface) {
+		logrus.Infof("Using nftables for IPv%s hostport mapping", getIPFamily(iptInterface.IsIPv6()))
+		return NewNFTablesHostportManager(nftInterface)
+	}
+	return NewHostportManager(iptInterface)
+}
+
+// useNFTables returns true if hostports should be programmed via nftables,
+// which is the case if the iptables binary is absent or the iptables backend
+// is not usable while nftables is supported by the kernel.
+func useNFTables(lookPath func(string) (string, error), iptables utiliptables.Interface, nftables utilnftables.Interface) bool {
+	binary := "iptables"
+	if iptables.IsIPv6() {
+		binary = "ip6tables"
+	}
+	if _, err := lookPath(binary); err == nil && iptables.Present() {
+		return false
+	}
+	return nftables.Present()
+}
+
 func (mh *metaHostportManager) Add(id string, podPortMapping *PodPortMapping, natInterfaceName string) error {
 	if utilnet.IsIPv6(podPortMapping.IP) {
 		return mh.ipv6HostportManager.Add(id, podPortMapping, natInterfaceName)
@@ -0,0 +1,303 @@
+// Package imagegc implements a policy-driven image garbage collector, which
+// evicts unused images from the local storage independently of the kubelet.
+package imagegc
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/containers/image/v5/types"
+	"github.com/cri-o/cri-o/internal/log"
+	"github.com/cri-o/cri-o/internal/storage"
+	"github.com/cri-o/cri-o/pkg/annotations"
+	crioTypes "github.com/cri-o/cri-o/pkg/types"
+)
+
+const (
+	// ReasonMaxUnusedAge is the eviction reason for images which have not
+	// been used for longer than the configured maximum age.
+	ReasonMaxUnusedAge = "max_unused_age"
+
+	// ReasonMaxImages is the eviction reason for images exceeding the
+	// configured maximum number of images.
+	ReasonMaxImages = "max_images"
+
+	// ReasonDiskUsage is the eviction reason for images removed to lower the
+	// image filesystem usage below the low threshold.
+	ReasonDiskUsage = "disk_usage"
+)
+
+// Policy defines which images get evicted. Every zero value disables the
+// corresponding rule.
+type Policy struct {
+	// MaxUnusedAge evicts images which have not been used by any container
+	// for longer than the duration.
+	MaxUnusedAge time.Duration
+
+	// MaxImages evicts the least recently used images until the number of
+	// images does not exceed the value.
+	MaxImages int
+
+	// HighThresholdPercent triggers the eviction of the least recently used
+	// images once the image filesystem usage reaches the percentage.
+	HighThresholdPercent int
+
+	// LowThresholdPercent is the image filesystem usage percentage the
+	// eviction triggered by HighThresholdPercent aims for.
+	LowThresholdPercent int
+}
+
+// Enabled returns true if at least one rule of the policy is enabled.
+func (p *Policy) Enabled() bool {
+	return p.MaxUnusedAge > 0 || p.MaxImages > 0 || p.HighThresholdPercent > 0
+}
+
+// Options are the dependencies of the GarbageCollector.
+type Options struct {
+	// ImageServer is used to list and remove the images.
+	ImageServer storage.ImageServer
+
+	// SystemContext is passed to the ImageServer.
+	SystemContext *types.SystemContext
+
+	// ProtectedImages is a list of image names which must never be removed,
+	// in addition to the pinned ones.
+	ProtectedImages []string
+
+	// ImagesInUse returns the IDs of all images referenced by a container.
+	ImagesInUse func() map[string]bool
+
+	// FsUsage returns the used and the total bytes of the image filesystem.
+	FsUsage func() (used, capacity uint64, err error)
+}
+
+// GarbageCollector removes images according to a Policy.
+type GarbageCollector struct {
+	policy Policy
+	opts   Options
+
+	// mu serializes the garbage collection runs and protects lastUsed.
+	mu sync.Mutex
+	// lastUsed is the last time an image has been used, seen in use, or the
+	// first time it has been seen at all if it has never been used.
+	lastUsed map[string]time.Time
+	// now can be overridden for testing purposes.
+	now func() time.Time
+}
+
+// New creates a new GarbageCollector.
+func New(policy Policy, opts *Options) *GarbageCollector {
+	return &GarbageCollector{
+		policy:   policy,
+		opts:     *opts,
+		lastUsed: make(map[string]time.Time),
+		now:      time.Now,
+	}
+}
+
+// Start runs the garbage collection every interval until stop gets closed.
+func (gc *GarbageCollector) Start(ctx context.Context, interval time.Duration, stop <-chan struct{}) {
+	if interval <= 0 || !gc.policy.Enabled() {
+		log.Debugf(ctx, "Image garbage collection is disabled")
+		return
+	}
+	log.Infof(ctx, "Starting image garbage collection every %v", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if _, err := gc.Run(ctx, false); err != nil {
+				log.Warnf(ctx, "Image garbage collection failed: %v", err)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// Run evicts the images selected by the policy and returns them. The images
+// are only selected but not removed if dryRun is true, which also leaves the
+// recorded usage of the images untouched.
+func (gc *GarbageCollector) Run(ctx context.Context, dryRun bool) (*crioTypes.ImageGCResult, error) {
+	ctx, span := log.StartSpan(ctx)
+	defer span.End()
+
+	gc.mu.Lock()
+	defer gc.mu.Unlock()
+
+	images, err := gc.opts.ImageServer.ListImages(gc.opts.SystemContext)
+	if err != nil {
+		return nil, fmt.Errorf("list images: %w", err)
+	}
+	candidates, lastUsed := gc.candidates(images)
+	if !dryRun {
+		gc.lastUsed = lastUsed
+	}
+
+	selected, err := gc.selectImages(len(images), candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &crioTypes.ImageGCResult{
+		DryRun: dryRun,
+		Images: []crioTypes.ImageGCImage{},
+	}
+	for _, c := range selected {
+		if !dryRun {
+			if err := gc.opts.ImageServer.DeleteImage(gc.opts.SystemContext, c.image.ID); err != nil {
+				log.Warnf(ctx, "Unable to remove image %s: %v", c.image.ID, err)
+				continue
+			}
+			delete(gc.lastUsed, c.id)
+			log.Infof(ctx, "Removed image %s (%v) because of %s", c.id, c.image.RepoTags, c.reason)
+		}
+		res.Images = append(res.Images, crioTypes.ImageGCImage{
+			ID:       c.id,
+			RepoTags: c.image.RepoTags,
+			Size:     c.size(),
+			LastUsed: c.lastUsed.UnixNano(),
+			Reason:   c.reason,
+		})
+		res.FreedBytes += c.size()
+	}
+	return res, nil
+}
+
+// candidate is an image which could be evicted.
+type candidate struct {
+	id       string
+	image    *storage.ImageResult
+	lastUsed time.Time
+	reason   string
+}
+
+func (c *candidate) size() uint64 {
+	if c.image.Size == nil {
+		return 0
+	}
+	return *c.image.Size
+}
+
+// candidates returns all images which are neither pinned, protected nor in
+// use, ordered from the least to the most recently used, together with the
+// updated last used times of the present images.
+func (gc *GarbageCollector) candidates(images []storage.ImageResult) ([]*candidate, map[string]time.Time) {
+	now := gc.now()
+	inUse := map[string]bool{}
+	if gc.opts.ImagesInUse != nil {
+		inUse = gc.opts.ImagesInUse()
+	}
+
+	updated := make(map[string]time.Time, len(images))
+	res := []*candidate{}
+	for i := range images {
+		image := &images[i]
+		id := image.ID.IDStringForOutOfProcessConsumptionOnly()
+		lastUsed, ok := gc.lastUsed[id]
+		switch {
+		case inUse[id]:
+			lastUsed = now
+		case image.LastUsed.After(lastUsed):
+			// Prefer the usage persisted in the image metadata, which
+			// survives restarts of the server.
+			lastUsed = image.LastUsed
+		case !ok:
+			lastUsed = now
+		}
+		updated[id] = lastUsed
+		if inUse[id] || image.Pinned || gc.isProtected(image) {
+			continue
+		}
+		res = append(res, &candidate{id: id, image: image, lastUsed: lastUsed})
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		if !res[i].lastUsed.Equal(res[j].lastUsed) {
+			return res[i].lastUsed.Before(res[j].lastUsed)
+		}
+		return res[i].id < res[j].id
+	})
+	return res, updated
+}
+
+// isProtected returns true if the image is a checkpoint or if any name of the
+// image matches one of the protected images. Checkpoints are never used by a
+// container and have to be removed explicitly.
+func (gc *GarbageCollector) isProtected(image *storage.ImageResult) bool {
+	if _, ok := image.Annotations[annotations.CheckpointAnnotationName]; ok {
+		return true
+	}
+	for _, name := range gc.opts.ProtectedImages {
+		if slices.Contains(image.RepoTags, name) || slices.Contains(image.RepoDigests, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// selectImages applies the policy to the candidates and returns the images
+// to be evicted, ordered from the least to the most recently used.
+func (gc *GarbageCollector) selectImages(total int, candidates []*candidate) ([]*candidate, error) {
+	now := gc.now()
+	selected := 0
+
+	if gc.policy.MaxUnusedAge > 0 {
+		for _, c := range candidates {
+			if now.Sub(c.lastUsed) > gc.policy.MaxUnusedAge {
+				c.reason = ReasonMaxUnusedAge
+				selected++
+			}
+		}
+	}
+
+	if gc.policy.MaxImages > 0 {
+		for _, c := range candidates {
+			if total-selected <= gc.policy.MaxImages {
+				break
+			}
+			if c.reason == "" {
+				c.reason = ReasonMaxImages
+				selected++
+			}
+		}
+	}
+
+	if gc.policy.HighThresholdPercent > 0 && gc.opts.FsUsage != nil {
+		used, capacity, err := gc.opts.FsUsage()
+		if err != nil {
+			return nil, fmt.Errorf("get image filesystem usage: %w", err)
+		}
+		if capacity > 0 && used*100 >= uint64(gc.policy.HighThresholdPercent)*capacity {
+			target := uint64(gc.policy.LowThresholdPercent) * capacity / 100
+			for _, c := range candidates {
+				if c.reason != "" {
+					used -= min(used, c.size())
+				}
+			}
+			for _, c := range candidates {
+				if used <= target {
+					break
+				}
+				if c.reason == "" {
+					c.reason = ReasonDiskUsage
+					used -= min(used, c.size())
+				}
+			}
+		}
+	}
+
+	res := []*candidate{}
+	for _, c := range candidates {
+		if c.reason != "" {
+			res = append(res, c)
+		}
+	}
+	return res, nil
+}
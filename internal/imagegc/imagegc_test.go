@@ -0,0 +1,241 @@
+package imagegc_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/cri-o/cri-o/internal/imagegc"
+	"github.com/cri-o/cri-o/internal/storage"
+	"github.com/cri-o/cri-o/pkg/annotations"
+	criostoragemock "github.com/cri-o/cri-o/test/mocks/criostorage"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// The actual test suite
+var _ = t.Describe("GarbageCollector", func() {
+	var (
+		mockCtrl        *gomock.Controller
+		imageServerMock *criostoragemock.MockImageServer
+		now             time.Time
+		inUse           map[string]bool
+		opts            *imagegc.Options
+	)
+
+	newImage := func(idChar, tag string, size uint64, pinned bool) storage.ImageResult {
+		id, err := storage.ParseStorageImageIDFromOutOfProcessData(strings.Repeat(idChar, 64))
+		Expect(err).ToNot(HaveOccurred())
+		return storage.ImageResult{
+			ID:       id,
+			RepoTags: []string{tag},
+			Size:     &size,
+			Pinned:   pinned,
+		}
+	}
+
+	newSut := func(policy imagegc.Policy) *imagegc.GarbageCollector {
+		sut := imagegc.New(policy, opts)
+		sut.SetNow(func() time.Time { return now })
+		return sut
+	}
+
+	resultIDs := func(sut *imagegc.GarbageCollector, dryRun bool) []string {
+		res, err := sut.Run(context.Background(), dryRun)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(res.DryRun).To(Equal(dryRun))
+		// Return the character the image ID has been created from.
+		ids := []string{}
+		for _, image := range res.Images {
+			ids = append(ids, image.ID[:1])
+		}
+		return ids
+	}
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		imageServerMock = criostoragemock.NewMockImageServer(mockCtrl)
+		now = time.Now()
+		inUse = map[string]bool{}
+		opts = &imagegc.Options{
+			ImageServer:     imageServerMock,
+			ProtectedImages: []string{"registry.k8s.io/pause:3.9"},
+			ImagesInUse:     func() map[string]bool { return inUse },
+		}
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	It("should select images unused for longer than the max age", func() {
+		// Given
+		images := []storage.ImageResult{
+			newImage("a", "quay.io/a:latest", 10, false),
+			newImage("b", "quay.io/b:latest", 10, false),
+		}
+		imageServerMock.EXPECT().ListImages(gomock.Any()).Return(images, nil).Times(2)
+		sut := newSut(imagegc.Policy{MaxUnusedAge: time.Hour})
+		Expect(resultIDs(sut, false)).To(BeEmpty())
+
+		// When
+		now = now.Add(2 * time.Hour)
+		inUse[strings.Repeat("b", 64)] = true
+		ids := resultIDs(sut, true)
+
+		// Then
+		Expect(ids).To(Equal([]string{"a"}))
+	})
+
+	It("should not record the usage of images on a dry run", func() {
+		// Given
+		images := []storage.ImageResult{
+			newImage("a", "quay.io/a:latest", 10, false),
+		}
+		imageServerMock.EXPECT().ListImages(gomock.Any()).Return(images, nil).Times(2)
+		sut := newSut(imagegc.Policy{MaxUnusedAge: time.Hour})
+		Expect(resultIDs(sut, true)).To(BeEmpty())
+
+		// When
+		now = now.Add(2 * time.Hour)
+		ids := resultIDs(sut, true)
+
+		// Then
+		Expect(ids).To(BeEmpty())
+	})
+
+	It("should select images by their persisted last usage", func() {
+		// Given
+		images := []storage.ImageResult{
+			newImage("a", "quay.io/a:latest", 10, false),
+			newImage("b", "quay.io/b:latest", 10, false),
+		}
+		images[0].LastUsed = now.Add(-2 * time.Hour)
+		images[1].LastUsed = now.Add(-time.Minute)
+		imageServerMock.EXPECT().ListImages(gomock.Any()).Return(images, nil)
+		sut := newSut(imagegc.Policy{MaxUnusedAge: time.Hour})
+
+		// When
+		ids := resultIDs(sut, true)
+
+		// Then
+		Expect(ids).To(Equal([]string{"a"}))
+	})
+
+	It("should never select pinned, protected, used or checkpoint images", func() {
+		// Given
+		images := []storage.ImageResult{
+			newImage("a", "quay.io/a:latest", 10, true),
+			newImage("b", "registry.k8s.io/pause:3.9", 10, false),
+			newImage("c", "quay.io/c:latest", 10, false),
+			newImage("d", "localhost/checkpoint:latest", 10, false),
+		}
+		inUse[strings.Repeat("c", 64)] = true
+		images[3].Annotations = map[string]string{annotations.CheckpointAnnotationName: "ctr"}
+		imageServerMock.EXPECT().ListImages(gomock.Any()).Return(images, nil)
+		sut := newSut(imagegc.Policy{MaxImages: 1})
+
+		// When
+		ids := resultIDs(sut, true)
+
+		// Then
+		Expect(ids).To(BeEmpty())
+	})
+
+	It("should remove the least recently used images exceeding the max images", func() {
+		// Given
+		images := []storage.ImageResult{
+			newImage("a", "quay.io/a:latest", 10, false),
+			newImage("b", "quay.io/b:latest", 10, false),
+			newImage("c", "quay.io/c:latest", 10, false),
+		}
+		images[1].LastUsed = now.Add(-2 * time.Minute)
+		images[2].LastUsed = now.Add(-time.Minute)
+		sut := newSut(imagegc.Policy{MaxImages: 1})
+
+		gomock.InOrder(
+			imageServerMock.EXPECT().ListImages(gomock.Any()).Return(images, nil),
+			imageServerMock.EXPECT().DeleteImage(gomock.Any(), images[1].ID).Return(nil),
+			imageServerMock.EXPECT().DeleteImage(gomock.Any(), images[2].ID).Return(nil),
+		)
+
+		// When
+		ids := resultIDs(sut, false)
+
+		// Then
+		Expect(ids).To(Equal([]string{"b", "c"}))
+	})
+
+	It("should remove images until the low threshold is reached", func() {
+		// Given
+		images := []storage.ImageResult{
+			newImage("a", "quay.io/a:latest", 20, false),
+			newImage("b", "quay.io/b:latest", 30, false),
+			newImage("c", "quay.io/c:latest", 10, false),
+		}
+		opts.FsUsage = func() (used, capacity uint64, err error) { return 90, 100, nil }
+		imageServerMock.EXPECT().ListImages(gomock.Any()).Return(images, nil)
+		sut := newSut(imagegc.Policy{HighThresholdPercent: 80, LowThresholdPercent: 50})
+
+		// When
+		res, err := sut.Run(context.Background(), true)
+
+		// Then
+		Expect(err).ToNot(HaveOccurred())
+		Expect(res.Images).To(HaveLen(2))
+		Expect(res.Images[0].Reason).To(Equal(imagegc.ReasonDiskUsage))
+		Expect(res.FreedBytes).To(BeEquivalentTo(50))
+	})
+
+	It("should not remove images below the high threshold", func() {
+		// Given
+		images := []storage.ImageResult{
+			newImage("a", "quay.io/a:latest", 20, false),
+		}
+		opts.FsUsage = func() (used, capacity uint64, err error) { return 79, 100, nil }
+		imageServerMock.EXPECT().ListImages(gomock.Any()).Return(images, nil)
+		sut := newSut(imagegc.Policy{HighThresholdPercent: 80, LowThresholdPercent: 50})
+
+		// When
+		ids := resultIDs(sut, true)
+
+		// Then
+		Expect(ids).To(BeEmpty())
+	})
+
+	It("should skip images which fail to be removed", func() {
+		// Given
+		images := []storage.ImageResult{
+			newImage("a", "quay.io/a:latest", 10, false),
+			newImage("b", "quay.io/b:latest", 10, false),
+		}
+		gomock.InOrder(
+			imageServerMock.EXPECT().ListImages(gomock.Any()).Return(images, nil),
+			imageServerMock.EXPECT().DeleteImage(gomock.Any(), images[0].ID).Return(errors.New("error")),
+			imageServerMock.EXPECT().DeleteImage(gomock.Any(), images[1].ID).Return(nil),
+		)
+		opts.FsUsage = func() (used, capacity uint64, err error) { return 100, 100, nil }
+		sut := newSut(imagegc.Policy{HighThresholdPercent: 80})
+
+		// When
+		ids := resultIDs(sut, false)
+
+		// Then
+		Expect(ids).To(Equal([]string{"b"}))
+	})
+
+	It("should fail if the images cannot be listed", func() {
+		// Given
+		imageServerMock.EXPECT().ListImages(gomock.Any()).Return(nil, errors.New("error"))
+		sut := newSut(imagegc.Policy{MaxImages: 1})
+
+		// When
+		res, err := sut.Run(context.Background(), true)
+
+		// Then
+		Expect(err).To(HaveOccurred())
+		Expect(res).To(BeNil())
+	})
+})
@@ -0,0 +1,14 @@
+//go:build test
+// +build test
+
+// All *_inject.go files are meant to be used by tests only. Purpose of this
+// files is to provide a way to inject mocked data into the current setup.
+
+package imagegc
+
+import "time"
+
+// SetNow overrides the clock of the garbage collector for testing purposes.
+func (gc *GarbageCollector) SetNow(now func() time.Time) {
+	gc.now = now
+}
@@ -2,6 +2,7 @@ package lib
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -15,6 +16,7 @@ import (
 	"github.com/cri-o/cri-o/internal/log"
 	"github.com/cri-o/cri-o/internal/oci"
 	"github.com/cri-o/cri-o/pkg/annotations"
+	"github.com/cri-o/cri-o/server/metrics"
 	rspec "github.com/opencontainers/runtime-spec/specs-go"
 	"github.com/opencontainers/runtime-tools/generate"
 )
@@ -29,6 +31,22 @@ type ContainerCheckpointOptions struct {
 	// TargetFile tells the API to read (or write) the checkpoint image
 	// from (or to) the filename set in TargetFile
 	TargetFile string
+	// TargetImage tells the API to store the checkpoint as OCI image with
+	// the name set in TargetImage in the local storage, additionally to
+	// writing it to TargetFile if set
+	TargetImage string
+	// ImageCompression is the compression of the checkpoint image layer,
+	// either "gzip" or "zstd"
+	ImageCompression string
+	// IncrementalChainLength is the maximum number of incremental
+	// checkpoints of a container which keeps running. Each of them only
+	// dumps the memory pages changed since the previous one. 0 disables
+	// incremental checkpoints.
+	IncrementalChainLength int
+	// Paused tells the API that the caller already paused the container,
+	// for example to checkpoint all containers of a pod at the same point
+	// in time. The container is then neither paused nor resumed here.
+	Paused bool
 }
 
 // ContainerCheckpoint checkpoints a running container.
@@ -37,6 +55,7 @@ func (c *ContainerServer) ContainerCheckpoint(
 	config *metadata.ContainerConfig,
 	opts *ContainerCheckpointOptions,
 ) (string, error) {
+	start := time.Now()
 	ctr, err := c.LookupContainer(ctx, config.ID)
 	if err != nil {
 		return "", fmt.Errorf("failed to find container %s: %w", config.ID, err)
@@ -48,9 +67,31 @@ func (c *ContainerServer) ContainerCheckpoint(
 		return "", fmt.Errorf("not able to read config for container %q: %w", ctr.ID(), err)
 	}
 
+	var expectedStatus rspec.ContainerState = oci.ContainerStateRunning
+	if opts.Paused {
+		expectedStatus = oci.ContainerStatePaused
+	}
 	cStatus := ctr.State()
-	if cStatus.Status != oci.ContainerStateRunning {
-		return "", fmt.Errorf("container %s is not running", ctr.ID())
+	if cStatus.Status != expectedStatus {
+		return "", fmt.Errorf("container %s is not %s", ctr.ID(), expectedStatus)
+	}
+
+	// The memory of the container gets pre-dumped before pausing it, which
+	// keeps the pause as short as possible.
+	incremental := opts.KeepRunning && opts.IncrementalChainLength > 0 && !opts.Paused
+	parentPath := ""
+	if incremental {
+		parentPath, err = c.prepareIncrementalCheckpoint(ctx, ctr, specgen.Config, opts.IncrementalChainLength)
+		if errors.Is(err, oci.ErrIncrementalCheckpointNotSupported) {
+			log.Debugf(ctx, "Falling back to a full checkpoint of container %s: %v", ctr.ID(), err)
+			incremental = false
+			err = resetCheckpointChain(ctr)
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to prepare incremental checkpoint of container %s: %w", ctr.ID(), err)
+		}
+	} else if err := resetCheckpointChain(ctr); err != nil {
+		return "", fmt.Errorf("failed to reset checkpoint chain of container %s: %w", ctr.ID(), err)
 	}
 
 	// At this point the container needs to be paused. As we first checkpoint
@@ -58,50 +99,67 @@ func (c *ContainerServer) ContainerCheckpoint(
 	// after checkpointing, there is a chance that the changed files we include
 	// in the checkpoint archive might change by the now again running processes
 	// in the container.
-	// Assuming this uses runc/crun (the OCI runtime is currently the only one
-	// supporting checkpointing), PauseContainer() will use the cgroup freezer
-	// to freeze the processes. CRIU will also use the cgroup freezer to freeze
+	// If this uses runc/crun, PauseContainer() will use the cgroup freezer
+	// to freeze the processes. VM based runtimes pause the task through
+	// their shim instead. CRIU will also use the cgroup freezer to freeze
 	// the processes if possible. If the cgroup is already frozen by runc/crun
 	// CRIU will not change the freezer status.
-	if err = c.runtime.PauseContainer(ctx, ctr); err != nil {
-		return "", fmt.Errorf("failed to pause container %q before checkpointing: %w", ctr.ID(), err)
-	}
-	defer func() {
-		if err := c.runtime.UpdateContainerStatus(ctx, ctr); err != nil {
-			log.Errorf(ctx, "Failed to update container status: %q: %v", ctr.ID(), err)
+	if !opts.Paused {
+		if err = c.runtime.PauseContainer(ctx, ctr); err != nil {
+			return "", fmt.Errorf("failed to pause container %q before checkpointing: %w", ctr.ID(), err)
 		}
-		if ctr.State().Status == oci.ContainerStatePaused {
-			err := c.runtime.UnpauseContainer(ctx, ctr)
-			if err != nil {
-				log.Errorf(ctx, "Failed to unpause container: %q: %v", ctr.ID(), err)
-			}
-		}
-		// container state needs to be written _after_ unpausing
-		if err = c.ContainerStateToDisk(ctx, ctr); err != nil {
-			log.Warnf(ctx, "Unable to write containers %s state to disk: %v", ctr.ID(), err)
-		}
-	}()
+		defer c.ResumeCheckpointedContainer(ctx, ctr)
+	}
 
-	if opts.TargetFile != "" {
+	exportFile := opts.TargetFile
+	if opts.TargetImage != "" && exportFile == "" {
+		// The checkpoint image gets created from a temporary archive
+		exportFile = filepath.Join(ctr.Dir(), checkpointImageArchive)
+		defer os.Remove(exportFile)
+	}
+
+	if exportFile != "" {
 		if err := c.prepareCheckpointExport(ctr); err != nil {
+			metrics.Instance().MetricContainersCheckpointRestoreFailuresInc(metrics.CheckpointRestorePhaseExport)
 			return "", fmt.Errorf("failed to write config dumps for container %s: %w", ctr.ID(), err)
 		}
 	}
 
-	if err := c.runtime.CheckpointContainer(ctx, ctr, specgen.Config, opts.KeepRunning); err != nil {
+	if err := c.runtime.CheckpointContainer(ctx, ctr, specgen.Config, &oci.CheckpointOptions{
+		LeaveRunning: opts.KeepRunning,
+		ParentPath:   parentPath,
+		// The checkpoint of an incremental chain becomes the parent of
+		// the next one, which only dumps the memory changed since.
+		TrackMem: incremental,
+	}); err != nil {
+		metrics.Instance().MetricContainersCheckpointRestoreFailuresInc(metrics.CheckpointRestorePhaseCRIUDump)
 		return "", fmt.Errorf("failed to checkpoint container %s: %w", ctr.ID(), err)
 	}
-	if opts.TargetFile != "" {
-		if err := c.exportCheckpoint(ctx, ctr, specgen.Config, opts.TargetFile); err != nil {
+	if exportFile != "" {
+		if err := c.exportCheckpoint(ctx, ctr, specgen.Config, exportFile); err != nil {
+			metrics.Instance().MetricContainersCheckpointRestoreFailuresInc(metrics.CheckpointRestorePhaseExport)
 			return "", fmt.Errorf("failed to write file system changes of container %s: %w", ctr.ID(), err)
 		}
+		if info, err := os.Stat(exportFile); err == nil {
+			metrics.Instance().MetricContainersCheckpointArchiveSizeObserve(info.Size())
+		}
 		defer func() {
+			if incremental {
+				// keep the checkpoint directory as parent of the next one
+				return
+			}
 			// clean up checkpoint directory
 			if err := os.RemoveAll(ctr.CheckpointPath()); err != nil {
 				log.Warnf(ctx, "Unable to remove checkpoint directory %s: %v", ctr.CheckpointPath(), err)
 			}
 		}()
 	}
+	if opts.TargetImage != "" {
+		if _, err := c.createCheckpointImage(ctx, ctr, exportFile, opts.TargetImage, opts.ImageCompression); err != nil {
+			metrics.Instance().MetricContainersCheckpointRestoreFailuresInc(metrics.CheckpointRestorePhaseImageCreation)
+			return "", fmt.Errorf("failed to create checkpoint image of container %s: %w", ctr.ID(), err)
+		}
+	}
 	if !opts.KeepRunning {
 		if err := c.storageRuntimeServer.StopContainer(ctx, ctr.ID()); err != nil {
 			return "", fmt.Errorf("failed to unmount container %s: %w", ctr.ID(), err)
@@ -123,9 +181,27 @@ func (c *ContainerServer) ContainerCheckpoint(
 		}
 	}
 
+	metrics.Instance().MetricContainersCheckpointDurationObserve(start)
 	return ctr.ID(), nil
 }
 
+// ResumeCheckpointedContainer unpauses the container after checkpointing it,
+// unless the checkpoint stopped it, and writes its state to disk.
+func (c *ContainerServer) ResumeCheckpointedContainer(ctx context.Context, ctr *oci.Container) {
+	if err := c.runtime.UpdateContainerStatus(ctx, ctr); err != nil {
+		log.Errorf(ctx, "Failed to update container status: %q: %v", ctr.ID(), err)
+	}
+	if ctr.State().Status == oci.ContainerStatePaused {
+		if err := c.runtime.UnpauseContainer(ctx, ctr); err != nil {
+			log.Errorf(ctx, "Failed to unpause container: %q: %v", ctr.ID(), err)
+		}
+	}
+	// container state needs to be written _after_ unpausing
+	if err := c.ContainerStateToDisk(ctx, ctr); err != nil {
+		log.Warnf(ctx, "Unable to write containers %s state to disk: %v", ctr.ID(), err)
+	}
+}
+
 // Copied from libpod/diff.go
 var containerMounts = map[string]bool{
 	"/dev":               true,
@@ -299,6 +375,9 @@ func (c *ContainerServer) exportCheckpoint(ctx context.Context, ctr *oci.Contain
 	if err != nil {
 		return err
 	}
+	if info, err := os.Stat(filepath.Join(dest, metadata.RootFsDiffTar)); err == nil {
+		metrics.Instance().MetricContainersCheckpointRootfsDiffSizeObserve(info.Size())
+	}
 
 	// Put log file into checkpoint archive
 	_, err = os.Stat(specgen.Annotations[annotations.LogPath])
@@ -321,6 +400,16 @@ func (c *ContainerServer) exportCheckpoint(ctx context.Context, ctr *oci.Contain
 		addToTarFiles = append(addToTarFiles, annotations.LogPath)
 	}
 
+	// Record the incremental checkpoint chain to be able to restore from it
+	parentFiles, err := writeCheckpointParents(ctr)
+	if err != nil {
+		return fmt.Errorf("error recording checkpoint chain of %q: %w", id, err)
+	}
+	if len(parentFiles) > 0 {
+		includeFiles = append(includeFiles, parentFiles...)
+		defer os.Remove(filepath.Join(dest, CheckpointParentsFile))
+	}
+
 	includeFiles = append(includeFiles, addToTarFiles...)
 
 	input, err := archive.TarWithOptions(ctr.Dir(), &archive.TarOptions{
@@ -0,0 +1,256 @@
+package lib
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"time"
+
+	criu "github.com/checkpoint-restore/go-criu/v7/utils"
+	"github.com/containers/image/v5/copy"
+	"github.com/containers/image/v5/oci/layout"
+	"github.com/containers/image/v5/pkg/compression"
+	"github.com/containers/image/v5/signature"
+	istorage "github.com/containers/image/v5/storage"
+	"github.com/cri-o/cri-o/internal/log"
+	"github.com/cri-o/cri-o/internal/oci"
+	"github.com/cri-o/cri-o/internal/version"
+	"github.com/cri-o/cri-o/pkg/annotations"
+	json "github.com/json-iterator/go"
+	digest "github.com/opencontainers/go-digest"
+	imgspecs "github.com/opencontainers/image-spec/specs-go"
+	imgspecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// checkpointImageArchive is the temporary checkpoint archive within the
+// container directory, from which the checkpoint image gets created.
+const checkpointImageArchive = "checkpoint-image.tar"
+
+// checkpointImageAnnotations returns the annotations of the checkpoint image
+// of the container.
+func checkpointImageAnnotations(ctr *oci.Container) map[string]string {
+	res := map[string]string{
+		annotations.CheckpointAnnotationName:         ctr.Name(),
+		annotations.CheckpointAnnotationRawImageName: ctr.UserRequestedImage(),
+		annotations.CheckpointAnnotationCRIOVersion:  version.Version,
+	}
+	if id := ctr.ImageID(); id != nil {
+		res[annotations.CheckpointAnnotationRootfsImageID] = id.IDStringForOutOfProcessConsumptionOnly()
+	}
+	if name := ctr.ImageName(); name != nil {
+		res[annotations.CheckpointAnnotationRootfsImageName] = name.StringForOutOfProcessConsumptionOnly()
+	}
+	if criuVersion, err := criu.GetCriuVersion(); err == nil {
+		res[annotations.CheckpointAnnotationCriuVersion] = strconv.Itoa(criuVersion)
+	}
+	return res
+}
+
+// checkpointLayerMediaType returns the OCI media type of a checkpoint image
+// layer compressed by the algorithm.
+func checkpointLayerMediaType(algo compression.Algorithm) (string, error) {
+	switch algo.Name() {
+	case compression.Gzip.Name():
+		return imgspecv1.MediaTypeImageLayerGzip, nil
+	case compression.Zstd.Name():
+		return imgspecv1.MediaTypeImageLayerZstd, nil
+	}
+	return "", fmt.Errorf("unsupported checkpoint image compression %q", algo.Name())
+}
+
+// createCheckpointImage stores the checkpoint archive as OCI image with the
+// provided name in the local storage and returns the image ID.
+func (c *ContainerServer) createCheckpointImage(ctx context.Context, ctr *oci.Container, archivePath, imageName, compressionName string) (string, error) {
+	ctx, span := log.StartSpan(ctx)
+	defer span.End()
+
+	algo, err := compression.AlgorithmByName(compressionName)
+	if err != nil {
+		return "", err
+	}
+	layerMediaType, err := checkpointLayerMediaType(algo)
+	if err != nil {
+		return "", err
+	}
+
+	layoutDir, err := os.MkdirTemp("", "checkpoint-image")
+	if err != nil {
+		return "", fmt.Errorf("create OCI layout directory: %w", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(layoutDir); err != nil {
+			log.Warnf(ctx, "Unable to remove OCI layout directory %s: %v", layoutDir, err)
+		}
+	}()
+
+	layer, diffID, err := writeCheckpointLayer(layoutDir, archivePath, algo)
+	if err != nil {
+		return "", fmt.Errorf("write checkpoint layer: %w", err)
+	}
+	layer.MediaType = layerMediaType
+
+	now := time.Now().UTC()
+	config, err := writeLayoutJSON(layoutDir, imgspecv1.MediaTypeImageConfig, &imgspecv1.Image{
+		Created: &now,
+		Platform: imgspecv1.Platform{
+			Architecture: runtime.GOARCH,
+			OS:           runtime.GOOS,
+		},
+		RootFS: imgspecv1.RootFS{
+			Type:    "layers",
+			DiffIDs: []digest.Digest{diffID},
+		},
+		History: []imgspecv1.History{{
+			Created:   &now,
+			CreatedBy: "checkpoint of container " + ctr.ID(),
+		}},
+	})
+	if err != nil {
+		return "", fmt.Errorf("write checkpoint image config: %w", err)
+	}
+
+	manifest, err := writeLayoutJSON(layoutDir, imgspecv1.MediaTypeImageManifest, &imgspecv1.Manifest{
+		Versioned:   imgspecs.Versioned{SchemaVersion: 2},
+		MediaType:   imgspecv1.MediaTypeImageManifest,
+		Config:      config,
+		Layers:      []imgspecv1.Descriptor{layer},
+		Annotations: checkpointImageAnnotations(ctr),
+	})
+	if err != nil {
+		return "", fmt.Errorf("write checkpoint image manifest: %w", err)
+	}
+
+	if err := writeLayoutIndex(layoutDir, manifest); err != nil {
+		return "", fmt.Errorf("write OCI layout index: %w", err)
+	}
+
+	srcRef, err := layout.NewReference(layoutDir, "")
+	if err != nil {
+		return "", fmt.Errorf("get OCI layout reference: %w", err)
+	}
+	destRef, err := istorage.Transport.ParseStoreReference(c.store, imageName)
+	if err != nil {
+		return "", fmt.Errorf("parse checkpoint image name %q: %w", imageName, err)
+	}
+
+	// The image is created locally from the checkpoint, which means that
+	// there is nothing to verify.
+	policyContext, err := signature.NewPolicyContext(&signature.Policy{
+		Default: signature.PolicyRequirements{signature.NewPRInsecureAcceptAnything()},
+	})
+	if err != nil {
+		return "", fmt.Errorf("create policy context: %w", err)
+	}
+	defer func() {
+		if err := policyContext.Destroy(); err != nil {
+			log.Warnf(ctx, "Unable to destroy policy context: %v", err)
+		}
+	}()
+
+	if _, err := copy.Image(ctx, policyContext, destRef, srcRef, &copy.Options{
+		SourceCtx:      c.config.SystemContext,
+		DestinationCtx: c.config.SystemContext,
+	}); err != nil {
+		return "", fmt.Errorf("copy checkpoint image into storage: %w", err)
+	}
+
+	img, err := istorage.Transport.GetStoreImage(c.store, destRef)
+	if err != nil {
+		return "", fmt.Errorf("get checkpoint image %s: %w", imageName, err)
+	}
+	log.Infof(ctx, "Stored checkpoint of container %s as image %s (%s)", ctr.ID(), imageName, img.ID)
+
+	return img.ID, nil
+}
+
+// writeCheckpointLayer compresses the checkpoint archive into a blob of the
+// OCI layout. It returns the descriptor of the blob without media type and
+// the digest of the uncompressed archive.
+func writeCheckpointLayer(layoutDir, archivePath string, algo compression.Algorithm) (imgspecv1.Descriptor, digest.Digest, error) {
+	archive, err := os.Open(archivePath)
+	if err != nil {
+		return imgspecv1.Descriptor{}, "", err
+	}
+	defer archive.Close()
+
+	blobsDir := filepath.Join(layoutDir, imgspecv1.ImageBlobsDir, digest.Canonical.String())
+	if err := os.MkdirAll(blobsDir, 0o700); err != nil {
+		return imgspecv1.Descriptor{}, "", err
+	}
+	blob, err := os.CreateTemp(blobsDir, "layer")
+	if err != nil {
+		return imgspecv1.Descriptor{}, "", err
+	}
+	defer blob.Close()
+
+	blobDigester := digest.Canonical.Digester()
+	compressor, err := compression.CompressStream(io.MultiWriter(blob, blobDigester.Hash()), algo, nil)
+	if err != nil {
+		return imgspecv1.Descriptor{}, "", err
+	}
+	diffIDDigester := digest.Canonical.Digester()
+	if _, err := io.Copy(compressor, io.TeeReader(archive, diffIDDigester.Hash())); err != nil {
+		compressor.Close()
+		return imgspecv1.Descriptor{}, "", err
+	}
+	if err := compressor.Close(); err != nil {
+		return imgspecv1.Descriptor{}, "", err
+	}
+	info, err := blob.Stat()
+	if err != nil {
+		return imgspecv1.Descriptor{}, "", err
+	}
+
+	blobDigest := blobDigester.Digest()
+	if err := os.Rename(blob.Name(), filepath.Join(blobsDir, blobDigest.Encoded())); err != nil {
+		return imgspecv1.Descriptor{}, "", err
+	}
+	return imgspecv1.Descriptor{
+		Digest: blobDigest,
+		Size:   info.Size(),
+	}, diffIDDigester.Digest(), nil
+}
+
+// writeLayoutJSON writes the value as JSON blob into the OCI layout and
+// returns its descriptor.
+func writeLayoutJSON(layoutDir, mediaType string, v any) (imgspecv1.Descriptor, error) {
+	content, err := json.Marshal(v)
+	if err != nil {
+		return imgspecv1.Descriptor{}, err
+	}
+	d := digest.Canonical.FromBytes(content)
+	path := filepath.Join(layoutDir, imgspecv1.ImageBlobsDir, d.Algorithm().String(), d.Encoded())
+	if err := os.WriteFile(path, content, 0o600); err != nil {
+		return imgspecv1.Descriptor{}, err
+	}
+	return imgspecv1.Descriptor{
+		MediaType: mediaType,
+		Digest:    d,
+		Size:      int64(len(content)),
+	}, nil
+}
+
+// writeLayoutIndex finalizes the OCI layout by writing the layout marker and
+// the index referencing the manifest.
+func writeLayoutIndex(layoutDir string, manifest imgspecv1.Descriptor) error {
+	layoutContent, err := json.Marshal(&imgspecv1.ImageLayout{Version: imgspecv1.ImageLayoutVersion})
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(layoutDir, imgspecv1.ImageLayoutFile), layoutContent, 0o600); err != nil {
+		return err
+	}
+	indexContent, err := json.Marshal(&imgspecv1.Index{
+		Versioned: imgspecs.Versioned{SchemaVersion: 2},
+		MediaType: imgspecv1.MediaTypeImageIndex,
+		Manifests: []imgspecv1.Descriptor{manifest},
+	})
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(layoutDir, imgspecv1.ImageIndexFile), indexContent, 0o600)
+}
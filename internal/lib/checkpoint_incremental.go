@@ -0,0 +1,186 @@
+package lib
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	metadata "github.com/checkpoint-restore/checkpointctl/lib"
+	"github.com/cri-o/cri-o/internal/log"
+	"github.com/cri-o/cri-o/internal/oci"
+	"github.com/cri-o/cri-o/server/metrics"
+	rspec "github.com/opencontainers/runtime-spec/specs-go"
+)
+
+const (
+	// CheckpointParentsDirectory is the directory within the container
+	// directory and the checkpoint archive containing the parent images of
+	// an incremental checkpoint, one sub directory per generation.
+	CheckpointParentsDirectory = "checkpoint-parents"
+
+	// CheckpointParentsFile is the file within the checkpoint archive which
+	// records the chain of parent images of an incremental checkpoint.
+	CheckpointParentsFile = "checkpoint-parents.json"
+
+	// criuParentLink is the symlink CRIU creates within the images directory
+	// pointing to the parent images.
+	criuParentLink = "parent"
+)
+
+// checkpointParentImages are the files of the parent images CRIU reads on
+// restore, which are the memory pages, their maps and the link to the next
+// parent. The other images of a parent are only needed to dump.
+var checkpointParentImages = []string{"pagemap-*.img", "pages-*.img", criuParentLink}
+
+// checkpointParents returns the parent image directories of the incremental
+// checkpoint chain of the container relative to the container directory,
+// ordered from the oldest to the newest.
+func checkpointParents(ctr *oci.Container) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(ctr.Dir(), CheckpointParentsDirectory))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	parents := make([]string, 0, len(entries))
+	for i := range entries {
+		parent := filepath.Join(CheckpointParentsDirectory, strconv.Itoa(i))
+		if _, err := os.Stat(filepath.Join(ctr.Dir(), parent)); err != nil {
+			return nil, fmt.Errorf("broken checkpoint chain: %w", err)
+		}
+		parents = append(parents, parent)
+	}
+	return parents, nil
+}
+
+// resetCheckpointChain removes the incremental checkpoint chain of the
+// container, including the last checkpoint images referencing it.
+func resetCheckpointChain(ctr *oci.Container) error {
+	parentsDir := filepath.Join(ctr.Dir(), CheckpointParentsDirectory)
+	if _, err := os.Stat(parentsDir); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err := os.RemoveAll(ctr.CheckpointPath()); err != nil {
+		return err
+	}
+	return os.RemoveAll(parentsDir)
+}
+
+// prepareIncrementalCheckpoint adds a new parent to the incremental checkpoint
+// chain of the container and returns its path relative to the checkpoint
+// path. The previous checkpoint becomes the new parent if it exists,
+// otherwise the memory of the still running container gets pre-dumped. A new
+// chain is started once it reaches maxChainLength.
+func (c *ContainerServer) prepareIncrementalCheckpoint(ctx context.Context, ctr *oci.Container, specgen *rspec.Spec, maxChainLength int) (string, error) {
+	parents, err := checkpointParents(ctr)
+	if err != nil || len(parents) >= maxChainLength {
+		log.Debugf(ctx, "Starting new checkpoint chain for container %s (previous: %d, error: %v)", ctr.ID(), len(parents), err)
+		if err := resetCheckpointChain(ctr); err != nil {
+			return "", fmt.Errorf("reset checkpoint chain: %w", err)
+		}
+		parents = nil
+	}
+
+	generation := strconv.Itoa(len(parents))
+	parent := filepath.Join(ctr.Dir(), CheckpointParentsDirectory, generation)
+	if err := os.MkdirAll(filepath.Dir(parent), 0o700); err != nil {
+		return "", fmt.Errorf("create checkpoint parents directory: %w", err)
+	}
+
+	_, err = os.Stat(filepath.Join(ctr.CheckpointPath(), "inventory.img"))
+	if len(parents) > 0 && err == nil {
+		// The previous checkpoint of the chain tracked the memory changes
+		// since then, which makes it the parent of the next one.
+		log.Debugf(ctx, "Using previous checkpoint of container %s as parent %s", ctr.ID(), generation)
+		if err := os.Rename(ctr.CheckpointPath(), parent); err != nil {
+			return "", fmt.Errorf("move previous checkpoint: %w", err)
+		}
+		// CRIU links the images to their parent by a relative symlink,
+		// which has to be adjusted to the new location.
+		link := filepath.Join(parent, criuParentLink)
+		if err := os.Remove(link); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf("remove parent link: %w", err)
+		}
+		if err := os.Symlink(filepath.Join("..", "..", parents[len(parents)-1]), link); err != nil {
+			return "", fmt.Errorf("link previous checkpoint to its parent: %w", err)
+		}
+	} else {
+		if err := os.RemoveAll(ctr.CheckpointPath()); err != nil {
+			return "", fmt.Errorf("remove stale checkpoint: %w", err)
+		}
+		if err := os.MkdirAll(parent, 0o700); err != nil {
+			return "", fmt.Errorf("create checkpoint parent directory: %w", err)
+		}
+		opts := &oci.CheckpointOptions{PreDump: true, ImagePath: parent}
+		if len(parents) > 0 {
+			opts.ParentPath = filepath.Join("..", "..", parents[len(parents)-1])
+		}
+		log.Debugf(ctx, "Pre-dumping container %s as checkpoint parent %s", ctr.ID(), generation)
+		if err := c.runtime.CheckpointContainer(ctx, ctr, specgen, opts); err != nil {
+			if !errors.Is(err, oci.ErrIncrementalCheckpointNotSupported) {
+				metrics.Instance().MetricContainersCheckpointRestoreFailuresInc(metrics.CheckpointRestorePhaseCRIUDump)
+			}
+			if err := os.RemoveAll(parent); err != nil {
+				log.Warnf(ctx, "Unable to remove checkpoint parent %s: %v", parent, err)
+			}
+			return "", fmt.Errorf("pre-dump: %w", err)
+		}
+	}
+
+	return filepath.Join("..", CheckpointParentsDirectory, generation), nil
+}
+
+// writeCheckpointParents records the incremental checkpoint chain of the
+// container within its directory to be included in the checkpoint archive.
+// It returns the files to be added to the archive, which are only the parent
+// images needed for restoring.
+func writeCheckpointParents(ctr *oci.Container) ([]string, error) {
+	parents, err := checkpointParents(ctr)
+	if err != nil {
+		return nil, err
+	}
+	if len(parents) == 0 {
+		return nil, nil
+	}
+	if _, err := metadata.WriteJSONFile(parents, ctr.Dir(), CheckpointParentsFile); err != nil {
+		return nil, err
+	}
+
+	files := []string{CheckpointParentsFile}
+	for _, parent := range parents {
+		for _, pattern := range checkpointParentImages {
+			matches, err := filepath.Glob(filepath.Join(ctr.Dir(), parent, pattern))
+			if err != nil {
+				return nil, err
+			}
+			for _, match := range matches {
+				files = append(files, filepath.Join(parent, filepath.Base(match)))
+			}
+		}
+	}
+	return files, nil
+}
+
+// verifyCheckpointParents ensures that all parent images recorded in an
+// imported checkpoint exist.
+func verifyCheckpointParents(ctr *oci.Container) error {
+	if _, err := os.Stat(filepath.Join(ctr.Dir(), CheckpointParentsFile)); errors.Is(err, os.ErrNotExist) {
+		// Not an incremental checkpoint
+		return nil
+	}
+	var parents []string
+	if _, err := metadata.ReadJSONFile(&parents, ctr.Dir(), CheckpointParentsFile); err != nil {
+		return err
+	}
+	for _, parent := range parents {
+		if _, err := os.Stat(filepath.Join(ctr.Dir(), parent)); err != nil {
+			return fmt.Errorf("incomplete checkpoint chain, missing parent %s: %w", parent, err)
+		}
+	}
+	return nil
+}
@@ -1,9 +1,15 @@
 package lib_test
 
 import (
+	"archive/tar"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
 	metadata "github.com/checkpoint-restore/checkpointctl/lib"
 	criu "github.com/checkpoint-restore/go-criu/v7/utils"
@@ -15,6 +21,7 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	specs "github.com/opencontainers/runtime-spec/specs-go"
+	types "k8s.io/cri-api/pkg/apis/runtime/v1"
 )
 
 // The actual test suite
@@ -150,6 +157,14 @@ var _ = t.Describe("ContainerCheckpoint", func() {
 
 			Expect(os.WriteFile("config.json", []byte(containerConfig), 0o644)).To(Succeed())
 
+			// The external bind mounts get written to the container directory.
+			ctr, err := oci.NewContainer(containerID, "", "", "",
+				make(map[string]string), make(map[string]string),
+				make(map[string]string), "", nil, nil, "",
+				&types.ContainerMetadata{}, sandboxID, false,
+				false, false, "", t.MustTempDir("container"), time.Now(), "")
+			Expect(err).ToNot(HaveOccurred())
+			myContainer = ctr
 			addContainerAndSandbox()
 			config := &metadata.ContainerConfig{
 				ID: containerID,
@@ -178,6 +193,124 @@ var _ = t.Describe("ContainerCheckpoint", func() {
 			// Then
 			Expect(err).ToNot(HaveOccurred())
 			Expect(res).To(ContainSubstring(config.ID))
+			Expect(filepath.Join(myContainer.Dir(), "bind.mounts")).To(BeARegularFile())
+		})
+	})
+	t.Describe("ContainerCheckpoint", func() {
+		It("should create an incremental checkpoint chain", func() {
+			// Given
+			addContainerAndSandbox()
+			config := &metadata.ContainerConfig{
+				ID: containerID,
+			}
+			opts := &lib.ContainerCheckpointOptions{
+				KeepRunning:            true,
+				IncrementalChainLength: 2,
+			}
+			defer os.RemoveAll(lib.CheckpointParentsDirectory)
+
+			myContainer.SetState(&oci.ContainerState{
+				State: specs.State{Status: oci.ContainerStateRunning},
+			})
+			myContainer.SetSpec(&specs.Spec{Version: "1.0.0"})
+			parent := func(generation string) string {
+				return filepath.Join(myContainer.Dir(), lib.CheckpointParentsDirectory, generation)
+			}
+
+			for range 2 {
+				_, err := sut.ContainerCheckpoint(context.Background(), config, opts)
+				Expect(err).ToNot(HaveOccurred())
+			}
+			Expect(parent("0")).To(BeADirectory())
+			Expect(parent("1")).To(BeADirectory())
+
+			// When
+			res, err := sut.ContainerCheckpoint(context.Background(), config, opts)
+
+			// Then
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res).To(Equal(config.ID))
+			Expect(parent("0")).To(BeADirectory())
+			Expect(parent("1")).ToNot(BeAnExistingFile())
+		})
+	})
+	t.Describe("ContainerCheckpoint", func() {
+		It("should export only the parent images needed to restore", func() {
+			// Given
+			// The archive gets created from the container directory.
+			ctr, err := oci.NewContainer(containerID, "", "", "",
+				make(map[string]string), make(map[string]string),
+				make(map[string]string), "", nil, nil, "",
+				&types.ContainerMetadata{}, sandboxID, false,
+				false, false, "", t.MustTempDir("container"), time.Now(), "")
+			Expect(err).ToNot(HaveOccurred())
+			myContainer = ctr
+			addContainerAndSandbox()
+			config := &metadata.ContainerConfig{
+				ID: containerID,
+			}
+			opts := &lib.ContainerCheckpointOptions{
+				TargetFile:             "cp.tar",
+				KeepRunning:            true,
+				IncrementalChainLength: 3,
+			}
+			defer os.RemoveAll("cp.tar")
+
+			myContainer.SetState(&oci.ContainerState{
+				State: specs.State{Status: oci.ContainerStateRunning},
+			})
+			myContainer.SetSpec(&specs.Spec{Version: "1.0.0"})
+
+			// A pre-dump and the previous checkpoint, which becomes the
+			// next parent.
+			preDump := filepath.Join(myContainer.Dir(), lib.CheckpointParentsDirectory, "0")
+			Expect(os.MkdirAll(preDump, 0o700)).To(Succeed())
+			Expect(os.MkdirAll(myContainer.CheckpointPath(), 0o700)).To(Succeed())
+			for _, file := range []string{
+				filepath.Join(preDump, "inventory.img"),
+				filepath.Join(preDump, "pagemap-1.img"),
+				filepath.Join(preDump, "pages-1.img"),
+				filepath.Join(myContainer.CheckpointPath(), "core-1.img"),
+				filepath.Join(myContainer.CheckpointPath(), "inventory.img"),
+				filepath.Join(myContainer.CheckpointPath(), "pagemap-1.img"),
+				filepath.Join(myContainer.CheckpointPath(), "pages-1.img"),
+			} {
+				Expect(os.WriteFile(file, []byte{}, 0o600)).To(Succeed())
+			}
+
+			gomock.InOrder(
+				storeMock.EXPECT().Container(gomock.Any()).Return(&cstorage.Container{}, nil),
+				storeMock.EXPECT().Changes(gomock.Any(), gomock.Any()).Return(nil, nil),
+				storeMock.EXPECT().Mount(gomock.Any(), gomock.Any()).Return("/tmp/", nil),
+			)
+
+			// When
+			_, err = sut.ContainerCheckpoint(context.Background(), config, opts)
+
+			// Then
+			Expect(err).ToNot(HaveOccurred())
+			f, err := os.Open("cp.tar")
+			Expect(err).ToNot(HaveOccurred())
+			defer f.Close()
+			files := []string{}
+			reader := tar.NewReader(f)
+			for {
+				header, err := reader.Next()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				Expect(err).ToNot(HaveOccurred())
+				if strings.HasPrefix(header.Name, lib.CheckpointParentsDirectory+"/") && header.Typeflag != tar.TypeDir {
+					files = append(files, header.Name)
+				}
+			}
+			Expect(files).To(ConsistOf(
+				"checkpoint-parents/0/pagemap-1.img",
+				"checkpoint-parents/0/pages-1.img",
+				"checkpoint-parents/1/pagemap-1.img",
+				"checkpoint-parents/1/pages-1.img",
+				"checkpoint-parents/1/parent",
+			))
 		})
 	})
 	t.Describe("ContainerCheckpoint", func() {
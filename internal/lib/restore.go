@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	metadata "github.com/checkpoint-restore/checkpointctl/lib"
 	"github.com/checkpoint-restore/go-criu/v7/stats"
@@ -13,6 +14,7 @@ import (
 	"github.com/cri-o/cri-o/internal/log"
 	"github.com/cri-o/cri-o/internal/oci"
 	"github.com/cri-o/cri-o/pkg/annotations"
+	"github.com/cri-o/cri-o/server/metrics"
 	"github.com/opencontainers/runtime-tools/generate"
 	"github.com/sirupsen/logrus"
 )
@@ -23,6 +25,7 @@ func (c *ContainerServer) ContainerRestore(
 	config *metadata.ContainerConfig,
 	opts *ContainerCheckpointOptions,
 ) (string, error) {
+	start := time.Now()
 	var ctr *oci.Container
 	var err error
 	ctr, err = c.LookupContainer(ctx, config.ID)
@@ -80,6 +83,8 @@ func (c *ContainerServer) ContainerRestore(
 			checkpoint := []string{
 				"artifacts",
 				metadata.CheckpointDirectory,
+				CheckpointParentsDirectory,
+				CheckpointParentsFile,
 				metadata.DevShmCheckpointTar,
 				metadata.RootFsDiffTar,
 				metadata.DeletedFilesFile,
@@ -100,6 +105,9 @@ func (c *ContainerServer) ContainerRestore(
 				return "", err
 			}
 		}
+		if err := verifyCheckpointParents(ctr); err != nil {
+			return "", err
+		}
 		if err := c.restoreFileSystemChanges(ctr, mountPoint); err != nil {
 			return "", err
 		}
@@ -253,6 +261,7 @@ func (c *ContainerServer) ContainerRestore(
 		sb.CgroupParent(),
 		sb.MountLabel(),
 	); err != nil {
+		metrics.Instance().MetricContainersCheckpointRestoreFailuresInc(metrics.CheckpointRestorePhaseCRIURestore)
 		return "", fmt.Errorf("failed to restore container %s: %w", ctr.ID(), err)
 	}
 	if err := c.ContainerStateToDisk(ctx, ctr); err != nil {
@@ -268,6 +277,10 @@ func (c *ContainerServer) ContainerRestore(
 		if err != nil {
 			log.Debugf(ctx, "Non-fatal: removal of checkpoint directory (%s) failed: %v", ctr.CheckpointPath(), err)
 		}
+		parentsDir := filepath.Join(ctr.Dir(), CheckpointParentsDirectory)
+		if err := os.RemoveAll(parentsDir); err != nil {
+			log.Debugf(ctx, "Non-fatal: removal of checkpoint parents directory (%s) failed: %v", parentsDir, err)
+		}
 		cleanup := [...]string{
 			metadata.RestoreLogFile,
 			metadata.DumpLogFile,
@@ -276,6 +289,7 @@ func (c *ContainerServer) ContainerRestore(
 			metadata.NetworkStatusFile,
 			metadata.RootFsDiffTar,
 			metadata.DeletedFilesFile,
+			CheckpointParentsFile,
 		}
 		for _, del := range cleanup {
 			var file string
@@ -296,6 +310,7 @@ func (c *ContainerServer) ContainerRestore(
 		}
 	}
 
+	metrics.Instance().MetricContainersRestoreDurationObserve(start)
 	return ctr.ID(), nil
 }
 
@@ -0,0 +1,291 @@
+package statsserver
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cri-o/cri-o/internal/config/cgmgr"
+	"github.com/cri-o/cri-o/internal/lib/sandbox"
+	types "k8s.io/cri-api/pkg/apis/runtime/v1"
+)
+
+// sysDevBlockPath is the sysfs directory containing the block devices by
+// their major and minor numbers.
+const sysDevBlockPath = "/sys/dev/block"
+
+// filesystemStats are the statistics of the filesystem containing the
+// writable layer of a container.
+type filesystemStats struct {
+	device      string
+	usageBytes  uint64
+	limitBytes  uint64
+	inodesTotal uint64
+	inodesFree  uint64
+}
+
+func generateSandboxDiskMetrics(sb *sandbox.Sandbox, fs *filesystemStats) []*types.Metric {
+	diskMetrics := []*containerMetric{
+		{
+			desc: &types.MetricDescriptor{
+				Name:      "container_fs_inodes_free",
+				Help:      "Number of available Inodes",
+				LabelKeys: append(baseLabelKeys, "device"),
+			},
+			valueFunc: func() metricValues {
+				return metricValues{{
+					value:      fs.inodesFree,
+					labels:     []string{fs.device},
+					metricType: types.MetricType_GAUGE,
+				}}
+			},
+		}, {
+			desc: &types.MetricDescriptor{
+				Name:      "container_fs_inodes_total",
+				Help:      "Number of Inodes",
+				LabelKeys: append(baseLabelKeys, "device"),
+			},
+			valueFunc: func() metricValues {
+				return metricValues{{
+					value:      fs.inodesTotal,
+					labels:     []string{fs.device},
+					metricType: types.MetricType_GAUGE,
+				}}
+			},
+		}, {
+			desc: &types.MetricDescriptor{
+				Name:      "container_fs_limit_bytes",
+				Help:      "Number of bytes that can be consumed by the container on this filesystem.",
+				LabelKeys: append(baseLabelKeys, "device"),
+			},
+			valueFunc: func() metricValues {
+				return metricValues{{
+					value:      fs.limitBytes,
+					labels:     []string{fs.device},
+					metricType: types.MetricType_GAUGE,
+				}}
+			},
+		}, {
+			desc: &types.MetricDescriptor{
+				Name:      "container_fs_usage_bytes",
+				Help:      "Number of bytes that are consumed by the container on this filesystem.",
+				LabelKeys: append(baseLabelKeys, "device"),
+			},
+			valueFunc: func() metricValues {
+				return metricValues{{
+					value:      fs.usageBytes,
+					labels:     []string{fs.device},
+					metricType: types.MetricType_GAUGE,
+				}}
+			},
+		},
+	}
+	return computeSandboxMetrics(sb, diskMetrics, "disk")
+}
+
+// generateSandboxDiskIOMetrics generates the block I/O metrics of the
+// container. The sector counts of cgroup v1 are not split by operation, which
+// is why the container_fs_sector_* metrics are derived from the transferred
+// bytes for both cgroup versions.
+func generateSandboxDiskIOMetrics(sb *sandbox.Sandbox, io *cgmgr.DiskIOStats, deviceName func(major, minor uint64) string) []*types.Metric {
+	// values returns the entries of the operation per device, divided by
+	// the provided unit.
+	values := func(entries []cgmgr.BlkioEntry, op string, unit uint64, metricType types.MetricType) metricValues {
+		res := make(metricValues, 0, len(entries))
+		for _, e := range entries {
+			if e.Op != op {
+				continue
+			}
+			res = append(res, metricValue{
+				value:      e.Value / unit,
+				labels:     []string{deviceName(e.Major, e.Minor)},
+				metricType: metricType,
+			})
+		}
+		return res
+	}
+	const (
+		nanosecondsPerSecond  = uint64(time.Second)
+		millisecondsPerSecond = uint64(time.Second / time.Millisecond)
+		// The kernel counts sectors in units of 512 bytes, independently of
+		// the sector size of the device.
+		bytesPerSector = uint64(512)
+	)
+
+	diskIOMetrics := []*containerMetric{
+		{
+			desc: &types.MetricDescriptor{
+				Name:      "container_fs_reads_bytes_total",
+				Help:      "Cumulative count of bytes read",
+				LabelKeys: append(baseLabelKeys, "device"),
+			},
+			valueFunc: func() metricValues {
+				return values(io.ServiceBytes, "Read", 1, types.MetricType_COUNTER)
+			},
+		}, {
+			desc: &types.MetricDescriptor{
+				Name:      "container_fs_reads_total",
+				Help:      "Cumulative count of reads completed",
+				LabelKeys: append(baseLabelKeys, "device"),
+			},
+			valueFunc: func() metricValues {
+				return values(io.Serviced, "Read", 1, types.MetricType_COUNTER)
+			},
+		}, {
+			desc: &types.MetricDescriptor{
+				Name:      "container_fs_sector_reads_total",
+				Help:      "Cumulative count of sector reads completed",
+				LabelKeys: append(baseLabelKeys, "device"),
+			},
+			valueFunc: func() metricValues {
+				return values(io.ServiceBytes, "Read", bytesPerSector, types.MetricType_COUNTER)
+			},
+		}, {
+			desc: &types.MetricDescriptor{
+				Name:      "container_fs_reads_merged_total",
+				Help:      "Cumulative count of reads merged",
+				LabelKeys: append(baseLabelKeys, "device"),
+			},
+			valueFunc: func() metricValues {
+				return values(io.Merged, "Read", 1, types.MetricType_COUNTER)
+			},
+		}, {
+			desc: &types.MetricDescriptor{
+				Name:      "container_fs_read_seconds_total",
+				Help:      "Cumulative count of seconds spent reading",
+				LabelKeys: append(baseLabelKeys, "device"),
+			},
+			valueFunc: func() metricValues {
+				return values(io.ServiceTime, "Read", nanosecondsPerSecond, types.MetricType_COUNTER)
+			},
+		}, {
+			desc: &types.MetricDescriptor{
+				Name:      "container_fs_writes_bytes_total",
+				Help:      "Cumulative count of bytes written",
+				LabelKeys: append(baseLabelKeys, "device"),
+			},
+			valueFunc: func() metricValues {
+				return values(io.ServiceBytes, "Write", 1, types.MetricType_COUNTER)
+			},
+		}, {
+			desc: &types.MetricDescriptor{
+				Name:      "container_fs_writes_total",
+				Help:      "Cumulative count of writes completed",
+				LabelKeys: append(baseLabelKeys, "device"),
+			},
+			valueFunc: func() metricValues {
+				return values(io.Serviced, "Write", 1, types.MetricType_COUNTER)
+			},
+		}, {
+			desc: &types.MetricDescriptor{
+				Name:      "container_fs_sector_writes_total",
+				Help:      "Cumulative count of sector writes completed",
+				LabelKeys: append(baseLabelKeys, "device"),
+			},
+			valueFunc: func() metricValues {
+				return values(io.ServiceBytes, "Write", bytesPerSector, types.MetricType_COUNTER)
+			},
+		}, {
+			desc: &types.MetricDescriptor{
+				Name:      "container_fs_writes_merged_total",
+				Help:      "Cumulative count of writes merged",
+				LabelKeys: append(baseLabelKeys, "device"),
+			},
+			valueFunc: func() metricValues {
+				return values(io.Merged, "Write", 1, types.MetricType_COUNTER)
+			},
+		}, {
+			desc: &types.MetricDescriptor{
+				Name:      "container_fs_write_seconds_total",
+				Help:      "Cumulative count of seconds spent writing",
+				LabelKeys: append(baseLabelKeys, "device"),
+			},
+			valueFunc: func() metricValues {
+				return values(io.ServiceTime, "Write", nanosecondsPerSecond, types.MetricType_COUNTER)
+			},
+		}, {
+			desc: &types.MetricDescriptor{
+				Name:      "container_fs_io_current",
+				Help:      "Number of I/Os currently in progress",
+				LabelKeys: append(baseLabelKeys, "device"),
+			},
+			valueFunc: func() metricValues {
+				return values(io.Queued, "Total", 1, types.MetricType_GAUGE)
+			},
+		}, {
+			desc: &types.MetricDescriptor{
+				Name:      "container_fs_io_time_seconds_total",
+				Help:      "Cumulative count of seconds spent doing I/Os",
+				LabelKeys: append(baseLabelKeys, "device"),
+			},
+			valueFunc: func() metricValues {
+				return values(io.Time, "", millisecondsPerSecond, types.MetricType_COUNTER)
+			},
+		}, {
+			desc: &types.MetricDescriptor{
+				Name:      "container_fs_io_time_weighted_seconds_total",
+				Help:      "Cumulative weighted I/O time in seconds",
+				LabelKeys: append(baseLabelKeys, "device"),
+			},
+			valueFunc: func() metricValues {
+				return values(io.WaitTime, "Total", nanosecondsPerSecond, types.MetricType_COUNTER)
+			},
+		}, {
+			desc: &types.MetricDescriptor{
+				Name:      "container_blkio_device_usage_total",
+				Help:      "Blkio device bytes usage",
+				LabelKeys: append(baseLabelKeys, "device", "major", "minor", "operation"),
+			},
+			valueFunc: func() metricValues {
+				res := make(metricValues, 0, len(io.ServiceBytes))
+				for _, e := range io.ServiceBytes {
+					res = append(res, metricValue{
+						value: e.Value,
+						labels: []string{
+							deviceName(e.Major, e.Minor),
+							strconv.FormatUint(e.Major, 10),
+							strconv.FormatUint(e.Minor, 10),
+							e.Op,
+						},
+						metricType: types.MetricType_COUNTER,
+					})
+				}
+				return res
+			},
+		},
+	}
+	return computeSandboxMetrics(sb, diskIOMetrics, "diskio")
+}
+
+// deviceName returns the path of the block device with the provided major and
+// minor numbers, or "major:minor" if it is unknown. Resolved names are cached
+// for the lifetime of the stats server.
+// Note: caller must hold the lock on the StatsServer.
+func (ss *StatsServer) deviceName(major, minor uint64) string {
+	id := fmt.Sprintf("%d:%d", major, minor)
+	if name, ok := ss.deviceNames[id]; ok {
+		return name
+	}
+	name := id
+	if uevent, err := os.ReadFile(filepath.Join(sysDevBlockPath, id, "uevent")); err == nil {
+		if devName := parseDevName(string(uevent)); devName != "" {
+			name = devName
+		}
+	}
+	ss.deviceNames[id] = name
+	return name
+}
+
+// parseDevName returns the device path from the DEVNAME entry of a uevent
+// file, or an empty string if there is none.
+func parseDevName(uevent string) string {
+	for _, line := range strings.Split(uevent, "\n") {
+		if devName, ok := strings.CutPrefix(line, "DEVNAME="); ok && devName != "" {
+			return "/dev/" + devName
+		}
+	}
+	return ""
+}
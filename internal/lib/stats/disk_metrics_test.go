@@ -0,0 +1,194 @@
+package statsserver
+
+import (
+	"time"
+
+	"github.com/cri-o/cri-o/internal/config/cgmgr"
+	"github.com/cri-o/cri-o/internal/lib/sandbox"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	types "k8s.io/cri-api/pkg/apis/runtime/v1"
+)
+
+var _ = Describe("DiskMetrics", func() {
+	var sb *sandbox.Sandbox
+
+	// metricsByName returns the value and the last label value, which is the
+	// device, of every metric with the provided name.
+	metricsByName := func(metrics []*types.Metric, name string) map[string]uint64 {
+		res := map[string]uint64{}
+		for _, m := range metrics {
+			if m.GetName() == name {
+				res[m.GetLabelValues()[len(m.GetLabelValues())-1]] = m.GetValue().GetValue()
+			}
+		}
+		return res
+	}
+
+	BeforeEach(func() {
+		var err error
+		sb, err = sandbox.New("sandboxID", "", "", "", "", nil, nil,
+			"", "", nil, "", "", false,
+			"", "", "", nil, false,
+			time.Now(), "", nil, nil)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	Describe("generateSandboxDiskMetrics", func() {
+		It("should report the filesystem statistics", func() {
+			// Given
+			fs := &filesystemStats{
+				device:      "/dev/sda1",
+				usageBytes:  1024,
+				limitBytes:  4096,
+				inodesTotal: 100,
+				inodesFree:  40,
+			}
+
+			// When
+			metrics := generateSandboxDiskMetrics(sb, fs)
+
+			// Then
+			Expect(metrics).To(HaveLen(4))
+			Expect(metricsByName(metrics, "container_fs_usage_bytes")).To(Equal(map[string]uint64{"/dev/sda1": 1024}))
+			Expect(metricsByName(metrics, "container_fs_limit_bytes")).To(Equal(map[string]uint64{"/dev/sda1": 4096}))
+			Expect(metricsByName(metrics, "container_fs_inodes_total")).To(Equal(map[string]uint64{"/dev/sda1": 100}))
+			Expect(metricsByName(metrics, "container_fs_inodes_free")).To(Equal(map[string]uint64{"/dev/sda1": 40}))
+			for _, m := range metrics {
+				Expect(m.GetLabelValues()).To(Equal([]string{"sandboxID", "POD", "", "disk", "/dev/sda1"}))
+				Expect(m.GetMetricType()).To(Equal(types.MetricType_GAUGE))
+			}
+		})
+	})
+
+	Describe("generateSandboxDiskIOMetrics", func() {
+		deviceName := func(major, minor uint64) string {
+			if major == 8 && minor == 0 {
+				return "/dev/sda"
+			}
+			return "/dev/sdb"
+		}
+
+		It("should report the block I/O statistics per device", func() {
+			// Given
+			io := &cgmgr.DiskIOStats{
+				ServiceBytes: []cgmgr.BlkioEntry{
+					{Major: 8, Minor: 0, Op: "Read", Value: 4096},
+					{Major: 8, Minor: 0, Op: "Write", Value: 1024},
+					{Major: 8, Minor: 16, Op: "Read", Value: 2048},
+					{Major: 8, Minor: 16, Op: "Total", Value: 2048},
+				},
+				Serviced: []cgmgr.BlkioEntry{
+					{Major: 8, Minor: 0, Op: "Read", Value: 3},
+					{Major: 8, Minor: 0, Op: "Write", Value: 1},
+				},
+				ServiceTime: []cgmgr.BlkioEntry{
+					{Major: 8, Minor: 0, Op: "Read", Value: uint64(2 * time.Second)},
+				},
+				Queued: []cgmgr.BlkioEntry{
+					{Major: 8, Minor: 16, Op: "Total", Value: 5},
+				},
+				Time: []cgmgr.BlkioEntry{
+					{Major: 8, Minor: 0, Value: 3000},
+				},
+			}
+
+			// When
+			metrics := generateSandboxDiskIOMetrics(sb, io, deviceName)
+
+			// Then
+			Expect(metricsByName(metrics, "container_fs_reads_bytes_total")).To(Equal(map[string]uint64{"/dev/sda": 4096, "/dev/sdb": 2048}))
+			Expect(metricsByName(metrics, "container_fs_writes_bytes_total")).To(Equal(map[string]uint64{"/dev/sda": 1024}))
+			Expect(metricsByName(metrics, "container_fs_reads_total")).To(Equal(map[string]uint64{"/dev/sda": 3}))
+			Expect(metricsByName(metrics, "container_fs_writes_total")).To(Equal(map[string]uint64{"/dev/sda": 1}))
+			Expect(metricsByName(metrics, "container_fs_sector_reads_total")).To(Equal(map[string]uint64{"/dev/sda": 8, "/dev/sdb": 4}))
+			Expect(metricsByName(metrics, "container_fs_sector_writes_total")).To(Equal(map[string]uint64{"/dev/sda": 2}))
+			Expect(metricsByName(metrics, "container_fs_read_seconds_total")).To(Equal(map[string]uint64{"/dev/sda": 2}))
+			Expect(metricsByName(metrics, "container_fs_io_current")).To(Equal(map[string]uint64{"/dev/sdb": 5}))
+			Expect(metricsByName(metrics, "container_fs_io_time_seconds_total")).To(Equal(map[string]uint64{"/dev/sda": 3}))
+			usage := [][]string{}
+			for _, m := range metrics {
+				if m.GetName() == "container_blkio_device_usage_total" {
+					usage = append(usage, m.GetLabelValues()[4:])
+				}
+			}
+			Expect(usage).To(Equal([][]string{
+				{"/dev/sda", "8", "0", "Read"},
+				{"/dev/sda", "8", "0", "Write"},
+				{"/dev/sdb", "8", "16", "Read"},
+				{"/dev/sdb", "8", "16", "Total"},
+			}))
+		})
+
+		It("should report all described metrics", func() {
+			// Given
+			entries := []cgmgr.BlkioEntry{
+				{Major: 8, Minor: 0, Op: "Read", Value: 1},
+				{Major: 8, Minor: 0, Op: "Write", Value: 1},
+				{Major: 8, Minor: 0, Op: "Total", Value: 1},
+			}
+			io := &cgmgr.DiskIOStats{
+				ServiceBytes: entries,
+				Serviced:     entries,
+				Merged:       entries,
+				ServiceTime:  entries,
+				WaitTime:     entries,
+				Queued:       entries,
+				Time:         []cgmgr.BlkioEntry{{Major: 8, Minor: 0, Value: 1}},
+			}
+			ss := &StatsServer{}
+			described := map[string]bool{}
+			for _, desc := range ss.PopulateMetricDescriptors([]string{"diskio"})["diskio"] {
+				described[desc.GetName()] = true
+			}
+
+			// When
+			metrics := generateSandboxDiskIOMetrics(sb, io, deviceName)
+
+			// Then
+			emitted := map[string]bool{}
+			for _, m := range metrics {
+				emitted[m.GetName()] = true
+			}
+			Expect(emitted).To(Equal(described))
+		})
+	})
+
+	Describe("parseDevName", func() {
+		DescribeTable("should parse the uevent file",
+			func(uevent, expected string) {
+				Expect(parseDevName(uevent)).To(Equal(expected))
+			},
+			Entry("with DEVNAME", "MAJOR=8\nMINOR=0\nDEVNAME=sda\nDEVTYPE=disk\n", "/dev/sda"),
+			Entry("with nested DEVNAME", "MAJOR=253\nMINOR=0\nDEVNAME=mapper/root\n", "/dev/mapper/root"),
+			Entry("without DEVNAME", "MAJOR=8\nMINOR=0\n", ""),
+			Entry("with empty DEVNAME", "DEVNAME=\n", ""),
+			Entry("with empty content", "", ""),
+		)
+	})
+
+	Describe("deviceName", func() {
+		It("should fall back to the device numbers for unknown devices", func() {
+			// Given
+			ss := &StatsServer{deviceNames: map[string]string{}}
+
+			// When
+			name := ss.deviceName(4095, 1048575)
+
+			// Then
+			Expect(name).To(Equal("4095:1048575"))
+			Expect(ss.deviceNames).To(HaveKeyWithValue("4095:1048575", "4095:1048575"))
+		})
+
+		It("should return the cached device name", func() {
+			// Given
+			ss := &StatsServer{deviceNames: map[string]string{"8:0": "/dev/cached"}}
+
+			// When
+			name := ss.deviceName(8, 0)
+
+			// Then
+			Expect(name).To(Equal("/dev/cached"))
+		})
+	})
+})
@@ -0,0 +1,85 @@
+package statsserver
+
+import (
+	"math"
+	"sort"
+
+	"github.com/cri-o/cri-o/internal/config/cgmgr"
+	"github.com/cri-o/cri-o/internal/lib/sandbox"
+	types "k8s.io/cri-api/pkg/apis/runtime/v1"
+)
+
+func generateSandboxHugetlbMetrics(sb *sandbox.Sandbox, hugetlb map[string]cgmgr.HugetlbStats) []*types.Metric {
+	pageSizes := make([]string, 0, len(hugetlb))
+	for pageSize := range hugetlb {
+		pageSizes = append(pageSizes, pageSize)
+	}
+	sort.Strings(pageSizes)
+
+	// values returns the value per page size, skipping the ones for which
+	// the value is not set.
+	values := func(value func(cgmgr.HugetlbStats) (uint64, bool), metricType types.MetricType) metricValues {
+		res := make(metricValues, 0, len(pageSizes))
+		for _, pageSize := range pageSizes {
+			v, ok := value(hugetlb[pageSize])
+			if !ok {
+				continue
+			}
+			res = append(res, metricValue{
+				value:      v,
+				labels:     []string{pageSize},
+				metricType: metricType,
+			})
+		}
+		return res
+	}
+
+	hugetlbMetrics := []*containerMetric{
+		{
+			desc: &types.MetricDescriptor{
+				Name:      "container_hugetlb_usage_bytes",
+				Help:      "Current hugepage usage in bytes",
+				LabelKeys: append(baseLabelKeys, "pagesize"),
+			},
+			valueFunc: func() metricValues {
+				return values(func(h cgmgr.HugetlbStats) (uint64, bool) {
+					return h.Usage, true
+				}, types.MetricType_GAUGE)
+			},
+		}, {
+			desc: &types.MetricDescriptor{
+				Name:      "container_hugetlb_max_usage_bytes",
+				Help:      "Maximum hugepage usage recorded in bytes",
+				LabelKeys: append(baseLabelKeys, "pagesize"),
+			},
+			valueFunc: func() metricValues {
+				return values(func(h cgmgr.HugetlbStats) (uint64, bool) {
+					return h.MaxUsage, h.MaxUsage > 0
+				}, types.MetricType_GAUGE)
+			},
+		}, {
+			desc: &types.MetricDescriptor{
+				Name:      "container_hugetlb_limit_bytes",
+				Help:      "Hugepage limit in bytes",
+				LabelKeys: append(baseLabelKeys, "pagesize"),
+			},
+			valueFunc: func() metricValues {
+				return values(func(h cgmgr.HugetlbStats) (uint64, bool) {
+					return h.Limit, h.Limit != math.MaxUint64
+				}, types.MetricType_GAUGE)
+			},
+		}, {
+			desc: &types.MetricDescriptor{
+				Name:      "container_hugetlb_failcnt",
+				Help:      "Number of hugepage usage hits limits",
+				LabelKeys: append(baseLabelKeys, "pagesize"),
+			},
+			valueFunc: func() metricValues {
+				return values(func(h cgmgr.HugetlbStats) (uint64, bool) {
+					return h.Failcnt, true
+				}, types.MetricType_COUNTER)
+			},
+		},
+	}
+	return computeSandboxMetrics(sb, hugetlbMetrics, "hugetlb")
+}
@@ -1,6 +1,7 @@
 package statsserver
 
 import (
+	"slices"
 	"time"
 
 	"github.com/cri-o/cri-o/internal/lib/sandbox"
@@ -299,6 +300,52 @@ func (ss *StatsServer) PopulateMetricDescriptors(includedKeys []string) map[stri
 				LabelKeys: baseLabelKeys,
 			},
 		},
+		"hugetlb": {
+			{
+				Name:      "container_hugetlb_usage_bytes",
+				Help:      "Current hugepage usage in bytes",
+				LabelKeys: append(baseLabelKeys, "pagesize"),
+			}, {
+				Name:      "container_hugetlb_max_usage_bytes",
+				Help:      "Maximum hugepage usage recorded in bytes",
+				LabelKeys: append(baseLabelKeys, "pagesize"),
+			}, {
+				Name:      "container_hugetlb_limit_bytes",
+				Help:      "Hugepage limit in bytes",
+				LabelKeys: append(baseLabelKeys, "pagesize"),
+			}, {
+				Name:      "container_hugetlb_failcnt",
+				Help:      "Number of hugepage usage hits limits",
+				LabelKeys: append(baseLabelKeys, "pagesize"),
+			},
+		},
+		"pressure": {
+			{
+				Name:      "container_pressure_cpu_waiting_seconds_total",
+				Help:      "Total time duration tasks in the container have waited due to CPU congestion.",
+				LabelKeys: baseLabelKeys,
+			}, {
+				Name:      "container_pressure_cpu_stalled_seconds_total",
+				Help:      "Total time duration no tasks in the container could make progress due to CPU congestion.",
+				LabelKeys: baseLabelKeys,
+			}, {
+				Name:      "container_pressure_memory_waiting_seconds_total",
+				Help:      "Total time duration tasks in the container have waited due to memory congestion.",
+				LabelKeys: baseLabelKeys,
+			}, {
+				Name:      "container_pressure_memory_stalled_seconds_total",
+				Help:      "Total time duration no tasks in the container could make progress due to memory congestion.",
+				LabelKeys: baseLabelKeys,
+			}, {
+				Name:      "container_pressure_io_waiting_seconds_total",
+				Help:      "Total time duration tasks in the container have waited due to IO congestion.",
+				LabelKeys: baseLabelKeys,
+			}, {
+				Name:      "container_pressure_io_stalled_seconds_total",
+				Help:      "Total time duration no tasks in the container could make progress due to IO congestion.",
+				LabelKeys: baseLabelKeys,
+			},
+		},
 		"processes": {
 			{
 				Name:      "container_processes",
@@ -347,12 +394,15 @@ func computeSandboxMetrics(sb *sandbox.Sandbox, metrics []*containerMetric, metr
 
 	for _, m := range metrics {
 		for _, v := range m.valueFunc() {
+			// Copy the base values, which would otherwise share the labels
+			// of the previous metric.
+			labelValues := append(slices.Clone(values), v.labels...)
 			newMetric := &types.Metric{
 				Name:        m.desc.Name,
 				Timestamp:   time.Now().UnixNano(),
 				MetricType:  v.metricType,
 				Value:       &types.UInt64Value{Value: v.value},
-				LabelValues: append(values, v.labels...),
+				LabelValues: labelValues,
 			}
 			calculatedMetrics = append(calculatedMetrics, newMetric)
 		}
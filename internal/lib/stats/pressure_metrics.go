@@ -0,0 +1,98 @@
+package statsserver
+
+import (
+	"time"
+
+	"github.com/cri-o/cri-o/internal/config/cgmgr"
+	"github.com/cri-o/cri-o/internal/lib/sandbox"
+	types "k8s.io/cri-api/pkg/apis/runtime/v1"
+)
+
+// generateSandboxPressureMetrics generates the pressure stall information
+// metrics of the container. The "some" totals are reported as waiting and the
+// "full" totals as stalled seconds. Resources without pressure stall
+// information, for example on cgroup v1, are skipped.
+func generateSandboxPressureMetrics(sb *sandbox.Sandbox, stats *cgmgr.CgroupStats) []*types.Metric {
+	var cpuPSI, memoryPSI, ioPSI *cgmgr.PSIStats
+	if stats.CPU != nil {
+		cpuPSI = stats.CPU.PSI
+	}
+	if stats.Memory != nil {
+		memoryPSI = stats.Memory.PSI
+	}
+	if stats.DiskIO != nil {
+		ioPSI = stats.DiskIO.PSI
+	}
+
+	// values returns the total of the pressure data in seconds.
+	values := func(psi *cgmgr.PSIStats, data func(*cgmgr.PSIStats) cgmgr.PSIData) metricValues {
+		if psi == nil {
+			return nil
+		}
+		return metricValues{{
+			value:      data(psi).Total / uint64(time.Second/time.Microsecond),
+			metricType: types.MetricType_COUNTER,
+		}}
+	}
+	some := func(psi *cgmgr.PSIStats) cgmgr.PSIData { return psi.Some }
+	full := func(psi *cgmgr.PSIStats) cgmgr.PSIData { return psi.Full }
+
+	pressureMetrics := []*containerMetric{
+		{
+			desc: &types.MetricDescriptor{
+				Name:      "container_pressure_cpu_waiting_seconds_total",
+				Help:      "Total time duration tasks in the container have waited due to CPU congestion.",
+				LabelKeys: baseLabelKeys,
+			},
+			valueFunc: func() metricValues {
+				return values(cpuPSI, some)
+			},
+		}, {
+			desc: &types.MetricDescriptor{
+				Name:      "container_pressure_cpu_stalled_seconds_total",
+				Help:      "Total time duration no tasks in the container could make progress due to CPU congestion.",
+				LabelKeys: baseLabelKeys,
+			},
+			valueFunc: func() metricValues {
+				return values(cpuPSI, full)
+			},
+		}, {
+			desc: &types.MetricDescriptor{
+				Name:      "container_pressure_memory_waiting_seconds_total",
+				Help:      "Total time duration tasks in the container have waited due to memory congestion.",
+				LabelKeys: baseLabelKeys,
+			},
+			valueFunc: func() metricValues {
+				return values(memoryPSI, some)
+			},
+		}, {
+			desc: &types.MetricDescriptor{
+				Name:      "container_pressure_memory_stalled_seconds_total",
+				Help:      "Total time duration no tasks in the container could make progress due to memory congestion.",
+				LabelKeys: baseLabelKeys,
+			},
+			valueFunc: func() metricValues {
+				return values(memoryPSI, full)
+			},
+		}, {
+			desc: &types.MetricDescriptor{
+				Name:      "container_pressure_io_waiting_seconds_total",
+				Help:      "Total time duration tasks in the container have waited due to IO congestion.",
+				LabelKeys: baseLabelKeys,
+			},
+			valueFunc: func() metricValues {
+				return values(ioPSI, some)
+			},
+		}, {
+			desc: &types.MetricDescriptor{
+				Name:      "container_pressure_io_stalled_seconds_total",
+				Help:      "Total time duration no tasks in the container could make progress due to IO congestion.",
+				LabelKeys: baseLabelKeys,
+			},
+			valueFunc: func() metricValues {
+				return values(ioPSI, full)
+			},
+		},
+	}
+	return computeSandboxMetrics(sb, pressureMetrics, "pressure")
+}
@@ -24,6 +24,7 @@ type StatsServer struct {
 	sboxStats        map[string]*types.PodSandboxStats
 	ctrStats         map[string]*types.ContainerStats
 	sboxMetrics      map[string]*SandboxMetrics
+	deviceNames      map[string]string
 	ctx              context.Context
 	parentServerIface
 	mutex sync.Mutex
@@ -49,6 +50,7 @@ func New(ctx context.Context, cs parentServerIface) *StatsServer {
 		sboxStats:         make(map[string]*types.PodSandboxStats),
 		ctrStats:          make(map[string]*types.ContainerStats),
 		sboxMetrics:       make(map[string]*SandboxMetrics),
+		deviceNames:       make(map[string]string),
 		parentServerIface: cs,
 		ctx:               ctx,
 	}
@@ -10,7 +10,9 @@ import (
 	"github.com/cri-o/cri-o/internal/lib/sandbox"
 	"github.com/cri-o/cri-o/internal/log"
 	"github.com/cri-o/cri-o/internal/oci"
+	"github.com/cri-o/cri-o/pkg/config"
 	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
 	types "k8s.io/cri-api/pkg/apis/runtime/v1"
 )
 
@@ -231,6 +233,30 @@ func (ss *StatsServer) containerMetricsFromCgStats(sb *sandbox.Sandbox, c *oci.C
 			}
 			oomMetrics := GenerateSandboxOOMMetrics(sb, c, oomCount)
 			metrics = append(metrics, oomMetrics...)
+		case "disk":
+			fsStats, err := ss.filesystemStats(c)
+			if err != nil {
+				log.Errorf(ss.ctx, "Unable to fetch filesystem stats for container %s: %v", c.ID(), err)
+				continue
+			}
+			metrics = append(metrics, generateSandboxDiskMetrics(sb, fsStats)...)
+		case "diskio":
+			if cgstats.DiskIO != nil {
+				metrics = append(metrics, generateSandboxDiskIOMetrics(sb, cgstats.DiskIO, ss.deviceName)...)
+			}
+		case "cpuLoad":
+			taskStates, err := ss.containerTaskStates(sb, c, cgstats)
+			if err != nil {
+				log.Errorf(ss.ctx, "Unable to fetch task states for container %s: %v", c.ID(), err)
+				continue
+			}
+			if taskStates != nil {
+				metrics = append(metrics, generateSandboxTaskStateMetrics(sb, taskStates)...)
+			}
+		case "hugetlb":
+			metrics = append(metrics, generateSandboxHugetlbMetrics(sb, cgstats.Hugetlb)...)
+		case "pressure":
+			metrics = append(metrics, generateSandboxPressureMetrics(sb, cgstats)...)
 		case "network":
 			continue // Network metrics are collected at the pod level only.
 		default:
@@ -243,6 +269,49 @@ func (ss *StatsServer) containerMetricsFromCgStats(sb *sandbox.Sandbox, c *oci.C
 	}
 }
 
+// containerTaskStates returns the task states of the container, which are only
+// read from the cgroup if the "cpuLoad" metrics are included. VM runtimes
+// report them with the container statistics, if at all.
+func (ss *StatsServer) containerTaskStates(sb *sandbox.Sandbox, c *oci.Container, cgstats *cgmgr.CgroupStats) (*cgmgr.TaskStateStats, error) {
+	if cgstats.TaskStates != nil {
+		return cgstats.TaskStates, nil
+	}
+	if runtimeType, err := ss.Runtime().RuntimeType(sb.RuntimeHandler()); err == nil && runtimeType == config.RuntimeTypeVM {
+		return nil, nil
+	}
+	cm, err := ss.Config().CgroupManager().ContainerCgroupManager(sb.CgroupParent(), c.ID())
+	if err != nil {
+		return nil, err
+	}
+	return cgmgr.CgroupTaskStates(cm)
+}
+
+// filesystemStats gathers the usage of the container's writable layer as well
+// as the capacity of the filesystem containing it.
+func (ss *StatsServer) filesystemStats(c *oci.Container) (*filesystemStats, error) {
+	writableLayer, err := ss.writableLayerForContainer(c)
+	if err != nil {
+		return nil, err
+	}
+	// The writable layers are stored below the graph root.
+	graphRoot := ss.Store().GraphRoot()
+	var statfs unix.Statfs_t
+	if err := unix.Statfs(graphRoot, &statfs); err != nil {
+		return nil, fmt.Errorf("statfs %s: %w", graphRoot, err)
+	}
+	var stat unix.Stat_t
+	if err := unix.Stat(graphRoot, &stat); err != nil {
+		return nil, fmt.Errorf("stat %s: %w", graphRoot, err)
+	}
+	return &filesystemStats{
+		device:      ss.deviceName(uint64(unix.Major(stat.Dev)), uint64(unix.Minor(stat.Dev))),
+		usageBytes:  writableLayer.UsedBytes.Value,
+		limitBytes:  statfs.Blocks * uint64(statfs.Bsize),
+		inodesTotal: statfs.Files,
+		inodesFree:  statfs.Ffree,
+	}, nil
+}
+
 // linkToInterface translates information found from the netlink package
 // into CRI the NetworkInterfaceUsage structure.
 func linkToInterface(link netlink.Link) (*types.NetworkInterfaceUsage, error) {
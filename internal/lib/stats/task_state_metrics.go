@@ -0,0 +1,30 @@
+package statsserver
+
+import (
+	"github.com/cri-o/cri-o/internal/config/cgmgr"
+	"github.com/cri-o/cri-o/internal/lib/sandbox"
+	types "k8s.io/cri-api/pkg/apis/runtime/v1"
+)
+
+// generateSandboxTaskStateMetrics generates the number of tasks per state of
+// the container. The load average of the "cpuLoad" metrics is not supported.
+func generateSandboxTaskStateMetrics(sb *sandbox.Sandbox, tasks *cgmgr.TaskStateStats) []*types.Metric {
+	taskStateMetrics := []*containerMetric{
+		{
+			desc: &types.MetricDescriptor{
+				Name:      "container_tasks_state",
+				Help:      "Number of tasks in given state",
+				LabelKeys: append(baseLabelKeys, "state"),
+			},
+			valueFunc: func() metricValues {
+				return metricValues{
+					{value: tasks.Sleeping, labels: []string{"sleeping"}, metricType: types.MetricType_GAUGE},
+					{value: tasks.Running, labels: []string{"running"}, metricType: types.MetricType_GAUGE},
+					{value: tasks.Stopped, labels: []string{"stopped"}, metricType: types.MetricType_GAUGE},
+					{value: tasks.Uninterruptible, labels: []string{"uninterruptible"}, metricType: types.MetricType_GAUGE},
+				}
+			},
+		},
+	}
+	return computeSandboxMetrics(sb, taskStateMetrics, "cpuLoad")
+}
@@ -0,0 +1,283 @@
+// Package nftables provides a minimal interface to program nftables tables,
+// chains and rules via netlink, without requiring the nft binary.
+package nftables
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// Interface is an injectable interface for programming nftables. Implementations
+// must be goroutine-safe.
+type Interface interface {
+	// Family returns the address family of the tables this instance is
+	// managing.
+	Family() Family
+	// Present checks if the kernel supports nftables.
+	Present() bool
+	// ListChains returns the names of all chains of the table, or an empty
+	// list if the table does not exist.
+	ListChains(table string) ([]string, error)
+	// Run applies all operations of the transaction atomically.
+	Run(tx *Transaction) error
+}
+
+// Family is the address family of an nftables table.
+type Family string
+
+const (
+	// FamilyIPv4 represents the ip table family.
+	FamilyIPv4 Family = "ip"
+	// FamilyIPv6 represents the ip6 table family.
+	FamilyIPv6 Family = "ip6"
+)
+
+// ChainHook is the netfilter hook a base chain is attached to.
+type ChainHook string
+
+const (
+	// HookPrerouting is the hook for incoming packets before routing.
+	HookPrerouting ChainHook = "prerouting"
+	// HookOutput is the hook for locally generated packets.
+	HookOutput ChainHook = "output"
+	// HookPostrouting is the hook for outgoing packets after routing.
+	HookPostrouting ChainHook = "postrouting"
+)
+
+// Standard priorities of nat base chains.
+const (
+	// PriorityDestinationNAT is the priority of the destination NAT chains.
+	PriorityDestinationNAT int32 = -100
+	// PrioritySourceNAT is the priority of the source NAT chains.
+	PrioritySourceNAT int32 = 100
+)
+
+// Chain is an nftables chain. Base chains are attached to a Hook and are
+// always of type nat, while regular chains have no hook.
+type Chain struct {
+	Name     string
+	Hook     ChainHook
+	Priority int32
+}
+
+// IsBaseChain returns true if the chain is attached to a hook.
+func (c *Chain) IsBaseChain() bool {
+	return c.Hook != ""
+}
+
+// NAT is the target of a destination NAT statement.
+type NAT struct {
+	Address net.IP
+	Port    uint16
+}
+
+// Rule is an nftables rule. All set matches have to apply for the statement
+// to be executed, which is exactly one of Jump, DNAT and Masquerade.
+type Rule struct {
+	Chain string
+
+	// DestinationLocal matches packets destined to a local address.
+	DestinationLocal bool
+	// DNATed matches packets of connections with translated destination.
+	DNATed bool
+	// OutputInterface matches the name of the output interface.
+	OutputInterface string
+	// Protocol matches the layer 4 protocol, either "tcp", "udp" or "sctp".
+	Protocol string
+	// SourceAddress and DestinationAddress match the address prefixes.
+	SourceAddress      *net.IPNet
+	DestinationAddress *net.IPNet
+	// DestinationPort matches the destination port, requires Protocol.
+	DestinationPort uint16
+
+	// Jump continues the evaluation in the chain with the provided name.
+	Jump string
+	// DNAT translates the destination address and port.
+	DNAT *NAT
+	// Masquerade translates the source address to the one of the output
+	// interface.
+	Masquerade bool
+
+	Comment string
+}
+
+// protocols maps the supported layer 4 protocols to their IANA numbers.
+var protocols = map[string]uint8{
+	"tcp":  6,
+	"udp":  17,
+	"sctp": 132,
+}
+
+// Validate checks that the rule can be expressed in nftables.
+func (r *Rule) Validate() error {
+	if r.Chain == "" {
+		return errors.New("rule without chain")
+	}
+	if r.Protocol != "" {
+		if _, ok := protocols[r.Protocol]; !ok {
+			return fmt.Errorf("unsupported protocol %q", r.Protocol)
+		}
+	} else if r.DestinationPort != 0 || (r.DNAT != nil && r.DNAT.Port != 0) {
+		return errors.New("port match or translation requires a protocol")
+	}
+	statements := 0
+	if r.Jump != "" {
+		statements++
+	}
+	if r.DNAT != nil {
+		if r.DNAT.Address.To16() == nil {
+			return errors.New("invalid DNAT address")
+		}
+		statements++
+	}
+	if r.Masquerade {
+		statements++
+	}
+	if statements != 1 {
+		return fmt.Errorf("rule requires exactly one statement, got %d", statements)
+	}
+	return nil
+}
+
+// String returns the rule in the syntax of the nft command line tool.
+func (r *Rule) String() string {
+	parts := []string{}
+	if r.DestinationLocal {
+		parts = append(parts, "fib daddr type local")
+	}
+	if r.DNATed {
+		parts = append(parts, "ct status dnat")
+	}
+	if r.OutputInterface != "" {
+		parts = append(parts, "oifname "+strconv.Quote(r.OutputInterface))
+	}
+	if r.Protocol != "" {
+		parts = append(parts, "meta l4proto "+r.Protocol)
+	}
+	if r.SourceAddress != nil {
+		parts = append(parts, addressKeyword(r.SourceAddress.IP)+" saddr "+prefixString(r.SourceAddress))
+	}
+	if r.DestinationAddress != nil {
+		parts = append(parts, addressKeyword(r.DestinationAddress.IP)+" daddr "+prefixString(r.DestinationAddress))
+	}
+	if r.DestinationPort != 0 {
+		parts = append(parts, "th dport "+strconv.Itoa(int(r.DestinationPort)))
+	}
+	switch {
+	case r.Jump != "":
+		parts = append(parts, "jump "+r.Jump)
+	case r.DNAT != nil && r.DNAT.Port != 0:
+		parts = append(parts, "dnat to "+net.JoinHostPort(r.DNAT.Address.String(), strconv.Itoa(int(r.DNAT.Port))))
+	case r.DNAT != nil:
+		parts = append(parts, "dnat to "+r.DNAT.Address.String())
+	case r.Masquerade:
+		parts = append(parts, "masquerade")
+	}
+	if r.Comment != "" {
+		parts = append(parts, "comment "+strconv.Quote(r.Comment))
+	}
+	return strings.Join(parts, " ")
+}
+
+func addressKeyword(ip net.IP) string {
+	if ip.To4() != nil {
+		return "ip"
+	}
+	return "ip6"
+}
+
+func prefixString(prefix *net.IPNet) string {
+	if ones, bits := prefix.Mask.Size(); ones == bits {
+		return prefix.IP.String()
+	}
+	return prefix.String()
+}
+
+// Verb is the kind of an Operation.
+type Verb string
+
+const (
+	// VerbAdd creates the object if it does not exist yet.
+	VerbAdd Verb = "add"
+	// VerbFlush removes all rules from a chain.
+	VerbFlush Verb = "flush"
+	// VerbDelete removes the object.
+	VerbDelete Verb = "delete"
+)
+
+// Operation is a single change of a Transaction. It applies to the table if
+// neither Chain nor Rule are set.
+type Operation struct {
+	Verb  Verb
+	Chain *Chain
+	Rule  *Rule
+}
+
+// Transaction is a list of operations on a single table, which get applied
+// atomically.
+type Transaction struct {
+	Family     Family
+	Table      string
+	Operations []Operation
+}
+
+// NewTransaction creates a new empty transaction for the table.
+func NewTransaction(family Family, table string) *Transaction {
+	return &Transaction{Family: family, Table: table}
+}
+
+// AddTable creates the table if it does not exist.
+func (tx *Transaction) AddTable() {
+	tx.Operations = append(tx.Operations, Operation{Verb: VerbAdd})
+}
+
+// DeleteTable removes the table including all of its chains.
+func (tx *Transaction) DeleteTable() {
+	tx.Operations = append(tx.Operations, Operation{Verb: VerbDelete})
+}
+
+// AddChain creates the chain if it does not exist.
+func (tx *Transaction) AddChain(chain *Chain) {
+	tx.Operations = append(tx.Operations, Operation{Verb: VerbAdd, Chain: chain})
+}
+
+// FlushChain removes all rules of an existing chain.
+func (tx *Transaction) FlushChain(name string) {
+	tx.Operations = append(tx.Operations, Operation{Verb: VerbFlush, Chain: &Chain{Name: name}})
+}
+
+// DeleteChain removes an existing chain, which must not be referenced anymore.
+func (tx *Transaction) DeleteChain(name string) {
+	tx.Operations = append(tx.Operations, Operation{Verb: VerbDelete, Chain: &Chain{Name: name}})
+}
+
+// AddRule appends the rule to its chain.
+func (tx *Transaction) AddRule(rule *Rule) {
+	tx.Operations = append(tx.Operations, Operation{Verb: VerbAdd, Rule: rule})
+}
+
+// String returns the transaction in the syntax of the nft command line tool.
+func (tx *Transaction) String() string {
+	var b strings.Builder
+	for _, op := range tx.Operations {
+		b.WriteString(tx.operationString(&op))
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+func (tx *Transaction) operationString(op *Operation) string {
+	table := fmt.Sprintf("%s %s", tx.Family, tx.Table)
+	switch {
+	case op.Rule != nil:
+		return fmt.Sprintf("%s rule %s %s %s", op.Verb, table, op.Rule.Chain, op.Rule)
+	case op.Chain != nil && op.Chain.IsBaseChain():
+		return fmt.Sprintf("%s chain %s %s { type nat hook %s priority %d ; }", op.Verb, table, op.Chain.Name, op.Chain.Hook, op.Chain.Priority)
+	case op.Chain != nil:
+		return fmt.Sprintf("%s chain %s %s", op.Verb, table, op.Chain.Name)
+	}
+	return fmt.Sprintf("%s table %s", op.Verb, table)
+}
@@ -0,0 +1,302 @@
+package nftables
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	nft "github.com/google/nftables"
+	"github.com/google/nftables/binaryutil"
+	"github.com/google/nftables/expr"
+	"github.com/google/nftables/userdata"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// ipsDstNAT is the conntrack status bit of connections with translated
+	// destination.
+	ipsDstNAT = 1 << 5
+
+	// maxCommentLength is the maximum length of a rule comment, which has to
+	// fit into the user data of the rule including its terminating null.
+	maxCommentLength = 254
+)
+
+type runner struct {
+	family Family
+	// opts are passed to every nftables connection.
+	opts []nft.ConnOption
+}
+
+// New returns a new Interface which programs nftables via netlink.
+func New(family Family) Interface {
+	return &runner{family: family}
+}
+
+func (r *runner) Family() Family {
+	return r.family
+}
+
+func (r *runner) tableFamily() nft.TableFamily {
+	if r.family == FamilyIPv6 {
+		return nft.TableFamilyIPv6
+	}
+	return nft.TableFamilyIPv4
+}
+
+func (r *runner) Present() bool {
+	c, err := nft.New(r.opts...)
+	if err != nil {
+		return false
+	}
+	// Listing the tables fails if the kernel lacks nftables support.
+	_, err = c.ListTablesOfFamily(r.tableFamily())
+	return err == nil
+}
+
+func (r *runner) ListChains(table string) ([]string, error) {
+	c, err := nft.New(r.opts...)
+	if err != nil {
+		return nil, err
+	}
+	all, err := c.ListChainsOfTableFamily(r.tableFamily())
+	if err != nil {
+		return nil, fmt.Errorf("list chains: %w", err)
+	}
+	chains := []string{}
+	for _, chain := range all {
+		if chain.Table != nil && chain.Table.Name == table {
+			chains = append(chains, chain.Name)
+		}
+	}
+	return chains, nil
+}
+
+func (r *runner) Run(tx *Transaction) error {
+	if tx.Family != r.family {
+		return fmt.Errorf("transaction family %s does not match %s", tx.Family, r.family)
+	}
+	logrus.Debugf("Running nftables transaction:\n%s", tx)
+
+	c, err := nft.New(r.opts...)
+	if err != nil {
+		return err
+	}
+	table := &nft.Table{Name: tx.Table, Family: r.tableFamily()}
+	for i := range tx.Operations {
+		if err := r.queueOperation(c, table, &tx.Operations[i]); err != nil {
+			return fmt.Errorf("%s: %w", tx.operationString(&tx.Operations[i]), err)
+		}
+	}
+	if err := c.Flush(); err != nil {
+		return fmt.Errorf("run nftables transaction: %w", err)
+	}
+	return nil
+}
+
+// queueOperation adds the operation to the batch of the connection, which
+// gets sent by flushing the connection.
+func (r *runner) queueOperation(c *nft.Conn, table *nft.Table, op *Operation) error {
+	switch {
+	case op.Rule != nil && op.Verb == VerbAdd:
+		rule, err := r.rule(table, op.Rule)
+		if err != nil {
+			return err
+		}
+		c.AddRule(rule)
+
+	case op.Chain != nil && op.Verb == VerbAdd:
+		chain := &nft.Chain{Name: op.Chain.Name, Table: table}
+		if op.Chain.IsBaseChain() {
+			hooknum, err := hookNumber(op.Chain.Hook)
+			if err != nil {
+				return err
+			}
+			chain.Hooknum = nft.ChainHookRef(hooknum)
+			chain.Priority = nft.ChainPriorityRef(nft.ChainPriority(op.Chain.Priority))
+			chain.Type = nft.ChainTypeNAT
+		}
+		c.AddChain(chain)
+
+	case op.Chain != nil && op.Verb == VerbFlush:
+		c.FlushChain(&nft.Chain{Name: op.Chain.Name, Table: table})
+
+	case op.Chain != nil && op.Verb == VerbDelete:
+		c.DelChain(&nft.Chain{Name: op.Chain.Name, Table: table})
+
+	case op.Chain == nil && op.Rule == nil && op.Verb == VerbAdd:
+		c.AddTable(table)
+
+	case op.Chain == nil && op.Rule == nil && op.Verb == VerbDelete:
+		c.DelTable(table)
+
+	default:
+		return fmt.Errorf("unsupported operation %q", op.Verb)
+	}
+	return nil
+}
+
+func hookNumber(hook ChainHook) (nft.ChainHook, error) {
+	switch hook {
+	case HookPrerouting:
+		return *nft.ChainHookPrerouting, nil
+	case HookOutput:
+		return *nft.ChainHookOutput, nil
+	case HookPostrouting:
+		return *nft.ChainHookPostrouting, nil
+	}
+	return 0, fmt.Errorf("unsupported hook %q", hook)
+}
+
+// rule converts the rule into an nftables rule of the table.
+func (r *runner) rule(table *nft.Table, rule *Rule) (*nft.Rule, error) {
+	if err := rule.Validate(); err != nil {
+		return nil, err
+	}
+	exprs, err := r.ruleExpressions(rule)
+	if err != nil {
+		return nil, err
+	}
+	res := &nft.Rule{
+		Table: table,
+		Chain: &nft.Chain{Name: rule.Chain, Table: table},
+		Exprs: exprs,
+	}
+	if rule.Comment != "" {
+		if len(rule.Comment) > maxCommentLength {
+			return nil, errors.New("rule comment is too long")
+		}
+		// The comment is stored the same way as by the nft command line tool.
+		res.UserData = userdata.AppendString(nil, userdata.TypeComment, rule.Comment)
+	}
+	return res, nil
+}
+
+// ruleExpressions returns the expressions of the matches and the statement of
+// the rule, which all use the first register.
+func (r *runner) ruleExpressions(rule *Rule) ([]expr.Any, error) {
+	exprs := []expr.Any{}
+	cmp := func(op expr.CmpOp, data []byte) {
+		exprs = append(exprs, &expr.Cmp{Op: op, Register: 1, Data: data})
+	}
+	address := func(prefix *net.IPNet, ipv4Offset, ipv6Offset uint32) error {
+		ip, err := r.familyAddress(prefix.IP)
+		if err != nil {
+			return err
+		}
+		offset := ipv4Offset
+		if r.family == FamilyIPv6 {
+			offset = ipv6Offset
+		}
+		mask := prefix.Mask
+		if len(mask) != len(ip) {
+			mask = net.CIDRMask(len(ip)*8, len(ip)*8)
+		}
+		exprs = append(exprs, &expr.Payload{
+			DestRegister: 1,
+			Base:         expr.PayloadBaseNetworkHeader,
+			Offset:       offset,
+			Len:          uint32(len(ip)),
+		})
+		if ones, bits := mask.Size(); ones != bits {
+			exprs = append(exprs, &expr.Bitwise{
+				SourceRegister: 1,
+				DestRegister:   1,
+				Len:            uint32(len(mask)),
+				Mask:           mask,
+				Xor:            make([]byte, len(mask)),
+			})
+		}
+		cmp(expr.CmpOpEq, ip.Mask(mask))
+		return nil
+	}
+
+	if rule.DestinationLocal {
+		exprs = append(exprs, &expr.Fib{Register: 1, ResultADDRTYPE: true, FlagDADDR: true})
+		cmp(expr.CmpOpEq, binaryutil.NativeEndian.PutUint32(unix.RTN_LOCAL))
+	}
+	if rule.DNATed {
+		exprs = append(exprs,
+			&expr.Ct{Register: 1, Key: expr.CtKeySTATUS},
+			&expr.Bitwise{
+				SourceRegister: 1,
+				DestRegister:   1,
+				Len:            4,
+				Mask:           binaryutil.NativeEndian.PutUint32(ipsDstNAT),
+				Xor:            make([]byte, 4),
+			},
+		)
+		cmp(expr.CmpOpNeq, make([]byte, 4))
+	}
+	if rule.OutputInterface != "" {
+		if len(rule.OutputInterface) >= unix.IFNAMSIZ {
+			return nil, fmt.Errorf("interface name %q is too long", rule.OutputInterface)
+		}
+		name := make([]byte, unix.IFNAMSIZ)
+		copy(name, rule.OutputInterface)
+		exprs = append(exprs, &expr.Meta{Key: expr.MetaKeyOIFNAME, Register: 1})
+		cmp(expr.CmpOpEq, name)
+	}
+	if rule.Protocol != "" {
+		exprs = append(exprs, &expr.Meta{Key: expr.MetaKeyL4PROTO, Register: 1})
+		cmp(expr.CmpOpEq, []byte{protocols[rule.Protocol]})
+	}
+	if rule.SourceAddress != nil {
+		if err := address(rule.SourceAddress, 12, 8); err != nil {
+			return nil, err
+		}
+	}
+	if rule.DestinationAddress != nil {
+		if err := address(rule.DestinationAddress, 16, 24); err != nil {
+			return nil, err
+		}
+	}
+	if rule.DestinationPort != 0 {
+		exprs = append(exprs, &expr.Payload{
+			DestRegister: 1,
+			Base:         expr.PayloadBaseTransportHeader,
+			Offset:       2,
+			Len:          2,
+		})
+		cmp(expr.CmpOpEq, binaryutil.BigEndian.PutUint16(rule.DestinationPort))
+	}
+
+	switch {
+	case rule.Jump != "":
+		exprs = append(exprs, &expr.Verdict{Kind: expr.VerdictJump, Chain: rule.Jump})
+
+	case rule.DNAT != nil:
+		ip, err := r.familyAddress(rule.DNAT.Address)
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, &expr.Immediate{Register: 1, Data: ip})
+		nat := &expr.NAT{
+			Type:       expr.NATTypeDestNAT,
+			Family:     uint32(r.tableFamily()),
+			RegAddrMin: 1,
+		}
+		if rule.DNAT.Port != 0 {
+			exprs = append(exprs, &expr.Immediate{Register: 2, Data: binaryutil.BigEndian.PutUint16(rule.DNAT.Port)})
+			nat.RegProtoMin = 2
+		}
+		exprs = append(exprs, nat)
+
+	case rule.Masquerade:
+		exprs = append(exprs, &expr.Masq{})
+	}
+	return exprs, nil
+}
+
+// familyAddress returns the address in the representation of the family of
+// the runner.
+func (r *runner) familyAddress(ip net.IP) (net.IP, error) {
+	if r.family == FamilyIPv6 {
+		return ip.To16(), nil
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4, nil
+	}
+	return nil, fmt.Errorf("address %s does not match family %s", ip, r.family)
+}
@@ -0,0 +1,354 @@
+package nftables
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	nft "github.com/google/nftables"
+	"github.com/google/nftables/binaryutil"
+	"github.com/google/nftables/expr"
+	"github.com/mdlayher/netlink"
+	"github.com/mdlayher/netlink/nltest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+// newTestRunner returns a runner which passes all requests to dial instead of
+// the kernel.
+func newTestRunner(family Family, dial nltest.Func) *runner {
+	return &runner{family: family, opts: []nft.ConnOption{nft.WithTestDial(dial)}}
+}
+
+// recordRequests returns a dial function recording all requests as strings.
+func recordRequests(t *testing.T, requests *[]string) nltest.Func {
+	return func(req []netlink.Message) ([]netlink.Message, error) {
+		for _, msg := range req {
+			*requests = append(*requests, requestString(t, msg))
+		}
+		return nil, nil
+	}
+}
+
+// requestString describes a request by its type and the attributes relevant
+// for the operations of the runner.
+func requestString(t *testing.T, msg netlink.Message) string {
+	switch msg.Header.Type {
+	case unix.NFNL_MSG_BATCH_BEGIN:
+		return "batch begin"
+	case unix.NFNL_MSG_BATCH_END:
+		return "batch end"
+	}
+	msgType := map[netlink.HeaderType]string{
+		unix.NFT_MSG_NEWTABLE: "newtable",
+		unix.NFT_MSG_DELTABLE: "deltable",
+		unix.NFT_MSG_NEWCHAIN: "newchain",
+		unix.NFT_MSG_DELCHAIN: "delchain",
+		unix.NFT_MSG_NEWRULE:  "newrule",
+		unix.NFT_MSG_DELRULE:  "delrule",
+	}[msg.Header.Type&^(unix.NFNL_SUBSYS_NFTABLES<<8)]
+	parts := []string{msgType, fmt.Sprintf("family=%d", msg.Data[0])}
+
+	ad, err := netlink.NewAttributeDecoder(msg.Data[4:])
+	require.NoError(t, err)
+	for ad.Next() {
+		// The attribute types of tables, chains and rules overlap.
+		switch {
+		case ad.Type() == unix.NFTA_TABLE_NAME:
+			parts = append(parts, "table="+ad.String())
+		case ad.Type() == unix.NFTA_CHAIN_NAME && strings.HasSuffix(msgType, "chain"),
+			ad.Type() == unix.NFTA_RULE_CHAIN && strings.HasSuffix(msgType, "rule"):
+			parts = append(parts, "chain="+ad.String())
+		case ad.Type() == unix.NFTA_CHAIN_HOOK && strings.HasSuffix(msgType, "chain"):
+			ad.Nested(func(nad *netlink.AttributeDecoder) error {
+				// Unlike netlink attributes, nftables encodes its values in
+				// network byte order.
+				nad.ByteOrder = binary.BigEndian
+				for nad.Next() {
+					switch nad.Type() {
+					case unix.NFTA_HOOK_HOOKNUM:
+						parts = append(parts, fmt.Sprintf("hook=%d", nad.Uint32()))
+					case unix.NFTA_HOOK_PRIORITY:
+						parts = append(parts, fmt.Sprintf("priority=%d", int32(nad.Uint32())))
+					}
+				}
+				return nil
+			})
+		case ad.Type() == unix.NFTA_CHAIN_TYPE && strings.HasSuffix(msgType, "chain"):
+			parts = append(parts, "type="+ad.String())
+		case ad.Type() == unix.NFTA_RULE_EXPRESSIONS && strings.HasSuffix(msgType, "rule"):
+			names := []string{}
+			ad.Nested(func(nad *netlink.AttributeDecoder) error {
+				for nad.Next() {
+					nad.Nested(func(ead *netlink.AttributeDecoder) error {
+						for ead.Next() {
+							if ead.Type() == unix.NFTA_EXPR_NAME {
+								names = append(names, ead.String())
+							}
+						}
+						return nil
+					})
+				}
+				return nil
+			})
+			parts = append(parts, "exprs="+strings.Join(names, ","))
+		case ad.Type() == unix.NFTA_RULE_USERDATA && strings.HasSuffix(msgType, "rule"):
+			parts = append(parts, fmt.Sprintf("userdata=%q", ad.Bytes()))
+		}
+	}
+	require.NoError(t, ad.Err())
+	return strings.Join(parts, " ")
+}
+
+func TestRun(t *testing.T) {
+	requests := []string{}
+	r := newTestRunner(FamilyIPv4, recordRequests(t, &requests))
+
+	tx := NewTransaction(FamilyIPv4, "t")
+	tx.AddTable()
+	tx.AddChain(&Chain{Name: "pre", Hook: HookPrerouting, Priority: PriorityDestinationNAT})
+	tx.AddChain(&Chain{Name: "post", Hook: HookPostrouting, Priority: PrioritySourceNAT})
+	tx.AddChain(&Chain{Name: "c"})
+	tx.FlushChain("c")
+	tx.AddRule(&Rule{Chain: "pre", DestinationLocal: true, Jump: "c"})
+	tx.AddRule(&Rule{Chain: "c", Masquerade: true, Comment: "comment"})
+	tx.DeleteChain("c")
+	tx.DeleteTable()
+	require.NoError(t, r.Run(tx))
+
+	assert.Equal(t, []string{
+		"batch begin",
+		"newtable family=2 table=t",
+		"newchain family=2 table=t chain=pre hook=0 priority=-100 type=nat",
+		"newchain family=2 table=t chain=post hook=4 priority=100 type=nat",
+		"newchain family=2 table=t chain=c",
+		"delrule family=2 table=t chain=c",
+		"newrule family=2 table=t chain=pre exprs=fib,cmp,immediate",
+		`newrule family=2 table=t chain=c exprs=masq userdata="\x00\bcomment\x00"`,
+		"delchain family=2 table=t chain=c",
+		"deltable family=2 table=t",
+		"batch end",
+	}, requests)
+}
+
+func TestRunFailure(t *testing.T) {
+	testCases := []struct {
+		name   string
+		family Family
+		tx     func(tx *Transaction)
+	}{
+		{"invalid rule", FamilyIPv4, func(tx *Transaction) {
+			tx.AddRule(&Rule{Chain: "c"})
+		}},
+		{"unsupported hook", FamilyIPv4, func(tx *Transaction) {
+			tx.AddChain(&Chain{Name: "c", Hook: "input"})
+		}},
+		{"address of other family", FamilyIPv4, func(tx *Transaction) {
+			tx.AddRule(&Rule{Chain: "c", SourceAddress: hostNetwork("fd00::1"), Masquerade: true})
+		}},
+		{"too long interface name", FamilyIPv4, func(tx *Transaction) {
+			tx.AddRule(&Rule{Chain: "c", OutputInterface: strings.Repeat("a", unix.IFNAMSIZ), Masquerade: true})
+		}},
+		{"too long comment", FamilyIPv4, func(tx *Transaction) {
+			tx.AddRule(&Rule{Chain: "c", Masquerade: true, Comment: strings.Repeat("a", 255)})
+		}},
+		{"unsupported operation", FamilyIPv4, func(tx *Transaction) {
+			tx.Operations = append(tx.Operations, Operation{Verb: VerbFlush})
+		}},
+	}
+	for _, tc := range testCases {
+		requests := []string{}
+		r := newTestRunner(tc.family, recordRequests(t, &requests))
+		tx := NewTransaction(tc.family, "t")
+		tc.tx(tx)
+		assert.Error(t, r.Run(tx), tc.name)
+		assert.Empty(t, requests, tc.name)
+	}
+
+	// Mismatching family
+	r := newTestRunner(FamilyIPv6, func([]netlink.Message) ([]netlink.Message, error) {
+		return nil, nil
+	})
+	assert.Error(t, r.Run(NewTransaction(FamilyIPv4, "t")))
+
+	// Error of the kernel
+	r = newTestRunner(FamilyIPv4, func(req []netlink.Message) ([]netlink.Message, error) {
+		if len(req) == 0 {
+			return nil, nil
+		}
+		return nltest.Error(int(unix.ENOENT), req[1:])
+	})
+	tx := NewTransaction(FamilyIPv4, "t")
+	tx.DeleteTable()
+	assert.Error(t, r.Run(tx))
+}
+
+func TestRuleExpressions(t *testing.T) {
+	dnatMask := binaryutil.NativeEndian.PutUint32(1 << 5)
+	testCases := []struct {
+		name     string
+		family   Family
+		rule     Rule
+		expected []expr.Any
+	}{
+		{
+			name:   "local jump",
+			family: FamilyIPv4,
+			rule:   Rule{DestinationLocal: true, Jump: "c"},
+			expected: []expr.Any{
+				&expr.Fib{Register: 1, ResultADDRTYPE: true, FlagDADDR: true},
+				&expr.Cmp{Op: expr.CmpOpEq, Register: 1, Data: binaryutil.NativeEndian.PutUint32(unix.RTN_LOCAL)},
+				&expr.Verdict{Kind: expr.VerdictJump, Chain: "c"},
+			},
+		},
+		{
+			name:   "dnat ipv4",
+			family: FamilyIPv4,
+			rule: Rule{
+				Protocol:           "tcp",
+				DestinationAddress: hostNetwork("192.168.0.1"),
+				DestinationPort:    8080,
+				DNAT:               &NAT{Address: net.ParseIP("10.1.1.2"), Port: 80},
+			},
+			expected: []expr.Any{
+				&expr.Meta{Key: expr.MetaKeyL4PROTO, Register: 1},
+				&expr.Cmp{Op: expr.CmpOpEq, Register: 1, Data: []byte{6}},
+				&expr.Payload{DestRegister: 1, Base: expr.PayloadBaseNetworkHeader, Offset: 16, Len: 4},
+				&expr.Cmp{Op: expr.CmpOpEq, Register: 1, Data: []byte{192, 168, 0, 1}},
+				&expr.Payload{DestRegister: 1, Base: expr.PayloadBaseTransportHeader, Offset: 2, Len: 2},
+				&expr.Cmp{Op: expr.CmpOpEq, Register: 1, Data: []byte{0x1f, 0x90}},
+				&expr.Immediate{Register: 1, Data: []byte{10, 1, 1, 2}},
+				&expr.Immediate{Register: 2, Data: []byte{0, 80}},
+				&expr.NAT{Type: expr.NATTypeDestNAT, Family: unix.NFPROTO_IPV4, RegAddrMin: 1, RegProtoMin: 2},
+			},
+		},
+		{
+			name:   "dnat ipv6 without port",
+			family: FamilyIPv6,
+			rule:   Rule{DNAT: &NAT{Address: net.ParseIP("fd00::1")}},
+			expected: []expr.Any{
+				&expr.Immediate{Register: 1, Data: net.ParseIP("fd00::1").To16()},
+				&expr.NAT{Type: expr.NATTypeDestNAT, Family: unix.NFPROTO_IPV6, RegAddrMin: 1},
+			},
+		},
+		{
+			name:   "masquerade hairpin ipv4",
+			family: FamilyIPv4,
+			rule: Rule{
+				DNATed:             true,
+				Protocol:           "udp",
+				SourceAddress:      hostNetwork("10.1.1.2"),
+				DestinationAddress: hostNetwork("10.1.1.2"),
+				DestinationPort:    53,
+				Masquerade:         true,
+			},
+			expected: []expr.Any{
+				&expr.Ct{Register: 1, Key: expr.CtKeySTATUS},
+				&expr.Bitwise{SourceRegister: 1, DestRegister: 1, Len: 4, Mask: dnatMask, Xor: []byte{0, 0, 0, 0}},
+				&expr.Cmp{Op: expr.CmpOpNeq, Register: 1, Data: []byte{0, 0, 0, 0}},
+				&expr.Meta{Key: expr.MetaKeyL4PROTO, Register: 1},
+				&expr.Cmp{Op: expr.CmpOpEq, Register: 1, Data: []byte{17}},
+				&expr.Payload{DestRegister: 1, Base: expr.PayloadBaseNetworkHeader, Offset: 12, Len: 4},
+				&expr.Cmp{Op: expr.CmpOpEq, Register: 1, Data: []byte{10, 1, 1, 2}},
+				&expr.Payload{DestRegister: 1, Base: expr.PayloadBaseNetworkHeader, Offset: 16, Len: 4},
+				&expr.Cmp{Op: expr.CmpOpEq, Register: 1, Data: []byte{10, 1, 1, 2}},
+				&expr.Payload{DestRegister: 1, Base: expr.PayloadBaseTransportHeader, Offset: 2, Len: 2},
+				&expr.Cmp{Op: expr.CmpOpEq, Register: 1, Data: []byte{0, 53}},
+				&expr.Masq{},
+			},
+		},
+		{
+			name:   "masquerade localhost ipv4",
+			family: FamilyIPv4,
+			rule: Rule{
+				OutputInterface: "cbr0",
+				SourceAddress:   &net.IPNet{IP: net.IPv4(127, 0, 0, 0), Mask: net.CIDRMask(8, 32)},
+				Masquerade:      true,
+			},
+			expected: []expr.Any{
+				&expr.Meta{Key: expr.MetaKeyOIFNAME, Register: 1},
+				&expr.Cmp{Op: expr.CmpOpEq, Register: 1, Data: []byte("cbr0\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00")},
+				&expr.Payload{DestRegister: 1, Base: expr.PayloadBaseNetworkHeader, Offset: 12, Len: 4},
+				&expr.Bitwise{SourceRegister: 1, DestRegister: 1, Len: 4, Mask: []byte{255, 0, 0, 0}, Xor: []byte{0, 0, 0, 0}},
+				&expr.Cmp{Op: expr.CmpOpEq, Register: 1, Data: []byte{127, 0, 0, 0}},
+				&expr.Masq{},
+			},
+		},
+		{
+			name:   "masquerade ipv6",
+			family: FamilyIPv6,
+			rule: Rule{
+				SourceAddress:      hostNetwork("2001:beef::2"),
+				DestinationAddress: hostNetwork("2001:beef::2"),
+				Masquerade:         true,
+			},
+			expected: []expr.Any{
+				&expr.Payload{DestRegister: 1, Base: expr.PayloadBaseNetworkHeader, Offset: 8, Len: 16},
+				&expr.Cmp{Op: expr.CmpOpEq, Register: 1, Data: net.ParseIP("2001:beef::2").To16()},
+				&expr.Payload{DestRegister: 1, Base: expr.PayloadBaseNetworkHeader, Offset: 24, Len: 16},
+				&expr.Cmp{Op: expr.CmpOpEq, Register: 1, Data: net.ParseIP("2001:beef::2").To16()},
+				&expr.Masq{},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		r := &runner{family: tc.family}
+		exprs, err := r.ruleExpressions(&tc.rule)
+		require.NoError(t, err, tc.name)
+		assert.Equal(t, tc.expected, exprs, tc.name)
+	}
+}
+
+func TestListChains(t *testing.T) {
+	chain := func(seq uint32, table, name string) netlink.Message {
+		return netlink.Message{
+			Header: netlink.Header{
+				Type:     netlink.HeaderType(unix.NFNL_SUBSYS_NFTABLES<<8 | unix.NFT_MSG_NEWCHAIN),
+				Sequence: seq,
+			},
+			Data: append([]byte{unix.NFPROTO_IPV4, unix.NFNETLINK_V0, 0, 0}, nltest.MustMarshalAttributes([]netlink.Attribute{
+				{Type: unix.NFTA_CHAIN_TABLE, Data: []byte(table + "\x00")},
+				{Type: unix.NFTA_CHAIN_NAME, Data: []byte(name + "\x00")},
+			})...),
+		}
+	}
+	r := newTestRunner(FamilyIPv4, func(req []netlink.Message) ([]netlink.Message, error) {
+		if len(req) == 0 {
+			return nil, nil
+		}
+		seq := req[0].Header.Sequence
+		return []netlink.Message{chain(seq, "t", "a"), chain(seq, "other", "b"), chain(seq, "t", "c")}, nil
+	})
+
+	chains, err := r.ListChains("t")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "c"}, chains)
+
+	chains, err = r.ListChains("missing")
+	require.NoError(t, err)
+	assert.Empty(t, chains)
+}
+
+func TestPresent(t *testing.T) {
+	r := newTestRunner(FamilyIPv4, func([]netlink.Message) ([]netlink.Message, error) {
+		return nil, nil
+	})
+	assert.True(t, r.Present())
+
+	r = newTestRunner(FamilyIPv4, func([]netlink.Message) ([]netlink.Message, error) {
+		return nil, errors.New("protocol not supported")
+	})
+	assert.False(t, r.Present())
+}
+
+func hostNetwork(ip string) *net.IPNet {
+	addr := net.ParseIP(ip)
+	if addr.To4() != nil {
+		return &net.IPNet{IP: addr.To4(), Mask: net.CIDRMask(32, 32)}
+	}
+	return &net.IPNet{IP: addr, Mask: net.CIDRMask(128, 128)}
+}
@@ -0,0 +1,65 @@
+package nftables
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRuleValidate(t *testing.T) {
+	testCases := []struct {
+		name        string
+		rule        Rule
+		expectError bool
+	}{
+		{"jump", Rule{Chain: "c", Jump: "other"}, false},
+		{"masquerade", Rule{Chain: "c", Masquerade: true}, false},
+		{"dnat", Rule{Chain: "c", Protocol: "tcp", DNAT: &NAT{Address: net.ParseIP("10.0.0.1"), Port: 80}}, false},
+		{"missing chain", Rule{Masquerade: true}, true},
+		{"missing statement", Rule{Chain: "c"}, true},
+		{"multiple statements", Rule{Chain: "c", Jump: "other", Masquerade: true}, true},
+		{"unsupported protocol", Rule{Chain: "c", Protocol: "icmp", Masquerade: true}, true},
+		{"port without protocol", Rule{Chain: "c", DestinationPort: 80, Masquerade: true}, true},
+		{"dnat port without protocol", Rule{Chain: "c", DNAT: &NAT{Address: net.ParseIP("10.0.0.1"), Port: 80}}, true},
+		{"invalid dnat address", Rule{Chain: "c", DNAT: &NAT{}}, true},
+	}
+	for _, tc := range testCases {
+		err := tc.rule.Validate()
+		if tc.expectError {
+			assert.Error(t, err, tc.name)
+		} else {
+			assert.NoError(t, err, tc.name)
+		}
+	}
+}
+
+func TestTransactionString(t *testing.T) {
+	_, prefix, _ := net.ParseCIDR("127.0.0.0/8")
+	tx := NewTransaction(FamilyIPv4, "t")
+	tx.AddTable()
+	tx.AddChain(&Chain{Name: "pre", Hook: HookPrerouting, Priority: PriorityDestinationNAT})
+	tx.AddChain(&Chain{Name: "c"})
+	tx.FlushChain("c")
+	tx.AddRule(&Rule{Chain: "pre", DestinationLocal: true, Jump: "c"})
+	tx.AddRule(&Rule{Chain: "c", OutputInterface: "eth0", SourceAddress: prefix, Masquerade: true, Comment: "localhost"})
+	tx.AddRule(&Rule{
+		Chain:           "c",
+		Protocol:        "udp",
+		DestinationPort: 53,
+		DNAT:            &NAT{Address: net.ParseIP("fd00::1"), Port: 5353},
+	})
+	tx.DeleteChain("c")
+	tx.DeleteTable()
+
+	assert.Equal(t, `add table ip t
+add chain ip t pre { type nat hook prerouting priority -100 ; }
+add chain ip t c
+flush chain ip t c
+add rule ip t pre fib daddr type local jump c
+add rule ip t c oifname "eth0" ip saddr 127.0.0.0/8 masquerade comment "localhost"
+add rule ip t c meta l4proto udp th dport 53 dnat to [fd00::1]:5353
+delete chain ip t c
+delete table ip t
+`, tx.String())
+}
@@ -0,0 +1,34 @@
+//go:build !linux
+// +build !linux
+
+package nftables
+
+import (
+	"fmt"
+	"runtime"
+)
+
+type runner struct {
+	family Family
+}
+
+// New returns a new Interface, which is not supported on this platform.
+func New(family Family) Interface {
+	return &runner{family: family}
+}
+
+func (r *runner) Family() Family {
+	return r.family
+}
+
+func (r *runner) Present() bool {
+	return false
+}
+
+func (r *runner) ListChains(string) ([]string, error) {
+	return nil, fmt.Errorf("nftables not supported on %s", runtime.GOOS)
+}
+
+func (r *runner) Run(*Transaction) error {
+	return fmt.Errorf("nftables not supported on %s", runtime.GOOS)
+}
@@ -0,0 +1,209 @@
+package nri
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	nri "github.com/containerd/nri/pkg/adaptation"
+	"github.com/containerd/nri/pkg/api"
+	"github.com/containerd/nri/pkg/stub"
+	config "github.com/cri-o/cri-o/internal/config/nri"
+	"github.com/sirupsen/logrus"
+)
+
+// BuiltinPlugin is a plugin compiled into CRI-O. Instead of connecting to the
+// NRI socket, it gets the container events in-process. It subscribes to the
+// CreateContainer, UpdateContainer and StopContainer events by implementing
+// the matching interface of the NRI stub, for example
+// stub.CreateContainerInterface, which keeps it interchangeable with an
+// external plugin. A plugin implementing stub.ConfigureInterface gets its
+// configuration and can restrict the events it gets through the returned
+// event mask.
+type BuiltinPlugin any
+
+// BuiltinPluginFactory creates a new instance of a builtin plugin.
+type BuiltinPluginFactory func() BuiltinPlugin
+
+var builtinPluginFactories = struct {
+	sync.Mutex
+	factories map[string]BuiltinPluginFactory
+}{
+	factories: make(map[string]BuiltinPluginFactory),
+}
+
+// RegisterBuiltinPlugin makes the builtin plugin available under the name,
+// which enables it in the crio.nri.builtin_plugins configuration.
+func RegisterBuiltinPlugin(name string, factory BuiltinPluginFactory) {
+	builtinPluginFactories.Lock()
+	defer builtinPluginFactories.Unlock()
+
+	if _, ok := builtinPluginFactories.factories[name]; ok {
+		panic(fmt.Sprintf("builtin NRI plugin %q is already registered", name))
+	}
+	builtinPluginFactories.factories[name] = factory
+}
+
+// BuiltinPlugins returns the names of all registered builtin plugins.
+func BuiltinPlugins() []string {
+	builtinPluginFactories.Lock()
+	defer builtinPluginFactories.Unlock()
+
+	names := make([]string, 0, len(builtinPluginFactories.factories))
+	for name := range builtinPluginFactories.factories {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+type builtinPlugin struct {
+	name   string
+	index  int
+	events api.EventMask
+	plugin BuiltinPlugin
+}
+
+// builtinPlugins are the enabled builtin plugins, ordered by the position
+// relative to the external plugins and their index.
+type builtinPlugins struct {
+	before []*builtinPlugin
+	after  []*builtinPlugin
+}
+
+// newBuiltinPlugins creates and configures the enabled builtin plugins.
+func newBuiltinPlugins(ctx context.Context, cfg map[string]*config.BuiltinPlugin, runtimeName, runtimeVersion string) (*builtinPlugins, error) {
+	plugins := &builtinPlugins{}
+	for name, pluginCfg := range cfg {
+		builtinPluginFactories.Lock()
+		factory, ok := builtinPluginFactories.factories[name]
+		builtinPluginFactories.Unlock()
+		if !ok {
+			return nil, fmt.Errorf("unknown builtin NRI plugin %q, available are: %s",
+				name, strings.Join(BuiltinPlugins(), ", "))
+		}
+		p := &builtinPlugin{
+			name:   name,
+			index:  pluginCfg.Index,
+			events: api.ValidEvents,
+			plugin: factory(),
+		}
+		if configurer, ok := p.plugin.(stub.ConfigureInterface); ok {
+			events, err := configurer.Configure(ctx, pluginCfg.Config, runtimeName, runtimeVersion)
+			if err != nil {
+				return nil, fmt.Errorf("failed to configure builtin NRI plugin %q: %w", name, err)
+			}
+			if events != 0 {
+				p.events = events
+			}
+		}
+		if pluginCfg.Position == config.BuiltinPluginPositionAfter {
+			plugins.after = append(plugins.after, p)
+		} else {
+			plugins.before = append(plugins.before, p)
+		}
+		logrus.Infof("Enabled builtin NRI plugin %q", name)
+	}
+	for _, list := range [][]*builtinPlugin{plugins.before, plugins.after} {
+		slices.SortFunc(list, func(a, b *builtinPlugin) int {
+			if a.index != b.index {
+				return a.index - b.index
+			}
+			return strings.Compare(a.name, b.name)
+		})
+	}
+	return plugins, nil
+}
+
+func (p *builtinPlugin) subscribed(event api.Event) bool {
+	return p.events.IsSet(event)
+}
+
+// createContainerBuiltin relays the CreateContainer request to the plugins in
+// order and returns their adjustments, which have to be applied in the same
+// order. Every plugin gets the container with the adjustments of the plugins
+// before it, and must not adjust a setting already claimed in owners by
+// another plugin.
+func createContainerBuiltin(ctx context.Context, plugins []*builtinPlugin, pod *nri.PodSandbox, ctr *nri.Container, owners adjustmentOwners) ([]*PluginAdjustment, []*pluginUpdates, error) {
+	var (
+		adjusts []*PluginAdjustment
+		updates []*pluginUpdates
+	)
+	for _, p := range plugins {
+		handler, ok := p.plugin.(stub.CreateContainerInterface)
+		if !ok || !p.subscribed(api.Event_CREATE_CONTAINER) {
+			continue
+		}
+		adjust, update, err := handler.CreateContainer(ctx, pod, ctr)
+		if err != nil {
+			return nil, nil, fmt.Errorf("builtin NRI plugin %q failed to handle CreateContainer: %w", p.name, err)
+		}
+		if adjust != nil {
+			if err := owners.claimAdjustment(p.name, adjust); err != nil {
+				return nil, nil, fmt.Errorf("builtin NRI plugin %q failed to adjust container: %w", p.name, err)
+			}
+			adjustContainer(ctr, adjust)
+			adjusts = append(adjusts, &PluginAdjustment{Plugin: p.name, Adjust: adjust})
+		}
+		if len(update) > 0 {
+			updates = append(updates, &pluginUpdates{plugin: p.name, updates: update})
+		}
+	}
+	return adjusts, updates, nil
+}
+
+// updateContainerBuiltin relays the UpdateContainer request to the plugins in
+// order and returns the resources of the container updated by them. An update
+// of a plugin for the container itself supersedes the requested resources for
+// all later plugins.
+func updateContainerBuiltin(ctx context.Context, plugins []*builtinPlugin, pod *nri.PodSandbox, ctr *nri.Container, resources *nri.LinuxResources) ([]*PluginResources, []*pluginUpdates, error) {
+	var (
+		changes []*PluginResources
+		updates []*pluginUpdates
+	)
+	for _, p := range plugins {
+		handler, ok := p.plugin.(stub.UpdateContainerInterface)
+		if !ok || !p.subscribed(api.Event_UPDATE_CONTAINER) {
+			continue
+		}
+		update, err := handler.UpdateContainer(ctx, pod, ctr, resources)
+		if err != nil {
+			return nil, nil, fmt.Errorf("builtin NRI plugin %q failed to handle UpdateContainer: %w", p.name, err)
+		}
+		var others []*nri.ContainerUpdate
+		for _, u := range update {
+			if u.GetContainerId() == ctr.GetId() {
+				resources = u.GetLinux().GetResources()
+				changes = append(changes, &PluginResources{Plugin: p.name, Resources: resources})
+				continue
+			}
+			others = append(others, u)
+		}
+		if len(others) > 0 {
+			updates = append(updates, &pluginUpdates{plugin: p.name, updates: others})
+		}
+	}
+	return changes, updates, nil
+}
+
+// stopContainerBuiltin relays the StopContainer request to the plugins in
+// order.
+func stopContainerBuiltin(ctx context.Context, plugins []*builtinPlugin, pod *nri.PodSandbox, ctr *nri.Container) ([]*pluginUpdates, error) {
+	var updates []*pluginUpdates
+	for _, p := range plugins {
+		handler, ok := p.plugin.(stub.StopContainerInterface)
+		if !ok || !p.subscribed(api.Event_STOP_CONTAINER) {
+			continue
+		}
+		update, err := handler.StopContainer(ctx, pod, ctr)
+		if err != nil {
+			return nil, fmt.Errorf("builtin NRI plugin %q failed to handle StopContainer: %w", p.name, err)
+		}
+		if len(update) > 0 {
+			updates = append(updates, &pluginUpdates{plugin: p.name, updates: update})
+		}
+	}
+	return updates, nil
+}
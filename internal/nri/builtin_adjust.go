@@ -0,0 +1,310 @@
+package nri
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/containerd/nri/pkg/api"
+)
+
+// adjustmentOwners records which plugin adjusted a setting of a container.
+// NRI rejects conflicting adjustments of external plugins, the owners do the
+// same between the builtin plugins and the merged external ones.
+type adjustmentOwners map[string]string
+
+func (o adjustmentOwners) claim(plugin string, subject ...string) error {
+	key := strings.Join(subject, " ")
+	if other, ok := o[key]; ok && other != plugin {
+		return fmt.Errorf("plugins %q and %q both tried to set %s", plugin, other, key)
+	}
+	o[key] = plugin
+	return nil
+}
+
+func (o adjustmentOwners) clear(subject ...string) {
+	delete(o, strings.Join(subject, " "))
+}
+
+// claimAdjustment claims the settings of the adjustment for the plugin.
+// Removing a setting releases it, so that another plugin can set it again.
+func (o adjustmentOwners) claimAdjustment(plugin string, adjust *api.ContainerAdjustment) error {
+	if adjust == nil {
+		return nil
+	}
+	annotations := make([]string, 0, len(adjust.GetAnnotations()))
+	for key := range adjust.GetAnnotations() {
+		annotations = append(annotations, key)
+	}
+	if err := o.claimKeys(plugin, "annotation", annotations); err != nil {
+		return err
+	}
+	mounts := make([]string, 0, len(adjust.GetMounts()))
+	for _, m := range adjust.GetMounts() {
+		mounts = append(mounts, m.GetDestination())
+	}
+	if err := o.claimKeys(plugin, "mount", mounts); err != nil {
+		return err
+	}
+	env := make([]string, 0, len(adjust.GetEnv()))
+	for _, e := range adjust.GetEnv() {
+		env = append(env, e.GetKey())
+	}
+	if err := o.claimKeys(plugin, "environment variable", env); err != nil {
+		return err
+	}
+	devices := make([]string, 0, len(adjust.GetLinux().GetDevices()))
+	for _, d := range adjust.GetLinux().GetDevices() {
+		devices = append(devices, d.GetPath())
+	}
+	if err := o.claimKeys(plugin, "device", devices); err != nil {
+		return err
+	}
+	if err := o.claimResources(plugin, adjust.GetLinux().GetResources()); err != nil {
+		return err
+	}
+	if adjust.GetLinux().GetCgroupsPath() != "" {
+		if err := o.claim(plugin, "cgroups path"); err != nil {
+			return err
+		}
+	}
+	for _, l := range adjust.GetRlimits() {
+		if err := o.claim(plugin, "rlimit", l.GetType()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// claimKeys claims the keys of the subject, of which the ones marked for
+// removal are released first, like NRI removes them before adding the others.
+func (o adjustmentOwners) claimKeys(plugin, subject string, keys []string) error {
+	for _, key := range keys {
+		if name, removed := api.IsMarkedForRemoval(key); removed {
+			o.clear(subject, name)
+		}
+	}
+	for _, key := range keys {
+		if _, removed := api.IsMarkedForRemoval(key); removed {
+			continue
+		}
+		if err := o.claim(plugin, subject, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o adjustmentOwners) claimResources(plugin string, resources *api.LinuxResources) error {
+	if resources == nil {
+		return nil
+	}
+	mem, cpu := resources.GetMemory(), resources.GetCpu()
+	settings := []struct {
+		set     bool
+		subject string
+	}{
+		{mem.GetLimit() != nil, "memory limit"},
+		{mem.GetReservation() != nil, "memory reservation"},
+		{mem.GetSwap() != nil, "memory swap limit"},
+		{mem.GetKernel() != nil, "memory kernel limit"},
+		{mem.GetKernelTcp() != nil, "memory TCP limit"},
+		{mem.GetSwappiness() != nil, "memory swappiness"},
+		{mem.GetDisableOomKiller() != nil, "memory disable OOM killer"},
+		{mem.GetUseHierarchy() != nil, "memory use hierarchy"},
+		{cpu.GetShares() != nil, "CPU shares"},
+		{cpu.GetQuota() != nil, "CPU quota"},
+		{cpu.GetPeriod() != nil, "CPU period"},
+		{cpu.GetRealtimeRuntime() != nil, "CPU realtime runtime"},
+		{cpu.GetRealtimePeriod() != nil, "CPU realtime period"},
+		{cpu.GetCpus() != "", "cpuset CPUs"},
+		{cpu.GetMems() != "", "cpuset memory nodes"},
+		{resources.GetBlockioClass() != nil, "block I/O class"},
+		{resources.GetRdtClass() != nil, "RDT class"},
+	}
+	for _, s := range settings {
+		if !s.set {
+			continue
+		}
+		if err := o.claim(plugin, s.subject); err != nil {
+			return err
+		}
+	}
+	for _, l := range resources.GetHugepageLimits() {
+		if err := o.claim(plugin, "hugepage limit of size", l.GetPageSize()); err != nil {
+			return err
+		}
+	}
+	for key := range resources.GetUnified() {
+		if err := o.claim(plugin, "unified resource", key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// adjustContainer applies the adjustment to the container the same way NRI
+// does between its plugins, so that later plugins get the adjusted container.
+func adjustContainer(ctr *api.Container, adjust *api.ContainerAdjustment) {
+	if adjust == nil {
+		return
+	}
+	for key := range adjust.GetAnnotations() {
+		if name, removed := api.IsMarkedForRemoval(key); removed {
+			delete(ctr.Annotations, name)
+		}
+	}
+	for key, value := range adjust.GetAnnotations() {
+		if _, removed := api.IsMarkedForRemoval(key); removed {
+			continue
+		}
+		if ctr.Annotations == nil {
+			ctr.Annotations = make(map[string]string)
+		}
+		ctr.Annotations[key] = value
+	}
+
+	for _, m := range adjust.GetMounts() {
+		destination, removed := m.IsMarkedForRemoval()
+		mounts := make([]*api.Mount, 0, len(ctr.Mounts)+1)
+		for _, existing := range ctr.Mounts {
+			if existing.GetDestination() != destination {
+				mounts = append(mounts, existing)
+			}
+		}
+		if !removed {
+			mounts = append(mounts, m)
+		}
+		ctr.Mounts = mounts
+	}
+
+	for _, e := range adjust.GetEnv() {
+		name, removed := e.IsMarkedForRemoval()
+		env := make([]string, 0, len(ctr.Env)+1)
+		for _, existing := range ctr.Env {
+			if key, _, _ := strings.Cut(existing, "="); key != name {
+				env = append(env, existing)
+			}
+		}
+		if !removed {
+			env = append(env, e.ToOCI())
+		}
+		ctr.Env = env
+	}
+
+	if hooks := adjust.GetHooks(); hooks != nil {
+		if ctr.Hooks == nil {
+			ctr.Hooks = &api.Hooks{}
+		}
+		ctr.Hooks.Append(hooks)
+	}
+
+	for _, l := range adjust.GetRlimits() {
+		ctr.Rlimits = append(ctr.Rlimits, l)
+	}
+
+	linux := adjust.GetLinux()
+	if linux == nil {
+		return
+	}
+	if ctr.Linux == nil {
+		ctr.Linux = &api.LinuxContainer{}
+	}
+	for _, d := range linux.GetDevices() {
+		path, removed := d.IsMarkedForRemoval()
+		devices := make([]*api.LinuxDevice, 0, len(ctr.Linux.Devices)+1)
+		for _, existing := range ctr.Linux.Devices {
+			if existing.GetPath() != path {
+				devices = append(devices, existing)
+			}
+		}
+		if !removed {
+			devices = append(devices, d)
+		}
+		ctr.Linux.Devices = devices
+	}
+	if linux.GetCgroupsPath() != "" {
+		ctr.Linux.CgroupsPath = linux.GetCgroupsPath()
+	}
+	adjustResources(ctr.Linux, linux.GetResources())
+}
+
+func adjustResources(linux *api.LinuxContainer, resources *api.LinuxResources) {
+	if resources == nil {
+		return
+	}
+	if linux.Resources == nil {
+		linux.Resources = &api.LinuxResources{}
+	}
+	res := linux.Resources
+
+	if mem := resources.GetMemory(); mem != nil {
+		if res.Memory == nil {
+			res.Memory = &api.LinuxMemory{}
+		}
+		if v := mem.GetLimit(); v != nil {
+			res.Memory.Limit = api.Int64(v.GetValue())
+		}
+		if v := mem.GetReservation(); v != nil {
+			res.Memory.Reservation = api.Int64(v.GetValue())
+		}
+		if v := mem.GetSwap(); v != nil {
+			res.Memory.Swap = api.Int64(v.GetValue())
+		}
+		if v := mem.GetKernel(); v != nil {
+			res.Memory.Kernel = api.Int64(v.GetValue())
+		}
+		if v := mem.GetKernelTcp(); v != nil {
+			res.Memory.KernelTcp = api.Int64(v.GetValue())
+		}
+		if v := mem.GetSwappiness(); v != nil {
+			res.Memory.Swappiness = api.UInt64(v.GetValue())
+		}
+		if v := mem.GetDisableOomKiller(); v != nil {
+			res.Memory.DisableOomKiller = api.Bool(v.GetValue())
+		}
+		if v := mem.GetUseHierarchy(); v != nil {
+			res.Memory.UseHierarchy = api.Bool(v.GetValue())
+		}
+	}
+
+	if cpu := resources.GetCpu(); cpu != nil {
+		if res.Cpu == nil {
+			res.Cpu = &api.LinuxCPU{}
+		}
+		if v := cpu.GetShares(); v != nil {
+			res.Cpu.Shares = api.UInt64(v.GetValue())
+		}
+		if v := cpu.GetQuota(); v != nil {
+			res.Cpu.Quota = api.Int64(v.GetValue())
+		}
+		if v := cpu.GetPeriod(); v != nil {
+			res.Cpu.Period = api.UInt64(v.GetValue())
+		}
+		if v := cpu.GetRealtimeRuntime(); v != nil {
+			res.Cpu.RealtimeRuntime = api.Int64(v.GetValue())
+		}
+		if v := cpu.GetRealtimePeriod(); v != nil {
+			res.Cpu.RealtimePeriod = api.UInt64(v.GetValue())
+		}
+		if v := cpu.GetCpus(); v != "" {
+			res.Cpu.Cpus = v
+		}
+		if v := cpu.GetMems(); v != "" {
+			res.Cpu.Mems = v
+		}
+	}
+
+	res.HugepageLimits = append(res.HugepageLimits, resources.GetHugepageLimits()...)
+	for key, value := range resources.GetUnified() {
+		if res.Unified == nil {
+			res.Unified = make(map[string]string)
+		}
+		res.Unified[key] = value
+	}
+	if v := resources.GetBlockioClass(); v != nil {
+		res.BlockioClass = api.String(v.GetValue())
+	}
+	if v := resources.GetRdtClass(); v != nil {
+		res.RdtClass = api.String(v.GetValue())
+	}
+}
@@ -0,0 +1,252 @@
+package nri
+
+import (
+	"context"
+	"slices"
+
+	"github.com/containerd/nri/pkg/api"
+	config "github.com/cri-o/cri-o/internal/config/nri"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// annotatingPlugin sets the annotation configured as its configuration and
+// records the annotations of the containers it gets in seenAnnotations.
+type annotatingPlugin struct {
+	annotation string
+}
+
+var seenAnnotations []map[string]string
+
+func (p *annotatingPlugin) Configure(_ context.Context, config, _, _ string) (api.EventMask, error) {
+	p.annotation = config
+	return 0, nil
+}
+
+func (p *annotatingPlugin) CreateContainer(_ context.Context, _ *api.PodSandbox, ctr *api.Container) (*api.ContainerAdjustment, []*api.ContainerUpdate, error) {
+	seenAnnotations = append(seenAnnotations, api.DupStringMap(ctr.GetAnnotations()))
+	adjust := &api.ContainerAdjustment{}
+	adjust.AddAnnotation(p.annotation, "true")
+	return adjust, nil, nil
+}
+
+func init() {
+	for _, name := range []string{"test-a", "test-b", "test-c"} {
+		RegisterBuiltinPlugin(name, func() BuiltinPlugin {
+			return &annotatingPlugin{}
+		})
+	}
+}
+
+var _ = Describe("BuiltinPlugins", func() {
+	BeforeEach(func() {
+		seenAnnotations = nil
+	})
+
+	names := func(plugins []*builtinPlugin) []string {
+		res := []string{}
+		for _, p := range plugins {
+			res = append(res, p.name)
+		}
+		return res
+	}
+
+	Describe("registry", func() {
+		It("should list the registered plugins sorted by name", func() {
+			// Given
+			// When
+			plugins := BuiltinPlugins()
+
+			// Then
+			Expect(plugins).To(ContainElements(DefaultUlimitsPlugin, "test-a", "test-b", "test-c"))
+			Expect(slices.IsSorted(plugins)).To(BeTrue())
+		})
+
+		It("should panic on duplicate registration", func() {
+			// Given
+			// When
+			register := func() {
+				RegisterBuiltinPlugin("test-a", func() BuiltinPlugin { return nil })
+			}
+
+			// Then
+			Expect(register).To(PanicWith(ContainSubstring(`"test-a" is already registered`)))
+		})
+
+		It("should fail to enable an unknown plugin", func() {
+			// Given
+			cfg := map[string]*config.BuiltinPlugin{"unknown": {}}
+
+			// When
+			_, err := newBuiltinPlugins(context.Background(), cfg, "cri-o", "1.0")
+
+			// Then
+			Expect(err).To(MatchError(ContainSubstring(`unknown builtin NRI plugin "unknown"`)))
+		})
+	})
+
+	Describe("ordering", func() {
+		It("should order the plugins by position, index and name", func() {
+			// Given
+			cfg := map[string]*config.BuiltinPlugin{
+				"test-a": {Index: 2, Position: config.BuiltinPluginPositionBefore},
+				"test-b": {Index: 1, Position: config.BuiltinPluginPositionAfter},
+				"test-c": {Index: 2, Position: config.BuiltinPluginPositionBefore},
+				DefaultUlimitsPlugin: {
+					Index:    1,
+					Position: config.BuiltinPluginPositionBefore,
+					Config:   "nofile=1024:2048",
+				},
+			}
+
+			// When
+			plugins, err := newBuiltinPlugins(context.Background(), cfg, "cri-o", "1.0")
+
+			// Then
+			Expect(err).ToNot(HaveOccurred())
+			Expect(names(plugins.before)).To(Equal([]string{DefaultUlimitsPlugin, "test-a", "test-c"}))
+			Expect(names(plugins.after)).To(Equal([]string{"test-b"}))
+		})
+	})
+
+	Describe("createContainerBuiltin", func() {
+		newPlugins := func(annotations ...string) []*builtinPlugin {
+			plugins := []*builtinPlugin{}
+			for i, annotation := range annotations {
+				plugins = append(plugins, &builtinPlugin{
+					name:   []string{"test-a", "test-b", "test-c"}[i],
+					events: api.ValidEvents,
+					plugin: &annotatingPlugin{annotation: annotation},
+				})
+			}
+			return plugins
+		}
+
+		It("should pass the adjustments to the later plugins", func() {
+			// Given
+			ctr := &api.Container{Id: "id", Annotations: map[string]string{"initial": "true"}}
+
+			// When
+			adjusts, _, err := createContainerBuiltin(context.Background(), newPlugins("first", "second"),
+				&api.PodSandbox{}, ctr, adjustmentOwners{})
+
+			// Then
+			Expect(err).ToNot(HaveOccurred())
+			Expect(adjusts).To(HaveLen(2))
+			Expect(adjusts[0].Plugin).To(Equal("test-a"))
+			Expect(adjusts[1].Plugin).To(Equal("test-b"))
+			Expect(seenAnnotations).To(Equal([]map[string]string{
+				{"initial": "true"},
+				{"initial": "true", "first": "true"},
+			}))
+			Expect(ctr.Annotations).To(HaveKey("second"))
+		})
+
+		It("should fail if two plugins adjust the same setting", func() {
+			// Given
+			ctr := &api.Container{Id: "id"}
+
+			// When
+			_, _, err := createContainerBuiltin(context.Background(), newPlugins("same", "same"),
+				&api.PodSandbox{}, ctr, adjustmentOwners{})
+
+			// Then
+			Expect(err).To(MatchError(ContainSubstring(`plugins "test-b" and "test-a" both tried to set annotation same`)))
+		})
+
+		It("should fail if a plugin adjusts a setting of the external plugins", func() {
+			// Given
+			owners := adjustmentOwners{}
+			external := &api.ContainerAdjustment{}
+			external.AddAnnotation("external", "true")
+			Expect(owners.claimAdjustment(ExternalPlugins, external)).To(Succeed())
+
+			// When
+			_, _, err := createContainerBuiltin(context.Background(), newPlugins("external"),
+				&api.PodSandbox{}, &api.Container{Id: "id"}, owners)
+
+			// Then
+			Expect(err).To(MatchError(ContainSubstring(`plugins "test-a" and "external" both tried to set annotation external`)))
+		})
+	})
+
+	Describe("adjustmentOwners", func() {
+		It("should fail if the external plugins adjust a setting of a builtin plugin", func() {
+			// Given
+			owners := adjustmentOwners{}
+			builtin := &api.ContainerAdjustment{}
+			builtin.AddRlimit("RLIMIT_NOFILE", 2048, 1024)
+			builtin.SetLinuxMemoryLimit(1024)
+			Expect(owners.claimAdjustment(DefaultUlimitsPlugin, builtin)).To(Succeed())
+
+			external := &api.ContainerAdjustment{}
+			external.AddRlimit("RLIMIT_NOFILE", 4096, 4096)
+
+			// When
+			err := owners.claimAdjustment(ExternalPlugins, external)
+
+			// Then
+			Expect(err).To(MatchError(ContainSubstring("both tried to set rlimit RLIMIT_NOFILE")))
+		})
+
+		It("should allow to set a setting again after its removal", func() {
+			// Given
+			owners := adjustmentOwners{}
+			builtin := &api.ContainerAdjustment{}
+			builtin.AddEnv("FOO", "builtin")
+			builtin.AddMount(&api.Mount{Destination: "/data", Source: "/builtin"})
+			Expect(owners.claimAdjustment("builtin", builtin)).To(Succeed())
+
+			external := &api.ContainerAdjustment{}
+			external.RemoveEnv("FOO")
+			external.AddEnv("FOO", "external")
+			external.RemoveMount("/data")
+
+			// When
+			err := owners.claimAdjustment(ExternalPlugins, external)
+
+			// Then
+			Expect(err).ToNot(HaveOccurred())
+			Expect(owners).To(HaveKeyWithValue("environment variable FOO", ExternalPlugins))
+			Expect(owners).ToNot(HaveKey("mount /data"))
+		})
+	})
+
+	Describe("adjustContainer", func() {
+		It("should apply the adjustment to the container", func() {
+			// Given
+			ctr := &api.Container{
+				Annotations: map[string]string{"keep": "true", "remove": "true"},
+				Env:         []string{"KEEP=1", "REPLACE=1", "REMOVE=1"},
+				Mounts: []*api.Mount{
+					{Destination: "/keep", Source: "/keep"},
+					{Destination: "/replace", Source: "/old"},
+				},
+			}
+			adjust := &api.ContainerAdjustment{}
+			adjust.RemoveAnnotation("remove")
+			adjust.AddAnnotation("add", "true")
+			adjust.AddEnv("REPLACE", "2")
+			adjust.RemoveEnv("REMOVE")
+			adjust.AddMount(&api.Mount{Destination: "/replace", Source: "/new"})
+			adjust.AddRlimit("RLIMIT_NOFILE", 2048, 1024)
+			adjust.SetLinuxMemoryLimit(1024)
+			adjust.SetLinuxCPUSetCPUs("0-1")
+			adjust.SetLinuxCgroupsPath("/custom")
+
+			// When
+			adjustContainer(ctr, adjust)
+
+			// Then
+			Expect(ctr.Annotations).To(Equal(map[string]string{"keep": "true", "add": "true"}))
+			Expect(ctr.Env).To(Equal([]string{"KEEP=1", "REPLACE=2"}))
+			Expect(ctr.Mounts).To(HaveLen(2))
+			Expect(ctr.Mounts[0].Destination).To(Equal("/keep"))
+			Expect(ctr.Mounts[1].Source).To(Equal("/new"))
+			Expect(ctr.Rlimits).To(HaveLen(1))
+			Expect(ctr.Linux.Resources.Memory.Limit.GetValue()).To(BeEquivalentTo(1024))
+			Expect(ctr.Linux.Resources.Cpu.Cpus).To(Equal("0-1"))
+			Expect(ctr.Linux.CgroupsPath).To(Equal("/custom"))
+		})
+	})
+})
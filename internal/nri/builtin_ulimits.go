@@ -0,0 +1,47 @@
+package nri
+
+import (
+	"context"
+	"strings"
+	"unicode"
+
+	"github.com/containerd/nri/pkg/api"
+	"github.com/cri-o/cri-o/internal/config/ulimits"
+)
+
+// DefaultUlimitsPlugin is the name of the builtin plugin setting the default
+// ulimits of all containers.
+const DefaultUlimitsPlugin = "default_ulimits"
+
+func init() {
+	RegisterBuiltinPlugin(DefaultUlimitsPlugin, func() BuiltinPlugin {
+		return &defaultUlimits{}
+	})
+}
+
+// defaultUlimits implements the default_ulimits option of the crio.runtime
+// table as builtin plugin. Its configuration is a list of ulimits in the same
+// "name=soft:hard" format, separated by commas or white space.
+type defaultUlimits struct {
+	ulimits *ulimits.Config
+}
+
+func (p *defaultUlimits) Configure(_ context.Context, config, _, _ string) (api.EventMask, error) {
+	p.ulimits = ulimits.New()
+	if err := p.ulimits.LoadUlimits(strings.FieldsFunc(config, func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	})); err != nil {
+		return 0, err
+	}
+
+	var events api.EventMask
+	return *events.Set(api.Event_CREATE_CONTAINER), nil
+}
+
+func (p *defaultUlimits) CreateContainer(_ context.Context, _ *api.PodSandbox, _ *api.Container) (*api.ContainerAdjustment, []*api.ContainerUpdate, error) {
+	adjust := &api.ContainerAdjustment{}
+	for _, u := range p.ulimits.Ulimits() {
+		adjust.AddRlimit(u.Name, u.Hard, u.Soft)
+	}
+	return adjust, nil, nil
+}
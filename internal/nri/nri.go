@@ -2,6 +2,7 @@ package nri
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 
@@ -11,6 +12,7 @@ import (
 
 	nri "github.com/containerd/nri/pkg/adaptation"
 	"github.com/cri-o/cri-o/internal/version"
+	crioTypes "github.com/cri-o/cri-o/pkg/types"
 )
 
 // API provides an API for interfacing NRI from the rest of cri-o. It is
@@ -45,8 +47,9 @@ type API interface {
 	// RemovePodSandbox relays pod removal events to NRI.
 	RemovePodSandbox(context.Context, PodSandbox) error
 
-	// CreateContainer relays container creation requests to NRI.
-	CreateContainer(context.Context, PodSandbox, Container) (*nri.ContainerAdjustment, error)
+	// CreateContainer relays container creation requests to NRI. The
+	// returned adjustments have to be applied in order.
+	CreateContainer(context.Context, PodSandbox, Container) ([]*PluginAdjustment, error)
 
 	// PostCreateContainer relays successful container creation events to NRI.
 	PostCreateContainer(context.Context, PodSandbox, Container) error
@@ -57,8 +60,10 @@ type API interface {
 	// PostStartContainer relays successful container startup events to NRI.
 	PostStartContainer(context.Context, PodSandbox, Container) error
 
-	// UpdateContainer relays container update requests to NRI.
-	UpdateContainer(context.Context, PodSandbox, Container, *nri.LinuxResources) (*nri.LinuxResources, error)
+	// UpdateContainer relays container update requests to NRI. It returns
+	// the resources updated by the plugins in order, the last one being
+	// effective, or none if the requested resources are unchanged.
+	UpdateContainer(context.Context, PodSandbox, Container, *nri.LinuxResources) ([]*PluginResources, error)
 
 	// PostUpdateContainer relays successful container update events to NRI.
 	PostUpdateContainer(context.Context, PodSandbox, Container) error
@@ -70,6 +75,39 @@ type API interface {
 	RemoveContainer(context.Context, PodSandbox, Container) error
 }
 
+// ExternalPlugins is the plugin name used for the adjustments and updates of
+// the external plugins, which NRI merges without recording their origin.
+const ExternalPlugins = crioTypes.NRIAuditExternalPlugins
+
+// PluginAdjustment is the adjustment of a container by a plugin.
+type PluginAdjustment struct {
+	Plugin string
+	Adjust *nri.ContainerAdjustment
+}
+
+// PluginResources are the resources of a container updated by a plugin.
+type PluginResources struct {
+	Plugin    string
+	Resources *nri.LinuxResources
+}
+
+// pluginUpdates are the updates of other containers requested by a plugin.
+type pluginUpdates struct {
+	plugin  string
+	updates []*nri.ContainerUpdate
+}
+
+type pluginKey struct{}
+
+// PluginFromContext returns the name of the plugin requesting a container
+// update or eviction from the Domain.
+func PluginFromContext(ctx context.Context) string {
+	if plugin, ok := ctx.Value(pluginKey{}).(string); ok {
+		return plugin
+	}
+	return ExternalPlugins
+}
+
 type State int
 
 const (
@@ -81,8 +119,9 @@ const (
 
 type local struct {
 	sync.Mutex
-	cfg *config.Config
-	nri *nri.Adaptation
+	cfg     *config.Config
+	nri     *nri.Adaptation
+	builtin *builtinPlugins
 
 	state map[string]State
 }
@@ -122,6 +161,11 @@ func New(cfg *config.Config) (*local, error) {
 		return nil, fmt.Errorf("failed to initialize NRI interface: %w", err)
 	}
 
+	l.builtin, err = newBuiltinPlugins(context.Background(), cfg.BuiltinPlugins, runtimeName, runtimeVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize builtin NRI plugins: %w", err)
+	}
+
 	l.state = make(map[string]State)
 
 	return l, nil
@@ -202,7 +246,7 @@ func (l *local) RemovePodSandbox(ctx context.Context, pod PodSandbox) error {
 	return err
 }
 
-func (l *local) CreateContainer(ctx context.Context, pod PodSandbox, ctr Container) (*nri.ContainerAdjustment, error) {
+func (l *local) CreateContainer(ctx context.Context, pod PodSandbox, ctr Container) ([]*PluginAdjustment, error) {
 	if !l.IsEnabled() {
 		return nil, nil
 	}
@@ -215,17 +259,37 @@ func (l *local) CreateContainer(ctx context.Context, pod PodSandbox, ctr Contain
 		Container: containerToNRI(ctr),
 	}
 
+	owners := adjustmentOwners{}
+	adjusts, updates, err := createContainerBuiltin(ctx, l.builtin.before, request.Pod, request.Container, owners)
+	if err != nil {
+		return nil, err
+	}
+
+	// NRI applies the adjustments of the external plugins to the request,
+	// which the builtin plugins after them get.
 	response, err := l.nri.CreateContainer(ctx, request)
 	l.setState(request.Container.Id, Created)
 	if err != nil {
 		return nil, err
 	}
+	if err := owners.claimAdjustment(ExternalPlugins, response.Adjust); err != nil {
+		return nil, fmt.Errorf("external NRI plugins failed to adjust container: %w", err)
+	}
+	adjusts = append(adjusts, &PluginAdjustment{Plugin: ExternalPlugins, Adjust: response.Adjust})
+	updates = append(updates, &pluginUpdates{plugin: ExternalPlugins, updates: response.Update})
 
-	if _, err := l.applyUpdates(ctx, response.Update); err != nil {
+	afterAdjusts, afterUpdates, err := createContainerBuiltin(ctx, l.builtin.after, request.Pod, request.Container, owners)
+	if err != nil {
 		return nil, err
 	}
+	adjusts = append(adjusts, afterAdjusts...)
+	updates = append(updates, afterUpdates...)
 
-	return response.Adjust, nil
+	if _, err := l.applyUpdates(ctx, updates); err != nil {
+		return nil, err
+	}
+
+	return adjusts, nil
 }
 
 func (l *local) PostCreateContainer(ctx context.Context, pod PodSandbox, ctr Container) error {
@@ -284,7 +348,7 @@ func (l *local) PostStartContainer(ctx context.Context, pod PodSandbox, ctr Cont
 	return err
 }
 
-func (l *local) UpdateContainer(ctx context.Context, pod PodSandbox, ctr Container, req *nri.LinuxResources) (*nri.LinuxResources, error) {
+func (l *local) UpdateContainer(ctx context.Context, pod PodSandbox, ctr Container, req *nri.LinuxResources) ([]*PluginResources, error) {
 	if !l.IsEnabled() {
 		return nil, nil
 	}
@@ -298,6 +362,14 @@ func (l *local) UpdateContainer(ctx context.Context, pod PodSandbox, ctr Contain
 		LinuxResources: req,
 	}
 
+	changes, updates, err := updateContainerBuiltin(ctx, l.builtin.before, request.Pod, request.Container, req)
+	if err != nil {
+		return nil, err
+	}
+	if cnt := len(changes); cnt > 0 {
+		request.LinuxResources = changes[cnt-1].Resources
+	}
+
 	response, err := l.nri.UpdateContainer(ctx, request)
 	if err != nil {
 		return nil, err
@@ -308,19 +380,25 @@ func (l *local) UpdateContainer(ctx context.Context, pod PodSandbox, ctr Contain
 		return nil, err
 	}
 
-	cnt := len(response.Update)
-	if cnt == 0 {
-		return nil, nil
+	resources := request.LinuxResources
+	if cnt := len(response.Update); cnt > 0 {
+		updates = append(updates, &pluginUpdates{plugin: ExternalPlugins, updates: response.Update[0 : cnt-1]})
+		resources = response.Update[cnt-1].GetLinux().GetResources()
+		changes = append(changes, &PluginResources{Plugin: ExternalPlugins, Resources: resources})
 	}
 
-	if cnt > 1 {
-		_, err = l.applyUpdates(ctx, response.Update[0:cnt-1])
-		if err != nil {
-			return nil, err
-		}
+	afterChanges, afterUpdates, err := updateContainerBuiltin(ctx, l.builtin.after, request.Pod, request.Container, resources)
+	if err != nil {
+		return nil, err
 	}
+	changes = append(changes, afterChanges...)
+	updates = append(updates, afterUpdates...)
 
-	return response.Update[cnt-1].GetLinux().GetResources(), nil
+	if _, err := l.applyUpdates(ctx, updates); err != nil {
+		return nil, err
+	}
+
+	return changes, nil
 }
 
 func (l *local) PostUpdateContainer(ctx context.Context, pod PodSandbox, ctr Container) error {
@@ -362,13 +440,25 @@ func (l *local) stopContainer(ctx context.Context, pod PodSandbox, ctr Container
 		Container: containerToNRI(ctr),
 	}
 
+	updates, err := stopContainerBuiltin(ctx, l.builtin.before, request.Pod, request.Container)
+	if err != nil {
+		log.Warnf(ctx, "Builtin NRI plugins failed to stop container %s: %v", request.Container.Id, err)
+	}
+
 	response, err := l.nri.StopContainer(ctx, request)
 	l.setState(request.Container.Id, Stopped)
 	if err != nil {
 		return err
 	}
+	updates = append(updates, &pluginUpdates{plugin: ExternalPlugins, updates: response.Update})
 
-	_, err = l.applyUpdates(ctx, response.Update)
+	afterUpdates, err := stopContainerBuiltin(ctx, l.builtin.after, request.Pod, request.Container)
+	if err != nil {
+		log.Warnf(ctx, "Builtin NRI plugins failed to stop container %s: %v", request.Container.Id, err)
+	}
+	updates = append(updates, afterUpdates...)
+
+	_, err = l.applyUpdates(ctx, updates)
 
 	return err
 }
@@ -431,7 +521,7 @@ func (l *local) syncPlugin(ctx context.Context, syncFn nri.SyncCB) error {
 		return err
 	}
 
-	_, err = l.applyUpdates(ctx, updates)
+	_, err = l.applyUpdates(ctx, []*pluginUpdates{{plugin: ExternalPlugins, updates: updates}})
 	if err != nil {
 		return err
 	}
@@ -445,13 +535,26 @@ func (l *local) updateFromPlugin(ctx context.Context, req []*nri.ContainerUpdate
 
 	log.Infof(ctx, "Unsolicited container update from NRI")
 
-	failed, err := l.applyUpdates(ctx, req)
+	failed, err := l.applyUpdates(ctx, []*pluginUpdates{{plugin: ExternalPlugins, updates: req}})
 	return failed, err
 }
 
-func (l *local) applyUpdates(ctx context.Context, updates []*nri.ContainerUpdate) ([]*nri.ContainerUpdate, error) {
-	failed, err := domains.updateContainers(ctx, updates)
-	return failed, err
+func (l *local) applyUpdates(ctx context.Context, updates []*pluginUpdates) ([]*nri.ContainerUpdate, error) {
+	var (
+		failed []*nri.ContainerUpdate
+		errs   []error
+	)
+	for _, u := range updates {
+		if len(u.updates) == 0 {
+			continue
+		}
+		f, err := domains.updateContainers(context.WithValue(ctx, pluginKey{}, u.plugin), u.updates)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		failed = append(failed, f...)
+	}
+	return failed, errors.Join(errs...)
 }
 
 func (l *local) evictContainers(ctx context.Context, evict []*nri.ContainerEviction) ([]*nri.ContainerEviction, error) {
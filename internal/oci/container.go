@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -18,6 +19,7 @@ import (
 	"github.com/cri-o/cri-o/internal/storage"
 	"github.com/cri-o/cri-o/internal/storage/references"
 	ann "github.com/cri-o/cri-o/pkg/annotations"
+	crioTypes "github.com/cri-o/cri-o/pkg/types"
 	json "github.com/json-iterator/go"
 	specs "github.com/opencontainers/runtime-spec/specs-go"
 	"github.com/sirupsen/logrus"
@@ -30,6 +32,9 @@ import (
 
 const defaultStopSignalInt = 15
 
+// maxNRIAuditEntries is the number of NRI audit entries kept per container.
+const maxNRIAuditEntries = 64
+
 var (
 	ErrContainerStopped = errors.New("container is already stopped")
 	ErrNotFound         = errors.New("container process not found")
@@ -86,7 +91,9 @@ func (c *Container) CRIAttributes() *types.ContainerAttributes {
 	}
 }
 
-// ContainerVolume is a bind mount for the container.
+// ContainerVolume is a bind mount for the container. Image volumes mount the
+// image or artifact referenced by Image, where ImageID is the ID of the mounted
+// storage image and empty for artifacts.
 type ContainerVolume struct {
 	ContainerPath     string                 `json:"container_path"`
 	HostPath          string                 `json:"host_path"`
@@ -94,6 +101,8 @@ type ContainerVolume struct {
 	RecursiveReadOnly bool                   `json:"recursive_read_only"`
 	Propagation       types.MountPropagation `json:"propagation"`
 	SelinuxRelabel    bool                   `json:"selinux_relabel"`
+	Image             *types.ImageSpec       `json:"image,omitempty"`
+	ImageID           string                 `json:"image_id,omitempty"`
 }
 
 // ContainerState represents the status of a container.
@@ -113,6 +122,8 @@ type ContainerState struct {
 	InitStartTime string `json:"initStartTime,omitempty"`
 	// Checkpoint/Restore related states
 	CheckpointedAt time.Time `json:"checkpointedTime,omitempty"`
+	// The changes of NRI plugins to the container, oldest first.
+	NRIAudit []crioTypes.NRIAuditEntry `json:"nriAudit,omitempty"`
 }
 
 // NewContainer creates a container object.
@@ -330,6 +341,24 @@ func (c *Container) SetCheckpointedAt(checkpointedAt time.Time) {
 	c.state.CheckpointedAt = checkpointedAt
 }
 
+// AddNRIAudit records the changes of an NRI plugin to the container. Only the
+// latest entries are kept.
+func (c *Container) AddNRIAudit(entry crioTypes.NRIAuditEntry) {
+	c.opLock.Lock()
+	defer c.opLock.Unlock()
+	c.state.NRIAudit = append(c.state.NRIAudit, entry)
+	if n := len(c.state.NRIAudit); n > maxNRIAuditEntries {
+		c.state.NRIAudit = c.state.NRIAudit[n-maxNRIAuditEntries:]
+	}
+}
+
+// NRIAudit returns the recorded changes of NRI plugins to the container.
+func (c *Container) NRIAudit() []crioTypes.NRIAuditEntry {
+	c.opLock.RLock()
+	defer c.opLock.RUnlock()
+	return slices.Clone(c.state.NRIAudit)
+}
+
 // Name returns the name of the container.
 func (c *Container) Name() string {
 	return c.name
@@ -29,6 +29,13 @@ func (c *Container) SetStateAndSpoofPid(state *ContainerState) {
 	c.state = state
 }
 
+// SetRuntimeImpl sets the runtime implementation used for the container
+func (r *Runtime) SetRuntimeImpl(c *Container, impl RuntimeImpl) {
+	r.runtimeImplMapMutex.Lock()
+	defer r.runtimeImplMapMutex.Unlock()
+	r.runtimeImplMap[c.ID()] = impl
+}
+
 type RuntimeOCI struct {
 	*runtimeOCI
 }
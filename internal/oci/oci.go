@@ -14,6 +14,7 @@ import (
 	"github.com/cri-o/cri-o/internal/config/cgmgr"
 	"github.com/cri-o/cri-o/internal/log"
 	"github.com/cri-o/cri-o/pkg/config"
+	crioTypes "github.com/cri-o/cri-o/pkg/types"
 	"github.com/docker/go-units"
 	rspec "github.com/opencontainers/runtime-spec/specs-go"
 	"golang.org/x/net/context"
@@ -77,7 +78,7 @@ type RuntimeImpl interface {
 	PortForwardContainer(context.Context, *Container, string,
 		int32, io.ReadWriteCloser) error
 	ReopenContainerLog(context.Context, *Container) error
-	CheckpointContainer(context.Context, *Container, *rspec.Spec, bool) error
+	CheckpointContainer(context.Context, *Container, *rspec.Spec, *CheckpointOptions) error
 	RestoreContainer(context.Context, *Container, string, string) error
 }
 
@@ -226,6 +227,21 @@ func (r *Runtime) RuntimeSupportsRROMounts(runtimeHandler string) bool {
 	return rh.RuntimeSupportsRROMounts()
 }
 
+// RuntimeHandlerCapabilities returns the report of the features supported by
+// the runtime of runtimeHandler.
+func (r *Runtime) RuntimeHandlerCapabilities(runtimeHandler string) (*crioTypes.RuntimeHandlerCapabilities, error) {
+	rh, err := r.getRuntimeHandler(runtimeHandler)
+	if err != nil {
+		return nil, err
+	}
+	name := runtimeHandler
+	if name == "" {
+		name = r.config.DefaultRuntime
+	}
+
+	return rh.Capabilities(name, r.config.CheckpointRestore()), nil
+}
+
 func (r *Runtime) newRuntimeImpl(c *Container) (RuntimeImpl, error) {
 	rh, err := r.getRuntimeHandler(c.runtimeHandler)
 	if err != nil {
@@ -474,14 +490,43 @@ func (e *ExecSyncError) Error() string {
 	return fmt.Sprintf("command error: %+v, stdout: %s, stderr: %s, exit code %d", e.Err, e.Stdout.Bytes(), e.Stderr.Bytes(), e.ExitCode)
 }
 
+// ErrIncrementalCheckpointNotSupported is returned if the runtime of a
+// container is not able to pre-dump it for an incremental checkpoint.
+var ErrIncrementalCheckpointNotSupported = errors.New("incremental checkpoints are not supported by the runtime")
+
+// CheckpointOptions are the options for checkpointing a container.
+type CheckpointOptions struct {
+	// LeaveRunning keeps the container running after checkpointing it.
+	LeaveRunning bool
+
+	// PreDump only dumps the memory of the container and starts tracking
+	// its changes, to be used as parent of a later checkpoint. The container
+	// always keeps running.
+	PreDump bool
+
+	// ImagePath is the directory the checkpoint images get written to.
+	// Defaults to the checkpoint path of the container.
+	ImagePath string
+
+	// ParentPath is the directory of the previous checkpoint images,
+	// relative to the image path. Only the memory pages changed since the
+	// parent checkpoint get dumped if set.
+	ParentPath string
+
+	// TrackMem keeps tracking the memory changes of a container left
+	// running, which allows the checkpoint to become the parent of the next
+	// one. A pre-dump always tracks the memory changes.
+	TrackMem bool
+}
+
 // CheckpointContainer checkpoints a container.
-func (r *Runtime) CheckpointContainer(ctx context.Context, c *Container, specgen *rspec.Spec, leaveRunning bool) error {
+func (r *Runtime) CheckpointContainer(ctx context.Context, c *Container, specgen *rspec.Spec, opts *CheckpointOptions) error {
 	impl, err := r.RuntimeImpl(c)
 	if err != nil {
 		return err
 	}
 
-	return impl.CheckpointContainer(ctx, c, specgen, leaveRunning)
+	return impl.CheckpointContainer(ctx, c, specgen, opts)
 }
 
 // RestoreContainer restores a container.
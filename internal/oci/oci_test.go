@@ -198,7 +198,7 @@ var _ = t.Describe("Oci", func() {
 				},
 			}
 			// When
-			err := sut.CheckpointContainer(context.Background(), myContainer, specgen, false)
+			err := sut.CheckpointContainer(context.Background(), myContainer, specgen, &oci.CheckpointOptions{})
 
 			// Then
 			Expect(err).ToNot(HaveOccurred())
@@ -224,12 +224,41 @@ var _ = t.Describe("Oci", func() {
 				},
 			}
 			// When
-			err := sut.CheckpointContainer(context.Background(), myContainer, specgen, true)
+			err := sut.CheckpointContainer(context.Background(), myContainer, specgen, &oci.CheckpointOptions{LeaveRunning: true})
 
 			// Then
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(Equal("configured runtime does not support checkpoint/restore"))
 		})
+		It("CheckpointContainer should fail to track memory without parent", func() {
+			if err := criu.CheckForCriu(criu.PodCriuVersion); err != nil {
+				Skip("Check CRIU: " + err.Error())
+			}
+			// Given
+			beforeEach()
+			defer os.RemoveAll("dump.log")
+			config.Runtimes["runc"] = &libconfig.RuntimeHandler{
+				RuntimePath: "/bin/true",
+			}
+
+			specgen := &specs.Spec{
+				Version: "1.0.0",
+				Process: &specs.Process{
+					SelinuxLabel: "",
+				},
+				Linux: &specs.Linux{
+					MountLabel: "",
+				},
+			}
+			// When
+			err := sut.CheckpointContainer(context.Background(), myContainer, specgen, &oci.CheckpointOptions{
+				LeaveRunning: true,
+				TrackMem:     true,
+			})
+
+			// Then
+			Expect(err).To(MatchError(oci.ErrIncrementalCheckpointNotSupported))
+		})
 		It("RestoreContainer should fail with destination sandbox detection", func() {
 			if err := criu.CheckForCriu(criu.PodCriuVersion); err != nil {
 				Skip("Check CRIU: " + err.Error())
@@ -0,0 +1,88 @@
+package oci
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cri-o/cri-o/pkg/config"
+	"github.com/cri-o/cri-o/utils/cmdrunner"
+	"golang.org/x/net/context"
+)
+
+// runtimeHealthCheckTimeout is the timeout of a single health check command
+// or connection check.
+const runtimeHealthCheckTimeout = 10 * time.Second
+
+// healthChecker is implemented by runtime implementations which keep a
+// connection to a long running process, like a shim or a conmon-rs server.
+type healthChecker interface {
+	// checkHealth returns an error if the process serving the container does
+	// not respond.
+	checkHealth(context.Context, *Container) error
+}
+
+// CheckRuntimeHandlerHealth checks the health of the runtime handler by running
+// its runtime and monitor binaries. It returns an error describing the failed
+// check if the runtime handler is unhealthy.
+func (r *Runtime) CheckRuntimeHandlerHealth(ctx context.Context, handler string) error {
+	rh, err := r.ValidateRuntimeHandler(handler)
+	if err != nil {
+		return err
+	}
+
+	switch rh.RuntimeType {
+	case config.RuntimeTypeVM:
+		// containerd shims print their version with -v.
+		return runHealthCheckCommand(ctx, rh.RuntimePath, "-v")
+	default:
+		if err := runHealthCheckCommand(ctx, rh.RuntimePath, "--version"); err != nil {
+			return err
+		}
+		if rh.MonitorPath != "" {
+			return runHealthCheckCommand(ctx, rh.MonitorPath, "--version")
+		}
+	}
+	return nil
+}
+
+// CheckContainerRuntimeHealth checks the connection to the shim or conmon-rs
+// server of the container, if its runtime implementation keeps one. It
+// returns nil for runtime implementations without a long running process.
+func (r *Runtime) CheckContainerRuntimeHealth(ctx context.Context, c *Container) error {
+	r.runtimeImplMapMutex.RLock()
+	impl, ok := r.runtimeImplMap[c.ID()]
+	r.runtimeImplMapMutex.RUnlock()
+	if !ok {
+		return nil
+	}
+	checker, ok := impl.(healthChecker)
+	if !ok {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, runtimeHealthCheckTimeout)
+	defer cancel()
+	if err := checker.checkHealth(ctx, c); err != nil {
+		return fmt.Errorf("runtime of container %s does not respond: %w", c.ID(), err)
+	}
+	return nil
+}
+
+func runHealthCheckCommand(ctx context.Context, path string, args ...string) error {
+	ctx, cancel := context.WithTimeout(ctx, runtimeHealthCheckTimeout)
+	defer cancel()
+
+	out, err := cmdrunner.CommandContext(ctx, path, args...).CombinedOutput() // nolint: gosec
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			err = fmt.Errorf("timed out after %v", runtimeHealthCheckTimeout)
+		}
+		if output := strings.TrimSpace(string(out)); output != "" {
+			err = fmt.Errorf("%w: %s", err, output)
+		}
+		return fmt.Errorf("run %s %s: %w", path, strings.Join(args, " "), err)
+	}
+	return nil
+}
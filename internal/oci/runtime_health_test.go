@@ -0,0 +1,97 @@
+package oci_test
+
+import (
+	"context"
+	"os/exec"
+
+	"github.com/cri-o/cri-o/internal/oci"
+	libconfig "github.com/cri-o/cri-o/pkg/config"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// The actual test suite
+var _ = t.Describe("RuntimeHealth", func() {
+	// The system under test
+	var sut *oci.Runtime
+
+	// Test constants
+	const (
+		healthyRuntime     = "healthy"
+		missingRuntime     = "missing"
+		missingMonitor     = "missing-monitor"
+		missingVMRuntime   = "missing-vm"
+		notExistingPath    = "/not/existing"
+		notExistingRuntime = "not-existing"
+	)
+
+	BeforeEach(func() {
+		echo, err := exec.LookPath("echo")
+		Expect(err).ToNot(HaveOccurred())
+
+		config, err = libconfig.DefaultConfig()
+		Expect(err).ToNot(HaveOccurred())
+		config.DefaultRuntime = healthyRuntime
+		config.Runtimes = libconfig.Runtimes{
+			healthyRuntime: {
+				RuntimePath: echo,
+				MonitorPath: echo,
+			},
+			missingRuntime: {
+				RuntimePath: notExistingPath,
+			},
+			missingMonitor: {
+				RuntimePath: echo,
+				MonitorPath: notExistingPath,
+			},
+			missingVMRuntime: {
+				RuntimePath: notExistingPath,
+				RuntimeType: libconfig.RuntimeTypeVM,
+			},
+		}
+		// so we have permission to make a directory within it
+		config.ContainerAttachSocketDir = t.MustTempDir("crio")
+
+		sut, err = oci.New(config)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	t.Describe("CheckRuntimeHandlerHealth", func() {
+		It("should succeed for a runtime handler with working binaries", func() {
+			// Given
+			// When
+			err := sut.CheckRuntimeHandlerHealth(context.Background(), healthyRuntime)
+
+			// Then
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		DescribeTable("should fail for a broken runtime handler",
+			func(handler string) {
+				// Given
+				// When
+				err := sut.CheckRuntimeHandlerHealth(context.Background(), handler)
+
+				// Then
+				Expect(err).To(HaveOccurred())
+			},
+			Entry("missing runtime", missingRuntime),
+			Entry("missing monitor", missingMonitor),
+			Entry("missing VM runtime", missingVMRuntime),
+			Entry("not existing runtime handler", notExistingRuntime),
+		)
+	})
+
+	t.Describe("CheckContainerRuntimeHealth", func() {
+		It("should succeed for a container without runtime implementation", func() {
+			// Given
+			beforeEach()
+
+			// When
+			err := sut.CheckContainerRuntimeHealth(context.Background(), myContainer)
+
+			// Then
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+})
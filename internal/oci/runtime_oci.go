@@ -1412,7 +1412,7 @@ func (r *runtimeOCI) defaultRuntimeArgs() []string {
 }
 
 // CheckpointContainer checkpoints a container.
-func (r *runtimeOCI) CheckpointContainer(ctx context.Context, c *Container, specgen *rspec.Spec, leaveRunning bool) error {
+func (r *runtimeOCI) CheckpointContainer(ctx context.Context, c *Container, specgen *rspec.Spec, opts *CheckpointOptions) error {
 	c.opLock.Lock()
 	defer c.opLock.Unlock()
 	runtimePath := c.RuntimePathForPlatform(r)
@@ -1436,6 +1436,9 @@ func (r *runtimeOCI) CheckpointContainer(ctx context.Context, c *Container, spec
 	workPath := c.Dir()
 	// imagePath is used by CRIU to store the actual checkpoint files
 	imagePath := c.CheckpointPath()
+	if opts.ImagePath != "" {
+		imagePath = opts.ImagePath
+	}
 
 	log.Debugf(ctx, "Writing checkpoint to %s", imagePath)
 	log.Debugf(ctx, "Writing checkpoint logs to %s", workPath)
@@ -1449,9 +1452,19 @@ func (r *runtimeOCI) CheckpointContainer(ctx context.Context, c *Container, spec
 		"--work-path",
 		workPath,
 	)
-	if leaveRunning {
+	if opts.PreDump {
+		args = append(args, "--pre-dump")
+	} else if opts.LeaveRunning {
 		args = append(args, "--leave-running")
 	}
+	if opts.ParentPath != "" {
+		// The parent path is relative to the image path. The runtime
+		// tracks the memory changes of every dump with a parent, there is
+		// no separate option to enable it.
+		args = append(args, "--parent-path", opts.ParentPath)
+	} else if opts.TrackMem && !opts.PreDump {
+		return fmt.Errorf("%w: tracking memory changes requires a parent checkpoint", ErrIncrementalCheckpointNotSupported)
+	}
 
 	args = append(args, c.ID())
 
@@ -1460,8 +1473,12 @@ func (r *runtimeOCI) CheckpointContainer(ctx context.Context, c *Container, spec
 		return fmt.Errorf("running %q %q failed: %w", runtimePath, args, err)
 	}
 
+	if opts.PreDump {
+		return nil
+	}
+
 	c.SetCheckpointedAt(time.Now())
-	if !leaveRunning {
+	if !opts.LeaveRunning {
 		c.state.Status = ContainerStateStopped
 		c.state.ExitCode = utils.Int32Ptr(0)
 		c.state.Finished = c.CheckpointedAt()
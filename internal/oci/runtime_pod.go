@@ -157,9 +157,9 @@ func (r *runtimePod) CheckpointContainer(
 	ctx context.Context,
 	c *Container,
 	specgen *rspec.Spec,
-	leaveRunning bool,
+	opts *CheckpointOptions,
 ) error {
-	return r.oci.CheckpointContainer(ctx, c, specgen, leaveRunning)
+	return r.oci.CheckpointContainer(ctx, c, specgen, opts)
 }
 
 func (r *runtimePod) RestoreContainer(
@@ -296,3 +296,9 @@ func (r *runtimePod) ReopenContainerLog(ctx context.Context, c *Container) error
 		ID: c.ID(),
 	})
 }
+
+// checkHealth pings the conmon-rs server of the pod.
+func (r *runtimePod) checkHealth(ctx context.Context, _ *Container) error {
+	_, err := r.client.Version(ctx, &conmonClient.VersionConfig{})
+	return err
+}
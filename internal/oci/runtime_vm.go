@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -61,6 +62,9 @@ type runtimeVM struct {
 	client     *ttrpc.Client
 	task       task.TaskService
 
+	// checkpointRestore is set if the shim implements the Checkpoint task API.
+	checkpointRestore bool
+
 	sync.Mutex
 	ctrs map[string]containerInfo
 }
@@ -96,13 +100,14 @@ func newRuntimeVM(handler *config.RuntimeHandler, exitsPath string) RuntimeImpl
 	typeurl.Register(&rspec.WindowsResources{}, prefix, "opencontainers/runtime-spec", major, "WindowsResources")
 
 	return &runtimeVM{
-		path:       handler.RuntimePath,
-		configPath: handler.RuntimeConfigPath,
-		exitsPath:  exitsPath,
-		pullImage:  handler.RuntimePullImage,
-		fifoDir:    filepath.Join(handler.RuntimeRoot, "crio", "fifo"),
-		ctx:        context.Background(),
-		ctrs:       make(map[string]containerInfo),
+		path:              handler.RuntimePath,
+		configPath:        handler.RuntimeConfigPath,
+		exitsPath:         exitsPath,
+		pullImage:         handler.RuntimePullImage,
+		checkpointRestore: handler.CheckpointRestore,
+		fifoDir:           filepath.Join(handler.RuntimeRoot, "crio", "fifo"),
+		ctx:               context.Background(),
+		ctrs:              make(map[string]containerInfo),
 	}
 }
 
@@ -201,6 +206,12 @@ func (r *runtimeVM) CreateContainer(ctx context.Context, c *Container, cgroupPar
 		Options:  opts,
 	}
 
+	if restore {
+		// The shim restores the task from the checkpoint images instead of
+		// starting the init process of the container.
+		request.Checkpoint = c.CheckpointPath()
+	}
+
 	if r.pullImage {
 		err := addVolumeMountsToCreateRequest(ctx, request, c)
 		if err != nil {
@@ -314,6 +325,13 @@ func (r *runtimeVM) StartContainer(ctx context.Context, c *Container) error {
 	c.opLock.Lock()
 	defer c.opLock.Unlock()
 
+	return r.startContainer(ctx, c)
+}
+
+// startContainer starts the task of the container and waits for its
+// termination in the background.
+// It does **not** Lock the container, thus it's the caller responsibility to do so, when needed.
+func (r *runtimeVM) startContainer(ctx context.Context, c *Container) error {
 	if err := r.start(c.ID(), ""); err != nil {
 		return err
 	}
@@ -1030,10 +1048,27 @@ func metricsV1ToCgroupStats(ctx context.Context, m *cgroupsV1.Metrics) *cgmgr.Cg
 			Current: m.Pids.Current,
 			Limit:   m.Pids.Limit,
 		},
+		DiskIO:     blkioV1ToDiskIOStats(m.Blkio),
+		Hugetlb:    hugetlbV1ToHugetlbStats(m.Hugetlb),
+		TaskStates: cgroupStatsV1ToTaskStates(m.CgroupStats),
 		SystemNano: time.Now().UnixNano(),
 	}
 }
 
+// cgroupStatsV1ToTaskStates returns the task states reported by the shim on
+// cgroup v1, which has no equivalent on cgroup v2.
+func cgroupStatsV1ToTaskStates(stats *cgroupsV1.CgroupStats) *cgmgr.TaskStateStats {
+	if stats == nil {
+		return nil
+	}
+	return &cgmgr.TaskStateStats{
+		Running:         stats.NrRunning,
+		Sleeping:        stats.NrSleeping,
+		Stopped:         stats.NrStopped,
+		Uninterruptible: stats.NrUninterruptible,
+	}
+}
+
 func metricsV2ToCgroupStats(ctx context.Context, m *cgroupsV2.Metrics) *cgmgr.CgroupStats {
 	var (
 		memLimit        uint64
@@ -1083,10 +1118,81 @@ func metricsV2ToCgroupStats(ctx context.Context, m *cgroupsV2.Metrics) *cgmgr.Cg
 			Current: m.Pids.Current,
 			Limit:   m.Pids.Limit,
 		},
+		DiskIO:     ioV2ToDiskIOStats(m.Io),
+		Hugetlb:    hugetlbV2ToHugetlbStats(m.Hugetlb),
 		SystemNano: time.Now().UnixNano(),
 	}
 }
 
+func blkioV1ToDiskIOStats(blkio *cgroupsV1.BlkIOStat) *cgmgr.DiskIOStats {
+	if blkio == nil {
+		return &cgmgr.DiskIOStats{}
+	}
+	entries := func(in []*cgroupsV1.BlkIOEntry) []cgmgr.BlkioEntry {
+		res := make([]cgmgr.BlkioEntry, 0, len(in))
+		for _, e := range in {
+			res = append(res, cgmgr.BlkioEntry{Major: e.Major, Minor: e.Minor, Op: e.Op, Value: e.Value})
+		}
+		return res
+	}
+	return &cgmgr.DiskIOStats{
+		ServiceBytes: entries(blkio.IoServiceBytesRecursive),
+		Serviced:     entries(blkio.IoServicedRecursive),
+		Merged:       entries(blkio.IoMergedRecursive),
+		ServiceTime:  entries(blkio.IoServiceTimeRecursive),
+		WaitTime:     entries(blkio.IoWaitTimeRecursive),
+		Queued:       entries(blkio.IoQueuedRecursive),
+		Time:         entries(blkio.IoTimeRecursive),
+	}
+}
+
+func ioV2ToDiskIOStats(io *cgroupsV2.IOStat) *cgmgr.DiskIOStats {
+	stats := &cgmgr.DiskIOStats{}
+	if io == nil {
+		return stats
+	}
+	// Map to the cgroup v1 layout, like libcontainer does for io.stat.
+	for _, e := range io.Usage {
+		stats.ServiceBytes = append(stats.ServiceBytes,
+			cgmgr.BlkioEntry{Major: e.Major, Minor: e.Minor, Op: "Read", Value: e.Rbytes},
+			cgmgr.BlkioEntry{Major: e.Major, Minor: e.Minor, Op: "Write", Value: e.Wbytes},
+		)
+		stats.Serviced = append(stats.Serviced,
+			cgmgr.BlkioEntry{Major: e.Major, Minor: e.Minor, Op: "Read", Value: e.Rios},
+			cgmgr.BlkioEntry{Major: e.Major, Minor: e.Minor, Op: "Write", Value: e.Wios},
+		)
+	}
+	return stats
+}
+
+// hugetlbV1ToHugetlbStats converts the hugetlb statistics of cgroup v1, which
+// do not contain the limit.
+func hugetlbV1ToHugetlbStats(hugetlb []*cgroupsV1.HugetlbStat) map[string]cgmgr.HugetlbStats {
+	res := make(map[string]cgmgr.HugetlbStats, len(hugetlb))
+	for _, h := range hugetlb {
+		res[h.Pagesize] = cgmgr.HugetlbStats{
+			Usage:    h.Usage,
+			MaxUsage: h.Max,
+			Limit:    math.MaxUint64,
+			Failcnt:  h.Failcnt,
+		}
+	}
+	return res
+}
+
+// hugetlbV2ToHugetlbStats converts the hugetlb statistics of cgroup v2, where
+// the max value is the limit.
+func hugetlbV2ToHugetlbStats(hugetlb []*cgroupsV2.HugeTlbStat) map[string]cgmgr.HugetlbStats {
+	res := make(map[string]cgmgr.HugetlbStats, len(hugetlb))
+	for _, h := range hugetlb {
+		res[h.Pagesize] = cgmgr.HugetlbStats{
+			Usage: h.Current,
+			Limit: h.Max,
+		}
+	}
+	return res
+}
+
 // SignalContainer sends a signal to a container process.
 func (r *runtimeVM) SignalContainer(ctx context.Context, c *Container, sig syscall.Signal) error {
 	log.Debugf(ctx, "RuntimeVM.SignalContainer() start")
@@ -1228,20 +1334,125 @@ func (r *runtimeVM) closeIO(ctrID, execID string) error {
 	return nil
 }
 
-// CheckpointContainer not implemented for runtimeVM
-func (r *runtimeVM) CheckpointContainer(ctx context.Context, c *Container, specgen *rspec.Spec, leaveRunning bool) error {
+// CheckpointContainer checkpoints a container using the Checkpoint task of
+// the shim. Incremental checkpoints are not supported.
+func (r *runtimeVM) CheckpointContainer(ctx context.Context, c *Container, specgen *rspec.Spec, opts *CheckpointOptions) error {
 	log.Debugf(ctx, "RuntimeVM.CheckpointContainer() start")
 	defer log.Debugf(ctx, "RuntimeVM.CheckpointContainer() end")
 
-	return errors.New("checkpointing not implemented for runtimeVM")
+	if !r.checkpointRestore {
+		return errVMCheckpointRestoreNotEnabled
+	}
+	if opts.PreDump || opts.ParentPath != "" || opts.TrackMem {
+		return ErrIncrementalCheckpointNotSupported
+	}
+
+	// Lock the container
+	c.opLock.Lock()
+	defer c.opLock.Unlock()
+
+	imagePath := c.CheckpointPath()
+	if opts.ImagePath != "" {
+		imagePath = opts.ImagePath
+	}
+	if err := os.MkdirAll(imagePath, 0o700); err != nil {
+		return fmt.Errorf("create checkpoint directory: %w", err)
+	}
+
+	log.Debugf(ctx, "Writing checkpoint to %s", imagePath)
+	if _, err := r.task.Checkpoint(r.ctx, &task.CheckpointTaskRequest{
+		ID:   c.ID(),
+		Path: imagePath,
+	}); err != nil {
+		return fmt.Errorf("checkpoint task: %w", errdefs.FromGRPC(err))
+	}
+	c.SetCheckpointedAt(time.Now())
+
+	if opts.LeaveRunning {
+		return nil
+	}
+
+	// The shim API has no option to stop the task as part of the
+	// checkpoint, which is why it gets killed afterwards.
+	if err := r.stopCheckpointedContainer(ctx, c); err != nil {
+		return fmt.Errorf("stop checkpointed container: %w", err)
+	}
+	c.state.Status = ContainerStateStopped
+	c.state.ExitCode = utils.Int32Ptr(0)
+	c.state.Finished = c.CheckpointedAt()
+
+	return nil
+}
+
+// errVMCheckpointRestoreNotEnabled is returned if the runtime handler of a
+// container is not configured with checkpoint_restore.
+var errVMCheckpointRestoreNotEnabled = errors.New("checkpoint/restore is not enabled for the runtime handler, set checkpoint_restore if its shim supports it")
+
+// stopCheckpointedContainer kills the task of the container, which may be
+// paused, and waits for it to terminate.
+// It does **not** Lock the container, thus it's the caller responsibility to do so, when needed.
+func (r *runtimeVM) stopCheckpointedContainer(ctx context.Context, c *Container) error {
+	// The channel is buffered to not block the goroutine if the container
+	// cannot be killed and nobody waits for it to terminate.
+	stopCh := make(chan error, 1)
+	go func() {
+		if _, err := r.wait(c.ID(), ""); err != nil && !errors.Is(err, errdefs.ErrNotFound) {
+			stopCh <- err
+		}
+		close(stopCh)
+	}()
+
+	if err := r.kill(c.ID(), "", syscall.SIGKILL, true); err != nil {
+		return err
+	}
+	// A paused task only terminates once it got resumed.
+	if c.state.Status == ContainerStatePaused {
+		if _, err := r.task.Resume(r.ctx, &task.ResumeRequest{ID: c.ID()}); err != nil {
+			log.Debugf(ctx, "Unable to resume killed container %s: %v", c.ID(), err)
+		}
+	}
+
+	return r.waitCtrTerminate(syscall.SIGKILL, stopCh, killContainerTimeout)
 }
 
-// RestoreContainer not implemented for runtimeVM
+// RestoreContainer restores a container by creating its task from the
+// checkpoint images and starting it.
 func (r *runtimeVM) RestoreContainer(ctx context.Context, c *Container, cgroupParent, mountLabel string) error {
 	log.Debugf(ctx, "RuntimeVM.RestoreContainer() start")
 	defer log.Debugf(ctx, "RuntimeVM.RestoreContainer() end")
 
-	return errors.New("restoring not implemented for runtimeVM")
+	if !r.checkpointRestore {
+		return errVMCheckpointRestoreNotEnabled
+	}
+
+	entries, err := os.ReadDir(c.CheckpointPath())
+	if err != nil {
+		return fmt.Errorf("a complete checkpoint for this container cannot be found, cannot restore: %w", err)
+	}
+	if len(entries) == 0 {
+		return fmt.Errorf("checkpoint directory %s of container %s is empty, cannot restore", c.CheckpointPath(), c.ID())
+	}
+
+	c.state.InitPid = 0
+	c.state.InitStartTime = ""
+
+	if err := r.CreateContainer(ctx, c, cgroupParent, true); err != nil {
+		return err
+	}
+
+	// Lock the container
+	c.opLock.Lock()
+	defer c.opLock.Unlock()
+
+	if err := r.startContainer(ctx, c); err != nil {
+		return err
+	}
+
+	// Once the container is restored, update the metadata
+	c.state.Status = ContainerStateRunning
+	c.state.ExitCode = nil
+
+	return nil
 }
 
 func EncodeKataVirtualVolumeToBase64(ctx context.Context, volume *katavolume.KataVirtualVolume) (string, error) {
@@ -1253,3 +1464,16 @@ func EncodeKataVirtualVolumeToBase64(ctx context.Context, volume *katavolume.Kat
 	option := base64.StdEncoding.EncodeToString(validKataVirtualVolumeJSON)
 	return option, nil
 }
+
+// checkHealth connects to the shim serving the container.
+func (r *runtimeVM) checkHealth(ctx context.Context, c *Container) error {
+	if r.task == nil {
+		return nil
+	}
+	if _, err := r.task.Connect(ctx, &task.ConnectRequest{
+		ID: c.ID(),
+	}); err != nil {
+		return errdefs.FromGRPC(err)
+	}
+	return nil
+}
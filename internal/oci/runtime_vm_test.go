@@ -0,0 +1,374 @@
+package oci_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	cgroupsV1 "github.com/containerd/cgroups/stats/v1"
+	"github.com/containerd/containerd/api/runtime/task/v2"
+	"github.com/containerd/containerd/protobuf"
+	"github.com/containerd/ttrpc"
+	"github.com/containerd/typeurl"
+	"github.com/cri-o/cri-o/internal/config/cgmgr"
+	"github.com/cri-o/cri-o/internal/oci"
+	libconfig "github.com/cri-o/cri-o/pkg/config"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/types/known/emptypb"
+	types "k8s.io/cri-api/pkg/apis/runtime/v1"
+)
+
+// fakeTaskService is a task service of a shim which records the create,
+// start and checkpoint requests and lets Wait return once the task got killed.
+type fakeTaskService struct {
+	task.TaskService
+
+	mutex         sync.Mutex
+	checkpointErr error
+	killErr       error
+	checkpoints   []*task.CheckpointTaskRequest
+	creates       []*task.CreateTaskRequest
+	starts        []string
+	signals       []uint32
+	resumed       bool
+	exited        chan struct{}
+	metrics       *cgroupsV1.Metrics
+}
+
+func newFakeTaskService() *fakeTaskService {
+	return &fakeTaskService{exited: make(chan struct{})}
+}
+
+func (f *fakeTaskService) Checkpoint(_ context.Context, req *task.CheckpointTaskRequest) (*emptypb.Empty, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.checkpointErr != nil {
+		return nil, f.checkpointErr
+	}
+	f.checkpoints = append(f.checkpoints, req)
+	return &emptypb.Empty{}, os.WriteFile(filepath.Join(req.Path, "inventory.img"), []byte{}, 0o600)
+}
+
+func (f *fakeTaskService) Create(_ context.Context, req *task.CreateTaskRequest) (*task.CreateTaskResponse, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.creates = append(f.creates, req)
+	return &task.CreateTaskResponse{Pid: uint32(os.Getpid())}, nil
+}
+
+func (f *fakeTaskService) Start(_ context.Context, req *task.StartRequest) (*task.StartResponse, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.starts = append(f.starts, req.ID)
+	return &task.StartResponse{Pid: uint32(os.Getpid())}, nil
+}
+
+func (f *fakeTaskService) Kill(_ context.Context, req *task.KillRequest) (*emptypb.Empty, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.killErr != nil {
+		return nil, f.killErr
+	}
+	f.signals = append(f.signals, req.Signal)
+	if req.Signal == uint32(syscall.SIGKILL) {
+		close(f.exited)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (f *fakeTaskService) Resume(context.Context, *task.ResumeRequest) (*emptypb.Empty, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.resumed = true
+	return &emptypb.Empty{}, nil
+}
+
+func (f *fakeTaskService) Stats(context.Context, *task.StatsRequest) (*task.StatsResponse, error) {
+	stats, err := typeurl.MarshalAny(f.metrics)
+	if err != nil {
+		return nil, err
+	}
+	return &task.StatsResponse{Stats: protobuf.FromAny(stats)}, nil
+}
+
+func (f *fakeTaskService) Wait(ctx context.Context, _ *task.WaitRequest) (*task.WaitResponse, error) {
+	select {
+	case <-f.exited:
+		return &task.WaitResponse{ExitStatus: 137}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// serveFakeShim serves the task service on a socket and returns the path of a
+// shim binary, which only prints the address of the socket when started.
+func serveFakeShim(taskService task.TaskService) string {
+	dir := t.MustTempDir("shim")
+	address := "unix://" + filepath.Join(dir, "shim.sock")
+	listener, err := net.Listen("unix", filepath.Join(dir, "shim.sock"))
+	Expect(err).ToNot(HaveOccurred())
+	server, err := ttrpc.NewServer()
+	Expect(err).ToNot(HaveOccurred())
+	task.RegisterTaskService(server, taskService)
+	go server.Serve(context.Background(), listener) //nolint:errcheck
+	DeferCleanup(server.Close)
+
+	shim := filepath.Join(dir, "containerd-shim-fake-v2")
+	Expect(os.WriteFile(shim, []byte("#!/bin/sh\necho "+address+"\n"), 0o755)).To(Succeed())
+	return shim
+}
+
+// The actual test suite
+var _ = t.Describe("RuntimeVM", func() {
+	var (
+		taskService *fakeTaskService
+		sut         oci.RuntimeVM
+		ctr         *oci.Container
+	)
+
+	BeforeEach(func() {
+		taskService = newFakeTaskService()
+		sut = oci.NewRuntimeVM(&libconfig.RuntimeHandler{
+			RuntimeRoot:       t.MustTempDir("root"),
+			CheckpointRestore: true,
+		}, t.MustTempDir("exits"), taskService)
+
+		var err error
+		ctr, err = oci.NewContainer(containerID, "", "", "",
+			make(map[string]string), make(map[string]string),
+			make(map[string]string), "", nil, nil, "",
+			&types.ContainerMetadata{}, sandboxID, false,
+			false, false, "", t.MustTempDir("ctr"), time.Now(), "")
+		Expect(err).ToNot(HaveOccurred())
+		ctr.SetState(&oci.ContainerState{})
+		ctr.State().Status = oci.ContainerStateRunning
+	})
+
+	t.Describe("CheckpointContainer", func() {
+		It("should checkpoint and keep the container running", func() {
+			// When
+			err := sut.CheckpointContainer(context.Background(), ctr, nil, &oci.CheckpointOptions{LeaveRunning: true})
+
+			// Then
+			Expect(err).ToNot(HaveOccurred())
+			Expect(taskService.checkpoints).To(HaveLen(1))
+			Expect(taskService.checkpoints[0].ID).To(Equal(containerID))
+			Expect(taskService.checkpoints[0].Path).To(Equal(ctr.CheckpointPath()))
+			Expect(filepath.Join(ctr.CheckpointPath(), "inventory.img")).To(BeAnExistingFile())
+			Expect(taskService.signals).To(BeEmpty())
+			Expect(ctr.CheckpointedAt()).NotTo(BeZero())
+			Expect(ctr.State().Status).To(BeEquivalentTo(oci.ContainerStateRunning))
+		})
+
+		It("should write the checkpoint to the provided image path", func() {
+			// Given
+			imagePath := filepath.Join(t.MustTempDir("images"), "checkpoint")
+
+			// When
+			err := sut.CheckpointContainer(context.Background(), ctr, nil, &oci.CheckpointOptions{
+				LeaveRunning: true,
+				ImagePath:    imagePath,
+			})
+
+			// Then
+			Expect(err).ToNot(HaveOccurred())
+			Expect(taskService.checkpoints).To(HaveLen(1))
+			Expect(taskService.checkpoints[0].Path).To(Equal(imagePath))
+		})
+
+		It("should stop the container after checkpointing it", func() {
+			// Given
+			ctr.State().Status = oci.ContainerStatePaused
+
+			// When
+			err := sut.CheckpointContainer(context.Background(), ctr, nil, &oci.CheckpointOptions{})
+
+			// Then
+			Expect(err).ToNot(HaveOccurred())
+			Expect(taskService.checkpoints).To(HaveLen(1))
+			Expect(taskService.signals).To(Equal([]uint32{uint32(syscall.SIGKILL)}))
+			Expect(taskService.resumed).To(BeTrue())
+			Expect(ctr.State().Status).To(BeEquivalentTo(oci.ContainerStateStopped))
+			Expect(*ctr.State().ExitCode).To(BeZero())
+			Expect(ctr.State().Finished).To(Equal(ctr.CheckpointedAt()))
+		})
+
+		It("should fail if the shim fails to checkpoint", func() {
+			// Given
+			taskService.checkpointErr = errors.New("not implemented")
+
+			// When
+			err := sut.CheckpointContainer(context.Background(), ctr, nil, &oci.CheckpointOptions{})
+
+			// Then
+			Expect(err).To(HaveOccurred())
+			Expect(taskService.signals).To(BeEmpty())
+			Expect(ctr.State().Status).To(BeEquivalentTo(oci.ContainerStateRunning))
+		})
+
+		It("should fail if the container cannot be killed after checkpointing it", func() {
+			// Given
+			taskService.killErr = errors.New("kill failed")
+
+			// When
+			err := sut.CheckpointContainer(context.Background(), ctr, nil, &oci.CheckpointOptions{})
+
+			// Then
+			Expect(err).To(HaveOccurred())
+			Expect(taskService.checkpoints).To(HaveLen(1))
+			Expect(ctr.State().Status).To(BeEquivalentTo(oci.ContainerStateRunning))
+		})
+
+		It("should fail if checkpoint/restore is not enabled for the runtime handler", func() {
+			// Given
+			sut = oci.NewRuntimeVM(&libconfig.RuntimeHandler{
+				RuntimeRoot: t.MustTempDir("root"),
+			}, t.MustTempDir("exits"), taskService)
+
+			// When
+			err := sut.CheckpointContainer(context.Background(), ctr, nil, &oci.CheckpointOptions{LeaveRunning: true})
+			restoreErr := sut.RestoreContainer(context.Background(), ctr, "", "")
+
+			// Then
+			Expect(err).To(HaveOccurred())
+			Expect(restoreErr).To(HaveOccurred())
+			Expect(taskService.checkpoints).To(BeEmpty())
+			Expect(taskService.creates).To(BeEmpty())
+		})
+
+		It("should not support incremental checkpoints", func() {
+			// When
+			err := sut.CheckpointContainer(context.Background(), ctr, nil, &oci.CheckpointOptions{PreDump: true})
+			trackErr := sut.CheckpointContainer(context.Background(), ctr, nil, &oci.CheckpointOptions{
+				LeaveRunning: true,
+				TrackMem:     true,
+			})
+
+			// Then
+			Expect(err).To(MatchError(oci.ErrIncrementalCheckpointNotSupported))
+			Expect(trackErr).To(MatchError(oci.ErrIncrementalCheckpointNotSupported))
+			Expect(taskService.checkpoints).To(BeEmpty())
+		})
+	})
+
+	t.Describe("RestoreContainer", func() {
+		It("should create the task from the checkpoint and start it", func() {
+			// Given
+			sut = oci.NewRuntimeVM(&libconfig.RuntimeHandler{
+				RuntimePath:       serveFakeShim(taskService),
+				RuntimeRoot:       t.MustTempDir("root"),
+				CheckpointRestore: true,
+			}, t.MustTempDir("exits"), taskService)
+			bundle := t.MustTempDir("bundle")
+			var err error
+			ctr, err = oci.NewContainer(containerID, "", bundle, filepath.Join(bundle, "ctr.log"),
+				make(map[string]string), make(map[string]string),
+				make(map[string]string), "", nil, nil, "",
+				&types.ContainerMetadata{}, sandboxID, false,
+				false, false, "", t.MustTempDir("ctr"), time.Now(), "")
+			Expect(err).ToNot(HaveOccurred())
+			ctr.SetState(&oci.ContainerState{})
+			ctr.State().Status = oci.ContainerStateStopped
+			Expect(os.MkdirAll(ctr.CheckpointPath(), 0o700)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(ctr.CheckpointPath(), "inventory.img"), []byte{}, 0o600)).To(Succeed())
+
+			// When
+			err = sut.RestoreContainer(context.Background(), ctr, "", "")
+
+			// Then
+			Expect(err).ToNot(HaveOccurred())
+			taskService.mutex.Lock()
+			defer taskService.mutex.Unlock()
+			Expect(taskService.creates).To(HaveLen(1))
+			Expect(taskService.creates[0].ID).To(Equal(containerID))
+			Expect(taskService.creates[0].Bundle).To(Equal(bundle))
+			Expect(taskService.creates[0].Checkpoint).To(Equal(ctr.CheckpointPath()))
+			Expect(taskService.starts).To(Equal([]string{containerID}))
+			Expect(ctr.State().Status).To(BeEquivalentTo(oci.ContainerStateRunning))
+			Expect(ctr.State().InitPid).To(Equal(os.Getpid()))
+		})
+
+		It("should fail without checkpoint", func() {
+			// Given
+			ctr.State().Status = oci.ContainerStateStopped
+
+			// When
+			err := sut.RestoreContainer(context.Background(), ctr, "", "")
+
+			// Then
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("cannot restore"))
+		})
+
+		It("should fail with an empty checkpoint", func() {
+			// Given
+			ctr.State().Status = oci.ContainerStateStopped
+			Expect(os.MkdirAll(ctr.CheckpointPath(), 0o700)).To(Succeed())
+
+			// When
+			err := sut.RestoreContainer(context.Background(), ctr, "", "")
+
+			// Then
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("is empty"))
+		})
+	})
+
+	t.Describe("ContainerStats", func() {
+		// metricsV1 returns the cgroup v1 metrics of a shim with the
+		// provided cgroup statistics.
+		metricsV1 := func(cgroupStats *cgroupsV1.CgroupStats) *cgroupsV1.Metrics {
+			return &cgroupsV1.Metrics{
+				Memory: &cgroupsV1.MemoryStat{
+					Usage:     &cgroupsV1.MemoryEntry{},
+					Swap:      &cgroupsV1.MemoryEntry{},
+					Kernel:    &cgroupsV1.MemoryEntry{},
+					KernelTCP: &cgroupsV1.MemoryEntry{},
+				},
+				CPU:         &cgroupsV1.CPUStat{Usage: &cgroupsV1.CPUUsage{}, Throttling: &cgroupsV1.Throttle{}},
+				Pids:        &cgroupsV1.PidsStat{},
+				CgroupStats: cgroupStats,
+			}
+		}
+
+		It("should report the task states of cgroup v1", func() {
+			// Given
+			taskService.metrics = metricsV1(&cgroupsV1.CgroupStats{
+				NrRunning:         1,
+				NrSleeping:        2,
+				NrStopped:         3,
+				NrUninterruptible: 4,
+			})
+
+			// When
+			stats, err := sut.ContainerStats(context.Background(), ctr, "")
+
+			// Then
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stats.TaskStates).To(Equal(&cgmgr.TaskStateStats{
+				Running:         1,
+				Sleeping:        2,
+				Stopped:         3,
+				Uninterruptible: 4,
+			}))
+		})
+
+		It("should not report task states without cgroup statistics", func() {
+			// Given
+			taskService.metrics = metricsV1(nil)
+
+			// When
+			stats, err := sut.ContainerStats(context.Background(), ctr, "")
+
+			// Then
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stats.TaskStates).To(BeNil())
+		})
+	})
+})
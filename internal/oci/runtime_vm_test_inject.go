@@ -0,0 +1,24 @@
+//go:build test
+// +build test
+
+// All *_inject.go files are meant to be used by tests only. Purpose of this
+// files is to provide a way to inject mocked data into the current setup.
+
+package oci
+
+import (
+	"github.com/containerd/containerd/api/runtime/task/v2"
+	"github.com/cri-o/cri-o/pkg/config"
+)
+
+type RuntimeVM struct {
+	*runtimeVM
+}
+
+// NewRuntimeVM creates a VM runtime which uses the provided task service
+// instead of starting a shim.
+func NewRuntimeVM(handler *config.RuntimeHandler, exitsPath string, taskService task.TaskService) RuntimeVM {
+	r := newRuntimeVM(handler, exitsPath).(*runtimeVM)
+	r.task = taskService
+	return RuntimeVM{runtimeVM: r}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"sync/atomic"
 
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"go.opentelemetry.io/otel"
@@ -44,16 +45,11 @@ func InitTracing(ctx context.Context, collectorAddress string, samplingRate int)
 		return nil, nil, err
 	}
 
-	// Only emit spans when the kubelet sends a request with a sampled trace
-	sampler := sdktrace.NeverSample()
-	// Or, emit spans for a fraction of transactions
-	if samplingRate > 0 {
-		sampler = sdktrace.TraceIDRatioBased(float64(samplingRate) / float64(1000000))
-	}
+	SetSamplingRate(samplingRate)
 	// batch span processor to aggregate spans before export.
 	bsp := sdktrace.NewBatchSpanProcessor(exporter)
 	tp = sdktrace.NewTracerProvider(
-		sdktrace.WithSampler(sdktrace.ParentBased(sampler)),
+		sdktrace.WithSampler(sdktrace.ParentBased(dynamicSampler{})),
 		sdktrace.WithSpanProcessor(bsp),
 		sdktrace.WithResource(res),
 	)
@@ -63,3 +59,37 @@ func InitTracing(ctx context.Context, collectorAddress string, samplingRate int)
 	opts := []otelgrpc.Option{otelgrpc.WithPropagators(tmp), otelgrpc.WithTracerProvider(tp)}
 	return tp, opts, nil
 }
+
+// currentSampler is the sampler used for spans without sampled parent, which
+// can be exchanged at runtime.
+var currentSampler atomic.Pointer[sdktrace.Sampler]
+
+// SetSamplingRate changes the number of samples to collect per million spans
+// of a running tracer provider.
+func SetSamplingRate(samplingRate int) {
+	// Only emit spans when the kubelet sends a request with a sampled trace
+	sampler := sdktrace.NeverSample()
+	// Or, emit spans for a fraction of transactions
+	if samplingRate > 0 {
+		sampler = sdktrace.TraceIDRatioBased(float64(samplingRate) / float64(1000000))
+	}
+	currentSampler.Store(&sampler)
+}
+
+// dynamicSampler delegates to the sampler set by SetSamplingRate.
+type dynamicSampler struct{}
+
+func (dynamicSampler) sampler() sdktrace.Sampler {
+	if sampler := currentSampler.Load(); sampler != nil {
+		return *sampler
+	}
+	return sdktrace.NeverSample()
+}
+
+func (d dynamicSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	return d.sampler().ShouldSample(p)
+}
+
+func (d dynamicSampler) Description() string {
+	return "DynamicSampler{" + d.sampler().Description() + "}"
+}
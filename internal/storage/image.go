@@ -8,6 +8,7 @@ import (
 	"io"
 	"net"
 	"os"
+	"path/filepath"
 	"regexp"
 	"sort"
 	"strings"
@@ -15,7 +16,9 @@ import (
 	"time"
 
 	"github.com/containers/image/v5/copy"
+	"github.com/containers/image/v5/docker"
 	"github.com/containers/image/v5/docker/reference"
+	"github.com/containers/image/v5/pkg/blobcache"
 	"github.com/containers/image/v5/pkg/shortnames"
 	"github.com/containers/image/v5/signature"
 	istorage "github.com/containers/image/v5/storage"
@@ -56,6 +59,11 @@ type ImageResult struct {
 	OCIConfig           *specs.Image
 	Annotations         map[string]string
 	Pinned              bool // pinned image to prevent it from garbage collection
+	// LastUsed is the last time a container has been created from the image,
+	// which is zero if the image has never been used.
+	LastUsed time.Time
+	// UsageCount is the number of containers created from the image.
+	UsageCount uint64
 }
 
 type indexInfo struct {
@@ -94,6 +102,8 @@ type imageService struct {
 	ctx                  context.Context
 	config               *config.Config
 	regexForPinnedImages []*regexp.Regexp
+	pullScheduler        PullScheduler
+	imageUsageLock       sync.Mutex
 }
 
 // ImageBeingPulled map[string]bool to keep track of the images haven't done pulling.
@@ -115,6 +125,10 @@ type ImageCopyOptions struct {
 	ProgressInterval time.Duration
 	Progress         chan types.ProgressProperties `json:"-"`
 	CgroupPull       CgroupPullConfiguration
+	// PartialBlobsDir is the directory keeping the downloaded blobs of
+	// failed or cancelled pulls to reuse them, or "" if the pull is not
+	// resumable.
+	PartialBlobsDir string
 }
 
 // ImageServer wraps up various CRI-related activities into a reusable
@@ -154,6 +168,10 @@ type ImageServer interface {
 
 	// UpdatePinnedImagesList updates pinned and pause images list in imageService.
 	UpdatePinnedImagesList(imageList []string)
+
+	// RecordImageUsage increments the usage count of the image and sets its
+	// last used time to now.
+	RecordImageUsage(id StorageImageID) error
 }
 
 func parseImageNames(image *storage.Image) (someName *RegistryImageReference, tags []reference.NamedTagged, digests []reference.Canonical, err error) {
@@ -309,6 +327,7 @@ func (svc *imageService) buildImageResult(image *storage.Image, cacheItem imageC
 			break
 		}
 	}
+	usage := imageUsageFromMetadata(image.Metadata)
 	return ImageResult{
 		ID:                  storageImageIDFromImage(image),
 		SomeNameOfThisImage: someName,
@@ -323,6 +342,8 @@ func (svc *imageService) buildImageResult(image *storage.Image, cacheItem imageC
 		OCIConfig:           cacheItem.config,
 		Annotations:         cacheItem.annotations,
 		Pinned:              imagePinned,
+		LastUsed:            usage.LastUsed,
+		UsageCount:          usage.Count,
 	}, nil
 }
 
@@ -649,12 +670,102 @@ func (svc *imageService) pullImageParent(ctx context.Context, imageName Registry
 	return destRef, nil
 }
 
+// partialBlobsDir returns the directory keeping the blobs of resumable pulls.
+func (svc *imageService) partialBlobsDir() string {
+	return filepath.Join(svc.store.GraphRoot(), partialBlobsDirName)
+}
+
 func (svc *imageService) PullImage(ctx context.Context, imageName RegistryImageReference, options *ImageCopyOptions) (types.ImageReference, error) {
-	if options.CgroupPull.UseNewCgroup {
-		return svc.pullImageParent(ctx, imageName, options.CgroupPull.ParentCgroup, options)
-	} else {
-		return pullImageImplementation(ctx, svc.lookup, svc.store, imageName, options)
+	if svc.config != nil && svc.config.ResumablePulls && options.PartialBlobsDir == "" {
+		options.PartialBlobsDir = svc.partialBlobsDir()
+	}
+	key := pullKey(imageName, options)
+
+	// The pull may outlive this call if other callers joined it, so it must
+	// not send to the progress channel of the caller after returning.
+	pullOptions := *options
+	var progress *progressForwarder
+	if options.Progress != nil {
+		progress = &progressForwarder{dst: options.Progress}
+		defer progress.detach()
+	}
+
+	return svc.pullScheduler.Schedule(ctx, key, imageName.Registry(), svc.pullPriority(imageName), func(ctx context.Context) (types.ImageReference, error) {
+		if progress != nil {
+			pullOptions.Progress = make(chan types.ProgressProperties)
+			done := progress.forward(pullOptions.Progress)
+			defer func() {
+				close(pullOptions.Progress)
+				<-done
+			}()
+		}
+		if pullOptions.CgroupPull.UseNewCgroup {
+			return svc.pullImageParent(ctx, imageName, pullOptions.CgroupPull.ParentCgroup, &pullOptions)
+		}
+		return pullImageImplementation(ctx, svc.lookup, svc.store, imageName, &pullOptions)
+	})
+}
+
+// progressForwarder forwards the progress of a pull to the channel of the
+// caller until it gets detached.
+type progressForwarder struct {
+	mu  sync.Mutex
+	dst chan<- types.ProgressProperties
+}
+
+// forward forwards the progress of src until src gets closed, which closes
+// the returned channel.
+func (f *progressForwarder) forward(src <-chan types.ProgressProperties) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for p := range src {
+			f.mu.Lock()
+			if f.dst != nil {
+				f.dst <- p
+			}
+			f.mu.Unlock()
+		}
+	}()
+	return done
+}
+
+// detach stops forwarding the progress, after which the channel of the caller
+// can be closed.
+func (f *progressForwarder) detach() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.dst = nil
+}
+
+// pullKey returns the key identifying identical image pulls, which can share
+// a single in-flight pull. The credentials, policies and registries
+// configurations are part of the key to not share pulls between differently
+// authorized or configured callers.
+func pullKey(imageName RegistryImageReference, options *ImageCopyOptions) string {
+	key := imageName.StringForOutOfProcessConsumptionOnly()
+	if sc := options.SourceCtx; sc != nil {
+		if auth := sc.DockerAuthConfig; auth != nil {
+			key += "\x00" + auth.Username + "\x00" + auth.Password + "\x00" + auth.IdentityToken
+		}
+		key += "\x00" + sc.AuthFilePath + "\x00" + sc.SignaturePolicyPath
+		key += "\x00" + sc.SystemRegistriesConfPath + "\x00" + sc.SystemRegistriesConfDirPath
+	}
+	return digest.FromString(key).String()
+}
+
+// pullPriority returns the scheduling priority for pulling imageName.
+func (svc *imageService) pullPriority(imageName RegistryImageReference) PullPriority {
+	name := imageName.StringForOutOfProcessConsumptionOnly()
+	if svc.config != nil {
+		if pauseImage, err := svc.config.ParsePauseImage(); err == nil && pauseImage.StringForOutOfProcessConsumptionOnly() == name {
+			return PullPrioritySandbox
+		}
+	}
+	if FilterPinnedImage(name, svc.regexForPinnedImages) {
+		return PullPriorityPinned
 	}
+	return PullPriorityDefault
 }
 
 // pullImageImplementation is called in PullImage, both directly and inside pullImageChild.
@@ -680,7 +791,20 @@ func pullImageImplementation(ctx context.Context, lookup *imageLookupService, st
 		return nil, err
 	}
 
-	_, err = copy.Image(ctx, policyContext, destRef, srcRef, &copy.Options{
+	var copyDestRef types.ImageReference = destRef
+	var resumableDest *blobcache.BlobCache
+	if options.PartialBlobsDir != "" {
+		resumableDest, err = NewResumableDestination(destRef, options.PartialBlobsDir, imageName)
+		if err != nil {
+			return nil, err
+		}
+		copyDestRef = resumableDest
+		if err := stagePartialBlobs(ctx, store, srcSystemContext, srcRef, resumableDest, options); err != nil {
+			return nil, err
+		}
+	}
+
+	_, err = copy.Image(ctx, policyContext, copyDestRef, srcRef, &copy.Options{
 		SourceCtx:        srcSystemContext,
 		DestinationCtx:   options.DestinationCtx,
 		OciDecryptConfig: options.OciDecryptConfig,
@@ -690,9 +814,45 @@ func pullImageImplementation(ctx context.Context, lookup *imageLookupService, st
 	if err != nil {
 		return nil, err
 	}
+	if resumableDest != nil {
+		if err := os.RemoveAll(resumableDest.Directory()); err != nil {
+			logrus.Warnf("Unable to remove partial blobs of image %s: %v", imageName.StringForOutOfProcessConsumptionOnly(), err)
+		}
+	}
 	return destRef, err
 }
 
+// stagePartialBlobs stages the layers of srcRef into the blob cache of a
+// resumable pull, resuming the downloads of a previous pull. The staged blobs
+// are only verified against their digest, the signature policy is still
+// enforced by the image copy before they get committed to the store. Errors
+// other than a cancellation only get logged, the image copy downloads the
+// layers which could not be staged itself.
+func stagePartialBlobs(ctx context.Context, store storage.Store, srcSystemContext *types.SystemContext, srcRef types.ImageReference, cache *blobcache.BlobCache, options *ImageCopyOptions) error {
+	src, err := srcRef.NewImageSource(ctx, srcSystemContext)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		logrus.Warnf("Unable to stage partial blobs of %s: %v", transports.ImageName(srcRef), err)
+		return nil
+	}
+	defer src.Close()
+
+	var ranges BlobRangeGetter
+	if ref := srcRef.DockerReference(); ref != nil && srcRef.Transport().Name() == docker.Transport.Name() {
+		ranges = NewRegistryBlobFetcher(srcSystemContext, ref)
+	}
+	stager := NewPartialBlobStager(options.PartialBlobsDir, cache, ranges, options.Progress, options.ProgressInterval)
+	if err := stager.StageLayers(ctx, srcSystemContext, src, store); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		logrus.Warnf("Unable to stage partial blobs of %s: %v", transports.ImageName(srcRef), err)
+	}
+	return nil
+}
+
 func (svc *imageService) UntagImage(systemContext *types.SystemContext, name RegistryImageReference) error {
 	unstableRef, err := istorage.Transport.NewStoreReference(svc.store, name.Raw(), "")
 	if err != nil {
@@ -866,6 +1026,11 @@ func GetImageService(ctx context.Context, store storage.Store, storageTransport
 		ctx:                  ctx,
 		config:               serverConfig,
 		regexForPinnedImages: CompileRegexpsForPinnedImages(serverConfig.PinnedImages),
+		pullScheduler:        NewPullScheduler(serverConfig.MaxConcurrentPullsPerRegistry),
+	}
+
+	if serverConfig.ResumablePulls {
+		go removeStalePartialBlobsPeriodically(ctx, is.partialBlobsDir(), partialBlobCleanupInterval)
 	}
 
 	serverConfig.InsecureRegistries = append(serverConfig.InsecureRegistries, "127.0.0.0/8")
@@ -567,6 +567,85 @@ var _ = t.Describe("Image", func() {
 		})
 	})
 
+	t.Describe("RecordImageUsage", func() {
+		var imageID storage.StorageImageID
+
+		BeforeEach(func() {
+			var err error
+			imageID, err = storage.ParseStorageImageIDFromOutOfProcessData(testSHA256)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should succeed to record the first usage", func() {
+			// Given
+			var metadata string
+			gomock.InOrder(
+				storeMock.EXPECT().Metadata(testSHA256).Return("", nil),
+				storeMock.EXPECT().SetMetadata(testSHA256, gomock.Any()).
+					DoAndReturn(func(_, m string) error {
+						metadata = m
+						return nil
+					}),
+			)
+
+			// When
+			err := sut.RecordImageUsage(imageID)
+
+			// Then
+			Expect(err).ToNot(HaveOccurred())
+			Expect(metadata).To(ContainSubstring(`"count":1`))
+		})
+
+		It("should succeed to increment the usage and keep other metadata", func() {
+			// Given
+			var metadata string
+			gomock.InOrder(
+				storeMock.EXPECT().Metadata(testSHA256).Return(
+					`{"signature-sizes":[1],"io.cri-o.usage":{"lastUsed":"2023-11-14T22:13:20Z","count":2}}`, nil),
+				storeMock.EXPECT().SetMetadata(testSHA256, gomock.Any()).
+					DoAndReturn(func(_, m string) error {
+						metadata = m
+						return nil
+					}),
+			)
+
+			// When
+			err := sut.RecordImageUsage(imageID)
+
+			// Then
+			Expect(err).ToNot(HaveOccurred())
+			Expect(metadata).To(ContainSubstring(`"signature-sizes":[1]`))
+			Expect(metadata).To(ContainSubstring(`"count":3`))
+			Expect(metadata).NotTo(ContainSubstring("2023-11-14"))
+		})
+
+		It("should fail if the metadata cannot be retrieved", func() {
+			// Given
+			gomock.InOrder(
+				storeMock.EXPECT().Metadata(testSHA256).Return("", t.TestError),
+			)
+
+			// When
+			err := sut.RecordImageUsage(imageID)
+
+			// Then
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should fail if the metadata is invalid", func() {
+			// Given
+			gomock.InOrder(
+				storeMock.EXPECT().Metadata(testSHA256).Return("invalid", nil),
+			)
+
+			// When
+			err := sut.RecordImageUsage(imageID)
+
+			// Then
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
 	t.Describe("PullImage", func() {
 		It("should fail on invalid policy path", func() {
 			// Given
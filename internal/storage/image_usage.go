@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+
+	json "github.com/json-iterator/go"
+	"github.com/sirupsen/logrus"
+)
+
+// imageUsageMetadataKey is the key of the usage information within the JSON
+// metadata of an image. The metadata is shared with containers/image, which
+// is why all other keys have to be preserved.
+const imageUsageMetadataKey = "io.cri-o.usage"
+
+// imageUsage is the usage information persisted in the image metadata.
+type imageUsage struct {
+	// LastUsed is the last time a container has been created from the image.
+	LastUsed time.Time `json:"lastUsed"`
+	// Count is the number of containers created from the image.
+	Count uint64 `json:"count"`
+}
+
+// imageUsageFromMetadata returns the usage information contained in the
+// provided image metadata, or an empty usage if it does not exist.
+func imageUsageFromMetadata(metadata string) imageUsage {
+	usage := imageUsage{}
+	if metadata == "" {
+		return usage
+	}
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal([]byte(metadata), &fields); err != nil {
+		logrus.Debugf("Unable to parse image metadata %q: %v", metadata, err)
+		return usage
+	}
+	if raw, ok := fields[imageUsageMetadataKey]; ok {
+		if err := json.Unmarshal(raw, &usage); err != nil {
+			logrus.Debugf("Unable to parse image usage %q: %v", raw, err)
+		}
+	}
+	return usage
+}
+
+// RecordImageUsage increments the usage count of the image and sets its last
+// used time to now.
+func (svc *imageService) RecordImageUsage(id StorageImageID) error {
+	svc.imageUsageLock.Lock()
+	defer svc.imageUsageLock.Unlock()
+
+	imageID := id.IDStringForOutOfProcessConsumptionOnly()
+	metadata, err := svc.store.Metadata(imageID)
+	if err != nil {
+		return fmt.Errorf("get metadata of image %s: %w", imageID, err)
+	}
+
+	fields := map[string]json.RawMessage{}
+	if metadata != "" {
+		if err := json.Unmarshal([]byte(metadata), &fields); err != nil {
+			return fmt.Errorf("parse metadata of image %s: %w", imageID, err)
+		}
+	}
+
+	usage := imageUsageFromMetadata(metadata)
+	usage.LastUsed = time.Now()
+	usage.Count++
+	raw, err := json.Marshal(usage)
+	if err != nil {
+		return fmt.Errorf("marshal image usage: %w", err)
+	}
+	fields[imageUsageMetadataKey] = raw
+
+	updated, err := json.Marshal(fields)
+	if err != nil {
+		return fmt.Errorf("marshal metadata of image %s: %w", imageID, err)
+	}
+	if err := svc.store.SetMetadata(imageID, string(updated)); err != nil {
+		return fmt.Errorf("set metadata of image %s: %w", imageID, err)
+	}
+	return nil
+}
@@ -0,0 +1,250 @@
+package storage
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/containers/image/v5/types"
+	"github.com/cri-o/cri-o/server/metrics"
+)
+
+// PullPriority is the scheduling priority of an image pull. Pulls with a
+// higher priority get the next free slot of a registry first.
+type PullPriority int
+
+const (
+	// PullPriorityDefault is the priority of regular image pulls.
+	PullPriorityDefault PullPriority = iota
+	// PullPriorityPinned is the priority of images matching `pinned_images`.
+	PullPriorityPinned
+	// PullPrioritySandbox is the priority of the pause image.
+	PullPrioritySandbox
+)
+
+// PullFunc performs the actual image pull, which should be cancelled once ctx
+// is done.
+type PullFunc func(ctx context.Context) (types.ImageReference, error)
+
+// PullScheduler schedules image pulls within the CRI-O process.
+type PullScheduler interface {
+	// Schedule runs pull once the registry has a free pull slot. Concurrent
+	// calls for the same key share a single in-flight pull and its result.
+	// The shared pull is only cancelled once the contexts of all callers are
+	// done.
+	Schedule(ctx context.Context, key, registry string, priority PullPriority, pull PullFunc) (types.ImageReference, error)
+}
+
+// pullScheduler is the default PullScheduler, which limits the number of
+// concurrent pulls per registry.
+type pullScheduler struct {
+	// maxPerRegistry is the maximum number of concurrent pulls per registry,
+	// where zero means unlimited.
+	maxPerRegistry int
+
+	mu         sync.Mutex
+	inFlight   map[string]*pullCall
+	registries map[string]*registryQueue
+	sequence   uint64
+}
+
+// pullCall is a single in-flight pull, which can be shared between callers.
+type pullCall struct {
+	done   chan struct{}
+	ticket *pullTicket
+	ref    types.ImageReference
+	err    error
+	// waiters is the number of callers waiting for the pull.
+	waiters int
+	// cancel cancels the pull once no caller waits for it anymore.
+	cancel context.CancelFunc
+}
+
+// registryQueue tracks the running and waiting pulls of a single registry.
+type registryQueue struct {
+	running int
+	waiting pullTickets
+}
+
+// pullTicket is a pull waiting for a free registry slot.
+type pullTicket struct {
+	priority PullPriority
+	sequence uint64
+	granted  bool
+	ready    chan struct{}
+	index    int
+}
+
+// NewPullScheduler creates a new PullScheduler which runs at most
+// maxPerRegistry pulls per registry concurrently. A value of zero does not
+// limit the concurrent pulls.
+func NewPullScheduler(maxPerRegistry int) PullScheduler {
+	return &pullScheduler{
+		maxPerRegistry: maxPerRegistry,
+		inFlight:       make(map[string]*pullCall),
+		registries:     make(map[string]*registryQueue),
+	}
+}
+
+// Schedule runs pull once the registry has a free pull slot.
+func (s *pullScheduler) Schedule(ctx context.Context, key, registry string, priority PullPriority, pull PullFunc) (types.ImageReference, error) {
+	s.mu.Lock()
+	call, ok := s.inFlight[key]
+	if ok {
+		// Let the shared pull inherit the higher priority of this caller.
+		if ticket := call.ticket; ticket != nil && !ticket.granted && priority > ticket.priority {
+			ticket.priority = priority
+			heap.Fix(&s.registries[registry].waiting, ticket.index)
+		}
+	} else {
+		// The pull runs detached from the context of this caller, because
+		// callers joining later should not fail if this one is cancelled.
+		pullCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		call = &pullCall{done: make(chan struct{}), cancel: cancel}
+		s.inFlight[key] = call
+		go s.run(pullCtx, call, key, registry, priority, pull)
+	}
+	call.waiters++
+	s.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.ref, call.err
+	case <-ctx.Done():
+		s.leave(call, key)
+		return nil, ctx.Err()
+	}
+}
+
+// leave removes a waiter from the pull, which gets cancelled if it was the
+// last one.
+func (s *pullScheduler) leave(call *pullCall, key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	call.waiters--
+	if call.waiters > 0 {
+		return
+	}
+	// Subsequent callers start a new pull instead of joining the cancelled one.
+	if s.inFlight[key] == call {
+		delete(s.inFlight, key)
+	}
+	call.cancel()
+}
+
+// run waits for a free slot of the registry and runs the pull.
+func (s *pullScheduler) run(ctx context.Context, call *pullCall, key, registry string, priority PullPriority, pull PullFunc) {
+	defer func() {
+		s.mu.Lock()
+		if s.inFlight[key] == call {
+			delete(s.inFlight, key)
+		}
+		s.mu.Unlock()
+		call.cancel()
+		close(call.done)
+	}()
+
+	s.mu.Lock()
+	queue, ok := s.registries[registry]
+	if !ok {
+		queue = &registryQueue{}
+		s.registries[registry] = queue
+	}
+
+	start := time.Now()
+	if s.maxPerRegistry <= 0 || queue.running < s.maxPerRegistry {
+		queue.running++
+		s.mu.Unlock()
+	} else {
+		s.sequence++
+		call.ticket = &pullTicket{
+			priority: priority,
+			sequence: s.sequence,
+			ready:    make(chan struct{}),
+		}
+		heap.Push(&queue.waiting, call.ticket)
+		metrics.Instance().MetricImagePullsQueueDepthSet(registry, queue.waiting.Len())
+		s.mu.Unlock()
+
+		select {
+		case <-call.ticket.ready:
+		case <-ctx.Done():
+			s.mu.Lock()
+			if !call.ticket.granted {
+				heap.Remove(&queue.waiting, call.ticket.index)
+				metrics.Instance().MetricImagePullsQueueDepthSet(registry, queue.waiting.Len())
+				s.mu.Unlock()
+				call.err = ctx.Err()
+				return
+			}
+			s.mu.Unlock()
+			s.release(registry, queue)
+			call.err = ctx.Err()
+			return
+		}
+	}
+	metrics.Instance().MetricImagePullsQueueWaitObserve(registry, time.Since(start))
+
+	defer s.release(registry, queue)
+	call.ref, call.err = pull(ctx)
+}
+
+// release frees a pull slot of the registry and grants it to the waiting
+// pull with the highest priority.
+func (s *pullScheduler) release(registry string, queue *registryQueue) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	queue.running--
+	if queue.waiting.Len() > 0 {
+		ticket, ok := heap.Pop(&queue.waiting).(*pullTicket)
+		if ok {
+			ticket.granted = true
+			queue.running++
+			close(ticket.ready)
+		}
+		metrics.Instance().MetricImagePullsQueueDepthSet(registry, queue.waiting.Len())
+	}
+	if queue.running == 0 && queue.waiting.Len() == 0 {
+		delete(s.registries, registry)
+	}
+}
+
+// pullTickets implements heap.Interface, ordered by descending priority and
+// ascending sequence.
+type pullTickets []*pullTicket
+
+func (p pullTickets) Len() int { return len(p) }
+
+func (p pullTickets) Less(i, j int) bool {
+	if p[i].priority != p[j].priority {
+		return p[i].priority > p[j].priority
+	}
+	return p[i].sequence < p[j].sequence
+}
+
+func (p pullTickets) Swap(i, j int) {
+	p[i], p[j] = p[j], p[i]
+	p[i].index = i
+	p[j].index = j
+}
+
+func (p *pullTickets) Push(x any) {
+	ticket, ok := x.(*pullTicket)
+	if !ok {
+		return
+	}
+	ticket.index = len(*p)
+	*p = append(*p, ticket)
+}
+
+func (p *pullTickets) Pop() any {
+	old := *p
+	n := len(old)
+	ticket := old[n-1]
+	old[n-1] = nil
+	ticket.index = -1
+	*p = old[:n-1]
+	return ticket
+}
@@ -0,0 +1,206 @@
+package storage_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/containers/image/v5/types"
+	"github.com/cri-o/cri-o/internal/storage"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// The actual test suite
+var _ = t.Describe("PullScheduler", func() {
+	const registry = "quay.io"
+
+	blockingPull := func(started chan<- string, release <-chan struct{}, name string) storage.PullFunc {
+		return func(context.Context) (types.ImageReference, error) {
+			started <- name
+			<-release
+			return nil, nil
+		}
+	}
+
+	It("should deduplicate identical pulls in flight", func() {
+		// Given
+		sut := storage.NewPullScheduler(0)
+		var pulls atomic.Int32
+		started := make(chan string, 2)
+		release := make(chan struct{})
+		pull := func(context.Context) (types.ImageReference, error) {
+			pulls.Add(1)
+			started <- "image"
+			<-release
+			return nil, nil
+		}
+
+		// When
+		wg := sync.WaitGroup{}
+		wg.Add(1)
+		go func() {
+			defer GinkgoRecover()
+			defer wg.Done()
+			_, err := sut.Schedule(context.Background(), "key", registry, storage.PullPriorityDefault, pull)
+			Expect(err).ToNot(HaveOccurred())
+		}()
+		Eventually(started).Should(Receive())
+
+		joined := make(chan error, 1)
+		go func() {
+			_, err := sut.Schedule(context.Background(), "key", registry, storage.PullPriorityDefault, pull)
+			joined <- err
+		}()
+		Consistently(joined).ShouldNot(Receive())
+		close(release)
+		wg.Wait()
+		Eventually(joined).Should(Receive(BeNil()))
+
+		// Then
+		Expect(pulls.Load()).To(BeEquivalentTo(1))
+	})
+
+	It("should continue a deduplicated pull if the first caller gets cancelled", func() {
+		// Given
+		sut := storage.NewPullScheduler(0)
+		started := make(chan string, 1)
+		release := make(chan struct{})
+		var pullErr atomic.Value
+		pull := func(ctx context.Context) (types.ImageReference, error) {
+			started <- "image"
+			select {
+			case <-release:
+				return nil, nil
+			case <-ctx.Done():
+				pullErr.Store(ctx.Err())
+				return nil, ctx.Err()
+			}
+		}
+		leaderCtx, cancelLeader := context.WithCancel(context.Background())
+		leader := make(chan error, 1)
+		go func() {
+			_, err := sut.Schedule(leaderCtx, "key", registry, storage.PullPriorityDefault, pull)
+			leader <- err
+		}()
+		Eventually(started).Should(Receive())
+		joined := make(chan error, 1)
+		go func() {
+			_, err := sut.Schedule(context.Background(), "key", registry, storage.PullPriorityDefault, pull)
+			joined <- err
+		}()
+		Consistently(joined).ShouldNot(Receive())
+
+		// When
+		cancelLeader()
+		Eventually(leader).Should(Receive(MatchError(context.Canceled)))
+		Consistently(joined).ShouldNot(Receive())
+		close(release)
+
+		// Then
+		Eventually(joined).Should(Receive(BeNil()))
+		Expect(pullErr.Load()).To(BeNil())
+		Consistently(started).ShouldNot(Receive())
+	})
+
+	It("should cancel a deduplicated pull if all callers get cancelled", func() {
+		// Given
+		sut := storage.NewPullScheduler(0)
+		started := make(chan string, 2)
+		cancelled := make(chan error, 1)
+		pull := func(ctx context.Context) (types.ImageReference, error) {
+			started <- "image"
+			<-ctx.Done()
+			cancelled <- ctx.Err()
+			return nil, ctx.Err()
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		errs := make(chan error, 2)
+		for range 2 {
+			go func() {
+				_, err := sut.Schedule(ctx, "key", registry, storage.PullPriorityDefault, pull)
+				errs <- err
+			}()
+		}
+		Eventually(started).Should(Receive())
+
+		// When
+		cancel()
+
+		// Then
+		Eventually(errs).Should(Receive(MatchError(context.Canceled)))
+		Eventually(errs).Should(Receive(MatchError(context.Canceled)))
+		Eventually(cancelled).Should(Receive(MatchError(context.Canceled)))
+		Expect(started).ToNot(Receive())
+	})
+
+	It("should limit the concurrent pulls and prefer higher priorities", func() {
+		// Given
+		sut := storage.NewPullScheduler(1)
+		started := make(chan string, 3)
+		release := make(chan struct{})
+		schedule := func(name string, priority storage.PullPriority) {
+			defer GinkgoRecover()
+			_, err := sut.Schedule(context.Background(), name, registry, priority, blockingPull(started, release, name))
+			Expect(err).ToNot(HaveOccurred())
+		}
+
+		// When
+		go schedule("first", storage.PullPriorityDefault)
+		Eventually(started).Should(Receive(Equal("first")))
+		go schedule("default", storage.PullPriorityDefault)
+		go schedule("pause", storage.PullPrioritySandbox)
+
+		// Then
+		Consistently(started).ShouldNot(Receive())
+		release <- struct{}{}
+		Eventually(started).Should(Receive(Equal("pause")))
+		release <- struct{}{}
+		Eventually(started).Should(Receive(Equal("default")))
+		close(release)
+	})
+
+	It("should not limit pulls of different registries", func() {
+		// Given
+		sut := storage.NewPullScheduler(1)
+		started := make(chan string, 2)
+		release := make(chan struct{})
+		defer close(release)
+
+		// When
+		for _, r := range []string{"quay.io", "docker.io"} {
+			go func(r string) {
+				defer GinkgoRecover()
+				_, err := sut.Schedule(context.Background(), r, r, storage.PullPriorityDefault, blockingPull(started, release, r))
+				Expect(err).ToNot(HaveOccurred())
+			}(r)
+		}
+
+		// Then
+		Eventually(started).Should(Receive())
+		Eventually(started).Should(Receive())
+	})
+
+	It("should stop waiting on context cancellation", func() {
+		// Given
+		sut := storage.NewPullScheduler(1)
+		started := make(chan string, 1)
+		release := make(chan struct{})
+		defer close(release)
+		go func() {
+			defer GinkgoRecover()
+			_, err := sut.Schedule(context.Background(), "first", registry, storage.PullPriorityDefault, blockingPull(started, release, "first"))
+			Expect(err).ToNot(HaveOccurred())
+		}()
+		Eventually(started).Should(Receive())
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		// When
+		_, err := sut.Schedule(ctx, "second", registry, storage.PullPriorityDefault, blockingPull(started, release, "second"))
+
+		// Then
+		Expect(err).To(MatchError(context.Canceled))
+		Consistently(started).ShouldNot(Receive())
+	})
+})
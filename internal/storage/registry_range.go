@@ -0,0 +1,265 @@
+package storage
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/containers/image/v5/docker/reference"
+	"github.com/containers/image/v5/pkg/docker/config"
+	"github.com/containers/image/v5/pkg/sysregistriesv2"
+	"github.com/containers/image/v5/pkg/tlsclientconfig"
+	"github.com/containers/image/v5/types"
+	"github.com/docker/distribution/registry/client/auth/challenge"
+)
+
+const (
+	// dockerHubRegistry is the domain of the Docker Hub in references.
+	dockerHubRegistry = "docker.io"
+	// dockerHubRegistryHost is the host serving the Docker Hub registry API.
+	dockerHubRegistryHost = "registry-1.docker.io"
+)
+
+// RegistryBlobFetcher requests the data of blobs of a repository starting at
+// an offset via HTTP range requests, which are not part of the public
+// containers/image API. It uses the endpoints, credentials and certificates
+// containers/image would use for the repository.
+type RegistryBlobFetcher struct {
+	sys *types.SystemContext
+	ref reference.Named
+}
+
+// NewRegistryBlobFetcher creates a new RegistryBlobFetcher for the
+// repository of ref.
+func NewRegistryBlobFetcher(sys *types.SystemContext, ref reference.Named) *RegistryBlobFetcher {
+	return &RegistryBlobFetcher{sys: sys, ref: ref}
+}
+
+// registryEndpoint is a location the repository can be pulled from.
+type registryEndpoint struct {
+	ref      reference.Named
+	insecure bool
+}
+
+// GetBlobFrom returns the data of the blob starting at offset from the first
+// endpoint of the repository which supports range requests.
+func (f *RegistryBlobFetcher) GetBlobFrom(ctx context.Context, info types.BlobInfo, offset int64) (io.ReadCloser, error) {
+	endpoints, err := f.endpoints()
+	if err != nil {
+		return nil, err
+	}
+	var errs []error
+	for _, endpoint := range endpoints {
+		body, err := f.getBlobFrom(ctx, endpoint, info, offset)
+		if err == nil {
+			return body, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", reference.Domain(endpoint.ref), err))
+	}
+	return nil, errors.Join(errs...)
+}
+
+// endpoints returns the mirrors and the location of the repository
+// according to the registries configuration.
+func (f *RegistryBlobFetcher) endpoints() ([]registryEndpoint, error) {
+	registry, err := sysregistriesv2.FindRegistry(f.sys, f.ref.Name())
+	if err != nil {
+		return nil, fmt.Errorf("find registry: %w", err)
+	}
+	if registry == nil {
+		return []registryEndpoint{{ref: f.ref}}, nil
+	}
+	if registry.Blocked {
+		return nil, fmt.Errorf("registry %s is blocked", registry.Prefix)
+	}
+	sources, err := registry.PullSourcesFromReference(f.ref)
+	if err != nil {
+		return nil, err
+	}
+	endpoints := make([]registryEndpoint, 0, len(sources))
+	for _, source := range sources {
+		endpoints = append(endpoints, registryEndpoint{ref: source.Reference, insecure: source.Endpoint.Insecure})
+	}
+	return endpoints, nil
+}
+
+func (f *RegistryBlobFetcher) getBlobFrom(ctx context.Context, endpoint registryEndpoint, info types.BlobInfo, offset int64) (io.ReadCloser, error) {
+	host := reference.Domain(endpoint.ref)
+	if host == dockerHubRegistry {
+		host = dockerHubRegistryHost
+	}
+	insecure := endpoint.insecure || f.sys != nil && f.sys.DockerInsecureSkipTLSVerify == types.OptionalBoolTrue
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: insecure} //nolint:gosec // configured insecure registry
+	if err := f.setupCertificates(host, tlsConfig); err != nil {
+		return nil, err
+	}
+	transport := tlsclientconfig.NewTransport()
+	transport.TLSClientConfig = tlsConfig
+	client := &http.Client{Transport: transport}
+
+	schemes := []string{"https"}
+	if insecure {
+		schemes = append(schemes, "http")
+	}
+	var errs []error
+	for _, scheme := range schemes {
+		blobURL := fmt.Sprintf("%s://%s/v2/%s/blobs/%s", scheme, host, reference.Path(endpoint.ref), info.Digest)
+		body, err := f.requestRange(ctx, client, endpoint.ref, blobURL, offset)
+		if err == nil {
+			return body, nil
+		}
+		errs = append(errs, err)
+	}
+	return nil, errors.Join(errs...)
+}
+
+// setupCertificates loads the certificates for host like containers/image.
+func (f *RegistryBlobFetcher) setupCertificates(host string, tlsConfig *tls.Config) error {
+	if f.sys != nil && f.sys.DockerCertPath != "" {
+		return tlsclientconfig.SetupCertificates(f.sys.DockerCertPath, tlsConfig)
+	}
+	dirs := []string{"/etc/containers/certs.d", "/etc/docker/certs.d"}
+	if f.sys != nil && f.sys.DockerPerHostCertDirPath != "" {
+		dirs = []string{f.sys.DockerPerHostCertDirPath}
+	}
+	for _, dir := range dirs {
+		certDir := filepath.Join(dir, host)
+		if _, err := os.Stat(certDir); err != nil {
+			continue
+		}
+		if err := tlsclientconfig.SetupCertificates(certDir, tlsConfig); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// requestRange requests the blob at blobURL starting at offset, and
+// authorizes the request if the registry challenges it.
+func (f *RegistryBlobFetcher) requestRange(ctx context.Context, client *http.Client, ref reference.Named, blobURL string, offset int64) (io.ReadCloser, error) {
+	resp, err := f.do(ctx, client, blobURL, offset, "")
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		authorization, err := f.authorize(ctx, client, ref, resp)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("authorize: %w", err)
+		}
+		if resp, err = f.do(ctx, client, blobURL, offset, authorization); err != nil {
+			return nil, err
+		}
+	}
+
+	if resp.StatusCode != http.StatusPartialContent {
+		resp.Body.Close()
+		return nil, fmt.Errorf("range request returned status %s", resp.Status)
+	}
+	if contentRange := resp.Header.Get("Content-Range"); !strings.HasPrefix(contentRange, "bytes "+strconv.FormatInt(offset, 10)+"-") {
+		resp.Body.Close()
+		return nil, fmt.Errorf("range request returned unexpected range %q", contentRange)
+	}
+	return resp.Body, nil
+}
+
+func (f *RegistryBlobFetcher) do(ctx context.Context, client *http.Client, blobURL string, offset int64, authorization string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, blobURL, http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	return client.Do(req)
+}
+
+// authorize returns the Authorization header answering the challenges of the
+// response, using the credentials configured for ref.
+func (f *RegistryBlobFetcher) authorize(ctx context.Context, client *http.Client, ref reference.Named, resp *http.Response) (string, error) {
+	creds, err := config.GetCredentialsForRef(f.sys, ref)
+	if err != nil {
+		return "", fmt.Errorf("get credentials: %w", err)
+	}
+	if creds.IdentityToken != "" {
+		return "", errors.New("identity tokens are not supported")
+	}
+
+	for _, c := range challenge.ResponseChallenges(resp) {
+		switch strings.ToLower(c.Scheme) {
+		case "basic":
+			if creds.Username == "" {
+				continue
+			}
+			req := &http.Request{Header: http.Header{}}
+			req.SetBasicAuth(creds.Username, creds.Password)
+			return req.Header.Get("Authorization"), nil
+		case "bearer":
+			token, err := f.bearerToken(ctx, client, ref, c.Parameters, &creds)
+			if err != nil {
+				return "", err
+			}
+			return "Bearer " + token, nil
+		}
+	}
+	return "", errors.New("no supported authentication challenge")
+}
+
+// bearerToken requests a token to pull from the repository of ref.
+func (f *RegistryBlobFetcher) bearerToken(ctx context.Context, client *http.Client, ref reference.Named, params map[string]string, creds *types.DockerAuthConfig) (string, error) {
+	realm, err := url.Parse(params["realm"])
+	if err != nil || realm.Host == "" {
+		return "", fmt.Errorf("invalid token realm %q", params["realm"])
+	}
+	query := realm.Query()
+	if service := params["service"]; service != "" {
+		query.Set("service", service)
+	}
+	query.Set("scope", "repository:"+reference.Path(ref)+":pull")
+	realm.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), http.NoBody)
+	if err != nil {
+		return "", err
+	}
+	if creds.Username != "" {
+		req.SetBasicAuth(creds.Username, creds.Password)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token request returned status %s", resp.Status)
+	}
+
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("decode token: %w", err)
+	}
+	if token.Token != "" {
+		return token.Token, nil
+	}
+	if token.AccessToken != "" {
+		return token.AccessToken, nil
+	}
+	return "", errors.New("token response contains no token")
+}
@@ -0,0 +1,116 @@
+package storage_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/containers/image/v5/docker/reference"
+	"github.com/containers/image/v5/types"
+	"github.com/cri-o/cri-o/internal/storage"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	digest "github.com/opencontainers/go-digest"
+)
+
+// The actual test suite
+var _ = t.Describe("RegistryBlobFetcher", func() {
+	var (
+		blob         []byte
+		info         types.BlobInfo
+		server       *httptest.Server
+		supportRange bool
+		sys          *types.SystemContext
+		ref          reference.Named
+	)
+
+	BeforeEach(func() {
+		blob = bytes.Repeat([]byte("0123456789"), 10)
+		info = types.BlobInfo{Digest: digest.FromBytes(blob), Size: int64(len(blob))}
+		supportRange = true
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+			if user, pass, ok := r.BasicAuth(); !ok || user != "user" || pass != "pass" ||
+				r.URL.Query().Get("scope") != "repository:crio/test:pull" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			fmt.Fprint(w, `{"token": "secret"}`)
+		})
+		mux.HandleFunc("/v2/crio/test/blobs/"+info.Digest.String(), func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer secret" {
+				w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="registry"`, server.URL))
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			if !supportRange {
+				r.Header.Del("Range")
+			}
+			http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(blob))
+		})
+		server = httptest.NewServer(mux)
+		DeferCleanup(server.Close)
+
+		host := strings.TrimPrefix(server.URL, "http://")
+		dir := t.MustTempDir("registries")
+		registriesConf := filepath.Join(dir, "registries.conf")
+		Expect(os.WriteFile(registriesConf, []byte(fmt.Sprintf("[[registry]]\nlocation = %q\ninsecure = true\n", host)), 0o600)).To(Succeed())
+		sys = &types.SystemContext{
+			SystemRegistriesConfPath:    registriesConf,
+			SystemRegistriesConfDirPath: filepath.Join(dir, "registries.conf.d"),
+			DockerPerHostCertDirPath:    filepath.Join(dir, "certs.d"),
+			DockerAuthConfig:            &types.DockerAuthConfig{Username: "user", Password: "pass"},
+		}
+
+		var err error
+		ref, err = reference.ParseNormalizedNamed(host + "/crio/test:latest")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("should get a blob starting at an offset", func() {
+		// Given
+		sut := storage.NewRegistryBlobFetcher(sys, ref)
+
+		// When
+		body, err := sut.GetBlobFrom(context.Background(), info, 30)
+
+		// Then
+		Expect(err).ToNot(HaveOccurred())
+		defer body.Close()
+		data, err := io.ReadAll(body)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(data).To(Equal(blob[30:]))
+	})
+
+	It("should fail if the registry does not support range requests", func() {
+		// Given
+		supportRange = false
+		sut := storage.NewRegistryBlobFetcher(sys, ref)
+
+		// When
+		_, err := sut.GetBlobFrom(context.Background(), info, 30)
+
+		// Then
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should fail without valid credentials", func() {
+		// Given
+		sys.DockerAuthConfig = &types.DockerAuthConfig{Username: "user", Password: "wrong"}
+		sut := storage.NewRegistryBlobFetcher(sys, ref)
+
+		// When
+		_, err := sut.GetBlobFrom(context.Background(), info, 30)
+
+		// Then
+		Expect(err).To(HaveOccurred())
+	})
+})
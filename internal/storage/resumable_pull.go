@@ -0,0 +1,328 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/containers/image/v5/manifest"
+	"github.com/containers/image/v5/pkg/blobcache"
+	"github.com/containers/image/v5/pkg/blobinfocache/none"
+	"github.com/containers/image/v5/types"
+	"github.com/containers/storage"
+	"github.com/containers/storage/pkg/chunked/toc"
+	digest "github.com/opencontainers/go-digest"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// partialBlobsDirName is the directory in the storage root keeping the
+	// downloaded blobs of resumable pulls.
+	partialBlobsDirName = "crio-partial-blobs"
+
+	// partialBlobPrefix is the prefix of the files in the partial blobs
+	// directory keeping the partially downloaded blobs, which are named by
+	// their digest.
+	partialBlobPrefix = "partial-"
+
+	// partialBlobMaxAge is the duration after which the blobs of pulls which
+	// have not been touched are removed.
+	partialBlobMaxAge = 24 * time.Hour
+
+	// partialBlobCleanupInterval is the interval of removing stale blobs.
+	partialBlobCleanupInterval = time.Hour
+)
+
+// NewResumableDestination wraps the destination reference of a pull of
+// imageName in a blob cache in a directory per image below dir. Every
+// completely downloaded or staged blob is kept in the cache, where a
+// subsequent pull of the image, for example after the previous pull got
+// cancelled, reuses it instead of downloading it again. The directory of the
+// cache should be removed once the image has been pulled successfully.
+func NewResumableDestination(destRef types.ImageReference, dir string, imageName RegistryImageReference) (*blobcache.BlobCache, error) {
+	cacheDir := filepath.Join(dir, digest.FromString(imageName.StringForOutOfProcessConsumptionOnly()).Encoded())
+	if err := os.MkdirAll(cacheDir, 0o700); err != nil {
+		return nil, fmt.Errorf("create partial blobs directory: %w", err)
+	}
+	return blobcache.NewBlobCache(destRef, cacheDir, types.PreserveOriginal)
+}
+
+// BlobRangeGetter returns the data of a blob starting at an offset.
+type BlobRangeGetter interface {
+	GetBlobFrom(ctx context.Context, info types.BlobInfo, offset int64) (io.ReadCloser, error)
+}
+
+// PartialBlobStager downloads the layers of an image into the blob cache of a
+// resumable pull before the image gets copied. Every download is staged in a
+// file keyed by the digest of the blob, where a subsequent pull resumes it via
+// a range request after the previous pull failed or got cancelled. Staged
+// blobs are verified against their digest before they get moved to the cache.
+type PartialBlobStager struct {
+	dir              string
+	cache            *blobcache.BlobCache
+	ranges           BlobRangeGetter
+	progress         chan<- types.ProgressProperties
+	progressInterval time.Duration
+}
+
+// NewPartialBlobStager creates a new PartialBlobStager, which stages the blobs
+// in dir and moves them to cache once they are complete. ranges is used to
+// resume downloads and can be nil if the source does not support them. The
+// progress of the downloads gets reported to progress every progressInterval,
+// if progress is not nil.
+func NewPartialBlobStager(dir string, cache *blobcache.BlobCache, ranges BlobRangeGetter, progress chan<- types.ProgressProperties, progressInterval time.Duration) *PartialBlobStager {
+	if progressInterval <= 0 {
+		progressInterval = time.Second
+	}
+	return &PartialBlobStager{
+		dir:              dir,
+		cache:            cache,
+		ranges:           ranges,
+		progress:         progress,
+		progressInterval: progressInterval,
+	}
+}
+
+// StageLayers stages the layers of the image of src for the platform of sys.
+// Layers which are already in the store, external or which support partial
+// pulls are left to the copy of the image. A layer which cannot be staged for
+// other reasons than a cancellation is left to the copy as well.
+func (s *PartialBlobStager) StageLayers(ctx context.Context, sys *types.SystemContext, src types.ImageSource, store storage.Store) error {
+	layers, err := imageLayers(ctx, sys, src)
+	if err != nil {
+		return err
+	}
+	for _, layer := range layers {
+		if len(layer.URLs) != 0 || layer.Digest.Validate() != nil {
+			continue
+		}
+		if tocDigest, err := toc.GetTOCDigest(layer.Annotations); err != nil || tocDigest != nil {
+			continue
+		}
+		if store != nil {
+			if layers, err := store.LayersByCompressedDigest(layer.Digest); err == nil && len(layers) > 0 {
+				continue
+			}
+		}
+		if err := s.StageBlob(ctx, src, layer.BlobInfo); err != nil {
+			if ctx.Err() != nil {
+				return err
+			}
+			logrus.Warnf("Unable to stage blob %s, leaving it to the image copy: %v", layer.Digest, err)
+		}
+	}
+	return nil
+}
+
+// imageLayers returns the layers of the image of src, choosing the instance
+// for the platform of sys if the image is a manifest list.
+func imageLayers(ctx context.Context, sys *types.SystemContext, src types.ImageSource) ([]manifest.LayerInfo, error) {
+	manifestBlob, mimeType, err := src.GetManifest(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("get manifest: %w", err)
+	}
+	if manifest.MIMETypeIsMultiImage(mimeType) {
+		list, err := manifest.ListFromBlob(manifestBlob, mimeType)
+		if err != nil {
+			return nil, fmt.Errorf("parse manifest list: %w", err)
+		}
+		instance, err := list.ChooseInstance(sys)
+		if err != nil {
+			return nil, fmt.Errorf("choose manifest instance: %w", err)
+		}
+		manifestBlob, mimeType, err = src.GetManifest(ctx, &instance)
+		if err != nil {
+			return nil, fmt.Errorf("get manifest %s: %w", instance, err)
+		}
+	}
+	m, err := manifest.FromBlob(manifestBlob, mimeType)
+	if err != nil {
+		return nil, fmt.Errorf("parse manifest: %w", err)
+	}
+	return m.LayerInfos(), nil
+}
+
+// StageBlob downloads the blob from src into the cache, resuming a previously
+// staged download if possible.
+func (s *PartialBlobStager) StageBlob(ctx context.Context, src types.ImageSource, info types.BlobInfo) error {
+	if cached, _, err := s.cache.HasBlob(info); err != nil || cached {
+		return err
+	}
+
+	path := filepath.Join(s.dir, partialBlobPrefix+info.Digest.Algorithm().String()+"-"+info.Digest.Encoded())
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return fmt.Errorf("open partial blob: %w", err)
+	}
+	defer file.Close()
+	if err := unix.Flock(int(file.Fd()), unix.LOCK_EX|unix.LOCK_NB); err != nil {
+		logrus.Debugf("Blob %s is staged by another pull", info.Digest)
+		return nil
+	}
+
+	// Digest the staged data, which leaves the file at its end to append the
+	// remaining data.
+	digester := info.Digest.Algorithm().Digester()
+	offset, err := io.Copy(digester.Hash(), file)
+	if err != nil {
+		return fmt.Errorf("read partial blob: %w", err)
+	}
+	restart := func() error {
+		digester = info.Digest.Algorithm().Digester()
+		offset = 0
+		if err := file.Truncate(0); err != nil {
+			return fmt.Errorf("truncate partial blob: %w", err)
+		}
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return fmt.Errorf("seek partial blob: %w", err)
+		}
+		return nil
+	}
+	if info.Size >= 0 && offset > info.Size {
+		if err := restart(); err != nil {
+			return err
+		}
+	}
+
+	var body io.ReadCloser
+	if offset > 0 && offset != info.Size {
+		if s.ranges != nil {
+			body, err = s.ranges.GetBlobFrom(ctx, info, offset)
+		} else {
+			err = errors.New("image source does not support range requests")
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			logrus.Infof("Unable to resume the download of blob %s at offset %d, downloading it again: %v", info.Digest, offset, err)
+			if err := restart(); err != nil {
+				return err
+			}
+		} else {
+			logrus.Infof("Resuming the download of blob %s at offset %d", info.Digest, offset)
+		}
+	}
+	if offset == 0 {
+		body, _, err = src.GetBlob(ctx, info, none.NoCache)
+		if err != nil {
+			return fmt.Errorf("get blob: %w", err)
+		}
+	}
+
+	if body != nil {
+		defer body.Close()
+		progress := s.newProgress(info, offset)
+		if _, err := io.Copy(io.MultiWriter(file, digester.Hash(), progress), body); err != nil {
+			return fmt.Errorf("download blob: %w", err)
+		}
+		progress.done()
+	}
+
+	if digester.Digest() != info.Digest {
+		// Start over on the next pull instead of resuming corrupted data.
+		if err := os.Remove(path); err != nil {
+			logrus.Warnf("Unable to remove partial blob %s: %v", info.Digest, err)
+		}
+		return fmt.Errorf("staged blob does not match digest %s", info.Digest)
+	}
+	if err := os.Rename(path, filepath.Join(s.cache.Directory(), info.Digest.String())); err != nil {
+		return fmt.Errorf("move staged blob into cache: %w", err)
+	}
+	return nil
+}
+
+// stageProgress reports the progress of a staged download.
+type stageProgress struct {
+	stager   *PartialBlobStager
+	artifact types.BlobInfo
+	offset   uint64
+	update   uint64
+	last     time.Time
+}
+
+func (s *PartialBlobStager) newProgress(info types.BlobInfo, offset int64) *stageProgress {
+	p := &stageProgress{stager: s, artifact: info, offset: uint64(offset), last: time.Now()}
+	p.send(types.ProgressEventNewArtifact)
+	return p
+}
+
+func (p *stageProgress) Write(b []byte) (int, error) {
+	p.offset += uint64(len(b))
+	p.update += uint64(len(b))
+	if time.Since(p.last) >= p.stager.progressInterval {
+		p.send(types.ProgressEventRead)
+		p.last = time.Now()
+	}
+	return len(b), nil
+}
+
+func (p *stageProgress) done() {
+	p.send(types.ProgressEventDone)
+}
+
+func (p *stageProgress) send(event types.ProgressEvent) {
+	if p.stager.progress == nil {
+		return
+	}
+	p.stager.progress <- types.ProgressProperties{
+		Event:        event,
+		Artifact:     p.artifact,
+		Offset:       p.offset,
+		OffsetUpdate: p.update,
+	}
+	p.update = 0
+}
+
+// RemoveStalePartialBlobs removes the partial blobs and the blob caches of
+// pulls in dir which have not been modified for maxAge.
+func RemoveStalePartialBlobs(dir string, maxAge time.Duration) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	var errs []error
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			if !os.IsNotExist(err) {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		if time.Since(info.ModTime()) < maxAge {
+			continue
+		}
+		logrus.Infof("Removing stale partial blobs %s", entry.Name())
+		if err := os.RemoveAll(filepath.Join(dir, entry.Name())); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// removeStalePartialBlobsPeriodically removes the stale blobs of resumable
+// pulls every interval until ctx is done.
+func removeStalePartialBlobsPeriodically(ctx context.Context, dir string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := RemoveStalePartialBlobs(dir, partialBlobMaxAge); err != nil {
+			logrus.Warnf("Unable to remove stale partial blobs: %v", err)
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
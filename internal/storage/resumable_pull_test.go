@@ -0,0 +1,312 @@
+package storage_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing/iotest"
+	"time"
+
+	"github.com/containers/image/v5/directory"
+	"github.com/containers/image/v5/pkg/blobcache"
+	"github.com/containers/image/v5/pkg/blobinfocache/none"
+	"github.com/containers/image/v5/types"
+	"github.com/cri-o/cri-o/internal/storage"
+	"github.com/cri-o/cri-o/internal/storage/references"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	digest "github.com/opencontainers/go-digest"
+	imgspecs "github.com/opencontainers/image-spec/specs-go"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// The actual test suite
+var _ = t.Describe("ResumableDestination", func() {
+	var (
+		dir  string
+		blob []byte
+		info types.BlobInfo
+	)
+
+	BeforeEach(func() {
+		dir = filepath.Join(t.MustTempDir("crio"), "partial-blobs")
+		blob = bytes.Repeat([]byte("0123456789"), 10)
+		info = types.BlobInfo{Digest: digest.FromBytes(blob), Size: int64(len(blob))}
+	})
+
+	imageName := func(name string) storage.RegistryImageReference {
+		ref, err := references.ParseRegistryImageReferenceFromOutOfProcessData(name)
+		Expect(err).ToNot(HaveOccurred())
+		return ref
+	}
+
+	newDestination := func(name string) (types.ImageDestination, string) {
+		destRef, err := directory.NewReference(t.MustTempDir("dest"))
+		Expect(err).ToNot(HaveOccurred())
+		cache, err := storage.NewResumableDestination(destRef, dir, imageName(name))
+		Expect(err).ToNot(HaveOccurred())
+		dest, err := cache.NewImageDestination(context.Background(), &types.SystemContext{})
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(dest.Close)
+		return dest, cache.Directory()
+	}
+
+	It("should use a cache directory per image", func() {
+		// Given
+		// When
+		_, first := newDestination("quay.io/crio/alpine:3.9")
+		_, again := newDestination("quay.io/crio/alpine:3.9")
+		_, other := newDestination("quay.io/crio/pause:latest")
+
+		// Then
+		Expect(filepath.Dir(first)).To(Equal(dir))
+		Expect(first).To(BeADirectory())
+		Expect(again).To(Equal(first))
+		Expect(other).ToNot(Equal(first))
+	})
+
+	It("should reuse the blobs of a cancelled pull", func() {
+		// Given
+		dest, _ := newDestination("quay.io/crio/alpine:3.9")
+		_, err := dest.PutBlob(context.Background(), bytes.NewReader(blob), info, none.NoCache, false)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(dest.Close()).To(Succeed())
+
+		// When
+		dest, _ = newDestination("quay.io/crio/alpine:3.9")
+		reused, _, err := dest.TryReusingBlob(context.Background(), info, none.NoCache, false)
+
+		// Then
+		Expect(err).ToNot(HaveOccurred())
+		Expect(reused).To(BeTrue())
+	})
+
+	It("should not reuse the blobs of other images", func() {
+		// Given
+		dest, _ := newDestination("quay.io/crio/alpine:3.9")
+		_, err := dest.PutBlob(context.Background(), bytes.NewReader(blob), info, none.NoCache, false)
+		Expect(err).ToNot(HaveOccurred())
+
+		// When
+		dest, _ = newDestination("quay.io/crio/pause:latest")
+		reused, _, err := dest.TryReusingBlob(context.Background(), info, none.NoCache, false)
+
+		// Then
+		Expect(err).ToNot(HaveOccurred())
+		Expect(reused).To(BeFalse())
+	})
+
+	It("should remove stale partial blobs", func() {
+		// Given
+		stale := filepath.Join(dir, "stale")
+		fresh := filepath.Join(dir, "fresh")
+		Expect(os.MkdirAll(stale, 0o700)).To(Succeed())
+		Expect(os.MkdirAll(fresh, 0o700)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(stale, "blob"), []byte("stale"), 0o600)).To(Succeed())
+		old := time.Now().Add(-2 * time.Hour)
+		Expect(os.Chtimes(stale, old, old)).To(Succeed())
+
+		// When
+		err := storage.RemoveStalePartialBlobs(dir, time.Hour)
+
+		// Then
+		Expect(err).ToNot(HaveOccurred())
+		Expect(stale).ToNot(BeADirectory())
+		Expect(fresh).To(BeADirectory())
+	})
+
+	It("should succeed to remove stale partial blobs without directory", func() {
+		// Given
+		// When
+		err := storage.RemoveStalePartialBlobs(dir, time.Hour)
+
+		// Then
+		Expect(err).ToNot(HaveOccurred())
+	})
+})
+
+// fakeImageSource serves the manifest and the blobs of an image, optionally
+// failing after failAfter bytes of a blob.
+type fakeImageSource struct {
+	types.ImageSource
+	manifest  []byte
+	blobs     map[digest.Digest][]byte
+	failAfter int
+	getBlobs  int
+}
+
+func (s *fakeImageSource) GetManifest(context.Context, *digest.Digest) (manifestBlob []byte, mimeType string, err error) {
+	return s.manifest, specs.MediaTypeImageManifest, nil
+}
+
+func (s *fakeImageSource) GetBlob(_ context.Context, info types.BlobInfo, _ types.BlobInfoCache) (io.ReadCloser, int64, error) {
+	s.getBlobs++
+	blob, ok := s.blobs[info.Digest]
+	if !ok {
+		return nil, -1, errors.New("blob unknown")
+	}
+	if s.failAfter > 0 {
+		return io.NopCloser(io.MultiReader(bytes.NewReader(blob[:s.failAfter]), iotest.ErrReader(errors.New("connection reset")))), -1, nil
+	}
+	return io.NopCloser(bytes.NewReader(blob)), int64(len(blob)), nil
+}
+
+// fakeBlobRangeGetter serves a blob starting at the requested offset.
+type fakeBlobRangeGetter struct {
+	blob   []byte
+	err    error
+	offset int64
+}
+
+func (g *fakeBlobRangeGetter) GetBlobFrom(_ context.Context, _ types.BlobInfo, offset int64) (io.ReadCloser, error) {
+	g.offset = offset
+	if g.err != nil {
+		return nil, g.err
+	}
+	return io.NopCloser(bytes.NewReader(g.blob[offset:])), nil
+}
+
+var _ = t.Describe("PartialBlobStager", func() {
+	var (
+		dir    string
+		blob   []byte
+		info   types.BlobInfo
+		cache  *blobcache.BlobCache
+		src    *fakeImageSource
+		ranges *fakeBlobRangeGetter
+	)
+
+	BeforeEach(func() {
+		dir = filepath.Join(t.MustTempDir("crio"), "partial-blobs")
+		blob = bytes.Repeat([]byte("0123456789"), 10)
+		info = types.BlobInfo{Digest: digest.FromBytes(blob), Size: int64(len(blob))}
+
+		destRef, err := directory.NewReference(t.MustTempDir("dest"))
+		Expect(err).ToNot(HaveOccurred())
+		imageName, err := references.ParseRegistryImageReferenceFromOutOfProcessData("quay.io/crio/alpine:3.9")
+		Expect(err).ToNot(HaveOccurred())
+		cache, err = storage.NewResumableDestination(destRef, dir, imageName)
+		Expect(err).ToNot(HaveOccurred())
+
+		src = &fakeImageSource{blobs: map[digest.Digest][]byte{info.Digest: blob}}
+		ranges = &fakeBlobRangeGetter{blob: blob}
+	})
+
+	partialBlob := func() string {
+		return filepath.Join(dir, "partial-sha256-"+info.Digest.Encoded())
+	}
+
+	isCached := func() bool {
+		cached, _, err := cache.HasBlob(info)
+		Expect(err).ToNot(HaveOccurred())
+		return cached
+	}
+
+	It("should stage a blob into the cache", func() {
+		// Given
+		progress := make(chan types.ProgressProperties, 10)
+		sut := storage.NewPartialBlobStager(dir, cache, ranges, progress, 0)
+
+		// When
+		err := sut.StageBlob(context.Background(), src, info)
+
+		// Then
+		Expect(err).ToNot(HaveOccurred())
+		Expect(isCached()).To(BeTrue())
+		Expect(partialBlob()).ToNot(BeAnExistingFile())
+		Expect(progress).To(Receive(HaveField("Event", types.ProgressEventNewArtifact)))
+		Expect(progress).To(Receive(And(
+			HaveField("Event", types.ProgressEventDone),
+			HaveField("Offset", uint64(len(blob))),
+		)))
+	})
+
+	It("should resume an interrupted download with a range request", func() {
+		// Given
+		sut := storage.NewPartialBlobStager(dir, cache, ranges, nil, 0)
+		src.failAfter = 30
+		Expect(sut.StageBlob(context.Background(), src, info)).NotTo(Succeed())
+		Expect(partialBlob()).To(BeAnExistingFile())
+		Expect(isCached()).To(BeFalse())
+		src.failAfter = 0
+		src.getBlobs = 0
+
+		// When
+		err := sut.StageBlob(context.Background(), src, info)
+
+		// Then
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ranges.offset).To(BeEquivalentTo(30))
+		Expect(src.getBlobs).To(BeZero())
+		Expect(isCached()).To(BeTrue())
+	})
+
+	It("should download the blob again if the range request fails", func() {
+		// Given
+		sut := storage.NewPartialBlobStager(dir, cache, ranges, nil, 0)
+		src.failAfter = 30
+		Expect(sut.StageBlob(context.Background(), src, info)).NotTo(Succeed())
+		src.failAfter = 0
+		ranges.err = errors.New("range not satisfiable")
+
+		// When
+		err := sut.StageBlob(context.Background(), src, info)
+
+		// Then
+		Expect(err).ToNot(HaveOccurred())
+		Expect(src.getBlobs).To(Equal(2))
+		Expect(isCached()).To(BeTrue())
+	})
+
+	It("should fail and remove a partial blob not matching its digest", func() {
+		// Given
+		sut := storage.NewPartialBlobStager(dir, cache, ranges, nil, 0)
+		Expect(os.WriteFile(partialBlob(), []byte("corrupted"), 0o600)).To(Succeed())
+
+		// When
+		err := sut.StageBlob(context.Background(), src, info)
+
+		// Then
+		Expect(err).To(HaveOccurred())
+		Expect(partialBlob()).ToNot(BeAnExistingFile())
+		Expect(isCached()).To(BeFalse())
+	})
+
+	It("should only stage layers without partial pull support", func() {
+		// Given
+		chunked := []byte("chunked")
+		chunkedInfo := types.BlobInfo{Digest: digest.FromBytes(chunked), Size: int64(len(chunked))}
+		src.blobs[chunkedInfo.Digest] = chunked
+		manifestBlob, err := json.Marshal(specs.Manifest{
+			Versioned: imgspecs.Versioned{SchemaVersion: 2},
+			MediaType: specs.MediaTypeImageManifest,
+			Config:    specs.Descriptor{MediaType: specs.MediaTypeImageConfig, Digest: digest.FromString("{}"), Size: 2},
+			Layers: []specs.Descriptor{
+				{MediaType: specs.MediaTypeImageLayerGzip, Digest: info.Digest, Size: info.Size},
+				{
+					MediaType:   specs.MediaTypeImageLayerGzip,
+					Digest:      chunkedInfo.Digest,
+					Size:        chunkedInfo.Size,
+					Annotations: map[string]string{"containerd.io/snapshot/stargz/toc.digest": digest.FromString("toc").String()},
+				},
+			},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		src.manifest = manifestBlob
+		sut := storage.NewPartialBlobStager(dir, cache, ranges, nil, 0)
+
+		// When
+		err = sut.StageLayers(context.Background(), &types.SystemContext{}, src, nil)
+
+		// Then
+		Expect(err).ToNot(HaveOccurred())
+		Expect(isCached()).To(BeTrue())
+		cached, _, err := cache.HasBlob(chunkedInfo)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cached).To(BeFalse())
+	})
+})
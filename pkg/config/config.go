@@ -15,8 +15,10 @@ import (
 	"time"
 
 	"github.com/BurntSushi/toml"
+	"github.com/containers/common/pkg/crutils"
 	"github.com/containers/common/pkg/hooks"
 	conmonconfig "github.com/containers/conmon/runner/config"
+	"github.com/containers/image/v5/pkg/compression"
 	"github.com/containers/image/v5/pkg/sysregistriesv2"
 	"github.com/containers/image/v5/types"
 	"github.com/containers/storage"
@@ -35,6 +37,7 @@ import (
 	"github.com/cri-o/cri-o/internal/config/ulimits"
 	"github.com/cri-o/cri-o/internal/storage/references"
 	"github.com/cri-o/cri-o/pkg/annotations"
+	crioTypes "github.com/cri-o/cri-o/pkg/types"
 	"github.com/cri-o/cri-o/server/otel-collector/collectors"
 	"github.com/cri-o/cri-o/server/useragent"
 	"github.com/cri-o/cri-o/utils"
@@ -61,6 +64,9 @@ const (
 	tasksetBinary              = "taskset"
 	MonitorExecCgroupDefault   = ""
 	MonitorExecCgroupContainer = "container"
+
+	defaultRuntimeHealthCheckInterval = "1m"
+	defaultPullProgressTimeout        = "10s"
 )
 
 // Config represents the entire set of configuration values that can be set for
@@ -192,6 +198,7 @@ func (c *RootConfig) GetStore() (storage.Store, error) {
 // runtimeHandlerFeatures represents the supported features of the runtime.
 type runtimeHandlerFeatures struct {
 	RecursiveReadOnlyMounts bool `json:"-"` // Internal use only.
+	CheckpointRestore       bool `json:"-"` // Internal use only.
 	features.Features
 }
 
@@ -247,6 +254,11 @@ type RuntimeHandler struct {
 	// ContainerMinMemory is the minimum memory that must be set for a container.
 	ContainerMinMemory string `toml:"container_min_memory,omitempty"`
 
+	// CheckpointRestore marks a VM runtime as supporting checkpoint and
+	// restore, because its shim implements the Checkpoint task API. The
+	// other runtime types are probed for support instead.
+	CheckpointRestore bool `toml:"checkpoint_restore,omitempty"`
+
 	// Output of the "features" subcommand.
 	// This is populated dynamically and not read from config.
 	features runtimeHandlerFeatures
@@ -412,6 +424,25 @@ type RuntimeConfig struct {
 	// to checkpoint and restore containers
 	EnableCriuSupport bool `toml:"enable_criu_support"`
 
+	// IncrementalCheckpointChainLength is the maximum number of incremental
+	// checkpoints of a container which keeps running, before a new chain
+	// gets started with a full memory dump. 0 disables incremental
+	// checkpoints.
+	IncrementalCheckpointChainLength int `toml:"incremental_checkpoint_chain_length"`
+
+	// CheckpointImages stores container checkpoints additionally as OCI
+	// images in the local storage.
+	CheckpointImages bool `toml:"checkpoint_images"`
+
+	// CheckpointImageCompression is the compression of checkpoint images,
+	// either "gzip" or "zstd".
+	CheckpointImageCompression string `toml:"checkpoint_image_compression"`
+
+	// RuntimeHealthCheckInterval is the interval of the health checks of the
+	// runtime handlers. New sandboxes are rejected for unhealthy runtime
+	// handlers. An empty value disables the health checks.
+	RuntimeHealthCheckInterval string `toml:"runtime_health_check_interval"`
+
 	// Runtimes defines a list of OCI compatible runtimes. The runtime to
 	// use is picked based on the runtime_handler provided by the CRI. If
 	// no runtime_handler is provided, the runtime will be picked based on
@@ -523,6 +554,9 @@ type ImageConfig struct {
 	// Pinned images will remain in the container runtime's storage until
 	// they are manually removed. Default value: empty list (no images pinned)
 	PinnedImages []string `toml:"pinned_images"`
+	// PrePullPinnedImages pulls the pinned images without glob or keyword
+	// pattern and the pause image in the background after startup.
+	PrePullPinnedImages bool `toml:"pre_pull_pinned_images"`
 	// SignaturePolicyPath is the name of the file which decides what sort
 	// of policy we use when deciding whether or not to trust an image that
 	// we've pulled.  Outside of testing situations, it is strongly advised
@@ -537,6 +571,15 @@ type ImageConfig struct {
 	// SignaturePolicyPath or system wide policy will be used as fallback.
 	// Must be an absolute path.
 	SignaturePolicyDir string `toml:"signature_policy_dir"`
+	// NamespacedRegistriesDir is the root path for pod namespace-separated
+	// registries configurations and credentials. Image pulls for a pod
+	// namespace use the <NAMESPACED_REGISTRIES_DIR>/<NAMESPACE>/registries.conf
+	// file together with its registries.conf.d drop-in directory instead of
+	// the system wide registries configuration, and the
+	// <NAMESPACED_REGISTRIES_DIR>/<NAMESPACE>/auth.json file instead of the
+	// GlobalAuthFile, if they exist. An empty value disables the lookup.
+	// Must be an absolute path.
+	NamespacedRegistriesDir string `toml:"namespaced_registries_dir"`
 	// InsecureRegistries is a list of registries that must be contacted w/o
 	// TLS verification.
 	InsecureRegistries []string `toml:"insecure_registries"`
@@ -548,6 +591,37 @@ type ImageConfig struct {
 	// reload the mirror registry when there is an update to the
 	// 'registries.conf.d' directory.
 	AutoReloadRegistries bool `toml:"auto_reload_registries"`
+	// MaxConcurrentPullsPerRegistry is the maximum number of image pulls
+	// running in parallel for a single registry. Further pulls are queued,
+	// where the pause image and pinned images are preferred. A value of 0
+	// does not limit the number of parallel pulls.
+	MaxConcurrentPullsPerRegistry int `toml:"max_concurrent_pulls_per_registry"`
+	// PullProgressTimeout is the duration after which an image pull gets
+	// cancelled if it did not make any progress. An empty value disables the
+	// timeout.
+	PullProgressTimeout string `toml:"pull_progress_timeout"`
+	// ResumablePulls keeps the downloaded image layers of failed or
+	// cancelled pulls in the storage root, where subsequent pulls of the
+	// same image reuse or resume them.
+	ResumablePulls bool `toml:"resumable_pulls"`
+	// ImageGCInterval is the interval of the built-in image garbage
+	// collection. An empty value disables the garbage collection.
+	ImageGCInterval string `toml:"image_gc_interval"`
+	// ImageGCMaxUnusedAge is the duration after which images not used by any
+	// container get removed. An empty value disables this policy.
+	ImageGCMaxUnusedAge string `toml:"image_gc_max_unused_age"`
+	// ImageGCMaxImages is the maximum number of images kept in the storage.
+	// The least recently used images get removed first. A value of 0
+	// disables this policy.
+	ImageGCMaxImages int `toml:"image_gc_max_images"`
+	// ImageGCHighThresholdPercent is the image filesystem usage in percent
+	// which triggers the removal of the least recently used images. A value
+	// of 0 disables this policy.
+	ImageGCHighThresholdPercent int `toml:"image_gc_high_threshold_percent"`
+	// ImageGCLowThresholdPercent is the image filesystem usage in percent
+	// the garbage collection aims for once ImageGCHighThresholdPercent has
+	// been reached.
+	ImageGCLowThresholdPercent int `toml:"image_gc_low_threshold_percent"`
 }
 
 // NetworkConfig represents the "crio.network" TOML config table
@@ -916,13 +990,16 @@ func DefaultConfig() (*Config, error) {
 			HostNetworkDisableSELinux:   true,
 			DisableHostPortMapping:      false,
 			EnableCriuSupport:           true,
+			CheckpointImageCompression:  compression.Gzip.Name(),
+			RuntimeHealthCheckInterval:  defaultRuntimeHealthCheckInterval,
 		},
 		ImageConfig: ImageConfig{
-			DefaultTransport:   "docker://",
-			PauseImage:         DefaultPauseImage,
-			PauseCommand:       "/pause",
-			ImageVolumes:       ImageVolumesMkdir,
-			SignaturePolicyDir: "/etc/crio/policies",
+			DefaultTransport:    "docker://",
+			PauseImage:          DefaultPauseImage,
+			PauseCommand:        "/pause",
+			ImageVolumes:        ImageVolumesMkdir,
+			SignaturePolicyDir:  "/etc/crio/policies",
+			PullProgressTimeout: defaultPullProgressTimeout,
 		},
 		NetworkConfig: NetworkConfig{
 			NetworkDir: cniConfigDir,
@@ -1097,6 +1174,21 @@ func (c *RuntimeConfig) Validate(systemContext *types.SystemContext, onExecution
 		return fmt.Errorf("log size max should be negative or >= %d", OCIBufSize)
 	}
 
+	if c.IncrementalCheckpointChainLength < 0 {
+		return fmt.Errorf("incremental checkpoint chain length should be >= 0, got %d", c.IncrementalCheckpointChainLength)
+	}
+
+	switch c.CheckpointImageCompression {
+	case compression.Gzip.Name(), compression.Zstd.Name():
+	default:
+		return fmt.Errorf("unsupported checkpoint image compression %q, should be %q or %q",
+			c.CheckpointImageCompression, compression.Gzip.Name(), compression.Zstd.Name())
+	}
+
+	if _, err := c.ParseRuntimeHealthCheckInterval(); err != nil {
+		return fmt.Errorf("invalid runtime health check interval %q: %w", c.RuntimeHealthCheckInterval, err)
+	}
+
 	// We need to ensure the container termination will be properly waited
 	// for by defining a minimal timeout value. This will prevent timeout
 	// value defined in the configuration file to be too low.
@@ -1313,6 +1405,15 @@ func (c *RuntimeConfig) initializeRuntimeFeatures() {
 		}
 		logrus.Debugf("Runtime handler %q container minimum memory set to %d bytes", name, memoryBytes)
 
+		// VM runtimes checkpoint and restore through their shim, which cannot
+		// be probed for support, while the other runtimes have to implement
+		// the checkpoint command of runc.
+		if handler.RuntimeType == RuntimeTypeVM {
+			handler.features.CheckpointRestore = handler.CheckpointRestore
+		} else {
+			handler.features.CheckpointRestore = crutils.CRRuntimeSupportsCheckpointRestore(handler.RuntimePath)
+		}
+
 		// If this returns an error, we just ignore it and assume the features sub-command is
 		// not supported by the runtime.
 		output, err := cmdrunner.CombinedOutput(handler.RuntimePath, "features")
@@ -1489,9 +1590,21 @@ func (c *ImageConfig) Validate(onExecution bool) error {
 	if !filepath.IsAbs(c.SignaturePolicyDir) {
 		return fmt.Errorf("signature policy dir %q is not absolute", c.SignaturePolicyDir)
 	}
+	if c.NamespacedRegistriesDir != "" && !filepath.IsAbs(c.NamespacedRegistriesDir) {
+		return fmt.Errorf("namespaced registries dir %q is not absolute", c.NamespacedRegistriesDir)
+	}
 	if _, err := c.ParsePauseImage(); err != nil {
 		return fmt.Errorf("invalid pause image %q: %w", c.PauseImage, err)
 	}
+	if c.MaxConcurrentPullsPerRegistry < 0 {
+		return fmt.Errorf("max concurrent pulls per registry %d must not be negative", c.MaxConcurrentPullsPerRegistry)
+	}
+	if _, err := c.ParsePullProgressTimeout(); err != nil {
+		return fmt.Errorf("invalid pull progress timeout %q: %w", c.PullProgressTimeout, err)
+	}
+	if err := c.validateImageGC(); err != nil {
+		return fmt.Errorf("invalid image garbage collection configuration: %w", err)
+	}
 	if onExecution {
 		if err := os.MkdirAll(c.SignaturePolicyDir, 0o755); err != nil {
 			return fmt.Errorf("cannot create signature policy dir: %w", err)
@@ -1500,11 +1613,108 @@ func (c *ImageConfig) Validate(onExecution bool) error {
 	return nil
 }
 
+// validateImageGC validates the image garbage collection options.
+func (c *ImageConfig) validateImageGC() error {
+	if _, err := c.ParseImageGCInterval(); err != nil {
+		return fmt.Errorf("invalid interval %q: %w", c.ImageGCInterval, err)
+	}
+	if _, err := c.ParseImageGCMaxUnusedAge(); err != nil {
+		return fmt.Errorf("invalid max unused age %q: %w", c.ImageGCMaxUnusedAge, err)
+	}
+	if c.ImageGCMaxImages < 0 {
+		return fmt.Errorf("max images %d must not be negative", c.ImageGCMaxImages)
+	}
+	if c.ImageGCHighThresholdPercent < 0 || c.ImageGCHighThresholdPercent > 100 {
+		return fmt.Errorf("high threshold percent %d must be between 0 and 100", c.ImageGCHighThresholdPercent)
+	}
+	if c.ImageGCLowThresholdPercent < 0 || c.ImageGCLowThresholdPercent > c.ImageGCHighThresholdPercent {
+		return fmt.Errorf("low threshold percent %d must be between 0 and the high threshold percent %d", c.ImageGCLowThresholdPercent, c.ImageGCHighThresholdPercent)
+	}
+	return nil
+}
+
+// ParsePullProgressTimeout parses the .PullProgressTimeout value, where an
+// empty value results in 0.
+func (c *ImageConfig) ParsePullProgressTimeout() (time.Duration, error) {
+	return parseNonNegativeDuration(c.PullProgressTimeout)
+}
+
+// ParseImageGCInterval parses the .ImageGCInterval value, where an empty
+// value results in 0.
+func (c *ImageConfig) ParseImageGCInterval() (time.Duration, error) {
+	return parseNonNegativeDuration(c.ImageGCInterval)
+}
+
+// ParseImageGCMaxUnusedAge parses the .ImageGCMaxUnusedAge value, where an
+// empty value results in 0.
+func (c *ImageConfig) ParseImageGCMaxUnusedAge() (time.Duration, error) {
+	return parseNonNegativeDuration(c.ImageGCMaxUnusedAge)
+}
+
+// ParseRuntimeHealthCheckInterval parses the .RuntimeHealthCheckInterval
+// value, where an empty value results in 0.
+func (c *RuntimeConfig) ParseRuntimeHealthCheckInterval() (time.Duration, error) {
+	return parseNonNegativeDuration(c.RuntimeHealthCheckInterval)
+}
+
+func parseNonNegativeDuration(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if duration < 0 {
+		return 0, errors.New("duration must not be negative")
+	}
+	return duration, nil
+}
+
 // ParsePauseImage parses the .PauseImage value as into a validated, well-typed value.
 func (c *ImageConfig) ParsePauseImage() (references.RegistryImageReference, error) {
 	return references.ParseRegistryImageReferenceFromOutOfProcessData(c.PauseImage)
 }
 
+// Files of a pod namespace in the NamespacedRegistriesDir.
+const (
+	namespacedRegistriesConfFile    = "registries.conf"
+	namespacedRegistriesConfDirName = "registries.conf.d"
+	namespacedAuthFile              = "auth.json"
+)
+
+// ApplyNamespacedRegistries changes the SystemContext to use the registries
+// configuration and credentials of the pod namespace from the
+// NamespacedRegistriesDir, if they exist. The registries configuration of the
+// namespace fully replaces the system wide one, including its drop-in
+// directory, so that the pulls of a namespace never use the mirrors of
+// another one.
+func (c *ImageConfig) ApplyNamespacedRegistries(sys *types.SystemContext, namespace string) error {
+	if c.NamespacedRegistriesDir == "" || namespace == "" {
+		return nil
+	}
+	if filepath.Base(namespace) != namespace || namespace == "." || namespace == ".." {
+		return fmt.Errorf("invalid namespace %q", namespace)
+	}
+	dir := filepath.Join(c.NamespacedRegistriesDir, namespace)
+
+	registriesConfPath := filepath.Join(dir, namespacedRegistriesConfFile)
+	if _, err := os.Stat(registriesConfPath); err == nil {
+		sys.SystemRegistriesConfPath = registriesConfPath
+		sys.SystemRegistriesConfDirPath = filepath.Join(dir, namespacedRegistriesConfDirName)
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("read registries configuration %s: %w", registriesConfPath, err)
+	}
+
+	authFilePath := filepath.Join(dir, namespacedAuthFile)
+	if _, err := os.Stat(authFilePath); err == nil {
+		sys.AuthFilePath = authFilePath
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("read auth file %s: %w", authFilePath, err)
+	}
+	return nil
+}
+
 // Validate is the main entry point for network configuration validation.
 // The parameter `onExecution` specifies if the validation should include
 // execution checks. It returns an `error` on validation failure, otherwise
@@ -1563,6 +1773,9 @@ func (r *RuntimeHandler) Validate(name string) error {
 	if err := r.ValidateRuntimeConfigPath(name); err != nil {
 		return err
 	}
+	if err := r.ValidateRuntimeCheckpointRestore(); err != nil {
+		return err
+	}
 	if err := r.ValidateRuntimeAllowedAnnotations(); err != nil {
 		return err
 	}
@@ -1634,6 +1847,15 @@ func (r *RuntimeHandler) ValidateRuntimeConfigPath(name string) error {
 	return nil
 }
 
+// ValidateRuntimeCheckpointRestore checks if `CheckpointRestore` is only set
+// for VM runtimes.
+func (r *RuntimeHandler) ValidateRuntimeCheckpointRestore() error {
+	if r.CheckpointRestore && r.RuntimeType != RuntimeTypeVM {
+		return errors.New("checkpoint_restore can only be used with the 'vm' runtime type")
+	}
+	return nil
+}
+
 func (r *RuntimeHandler) ValidateRuntimeAllowedAnnotations() error {
 	disallowed, err := validateAllowedAndGenerateDisallowedAnnotations(r.AllowedAnnotations)
 	if err != nil {
@@ -1709,6 +1931,58 @@ func (r *RuntimeHandler) RuntimeSupportsMountFlag(flag string) bool {
 	return slices.Contains(r.features.MountOptions, flag)
 }
 
+// RuntimeSupportsCheckpointRestore returns whether this runtime supports
+// checkpointing and restoring containers.
+func (r *RuntimeHandler) RuntimeSupportsCheckpointRestore() bool {
+	return r.features.CheckpointRestore
+}
+
+// Capabilities returns the report of the features supported by the runtime
+// handler with the name. Checkpoint/restore support additionally requires
+// checkpointRestore to be enabled.
+func (r *RuntimeHandler) Capabilities(name string, checkpointRestore bool) *crioTypes.RuntimeHandlerCapabilities {
+	caps := &crioTypes.RuntimeHandlerCapabilities{
+		Name:                    name,
+		RuntimeType:             r.RuntimeType,
+		FeaturesReported:        r.features.OCIVersionMin != "",
+		OCIVersionMin:           r.features.OCIVersionMin,
+		OCIVersionMax:           r.features.OCIVersionMax,
+		MountOptions:            r.features.MountOptions,
+		UserNamespaces:          r.RuntimeSupportsIDMap(),
+		RecursiveReadOnlyMounts: r.RuntimeSupportsRROMounts(),
+		CheckpointRestore:       checkpointRestore && r.RuntimeSupportsCheckpointRestore(),
+	}
+	if caps.RuntimeType == "" {
+		caps.RuntimeType = DefaultRuntimeType
+	}
+	if linux := r.features.Linux; linux != nil {
+		caps.Namespaces = linux.Namespaces
+		if linux.Seccomp != nil && linux.Seccomp.Enabled != nil && *linux.Seccomp.Enabled {
+			caps.SeccompActions = linux.Seccomp.Actions
+		}
+		if linux.Cgroup != nil && linux.Cgroup.V2 != nil && *linux.Cgroup.V2 {
+			caps.CgroupV2 = true
+			if node.CgroupIsV2() {
+				caps.CgroupV2Controllers = node.CgroupControllers()
+			}
+		}
+	}
+	return caps
+}
+
+// RuntimeHandlerCapabilities returns the capability reports of all runtime
+// handlers, sorted by their name.
+func (c *RuntimeConfig) RuntimeHandlerCapabilities() []crioTypes.RuntimeHandlerCapabilities {
+	res := make([]crioTypes.RuntimeHandlerCapabilities, 0, len(c.Runtimes))
+	for name, handler := range c.Runtimes {
+		res = append(res, *handler.Capabilities(name, c.CheckpointRestore()))
+	}
+	slices.SortFunc(res, func(a, b crioTypes.RuntimeHandlerCapabilities) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return res
+}
+
 func validateAllowedAndGenerateDisallowedAnnotations(allowed []string) (disallowed []string, _ error) {
 	disallowedMap := make(map[string]struct{})
 	for _, ann := range annotations.AllAllowedAnnotations {
@@ -6,7 +6,9 @@ import (
 	"path"
 	"path/filepath"
 
+	"github.com/containers/image/v5/types"
 	"github.com/containers/storage"
+	"github.com/cri-o/cri-o/internal/config/nri"
 	crioann "github.com/cri-o/cri-o/pkg/annotations"
 	"github.com/cri-o/cri-o/pkg/config"
 	"github.com/cri-o/cri-o/utils/cmdrunner"
@@ -138,6 +140,32 @@ var _ = t.Describe("Config", func() {
 			// Then
 			Expect(err).To(HaveOccurred())
 		})
+
+		It("should default the position of builtin NRI plugins", func() {
+			// Given
+			plugin := &nri.BuiltinPlugin{}
+			sut.NRI.BuiltinPlugins = map[string]*nri.BuiltinPlugin{"default_ulimits": plugin}
+
+			// When
+			err := sut.Validate(false)
+
+			// Then
+			Expect(err).ToNot(HaveOccurred())
+			Expect(plugin.Position).To(Equal(nri.BuiltinPluginPositionBefore))
+		})
+
+		It("should fail on invalid position of builtin NRI plugins", func() {
+			// Given
+			sut.NRI.BuiltinPlugins = map[string]*nri.BuiltinPlugin{
+				"default_ulimits": {Position: "between"},
+			}
+
+			// When
+			err := sut.Validate(false)
+
+			// Then
+			Expect(err).To(HaveOccurred())
+		})
 	})
 
 	t.Describe("ValidateAPIConfig", func() {
@@ -395,6 +423,39 @@ var _ = t.Describe("Config", func() {
 			Expect(err).To(HaveOccurred())
 		})
 
+		It("should fail on negative incremental checkpoint chain length", func() {
+			// Given
+			sut.IncrementalCheckpointChainLength = -1
+
+			// When
+			err := sut.Validate(false)
+
+			// Then
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should fail on unsupported checkpoint image compression", func() {
+			// Given
+			sut.CheckpointImageCompression = "xz"
+
+			// When
+			err := sut.Validate(false)
+
+			// Then
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should fail on invalid runtime health check interval", func() {
+			// Given
+			sut.RuntimeHealthCheckInterval = "-1m"
+
+			// When
+			err := sut.Validate(false)
+
+			// Then
+			Expect(err).To(HaveOccurred())
+		})
+
 		It("should succeed without defaultRuntime set", func() {
 			// Given
 			sut.DefaultRuntime = ""
@@ -772,6 +833,66 @@ var _ = t.Describe("Config", func() {
 			// Then
 			Expect(err).To(HaveOccurred())
 		})
+
+		It("should fail when NamespacedRegistriesDir is not absolute", func() {
+			// Given
+			sut.ImageConfig.NamespacedRegistriesDir = "registries"
+
+			// When
+			err := sut.ImageConfig.Validate(false)
+
+			// Then
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should fail when PullProgressTimeout is invalid", func() {
+			// Given
+			sut.ImageConfig.PullProgressTimeout = "invalid"
+
+			// When
+			err := sut.ImageConfig.Validate(false)
+
+			// Then
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should succeed with valid image garbage collection options", func() {
+			// Given
+			sut.ImageConfig.ImageGCInterval = "5m"
+			sut.ImageConfig.ImageGCMaxUnusedAge = "24h"
+			sut.ImageConfig.ImageGCMaxImages = 10
+			sut.ImageConfig.ImageGCHighThresholdPercent = 85
+			sut.ImageConfig.ImageGCLowThresholdPercent = 80
+
+			// When
+			err := sut.ImageConfig.Validate(false)
+
+			// Then
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should fail when ImageGCInterval is invalid", func() {
+			// Given
+			sut.ImageConfig.ImageGCInterval = "-5m"
+
+			// When
+			err := sut.ImageConfig.Validate(false)
+
+			// Then
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should fail when ImageGCLowThresholdPercent exceeds the high threshold", func() {
+			// Given
+			sut.ImageConfig.ImageGCHighThresholdPercent = 80
+			sut.ImageConfig.ImageGCLowThresholdPercent = 85
+
+			// When
+			err := sut.ImageConfig.Validate(false)
+
+			// Then
+			Expect(err).To(HaveOccurred())
+		})
 	})
 
 	t.Describe("ImageConfig.ParsePauseImage", func() {
@@ -827,6 +948,53 @@ var _ = t.Describe("Config", func() {
 		})
 	})
 
+	t.Describe("ImageConfig.ApplyNamespacedRegistries", func() {
+		const namespace = "tenant"
+
+		It("should use the registries configuration and auth file of the namespace", func() {
+			// Given
+			sut.ImageConfig.NamespacedRegistriesDir = t.MustTempDir("registries")
+			dir := filepath.Join(sut.ImageConfig.NamespacedRegistriesDir, namespace)
+			Expect(os.MkdirAll(dir, 0o755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(dir, "registries.conf"), []byte{}, 0o644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(dir, "auth.json"), []byte("{}"), 0o600)).To(Succeed())
+			sys := &types.SystemContext{AuthFilePath: "/global/auth.json"}
+
+			// When
+			err := sut.ImageConfig.ApplyNamespacedRegistries(sys, namespace)
+
+			// Then
+			Expect(err).ToNot(HaveOccurred())
+			Expect(sys.SystemRegistriesConfPath).To(Equal(filepath.Join(dir, "registries.conf")))
+			Expect(sys.SystemRegistriesConfDirPath).To(Equal(filepath.Join(dir, "registries.conf.d")))
+			Expect(sys.AuthFilePath).To(Equal(filepath.Join(dir, "auth.json")))
+		})
+
+		It("should keep the system context without namespace configuration", func() {
+			// Given
+			sut.ImageConfig.NamespacedRegistriesDir = t.MustTempDir("registries")
+			sys := &types.SystemContext{AuthFilePath: "/global/auth.json"}
+
+			// When
+			err := sut.ImageConfig.ApplyNamespacedRegistries(sys, namespace)
+
+			// Then
+			Expect(err).ToNot(HaveOccurred())
+			Expect(sys).To(Equal(&types.SystemContext{AuthFilePath: "/global/auth.json"}))
+		})
+
+		It("should fail with an invalid namespace", func() {
+			// Given
+			sut.ImageConfig.NamespacedRegistriesDir = t.MustTempDir("registries")
+
+			// When
+			err := sut.ImageConfig.ApplyNamespacedRegistries(&types.SystemContext{}, "..")
+
+			// Then
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
 	t.Describe("ValidateNetworkConfig", func() {
 		It("should succeed with default config", func() {
 			// Given
@@ -1421,6 +1589,34 @@ var _ = t.Describe("Config", func() {
 		})
 	})
 
+	t.Describe("ValidateRuntimeCheckpointRestore", func() {
+		It("should fail with OCI runtime type when checkpoint_restore is used", func() {
+			// Given
+			sut.Runtimes["runc"] = &config.RuntimeHandler{
+				CheckpointRestore: true, RuntimeType: config.DefaultRuntimeType,
+			}
+
+			// When
+			err := sut.Runtimes["runc"].ValidateRuntimeCheckpointRestore()
+
+			// Then
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should succeed with VM runtime type when checkpoint_restore is used", func() {
+			// Given
+			sut.Runtimes["kata"] = &config.RuntimeHandler{
+				CheckpointRestore: true, RuntimeType: config.RuntimeTypeVM,
+			}
+
+			// When
+			err := sut.Runtimes["kata"].ValidateRuntimeCheckpointRestore()
+
+			// Then
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
 	t.Describe("RuntimeHandlerFeatures", func() {
 		It("should fail to load runtime features with nothing to load", func() {
 			// Given
@@ -1530,5 +1726,53 @@ var _ = t.Describe("Config", func() {
 			// Then
 			Expect(ok).To(BeTrue())
 		})
+
+		It("should report the capabilities of the runtime", func() {
+			// Given
+			handler := &config.RuntimeHandler{}
+			Expect(handler.LoadRuntimeFeatures(
+				[]byte(`
+					{
+					  "ociVersionMin": "1.0.0",
+					  "ociVersionMax": "1.2.0",
+					  "mountOptions": ["ro", "rshared"],
+					  "linux": {
+					    "namespaces": ["mount", "pid"],
+					    "seccomp": {
+					      "enabled": true,
+					      "actions": ["SCMP_ACT_ALLOW", "SCMP_ACT_ERRNO"]
+					    }
+					  }
+					}
+				`),
+			)).To(Succeed())
+
+			// When
+			caps := handler.Capabilities("runc", true)
+
+			// Then
+			Expect(caps.Name).To(Equal("runc"))
+			Expect(caps.RuntimeType).To(Equal(config.DefaultRuntimeType))
+			Expect(caps.FeaturesReported).To(BeTrue())
+			Expect(caps.OCIVersionMax).To(Equal("1.2.0"))
+			Expect(caps.MountOptions).To(ConsistOf("ro", "rshared"))
+			Expect(caps.Namespaces).To(ConsistOf("mount", "pid"))
+			Expect(caps.SeccompActions).To(ConsistOf("SCMP_ACT_ALLOW", "SCMP_ACT_ERRNO"))
+			Expect(caps.CgroupV2).To(BeFalse())
+			Expect(caps.CheckpointRestore).To(BeFalse())
+		})
+
+		It("should report unknown capabilities without runtime features", func() {
+			// Given
+			handler := &config.RuntimeHandler{RuntimeType: config.RuntimeTypeVM}
+
+			// When
+			caps := handler.Capabilities("kata", true)
+
+			// Then
+			Expect(caps.RuntimeType).To(Equal(config.RuntimeTypeVM))
+			Expect(caps.FeaturesReported).To(BeFalse())
+			Expect(caps.Namespaces).To(BeEmpty())
+		})
 	})
 })
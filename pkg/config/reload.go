@@ -4,10 +4,12 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 
 	"github.com/containers/image/v5/pkg/sysregistriesv2"
+	"github.com/containers/image/v5/types"
 	"github.com/cri-o/cri-o/internal/log"
 	"github.com/sirupsen/logrus"
 	"tags.cncf.io/container-device-interface/pkg/cdi"
@@ -166,7 +168,9 @@ func (c *Config) ReloadPinnedImages(newConfig *Config) {
 }
 
 // ReloadRegistries reloads the registry configuration from the Configs
-// `SystemContext`. The method errors in case of any update failure.
+// `SystemContext` as well as the registry configurations of all pod namespaces
+// in the `NamespacedRegistriesDir`. The method errors in case of any update
+// failure.
 func (c *Config) ReloadRegistries() error {
 	registries, err := sysregistriesv2.TryUpdatingCache(c.SystemContext)
 	if err != nil {
@@ -177,6 +181,48 @@ func (c *Config) ReloadRegistries() error {
 		)
 	}
 	logrus.Infof("Applied new registry configuration: %+v", registries)
+	return c.reloadNamespacedRegistries()
+}
+
+// reloadNamespacedRegistries reloads the registry configurations of all pod
+// namespaces in the `NamespacedRegistriesDir`.
+func (c *Config) reloadNamespacedRegistries() error {
+	if c.NamespacedRegistriesDir == "" {
+		return nil
+	}
+	entries, err := os.ReadDir(c.NamespacedRegistriesDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("read namespaced registries dir: %w", err)
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		namespace := entry.Name()
+		sys := &types.SystemContext{}
+		if c.SystemContext != nil {
+			*sys = *c.SystemContext
+		}
+		if err := c.ApplyNamespacedRegistries(sys, namespace); err != nil {
+			return err
+		}
+		if sys.SystemRegistriesConfPath != filepath.Join(c.NamespacedRegistriesDir, namespace, namespacedRegistriesConfFile) {
+			// The namespace uses the system wide registries configuration.
+			continue
+		}
+		registries, err := sysregistriesv2.TryUpdatingCache(sys)
+		if err != nil {
+			return fmt.Errorf(
+				"registries reload of namespace %s failed: %s: %w",
+				namespace, sys.SystemRegistriesConfPath, err,
+			)
+		}
+		logrus.Infof("Applied new registry configuration of namespace %s: %+v", namespace, registries)
+	}
 	return nil
 }
 
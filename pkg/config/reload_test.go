@@ -2,6 +2,7 @@ package config_test
 
 import (
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/containers/common/pkg/apparmor"
@@ -268,6 +269,35 @@ var _ = t.Describe("Config", func() {
 			// Then
 			Expect(err).To(HaveOccurred())
 		})
+
+		It("should succeed to reload namespaced registries", func() {
+			// Given
+			sut.NamespacedRegistriesDir = t.MustTempDir("registries")
+			dir := filepath.Join(sut.NamespacedRegistriesDir, "tenant")
+			Expect(os.MkdirAll(dir, 0o755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(dir, "registries.conf"), []byte(`unqualified-search-registries = ["quay.io"]`), 0o644)).To(Succeed())
+			Expect(os.MkdirAll(filepath.Join(sut.NamespacedRegistriesDir, "other"), 0o755)).To(Succeed())
+
+			// When
+			err := sut.ReloadRegistries()
+
+			// Then
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should fail if namespaced registries file is invalid", func() {
+			// Given
+			sut.NamespacedRegistriesDir = t.MustTempDir("registries")
+			dir := filepath.Join(sut.NamespacedRegistriesDir, "tenant")
+			Expect(os.MkdirAll(dir, 0o755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(dir, "registries.conf"), []byte("invalid"), 0o644)).To(Succeed())
+
+			// When
+			err := sut.ReloadRegistries()
+
+			// Then
+			Expect(err).To(HaveOccurred())
+		})
 	})
 
 	t.Describe("ReloadSeccompProfile", func() {
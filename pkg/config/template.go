@@ -440,6 +440,26 @@ func initCrioTemplateConfig(c *Config) ([]*templateConfigValue, error) {
 			group:          crioRuntimeConfig,
 			isDefaultValue: simpleEqual(dc.EnableCriuSupport, c.EnableCriuSupport),
 		},
+		{
+			templateString: templateStringCrioRuntimeIncrementalCheckpointChainLength,
+			group:          crioRuntimeConfig,
+			isDefaultValue: simpleEqual(dc.IncrementalCheckpointChainLength, c.IncrementalCheckpointChainLength),
+		},
+		{
+			templateString: templateStringCrioRuntimeCheckpointImages,
+			group:          crioRuntimeConfig,
+			isDefaultValue: simpleEqual(dc.CheckpointImages, c.CheckpointImages),
+		},
+		{
+			templateString: templateStringCrioRuntimeCheckpointImageCompression,
+			group:          crioRuntimeConfig,
+			isDefaultValue: simpleEqual(dc.CheckpointImageCompression, c.CheckpointImageCompression),
+		},
+		{
+			templateString: templateStringCrioRuntimeRuntimeHealthCheckInterval,
+			group:          crioRuntimeConfig,
+			isDefaultValue: simpleEqual(dc.RuntimeHealthCheckInterval, c.RuntimeHealthCheckInterval),
+		},
 		{
 			templateString: templateStringCrioRuntimeEnablePodEvents,
 			group:          crioRuntimeConfig,
@@ -510,6 +530,11 @@ func initCrioTemplateConfig(c *Config) ([]*templateConfigValue, error) {
 			group:          crioImageConfig,
 			isDefaultValue: stringSliceEqual(dc.PinnedImages, c.PinnedImages),
 		},
+		{
+			templateString: templateStringCrioImagePrePullPinnedImages,
+			group:          crioImageConfig,
+			isDefaultValue: simpleEqual(dc.PrePullPinnedImages, c.PrePullPinnedImages),
+		},
 		{
 			templateString: templateStringCrioImageSignaturePolicy,
 			group:          crioImageConfig,
@@ -520,6 +545,11 @@ func initCrioTemplateConfig(c *Config) ([]*templateConfigValue, error) {
 			group:          crioImageConfig,
 			isDefaultValue: simpleEqual(dc.SignaturePolicyDir, c.SignaturePolicyDir),
 		},
+		{
+			templateString: templateStringCrioImageNamespacedRegistriesDir,
+			group:          crioImageConfig,
+			isDefaultValue: simpleEqual(dc.NamespacedRegistriesDir, c.NamespacedRegistriesDir),
+		},
 		{
 			templateString: templateStringCrioImageInsecureRegistries,
 			group:          crioImageConfig,
@@ -540,6 +570,46 @@ func initCrioTemplateConfig(c *Config) ([]*templateConfigValue, error) {
 			group:          crioImageConfig,
 			isDefaultValue: simpleEqual(dc.AutoReloadRegistries, c.AutoReloadRegistries),
 		},
+		{
+			templateString: templateStringCrioImageMaxConcurrentPullsPerRegistry,
+			group:          crioImageConfig,
+			isDefaultValue: simpleEqual(dc.MaxConcurrentPullsPerRegistry, c.MaxConcurrentPullsPerRegistry),
+		},
+		{
+			templateString: templateStringCrioImagePullProgressTimeout,
+			group:          crioImageConfig,
+			isDefaultValue: simpleEqual(dc.PullProgressTimeout, c.PullProgressTimeout),
+		},
+		{
+			templateString: templateStringCrioImageResumablePulls,
+			group:          crioImageConfig,
+			isDefaultValue: simpleEqual(dc.ResumablePulls, c.ResumablePulls),
+		},
+		{
+			templateString: templateStringCrioImageImageGCInterval,
+			group:          crioImageConfig,
+			isDefaultValue: simpleEqual(dc.ImageGCInterval, c.ImageGCInterval),
+		},
+		{
+			templateString: templateStringCrioImageImageGCMaxUnusedAge,
+			group:          crioImageConfig,
+			isDefaultValue: simpleEqual(dc.ImageGCMaxUnusedAge, c.ImageGCMaxUnusedAge),
+		},
+		{
+			templateString: templateStringCrioImageImageGCMaxImages,
+			group:          crioImageConfig,
+			isDefaultValue: simpleEqual(dc.ImageGCMaxImages, c.ImageGCMaxImages),
+		},
+		{
+			templateString: templateStringCrioImageImageGCHighThresholdPercent,
+			group:          crioImageConfig,
+			isDefaultValue: simpleEqual(dc.ImageGCHighThresholdPercent, c.ImageGCHighThresholdPercent),
+		},
+		{
+			templateString: templateStringCrioImageImageGCLowThresholdPercent,
+			group:          crioImageConfig,
+			isDefaultValue: simpleEqual(dc.ImageGCLowThresholdPercent, c.ImageGCLowThresholdPercent),
+		},
 		{
 			templateString: templateStringCrioNetworkCniDefaultNetwork,
 			group:          crioNetworkConfig,
@@ -655,6 +725,16 @@ func initCrioTemplateConfig(c *Config) ([]*templateConfigValue, error) {
 			group:          crioNRIConfig,
 			isDefaultValue: simpleEqual(dc.NRI.PluginRequestTimeout, c.NRI.PluginRequestTimeout),
 		},
+		{
+			templateString: templateStringCrioNRIDryRun,
+			group:          crioNRIConfig,
+			isDefaultValue: simpleEqual(dc.NRI.DryRun, c.NRI.DryRun),
+		},
+		{
+			templateString: templateStringCrioNRIBuiltinPlugins,
+			group:          crioNRIConfig,
+			isDefaultValue: reflect.DeepEqual(dc.NRI.BuiltinPlugins, c.NRI.BuiltinPlugins),
+		},
 	}
 
 	return crioTemplateConfig, nil
@@ -1199,6 +1279,37 @@ const templateStringCrioRuntimeEnableCriuSupport = `# Globally enable/disable CR
 
 `
 
+const templateStringCrioRuntimeIncrementalCheckpointChainLength = `# Maximum number of incremental checkpoints of a running container. Every
+# checkpoint after the first one of a chain only dumps the memory pages changed
+# since the previous checkpoint. A new chain is started once the length is
+# reached. Set to 0 to always dump the full memory of the container.
+{{ $.Comment }}incremental_checkpoint_chain_length = {{ .IncrementalCheckpointChainLength }}
+
+`
+
+const templateStringCrioRuntimeCheckpointImages = `# Store container checkpoints additionally as OCI images in the local storage.
+# The checkpoint archive is still written to the requested location, if any.
+# The image is named after the requested location and can be listed and
+# removed using "crio status checkpoints".
+{{ $.Comment }}checkpoint_images = {{ .CheckpointImages }}
+
+`
+
+const templateStringCrioRuntimeCheckpointImageCompression = `# Compression of checkpoint images, either "gzip" or "zstd".
+{{ $.Comment }}checkpoint_image_compression = "{{ .CheckpointImageCompression }}"
+
+`
+
+const templateStringCrioRuntimeRuntimeHealthCheckInterval = `# Interval of the health checks of the runtime handlers, which run the runtime
+# and monitor binaries and check the connections to running shims and conmon-rs
+# servers. The results are reported as one RuntimeHandlerReady/<name> condition
+# per runtime handler in the CRI Status, which does not affect the RuntimeReady
+# condition, and new sandboxes are rejected for unhealthy runtime handlers until
+# they recover. Set to an empty value to disable the health checks.
+{{ $.Comment }}runtime_health_check_interval = "{{ .RuntimeHealthCheckInterval }}"
+
+`
+
 const templateStringCrioRuntimeEnablePodEvents = `# Enable/disable the generation of the container,
 # sandbox lifecycle events to be sent to the Kubelet to optimize the PLEG
 {{ $.Comment }}enable_pod_events = {{ .EnablePodEvents }}
@@ -1284,6 +1395,9 @@ const templateStringCrioRuntimeRuntimesRuntimeHandler = `# The "crio.runtime.run
 # - container_min_memory (optional, string): The minimum memory that must be set for a container.
 #   This value can be used to override the currently set global value for a specific runtime. If not set,
 #   a global default value of "12 MiB" will be used.
+# - checkpoint_restore (optional, bool): Whether the shim of a VM runtime implements
+#   the Checkpoint task API, which is required to checkpoint and restore its containers.
+#   This can only be used with the VM runtime_type.
 #
 # Using the seccomp notifier feature:
 #
@@ -1338,9 +1452,13 @@ const templateStringCrioRuntimeWorkloads = `# The workloads table defines ways t
 # that work based on annotations, rather than the CRI.
 # Note, the behavior of this table is EXPERIMENTAL and may change at any time.
 # Each workload, has a name, activation_annotation, annotation_prefix and set of resources it supports mutating.
-# The currently supported resources are "cpuperiod" "cpuquota", "cpushares", "cpulimit" and "cpuset". The values for "cpuperiod" and "cpuquota" are denoted in microseconds.
+# The currently supported resources are "cpuperiod" "cpuquota", "cpushares", "cpulimit", "cpuset", "memorylimit", "memoryreservation",
+# "memoryswap", "hugepagelimits", "pidslimit" and "blkioweight". The values for "cpuperiod" and "cpuquota" are denoted in microseconds.
 # The value for "cpulimit" is denoted in millicores, this value is used to calculate the "cpuquota" with the supplied "cpuperiod" or the default "cpuperiod".
 # Note that the "cpulimit" field overrides the "cpuquota" value supplied in this configuration.
+# The values for "memorylimit", "memoryreservation" and "memoryswap" are denoted in bytes, where "memoryswap" is the limit of memory plus swap
+# and requires a "memorylimit". The "hugepagelimits" are denoted in bytes per page size, for example { "2MB" = 1073741824 }.
+# A "memoryswap" or "pidslimit" of -1 means unlimited and the "blkioweight" has to be between 10 and 1000.
 # Each resource can have a default value specified, or be empty.
 # For a container to opt-into this workload, the pod should be configured with the annotation $activation_annotation (key only, value is ignored).
 # To customize per-container, an annotation of the form $annotation_prefix.$resource/$ctrName = "value" can be specified
@@ -1356,6 +1474,8 @@ const templateStringCrioRuntimeWorkloads = `# The workloads table defines ways t
 # cpuquota = "1000"
 # cpuperiod = "100000"
 # cpulimit = "35"
+# memorylimit = "1073741824"
+# pidslimit = "1024"
 # Where:
 # The workload name is workload-type.
 # To specify, the pod must have the "io.crio.workload" annotation (this is a precise string match).
@@ -1372,7 +1492,15 @@ const templateStringCrioRuntimeWorkloads = `# The workloads table defines ways t
 {{ $.Comment }}cpuquota = {{ $workload_config.Resources.CPUQuota }}
 {{ $.Comment }}cpuperiod = {{ $workload_config.Resources.CPUPeriod }}
 {{ $.Comment }}cpushares = {{ $workload_config.Resources.CPUShares }}
-{{ $.Comment }}cpulimit = {{ $workload_config.Resources.CPULimit }}{{ end }}
+{{ $.Comment }}cpulimit = {{ $workload_config.Resources.CPULimit }}
+{{ $.Comment }}memorylimit = {{ $workload_config.Resources.MemoryLimit }}
+{{ $.Comment }}memoryreservation = {{ $workload_config.Resources.MemoryReservation }}
+{{ $.Comment }}memoryswap = {{ $workload_config.Resources.MemorySwap }}
+{{ $.Comment }}pidslimit = {{ $workload_config.Resources.PidsLimit }}
+{{ $.Comment }}blkioweight = {{ $workload_config.Resources.BlkioWeight }}
+{{ if $workload_config.Resources.HugepageLimits }}{{ $.Comment }}[crio.runtime.workloads.{{ $workload_type }}.resources.hugepagelimits]
+{{ range $page_size, $limit := $workload_config.Resources.HugepageLimits }}{{ $.Comment }}"{{ $page_size }}" = {{ $limit }}
+{{ end }}{{ end }}{{ end }}
 {{ end }}
 `
 
@@ -1447,6 +1575,14 @@ const templateStringCrioImagePinnedImages = `# List of images to be excluded fro
 
 `
 
+const templateStringCrioImagePrePullPinnedImages = `# If true, the pinned_images without glob or keyword pattern and the pause_image
+# are pulled in the background after startup and after a configuration reload
+# adding new pinned images. Failed pulls are retried with an exponential
+# backoff, while the Status RPC reports the condition PinnedImagesReady.
+{{ $.Comment }}pre_pull_pinned_images = {{ .PrePullPinnedImages }}
+
+`
+
 const templateStringCrioImageSignaturePolicy = `# Path to the file which decides what sort of policy we use when deciding
 # whether or not to trust an image that we've pulled. It is not recommended that
 # this option be used, as the default behavior of using the system-wide default
@@ -1465,6 +1601,18 @@ const templateStringCrioImageSignaturePolicyDir = `# Root path for pod namespace
 
 `
 
+const templateStringCrioImageNamespacedRegistriesDir = `# Root path for pod namespace-separated registries configurations and
+# credentials. Image pulls for a pod namespace use the
+# <NAMESPACED_REGISTRIES_DIR>/<NAMESPACE>/registries.conf file and its
+# registries.conf.d drop-in directory instead of the system wide registries
+# configuration, as well as the <NAMESPACED_REGISTRIES_DIR>/<NAMESPACE>/auth.json
+# file instead of the global_auth_file, if they exist. The configurations are
+# reloaded together with the system wide one. An empty value disables the
+# lookup. Must be an absolute path.
+{{ $.Comment }}namespaced_registries_dir = "{{ .NamespacedRegistriesDir }}"
+
+`
+
 const templateStringCrioImageInsecureRegistries = `# List of registries to skip TLS verification for pulling images. Please
 # consider configuring the registries via /etc/containers/registries.conf before
 # changing them here.
@@ -1490,6 +1638,61 @@ const templateStringCrioImageAutoReloadRegistries = `# If true, CRI-O will autom
 
 `
 
+const templateStringCrioImageMaxConcurrentPullsPerRegistry = `# Maximum number of image pulls running in parallel for a single registry.
+# Further pulls are queued, where the pause image and pinned images are pulled
+# first. Identical image pulls which are already in progress are deduplicated.
+# The value 0 does not limit the number of parallel pulls.
+{{ $.Comment }}max_concurrent_pulls_per_registry = {{ .MaxConcurrentPullsPerRegistry }}
+
+`
+
+const templateStringCrioImagePullProgressTimeout = `# Cancel an image pull if it did not make any progress for this duration. An
+# empty value disables the timeout.
+{{ $.Comment }}pull_progress_timeout = "{{ .PullProgressTimeout }}"
+
+`
+
+const templateStringCrioImageResumablePulls = `# If true, the downloaded layers of failed or cancelled image pulls are kept in
+# the storage root. Subsequent pulls of the same image reuse the complete layers
+# and resume the partially downloaded ones via HTTP range requests, verifying
+# them against their digest. Layers which have not been touched for a day are
+# removed, which is checked every hour.
+{{ $.Comment }}resumable_pulls = {{ .ResumablePulls }}
+
+`
+
+const templateStringCrioImageImageGCInterval = `# Interval of the built-in image garbage collection, which removes images not
+# used by any container according to the image_gc_* policies. Pinned images and
+# the pause image are never removed. An empty value disables the garbage
+# collection.
+{{ $.Comment }}image_gc_interval = "{{ .ImageGCInterval }}"
+
+`
+
+const templateStringCrioImageImageGCMaxUnusedAge = `# Remove images which have not been used by any container for longer than this
+# duration. An empty value disables this policy.
+{{ $.Comment }}image_gc_max_unused_age = "{{ .ImageGCMaxUnusedAge }}"
+
+`
+
+const templateStringCrioImageImageGCMaxImages = `# Maximum number of images kept in the storage, where the least recently used
+# images are removed first. The value 0 disables this policy.
+{{ $.Comment }}image_gc_max_images = {{ .ImageGCMaxImages }}
+
+`
+
+const templateStringCrioImageImageGCHighThresholdPercent = `# Image filesystem usage in percent, which triggers the removal of the least
+# recently used images. The value 0 disables this policy.
+{{ $.Comment }}image_gc_high_threshold_percent = {{ .ImageGCHighThresholdPercent }}
+
+`
+
+const templateStringCrioImageImageGCLowThresholdPercent = `# Image filesystem usage in percent the garbage collection aims for, once the
+# image_gc_high_threshold_percent has been reached.
+{{ $.Comment }}image_gc_low_threshold_percent = {{ .ImageGCLowThresholdPercent }}
+
+`
+
 const templateStringCrioNetwork = `# The crio.network table containers settings pertaining to the management of
 # CNI plugins.
 [crio.network]
@@ -1643,3 +1846,32 @@ const templateStringCrioNRIPluginRequestTimeout = `# Timeout for a plugin to han
 {{ $.Comment }}nri_plugin_request_timeout = "{{ .NRI.PluginRequestTimeout }}"
 
 `
+
+const templateStringCrioNRIDryRun = `# Record and log the container adjustments and updates of NRI plugins in the
+# audit trail of the containers without applying them. The changes of all
+# external plugins are recorded together as plugin "external", because NRI
+# does not attribute them individually.
+{{ $.Comment }}nri_dry_run = {{ .NRI.DryRun }}
+
+`
+
+const templateStringCrioNRIBuiltinPlugins = `# The builtin_plugins table enables NRI plugins compiled into CRI-O by their name.
+# Builtin plugins handle the CreateContainer, UpdateContainer and StopContainer
+# events in-process, either "before" or "after" the external plugins, ordered by
+# their index. A later plugin gets the container with the adjustments of the
+# earlier ones, creating the container fails if two plugins adjust the same
+# setting. The updates of a later plugin take precedence.
+# The only builtin plugin currently available is "default_ulimits", which sets the
+# ulimits of all containers, configured in the format of default_ulimits.
+# Example:
+# [crio.nri.builtin_plugins.default_ulimits]
+# index = 0
+# position = "before"
+# config = "nofile=1024:2048, nproc=4096:4096"
+{{ range $name, $plugin := .NRI.BuiltinPlugins }}
+{{ $.Comment }}[crio.nri.builtin_plugins.{{ $name }}]
+{{ $.Comment }}index = {{ $plugin.Index }}
+{{ $.Comment }}position = "{{ $plugin.Position }}"
+{{ $.Comment }}config = "{{ $plugin.Config }}"
+{{ end }}
+`
@@ -3,8 +3,12 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 
+	"github.com/cri-o/cri-o/internal/config/node"
 	"github.com/opencontainers/runtime-tools/generate"
 	"github.com/sirupsen/logrus"
 	"k8s.io/utils/cpuset"
@@ -18,6 +22,9 @@ const (
 	// defined here:
 	// https://github.com/torvalds/linux/blob/cac03ac368fabff0122853de2422d4e17a32de08/kernel/sched/core.c#L10546
 	minQuotaPeriod = 1000
+	// The range of the blkio weight, where 0 means unset.
+	minBlkioWeight = 10
+	maxBlkioWeight = 1000
 )
 
 type Workloads map[string]*WorkloadConfig
@@ -46,6 +53,12 @@ type WorkloadConfig struct {
 	// `cpuperiod`: configure cpu period for a given container
 	// `cpuset`: configure cpuset for a given container
 	// `cpulimit`: configure cpu quota in millicores for a given container, overrides the `cpuquota` field
+	// `memorylimit`: configure memory limit in bytes for a given container
+	// `memoryreservation`: configure memory reservation in bytes for a given container
+	// `memoryswap`: configure the memory plus swap limit in bytes for a given container
+	// `hugepagelimits`: configure hugepage limits in bytes per page size for a given container
+	// `pidslimit`: configure the maximum number of processes for a given container
+	// `blkioweight`: configure the block IO weight for a given container
 	// The value of the map is the default value for that resource.
 	// If a container is configured to use this workload, and does not specify
 	// the annotation with the resource and value, the default value will apply.
@@ -67,6 +80,21 @@ type Resources struct {
 	CPUSet string `json:"cpuset,omitempty"`
 	// Specifies the CPU limit in millicores. This will be used to calculate the CPU quota.
 	CPULimit int64 `json:"cpulimit,omitempty"`
+	// Specifies the memory limit of this Pod in bytes.
+	MemoryLimit int64 `json:"memorylimit,omitempty"`
+	// Specifies the memory reservation of this Pod in bytes.
+	MemoryReservation int64 `json:"memoryreservation,omitempty"`
+	// Specifies the memory plus swap limit of this Pod in bytes, where -1
+	// means unlimited swap.
+	MemorySwap int64 `json:"memoryswap,omitempty"`
+	// Specifies the hugepage limits of this Pod in bytes, keyed by the page
+	// size, for example "2MB".
+	HugepageLimits map[string]uint64 `json:"hugepagelimits,omitempty"`
+	// Specifies the maximum number of processes of this Pod, where -1 means
+	// unlimited.
+	PidsLimit int64 `json:"pidslimit,omitempty"`
+	// Specifies the block IO weight of this Pod, between 10 and 1000.
+	BlkioWeight uint16 `json:"blkioweight,omitempty"`
 }
 
 func (w Workloads) Validate() error {
@@ -184,12 +212,41 @@ func resourcesFromAnnotation(prefix, ctrName string, allAnnotations map[string]s
 	if resources.CPULimit == 0 {
 		resources.CPULimit = defaultResources.CPULimit
 	}
+	if resources.MemoryLimit == 0 {
+		resources.MemoryLimit = defaultResources.MemoryLimit
+	}
+	if resources.MemoryReservation == 0 {
+		resources.MemoryReservation = defaultResources.MemoryReservation
+	}
+	if resources.MemorySwap == 0 {
+		resources.MemorySwap = defaultResources.MemorySwap
+	}
+	if resources.PidsLimit == 0 {
+		resources.PidsLimit = defaultResources.PidsLimit
+	}
+	if resources.BlkioWeight == 0 {
+		resources.BlkioWeight = defaultResources.BlkioWeight
+	}
+	for pageSize, limit := range defaultResources.HugepageLimits {
+		if _, ok := resources.HugepageLimits[pageSize]; !ok {
+			if resources.HugepageLimits == nil {
+				resources.HugepageLimits = make(map[string]uint64, len(defaultResources.HugepageLimits))
+			}
+			resources.HugepageLimits[pageSize] = limit
+		}
+	}
 
 	// If a CPU Limit in Milli is supplied via the annotation, calculate quota with the given CPU period.
 	if resources.CPULimit != 0 {
 		resources.CPUQuota = milliCPUToQuota(resources.CPULimit, int64(resources.CPUPeriod))
 	}
 
+	// The merged resources have to be as valid as the defaults, for example
+	// an overridden memory limit must not exceed the default swap limit.
+	if err := resources.ValidateDefaults(); err != nil {
+		return nil, fmt.Errorf("invalid resources in annotation %s: %w", annotationKey, err)
+	}
+
 	return resources, nil
 }
 
@@ -228,10 +285,57 @@ func (r *Resources) ValidateDefaults() error {
 	if r.CPUPeriod != 0 && r.CPUPeriod < minQuotaPeriod {
 		return fmt.Errorf("cpuperiod %d cannot be less than 1000 microseconds", r.CPUPeriod)
 	}
+	if r.MemoryLimit < 0 {
+		return fmt.Errorf("memorylimit %d cannot be negative", r.MemoryLimit)
+	}
+	if r.MemoryReservation < 0 {
+		return fmt.Errorf("memoryreservation %d cannot be negative", r.MemoryReservation)
+	}
+	if r.MemoryLimit != 0 && r.MemoryReservation > r.MemoryLimit {
+		return fmt.Errorf("memoryreservation %d cannot be greater than memorylimit %d", r.MemoryReservation, r.MemoryLimit)
+	}
+	if r.MemorySwap < -1 {
+		return fmt.Errorf("memoryswap %d cannot be less than -1", r.MemorySwap)
+	}
+	if r.MemorySwap > 0 && r.MemorySwap < r.MemoryLimit {
+		return fmt.Errorf("memoryswap %d cannot be less than memorylimit %d", r.MemorySwap, r.MemoryLimit)
+	}
+	if r.MemorySwap != 0 && r.MemoryLimit == 0 {
+		return fmt.Errorf("memoryswap %d requires a memorylimit", r.MemorySwap)
+	}
+	for pageSize := range r.HugepageLimits {
+		if err := validateHugepageSize(pageSize); err != nil {
+			return err
+		}
+	}
+	if r.PidsLimit < -1 {
+		return fmt.Errorf("pidslimit %d cannot be less than -1", r.PidsLimit)
+	}
+	if r.BlkioWeight != 0 && (r.BlkioWeight < minBlkioWeight || r.BlkioWeight > maxBlkioWeight) {
+		return fmt.Errorf("blkioweight %d must be between %d and %d", r.BlkioWeight, minBlkioWeight, maxBlkioWeight)
+	}
 
 	return nil
 }
 
+// hugepageSizeRegexp matches the page sizes of the hugetlb cgroup controller
+// as used by the OCI runtimes, like "2MB" or "1GB".
+var hugepageSizeRegexp = regexp.MustCompile(`^([1-9][0-9]*)(KB|MB|GB)$`)
+
+// validateHugepageSize returns an error if the page size is not a power of two
+// in the format of the hugetlb cgroup controller.
+func validateHugepageSize(pageSize string) error {
+	match := hugepageSizeRegexp.FindStringSubmatch(pageSize)
+	if match == nil {
+		return fmt.Errorf("invalid hugepage size %q, expected <size>KB, <size>MB or <size>GB", pageSize)
+	}
+	size, err := strconv.ParseUint(match[1], 10, 32)
+	if err != nil || size&(size-1) != 0 {
+		return fmt.Errorf("invalid hugepage size %q, the size has to be a power of two", pageSize)
+	}
+	return nil
+}
+
 func (r *Resources) MutateSpec(specgen *generate.Generator) {
 	if r == nil {
 		return
@@ -248,4 +352,38 @@ func (r *Resources) MutateSpec(specgen *generate.Generator) {
 	if r.CPUPeriod != 0 {
 		specgen.SetLinuxResourcesCPUPeriod(r.CPUPeriod)
 	}
+	if r.MemoryLimit != 0 {
+		specgen.SetLinuxResourcesMemoryLimit(r.MemoryLimit)
+		// Keep the memory plus swap limit in sync with the memory limit,
+		// like it is done for the limits of the CRI.
+		swap := r.MemoryLimit
+		if r.MemorySwap != 0 {
+			swap = r.MemorySwap
+		}
+		// If node doesn't have memory swap, then skip setting
+		// otherwise the container creation fails.
+		if node.CgroupHasMemorySwap() {
+			specgen.SetLinuxResourcesMemorySwap(swap)
+		}
+	}
+	if r.MemoryReservation != 0 {
+		specgen.SetLinuxResourcesMemoryReservation(r.MemoryReservation)
+	}
+	// If the kernel has no support for hugetlb, silently ignore the limits
+	if len(r.HugepageLimits) > 0 && node.CgroupHasHugetlb() {
+		pageSizes := make([]string, 0, len(r.HugepageLimits))
+		for pageSize := range r.HugepageLimits {
+			pageSizes = append(pageSizes, pageSize)
+		}
+		slices.Sort(pageSizes)
+		for _, pageSize := range pageSizes {
+			specgen.AddLinuxResourcesHugepageLimit(pageSize, r.HugepageLimits[pageSize])
+		}
+	}
+	if r.PidsLimit != 0 {
+		specgen.SetLinuxResourcesPidsLimit(r.PidsLimit)
+	}
+	if r.BlkioWeight != 0 {
+		specgen.SetLinuxResourcesBlockIOWeight(r.BlkioWeight)
+	}
 }
@@ -72,6 +72,93 @@ var _ = t.Describe("Workloads config", func() {
 		Expect(err).To(HaveOccurred())
 	})
 
+	It("should fail on invalid memory, hugepage, pids and blkio resources", func() {
+		testCases := []struct {
+			description string
+			resources   config.Resources
+		}{
+			{
+				description: "when memorylimit is negative",
+				resources: config.Resources{
+					MemoryLimit: -1,
+				},
+			},
+			{
+				description: "when memoryreservation is greater than memorylimit",
+				resources: config.Resources{
+					MemoryLimit:       1024 * 1024 * 1024,
+					MemoryReservation: 2 * 1024 * 1024 * 1024,
+				},
+			},
+			{
+				description: "when memoryswap is less than memorylimit",
+				resources: config.Resources{
+					MemoryLimit: 1024 * 1024 * 1024,
+					MemorySwap:  1024 * 1024,
+				},
+			},
+			{
+				description: "when memoryswap is set without memorylimit",
+				resources: config.Resources{
+					MemorySwap: -1,
+				},
+			},
+			{
+				description: "when hugepage size is invalid",
+				resources: config.Resources{
+					HugepageLimits: map[string]uint64{"invalid": 1024},
+				},
+			},
+			{
+				description: "when hugepage size is without unit",
+				resources: config.Resources{
+					HugepageLimits: map[string]uint64{"2M": 1024},
+				},
+			},
+			{
+				description: "when hugepage size is in lower case",
+				resources: config.Resources{
+					HugepageLimits: map[string]uint64{"2mb": 1024},
+				},
+			},
+			{
+				description: "when hugepage size is not a power of two",
+				resources: config.Resources{
+					HugepageLimits: map[string]uint64{"3MB": 1024},
+				},
+			},
+			{
+				description: "when pidslimit is less than -1",
+				resources: config.Resources{
+					PidsLimit: -2,
+				},
+			},
+			{
+				description: "when blkioweight is out of range",
+				resources: config.Resources{
+					BlkioWeight: 1001,
+				},
+			},
+		}
+
+		for _, tc := range testCases {
+			By(tc.description, func() {
+				// Given
+				workloads := config.Workloads{
+					"management": &config.WorkloadConfig{
+						ActivationAnnotation: "target.workload.openshift.io/management",
+						AnnotationPrefix:     "resources.workload.openshift.io",
+						Resources:            &tc.resources,
+					},
+				}
+				// When
+				err := workloads.Validate()
+				// Then
+				Expect(err).To(HaveOccurred())
+			})
+		}
+	})
+
 	It("should contain default values for resources", func() {
 		// Given
 		workloads := config.Workloads{
@@ -116,6 +203,32 @@ var _ = t.Describe("Workloads config", func() {
 					CPUSet: "0-1",
 				},
 			},
+			{
+				description: "when memory resources are provided",
+				resources: config.Resources{
+					MemoryLimit:       1024 * 1024 * 1024,
+					MemoryReservation: 512 * 1024 * 1024,
+					MemorySwap:        -1,
+				},
+			},
+			{
+				description: "when only hugepagelimits are provided",
+				resources: config.Resources{
+					HugepageLimits: map[string]uint64{"2MB": 1024 * 1024 * 1024},
+				},
+			},
+			{
+				description: "when only pidslimit is provided",
+				resources: config.Resources{
+					PidsLimit: -1,
+				},
+			},
+			{
+				description: "when only blkioweight is provided",
+				resources: config.Resources{
+					BlkioWeight: 500,
+				},
+			},
 		}
 
 		for _, tc := range testCases {
@@ -168,6 +281,32 @@ var _ = t.Describe("Workloads config", func() {
 		}
 	})
 
+	It("resources should mutate the memory, pids and blkio of the container spec", func() {
+		// Given
+		resources := config.Resources{
+			MemoryLimit:       1024 * 1024 * 1024,
+			MemoryReservation: 512 * 1024 * 1024,
+			PidsLimit:         1024,
+			BlkioWeight:       500,
+		}
+		g := &generate.Generator{
+			Config: &rspec.Spec{
+				Linux: &rspec.Linux{
+					Resources: &rspec.LinuxResources{},
+				},
+			},
+		}
+
+		// When
+		resources.MutateSpec(g)
+
+		// Then
+		Expect(g.Config.Linux.Resources.Memory.Limit).To(Equal(pointer(int64(1024 * 1024 * 1024))))
+		Expect(g.Config.Linux.Resources.Memory.Reservation).To(Equal(pointer(int64(512 * 1024 * 1024))))
+		Expect(g.Config.Linux.Resources.Pids.Limit).To(Equal(int64(1024)))
+		Expect(g.Config.Linux.Resources.BlockIO.Weight).To(Equal(pointer(uint16(500))))
+	})
+
 	It("should mutate container spec based on annotation", func() {
 		const (
 			workloadsKey                = "management"
@@ -263,4 +402,83 @@ var _ = t.Describe("Workloads config", func() {
 			})
 		}
 	})
+
+	It("should mutate container memory and pids based on annotation", func() {
+		const (
+			containerName               = "limitbox"
+			resourceContainerPrefix     = "resources.workload.openshift.io"
+			resourceContainerAnnotation = resourceContainerPrefix + "/" + containerName
+			workloadTargetAnnotation    = "target.workload.openshift.io/management"
+		)
+
+		// Given
+		workloads := config.Workloads{
+			"management": &config.WorkloadConfig{
+				AnnotationPrefix:     resourceContainerPrefix,
+				ActivationAnnotation: workloadTargetAnnotation,
+				Resources: &config.Resources{
+					MemoryLimit: 1024 * 1024 * 1024,
+					PidsLimit:   512,
+				},
+			},
+		}
+		annotations := map[string]string{
+			resourceContainerAnnotation: `{"memorylimit":536870912,"memoryreservation":268435456}`,
+			workloadTargetAnnotation:    `{"effect":"PreferredDuringScheduling"}`,
+		}
+		g := &generate.Generator{
+			Config: &rspec.Spec{
+				Linux: &rspec.Linux{
+					Resources: &rspec.LinuxResources{},
+				},
+			},
+		}
+
+		// When
+		err := workloads.MutateSpecGivenAnnotations(containerName, g, annotations)
+
+		// Then
+		Expect(err).NotTo(HaveOccurred())
+		Expect(g.Config.Linux.Resources.Memory.Limit).To(Equal(pointer(int64(536870912))))
+		Expect(g.Config.Linux.Resources.Memory.Reservation).To(Equal(pointer(int64(268435456))))
+		Expect(g.Config.Linux.Resources.Pids.Limit).To(Equal(int64(512)))
+	})
+
+	It("should fail to mutate the spec if the annotation results in invalid resources", func() {
+		const (
+			containerName               = "limitbox"
+			resourceContainerPrefix     = "resources.workload.openshift.io"
+			resourceContainerAnnotation = resourceContainerPrefix + "/" + containerName
+			workloadTargetAnnotation    = "target.workload.openshift.io/management"
+		)
+
+		// Given
+		workloads := config.Workloads{
+			"management": &config.WorkloadConfig{
+				AnnotationPrefix:     resourceContainerPrefix,
+				ActivationAnnotation: workloadTargetAnnotation,
+				Resources: &config.Resources{
+					MemoryLimit: 512 * 1024 * 1024,
+					MemorySwap:  1024 * 1024 * 1024,
+				},
+			},
+		}
+		annotations := map[string]string{
+			resourceContainerAnnotation: `{"memorylimit":2147483648}`,
+			workloadTargetAnnotation:    `{"effect":"PreferredDuringScheduling"}`,
+		}
+		g := &generate.Generator{
+			Config: &rspec.Spec{
+				Linux: &rspec.Linux{
+					Resources: &rspec.LinuxResources{},
+				},
+			},
+		}
+
+		// When
+		err := workloads.MutateSpecGivenAnnotations(containerName, g, annotations)
+
+		// Then
+		Expect(err).To(HaveOccurred())
+	})
 })
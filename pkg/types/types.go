@@ -6,6 +6,7 @@ import (
 
 // ContainerInfo stores information about containers
 type ContainerInfo struct {
+	ID              string            `json:"id"`
 	Name            string            `json:"name"`
 	Pid             int               `json:"pid"`
 	Image           string            `json:"image"`     // If set, _some_ name of the image imageID; it may have NO RELATIONSHIP to the users’ requested image name.
@@ -18,6 +19,55 @@ type ContainerInfo struct {
 	Root            string            `json:"root"`
 	Sandbox         string            `json:"sandbox"`
 	IPs             []string          `json:"ip_addresses"`
+	State           string            `json:"state"`
+	RuntimeHandler  string            `json:"runtime_handler"`
+	NRIAudit        []NRIAuditEntry   `json:"nri_audit,omitempty"`
+}
+
+// NRIAuditExternalPlugins is the plugin of the audit entries of all external
+// NRI plugins. NRI merges their changes without recording which plugin made
+// them, so they cannot be attributed to the individual plugins.
+const NRIAuditExternalPlugins = "external"
+
+// NRIAuditEntry records the changes of an NRI plugin to a container
+type NRIAuditEntry struct {
+	Plugin  string           `json:"plugin"`
+	Event   string           `json:"event"`
+	Time    int64            `json:"time"` // Unix time in nanoseconds.
+	DryRun  bool             `json:"dry_run"`
+	Changes []NRIAuditChange `json:"changes"`
+}
+
+// NRIAuditChange is a changed field of the container spec or resources. The
+// path uses dots for the JSON fields and array indexes, like "process.env.3".
+type NRIAuditChange struct {
+	Path string `json:"path"`
+	Old  any    `json:"old,omitempty"`
+	New  any    `json:"new,omitempty"`
+}
+
+// PodInfo stores information about pod sandboxes
+type PodInfo struct {
+	ID             string            `json:"id"`
+	Name           string            `json:"name"`
+	Namespace      string            `json:"namespace"`
+	UID            string            `json:"uid"`
+	State          string            `json:"state"`
+	CreatedTime    int64             `json:"created_time"`
+	Labels         map[string]string `json:"labels"`
+	Annotations    map[string]string `json:"annotations"`
+	RuntimeHandler string            `json:"runtime_handler"`
+	IPs            []string          `json:"ip_addresses"`
+	InfraContainer string            `json:"infra_container"`
+	Containers     []string          `json:"containers"`
+}
+
+// InspectFilter restricts the pods and containers listed by the inspect API.
+// Empty fields match everything.
+type InspectFilter struct {
+	State          string // The container state, like "running", or the pod state "ready" or "notready".
+	LabelSelector  string // A Kubernetes label selector, like "app=nginx,tier!=frontend".
+	RuntimeHandler string
 }
 
 // IDMappings specifies the ID mappings used for containers.
@@ -28,9 +78,111 @@ type IDMappings struct {
 
 // CrioInfo stores information about the crio daemon
 type CrioInfo struct {
-	StorageDriver     string     `json:"storage_driver"`
-	StorageImage      string     `json:"storage_image"`
-	StorageRoot       string     `json:"storage_root"`
-	CgroupDriver      string     `json:"cgroup_driver"`
-	DefaultIDMappings IDMappings `json:"default_id_mappings"`
+	StorageDriver     string                       `json:"storage_driver"`
+	StorageImage      string                       `json:"storage_image"`
+	StorageRoot       string                       `json:"storage_root"`
+	CgroupDriver      string                       `json:"cgroup_driver"`
+	DefaultIDMappings IDMappings                   `json:"default_id_mappings"`
+	RuntimeHandlers   []RuntimeHandlerCapabilities `json:"runtime_handlers"`
+}
+
+// RuntimeHandlerCapabilities stores the features supported by a runtime
+// handler, as reported by its "features" subcommand
+type RuntimeHandlerCapabilities struct {
+	Name                    string   `json:"name"`
+	RuntimeType             string   `json:"runtime_type"`
+	FeaturesReported        bool     `json:"features_reported"` // If false, the runtime did not report its features and they are unknown.
+	OCIVersionMin           string   `json:"oci_version_min"`
+	OCIVersionMax           string   `json:"oci_version_max"`
+	MountOptions            []string `json:"mount_options"`
+	Namespaces              []string `json:"namespaces"`
+	SeccompActions          []string `json:"seccomp_actions"`
+	CgroupV2                bool     `json:"cgroup_v2"`
+	CgroupV2Controllers     []string `json:"cgroup_v2_controllers"` // The controllers of the node, if both the node and the runtime support cgroup v2.
+	UserNamespaces          bool     `json:"user_namespaces"`
+	RecursiveReadOnlyMounts bool     `json:"recursive_read_only_mounts"`
+	CheckpointRestore       bool     `json:"checkpoint_restore"`
+}
+
+// ImageGCResult is the result of an image garbage collection run.
+type ImageGCResult struct {
+	DryRun     bool           `json:"dry_run"`
+	Images     []ImageGCImage `json:"images"`
+	FreedBytes uint64         `json:"freed_bytes"`
+}
+
+// ImageGCImage is an image selected by the image garbage collection.
+type ImageGCImage struct {
+	ID       string   `json:"id"`
+	RepoTags []string `json:"repo_tags"`
+	Size     uint64   `json:"size"`
+	LastUsed int64    `json:"last_used"`
+	Reason   string   `json:"reason"`
+}
+
+// ImageInfo stores information about an image
+type ImageInfo struct {
+	ID          string   `json:"id"`
+	RepoTags    []string `json:"repo_tags"`
+	RepoDigests []string `json:"repo_digests"`
+	Size        uint64   `json:"size"`
+	Pinned      bool     `json:"pinned"`
+	LastUsed    int64    `json:"last_used"` // Unix time in nanoseconds, or 0 if the image has never been used.
+	UsageCount  uint64   `json:"usage_count"`
+}
+
+// CheckpointInfo stores information about a checkpoint image
+type CheckpointInfo struct {
+	ID              string   `json:"id"`
+	RepoTags        []string `json:"repo_tags"`
+	Size            uint64   `json:"size"`
+	Created         int64    `json:"created"` // Unix time in nanoseconds, or 0 if unknown.
+	Container       string   `json:"container"`
+	RootfsImageName string   `json:"rootfs_image_name"`
+	CRIOVersion     string   `json:"crio_version"`
+	CriuVersion     string   `json:"criu_version"`
+}
+
+// PodCheckpointInfo stores information about a pod checkpoint archive
+type PodCheckpointInfo struct {
+	ID         string   `json:"id"`
+	Path       string   `json:"path"`
+	Containers []string `json:"containers"`
+}
+
+// PullInfo stores information about an image pull in progress
+type PullInfo struct {
+	Image           string          `json:"image"`
+	Candidate       string          `json:"candidate"`  // The fully qualified image name currently being pulled, or "" while resolving the image name.
+	StartTime       int64           `json:"start_time"` // Unix time in nanoseconds.
+	DownloadedBytes uint64          `json:"downloaded_bytes"`
+	TotalBytes      int64           `json:"total_bytes"` // The sum of the known layer sizes.
+	Layers          []PullLayerInfo `json:"layers"`
+	Pods            []string        `json:"pods"` // The namespace/name of the pods waiting on the pull.
+}
+
+// PullLayerInfo stores the progress of a single blob of an image pull
+type PullLayerInfo struct {
+	Digest          string `json:"digest"`
+	MediaType       string `json:"media_type"`
+	Size            int64  `json:"size"` // -1 if unknown.
+	DownloadedBytes uint64 `json:"downloaded_bytes"`
+	State           string `json:"state"` // One of "pending", "downloading", "done" or "skipped".
+}
+
+// DebugInfo stores the current debug settings of the crio daemon
+type DebugInfo struct {
+	LogLevel                      string          `json:"log_level"`
+	LogFilter                     string          `json:"log_filter"`
+	TracingEnabled                bool            `json:"tracing_enabled"`
+	TracingSamplingRatePerMillion int             `json:"tracing_sampling_rate_per_million"`
+	Overrides                     []DebugOverride `json:"overrides"`
+}
+
+// DebugOverride is a temporary change of a debug setting
+type DebugOverride struct {
+	Setting  string `json:"setting"`
+	Value    string `json:"value"`
+	Previous string `json:"previous"`
+	Expires  int64  `json:"expires"` // Unix time in nanoseconds.
 }
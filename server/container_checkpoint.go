@@ -2,8 +2,11 @@ package server
 
 import (
 	"errors"
+	"path/filepath"
+	"strings"
 
 	metadata "github.com/checkpoint-restore/checkpointctl/lib"
+	"github.com/containers/storage/pkg/stringid"
 	"github.com/cri-o/cri-o/internal/lib"
 	"github.com/cri-o/cri-o/internal/log"
 	"golang.org/x/net/context"
@@ -18,7 +21,7 @@ func (s *Server) CheckpointContainer(ctx context.Context, req *types.CheckpointC
 		return nil, errors.New("checkpoint/restore support not available")
 	}
 
-	_, err := s.GetContainerFromShortID(ctx, req.ContainerId)
+	ctr, err := s.GetContainerFromShortID(ctx, req.ContainerId)
 	if err != nil {
 		return nil, status.Errorf(codes.NotFound, "could not find container %q: %v", req.ContainerId, err)
 	}
@@ -31,7 +34,15 @@ func (s *Server) CheckpointContainer(ctx context.Context, req *types.CheckpointC
 		TargetFile: req.Location,
 		// For the forensic container checkpointing use case we
 		// keep the container running after checkpointing it.
-		KeepRunning: true,
+		KeepRunning:            true,
+		IncrementalChainLength: s.config.IncrementalCheckpointChainLength,
+	}
+	if s.config.CheckpointImages {
+		// The archive is still written to the requested location, which
+		// the kubelet always sets.
+		opts.TargetImage = checkpointImageName(req.Location, ctr.ID())
+		opts.ImageCompression = s.config.CheckpointImageCompression
+		log.Infof(ctx, "Storing checkpoint of container %s as image %s", req.ContainerId, opts.TargetImage)
 	}
 
 	_, err = s.ContainerServer.ContainerCheckpoint(ctx, config, opts)
@@ -43,3 +54,27 @@ func (s *Server) CheckpointContainer(ctx context.Context, req *types.CheckpointC
 
 	return &types.CheckpointContainerResponse{}, nil
 }
+
+// checkpointImageName returns the name of the checkpoint image of the
+// container, which is derived from the requested checkpoint location.
+func checkpointImageName(location, ctrID string) string {
+	name := strings.TrimSuffix(filepath.Base(location), filepath.Ext(location))
+	if location == "" {
+		name = "checkpoint-" + stringid.TruncateID(ctrID)
+	}
+
+	// Map everything not allowed within a reference path component to a
+	// separator.
+	name = strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '-'
+	}, strings.ToLower(name))
+	name = strings.Trim(name, "-")
+	if name == "" {
+		name = "checkpoint-" + stringid.TruncateID(ctrID)
+	}
+
+	return "localhost/" + name + ":latest"
+}
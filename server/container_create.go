@@ -426,6 +426,10 @@ func (s *Server) CreateContainer(ctx context.Context, req *types.CreateContainer
 	if err != nil {
 		return nil, err
 	}
+	resourceCleaner.Add(ctx, "createCtr: unmounting images of container "+ctr.ID(), func() error {
+		s.unmountImages(ctx, newContainer.Volumes())
+		return nil
+	})
 	resourceCleaner.Add(ctx, "createCtr: deleting container "+ctr.ID()+" from storage", func() error {
 		if err := s.StorageRuntimeServer().DeleteContainer(ctx, ctr.ID()); err != nil {
 			return fmt.Errorf("failed to cleanup container storage: %w", err)
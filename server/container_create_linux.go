@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -29,6 +30,7 @@ import (
 	"github.com/cri-o/cri-o/internal/runtimehandlerhooks"
 	"github.com/cri-o/cri-o/internal/storage"
 	crioann "github.com/cri-o/cri-o/pkg/annotations"
+	crioTypes "github.com/cri-o/cri-o/pkg/types"
 	securejoin "github.com/cyphar/filepath-securejoin"
 	rspec "github.com/opencontainers/runtime-spec/specs-go"
 	"github.com/opencontainers/runtime-tools/generate"
@@ -223,6 +225,9 @@ func (s *Server) createSandboxContainer(ctx context.Context, ctr ctrfactory.Cont
 
 	imageName := imgResult.SomeNameOfThisImage
 	imageID := imgResult.ID
+	if err := s.StorageImageServer().RecordImageUsage(imageID); err != nil {
+		log.Warnf(ctx, "Unable to record the usage of image %s: %v", imageID, err)
+	}
 	someRepoDigest := ""
 	if len(imgResult.RepoDigests) > 0 {
 		someRepoDigest = imgResult.RepoDigests[0]
@@ -313,7 +318,18 @@ func (s *Server) createSandboxContainer(ctx context.Context, ctr ctrfactory.Cont
 	s.resourceStore.SetStageForResource(ctx, ctr.Name(), "container volume configuration")
 	idMapSupport := s.Runtime().RuntimeSupportsIDMap(sb.RuntimeHandler())
 	rroSupport := s.Runtime().RuntimeSupportsRROMounts(sb.RuntimeHandler())
-	containerVolumes, ociMounts, err := addOCIBindMounts(ctx, ctr, mountLabel, s.config.RuntimeConfig.BindMountPrefix, s.config.AbsentMountSourcesToReject, maybeRelabel, skipRelabel, cgroup2RW, idMapSupport, rroSupport, s.Config().Root)
+	imageMounts, err := s.mountImages(ctx, ctr, containerInfo.Dir, mountLabel)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if retErr != nil {
+			for _, mount := range imageMounts {
+				s.unmountImage(ctx, mount.imageID)
+			}
+		}
+	}()
+	containerVolumes, ociMounts, err := addOCIBindMounts(ctx, ctr, mountLabel, s.config.RuntimeConfig.BindMountPrefix, s.config.AbsentMountSourcesToReject, maybeRelabel, skipRelabel, cgroup2RW, idMapSupport, rroSupport, s.Config().Root, imageMounts)
 	if err != nil {
 		return nil, err
 	}
@@ -925,6 +941,14 @@ func (s *Server) createSandboxContainer(ctx context.Context, ctr ctrfactory.Cont
 		}
 	}
 
+	capabilities, err := s.Runtime().RuntimeHandlerCapabilities(sb.RuntimeHandler())
+	if err != nil {
+		return nil, err
+	}
+	if err := validateRuntimeCapabilities(specgen.Config, capabilities); err != nil {
+		return nil, fmt.Errorf("container %s is not supported: %w", ociContainer.ID(), err)
+	}
+
 	saveOptions := generate.ExportOptions{}
 	if err := specgen.SaveToFile(filepath.Join(containerInfo.Dir, "config.json"), saveOptions); err != nil {
 		return nil, err
@@ -1025,7 +1049,7 @@ func clearReadOnly(m *rspec.Mount) {
 	m.Options = append(m.Options, "rw")
 }
 
-func addOCIBindMounts(ctx context.Context, ctr ctrfactory.Container, mountLabel, bindMountPrefix string, absentMountSourcesToReject []string, maybeRelabel, skipRelabel, cgroup2RW, idMapSupport, rroSupport bool, storageRoot string) ([]oci.ContainerVolume, []rspec.Mount, error) {
+func addOCIBindMounts(ctx context.Context, ctr ctrfactory.Container, mountLabel, bindMountPrefix string, absentMountSourcesToReject []string, maybeRelabel, skipRelabel, cgroup2RW, idMapSupport, rroSupport bool, storageRoot string, imageMounts map[string]*imageMount) ([]oci.ContainerVolume, []rspec.Mount, error) {
 	ctx, span := log.StartSpan(ctx)
 	defer span.End()
 
@@ -1074,6 +1098,15 @@ func addOCIBindMounts(ctx context.Context, ctr ctrfactory.Container, mountLabel,
 		if dest == "" {
 			return nil, nil, errors.New("mount.ContainerPath is empty")
 		}
+		if m.GetImage().GetImage() != "" {
+			volume, ociMount, err := addOCIImageMount(ctx, m, imageMounts, mountLabel, maybeRelabel, skipRelabel, idMapSupport)
+			if err != nil {
+				return nil, nil, err
+			}
+			volumes = append(volumes, *volume)
+			ociMounts = append(ociMounts, *ociMount)
+			continue
+		}
 		if m.HostPath == "" {
 			return nil, nil, errors.New("mount.HostPath is empty")
 		}
@@ -1228,6 +1261,52 @@ func addOCIBindMounts(ctx context.Context, ctr ctrfactory.Container, mountLabel,
 	return volumes, ociMounts, nil
 }
 
+// addOCIImageMount returns the volume and the read-only bind mount of the
+// image or artifact mounted for the image volume m.
+func addOCIImageMount(ctx context.Context, m *types.Mount, imageMounts map[string]*imageMount, mountLabel string, maybeRelabel, skipRelabel, idMapSupport bool) (*oci.ContainerVolume, *rspec.Mount, error) {
+	if m.HostPath != "" {
+		return nil, nil, fmt.Errorf("mount.HostPath has to be empty for image volume %s", m.ContainerPath)
+	}
+	mount, ok := imageMounts[filepath.Clean(m.ContainerPath)]
+	if !ok {
+		return nil, nil, fmt.Errorf("image %s of volume %s is not mounted", m.Image.Image, m.ContainerPath)
+	}
+
+	// Storage images get labeled when being mounted, while the artifacts
+	// are written into the container directory.
+	if mount.imageID == "" && m.SelinuxRelabel {
+		if skipRelabel {
+			log.Debugf(ctx, "Skipping relabel for %s because of super privileged container (type: spc_t)", mount.mountPoint)
+		} else if err := securityLabel(mount.mountPoint, mountLabel, false, maybeRelabel); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	uidMappings := getOCIMappings(m.UidMappings)
+	gidMappings := getOCIMappings(m.GidMappings)
+	if (uidMappings != nil || gidMappings != nil) && !idMapSupport {
+		return nil, nil, errors.New("idmap mounts specified but OCI runtime does not support them. Perhaps the OCI runtime is too old")
+	}
+
+	volume := &oci.ContainerVolume{
+		ContainerPath:  m.ContainerPath,
+		HostPath:       mount.mountPoint,
+		Readonly:       true,
+		Propagation:    types.MountPropagation_PROPAGATION_PRIVATE,
+		SelinuxRelabel: m.SelinuxRelabel,
+		Image:          m.Image,
+		ImageID:        mount.imageID,
+	}
+	ociMount := &rspec.Mount{
+		Source:      mount.mountPoint,
+		Destination: m.ContainerPath,
+		Options:     []string{"rbind", "rprivate", "ro", "nosuid", "nodev"},
+		UIDMappings: uidMappings,
+		GIDMappings: gidMappings,
+	}
+	return volume, ociMount, nil
+}
+
 func getOCIMappings(m []*types.IDMapping) []rspec.LinuxIDMapping {
 	if len(m) == 0 {
 		return nil
@@ -1364,3 +1443,68 @@ func isSubDirectoryOf(base, target string) bool {
 	}
 	return strings.HasPrefix(base, target)
 }
+
+// runtimeMountOptions are the mount options derived from the CRI mounts, which
+// depend on the support of the runtime.
+var runtimeMountOptions = []string{
+	"private", "rprivate", "shared", "rshared", "slave", "rslave",
+	"rro", "idmap", "ridmap",
+}
+
+// validateRuntimeCapabilities checks that the runtime handler supports the
+// namespaces, mount options, seccomp actions and cgroup v2 controllers used by
+// the spec, to fail with a clear error instead of a failing runtime. Features
+// which the runtime does not report are not checked.
+func validateRuntimeCapabilities(spec *rspec.Spec, capabilities *crioTypes.RuntimeHandlerCapabilities) error {
+	if !capabilities.FeaturesReported || spec.Linux == nil {
+		return nil
+	}
+	handler := capabilities.Name
+
+	if len(capabilities.Namespaces) > 0 {
+		for _, ns := range spec.Linux.Namespaces {
+			if !slices.Contains(capabilities.Namespaces, string(ns.Type)) {
+				return fmt.Errorf("runtime handler %q does not support the %s namespace", handler, ns.Type)
+			}
+		}
+	}
+
+	if len(capabilities.MountOptions) > 0 {
+		for _, m := range spec.Mounts {
+			for _, option := range m.Options {
+				if slices.Contains(runtimeMountOptions, option) && !slices.Contains(capabilities.MountOptions, option) {
+					return fmt.Errorf("runtime handler %q does not support the mount option %q of mount %s", handler, option, m.Destination)
+				}
+			}
+		}
+	}
+
+	if seccomp := spec.Linux.Seccomp; seccomp != nil && len(capabilities.SeccompActions) > 0 {
+		actions := []rspec.LinuxSeccompAction{seccomp.DefaultAction}
+		for i := range seccomp.Syscalls {
+			actions = append(actions, seccomp.Syscalls[i].Action)
+		}
+		for _, action := range actions {
+			if action != "" && !slices.Contains(capabilities.SeccompActions, string(action)) {
+				return fmt.Errorf("runtime handler %q does not support the seccomp action %s", handler, action)
+			}
+		}
+	}
+
+	if resources := spec.Linux.Resources; resources != nil && len(resources.Unified) > 0 && node.CgroupIsV2() {
+		if !capabilities.CgroupV2 {
+			return fmt.Errorf("runtime handler %q does not support cgroup v2", handler)
+		}
+		for key := range resources.Unified {
+			controller, _, _ := strings.Cut(key, ".")
+			if controller == "cgroup" || len(capabilities.CgroupV2Controllers) == 0 {
+				continue
+			}
+			if !slices.Contains(capabilities.CgroupV2Controllers, controller) {
+				return fmt.Errorf("cgroup v2 controller %s of %s is not available for runtime handler %q", controller, key, handler)
+			}
+		}
+	}
+
+	return nil
+}
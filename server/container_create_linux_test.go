@@ -2,9 +2,15 @@ package server
 
 import (
 	"context"
+	"slices"
 	"testing"
 
+	"github.com/cri-o/cri-o/internal/config/ociartifact"
 	"github.com/cri-o/cri-o/internal/factory/container"
+	crioTypes "github.com/cri-o/cri-o/pkg/types"
+	digest "github.com/opencontainers/go-digest"
+	v1 "github.com/opencontainers/image-spec/specs-go/v1"
+	rspec "github.com/opencontainers/runtime-spec/specs-go"
 	types "k8s.io/cri-api/pkg/apis/runtime/v1"
 )
 
@@ -31,7 +37,7 @@ func TestAddOCIBindsForDev(t *testing.T) {
 		t.Error(err)
 	}
 
-	_, binds, err := addOCIBindMounts(context.Background(), ctr, "", "", nil, false, false, false, false, false, "")
+	_, binds, err := addOCIBindMounts(context.Background(), ctr, "", "", nil, false, false, false, false, false, "", nil)
 	if err != nil {
 		t.Error(err)
 	}
@@ -75,7 +81,7 @@ func TestAddOCIBindsForSys(t *testing.T) {
 		t.Error(err)
 	}
 
-	_, binds, err := addOCIBindMounts(context.Background(), ctr, "", "", nil, false, false, false, false, false, "")
+	_, binds, err := addOCIBindMounts(context.Background(), ctr, "", "", nil, false, false, false, false, false, "", nil)
 	if err != nil {
 		t.Error(err)
 	}
@@ -124,7 +130,7 @@ func TestAddOCIBindsRROMounts(t *testing.T) {
 
 	ctx := context.TODO()
 
-	_, binds, err := addOCIBindMounts(ctx, ctr, "", "", nil, false, false, false, false, true, "")
+	_, binds, err := addOCIBindMounts(ctx, ctr, "", "", nil, false, false, false, false, true, "", nil)
 	if err != nil {
 		t.Errorf("Should not fail to create RRO mount, got: %v", err)
 	}
@@ -219,7 +225,7 @@ func TestAddOCIBindsRROMountsError(t *testing.T) {
 				t.Fatalf("Should set container configuration, got: %v", err)
 			}
 
-			_, _, err = addOCIBindMounts(ctx, ctr, "", "", nil, false, false, false, false, tc.rroSupport, "")
+			_, _, err = addOCIBindMounts(ctx, ctr, "", "", nil, false, false, false, false, tc.rroSupport, "", nil)
 			if err == nil {
 				t.Error("Should fail to add an RRO mount with a specific error")
 			}
@@ -248,7 +254,7 @@ func TestAddOCIBindsCGroupRW(t *testing.T) {
 	}); err != nil {
 		t.Error(err)
 	}
-	_, _, err = addOCIBindMounts(context.Background(), ctr, "", "", nil, false, false, true, false, false, "")
+	_, _, err = addOCIBindMounts(context.Background(), ctr, "", "", nil, false, false, true, false, false, "", nil)
 	if err != nil {
 		t.Error(err)
 	}
@@ -282,7 +288,7 @@ func TestAddOCIBindsCGroupRW(t *testing.T) {
 		t.Error(err)
 	}
 	var hasCgroupRO bool
-	_, _, err = addOCIBindMounts(context.Background(), ctr, "", "", nil, false, false, false, false, false, "")
+	_, _, err = addOCIBindMounts(context.Background(), ctr, "", "", nil, false, false, false, false, false, "", nil)
 	if err != nil {
 		t.Error(err)
 	}
@@ -330,12 +336,12 @@ func TestAddOCIBindsErrorWithoutIDMap(t *testing.T) {
 	}); err != nil {
 		t.Fatal(err)
 	}
-	_, _, err = addOCIBindMounts(context.Background(), ctr, "", "", nil, false, false, false, false, false, "")
+	_, _, err = addOCIBindMounts(context.Background(), ctr, "", "", nil, false, false, false, false, false, "", nil)
 	if err == nil {
 		t.Errorf("Should have failed to create id mapped mount with no id map support")
 	}
 
-	_, _, err = addOCIBindMounts(context.Background(), ctr, "", "", nil, false, false, false, true, false, "")
+	_, _, err = addOCIBindMounts(context.Background(), ctr, "", "", nil, false, false, false, true, false, "", nil)
 	if err != nil {
 		t.Errorf("%v", err)
 	}
@@ -365,3 +371,168 @@ func TestIsSubDirectoryOf(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateRuntimeCapabilities(t *testing.T) {
+	capabilities := &crioTypes.RuntimeHandlerCapabilities{
+		Name:             "runc",
+		FeaturesReported: true,
+		MountOptions:     []string{"ro", "rbind", "rprivate"},
+		Namespaces:       []string{"mount", "network", "pid"},
+		SeccompActions:   []string{"SCMP_ACT_ALLOW", "SCMP_ACT_ERRNO"},
+	}
+	newSpec := func() *rspec.Spec {
+		return &rspec.Spec{
+			Mounts: []rspec.Mount{
+				{Destination: "/data", Options: []string{"rbind", "rprivate", "ro", "tmpcopyup"}},
+			},
+			Linux: &rspec.Linux{
+				Namespaces: []rspec.LinuxNamespace{
+					{Type: rspec.MountNamespace},
+					{Type: rspec.PIDNamespace},
+				},
+				Seccomp: &rspec.LinuxSeccomp{
+					DefaultAction: rspec.ActErrno,
+					Syscalls: []rspec.LinuxSyscall{
+						{Names: []string{"read"}, Action: rspec.ActAllow},
+					},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(*rspec.Spec)
+		wantErr bool
+	}{
+		{"supported", func(*rspec.Spec) {}, false},
+		{"unsupported namespace", func(s *rspec.Spec) {
+			s.Linux.Namespaces = append(s.Linux.Namespaces, rspec.LinuxNamespace{Type: rspec.UserNamespace})
+		}, true},
+		{"unsupported mount option", func(s *rspec.Spec) {
+			s.Mounts[0].Options = append(s.Mounts[0].Options, "rshared")
+		}, true},
+		{"unsupported seccomp action", func(s *rspec.Spec) {
+			s.Linux.Seccomp.Syscalls[0].Action = rspec.ActNotify
+		}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := newSpec()
+			tt.modify(spec)
+			err := validateRuntimeCapabilities(spec, capabilities)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("got error %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	t.Run("unreported features", func(t *testing.T) {
+		spec := newSpec()
+		spec.Linux.Namespaces = append(spec.Linux.Namespaces, rspec.LinuxNamespace{Type: rspec.UserNamespace})
+		if err := validateRuntimeCapabilities(spec, &crioTypes.RuntimeHandlerCapabilities{Name: "kata"}); err != nil {
+			t.Errorf("unexpected error %v", err)
+		}
+	})
+}
+
+func TestAddOCIBindsImageMounts(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		mount       *types.Mount
+		imageMounts map[string]*imageMount
+		wantErr     bool
+	}{
+		{
+			name:  "image",
+			mount: &types.Mount{ContainerPath: "/image/", Image: &types.ImageSpec{Image: "image"}},
+			imageMounts: map[string]*imageMount{
+				"/image": {mountPoint: "/storage/image", imageID: "id"},
+			},
+		},
+		{
+			name:  "artifact",
+			mount: &types.Mount{ContainerPath: "/image", Image: &types.ImageSpec{Image: "artifact"}},
+			imageMounts: map[string]*imageMount{
+				"/image": {mountPoint: "/storage/image"},
+			},
+		},
+		{
+			name:    "not mounted",
+			mount:   &types.Mount{ContainerPath: "/image", Image: &types.ImageSpec{Image: "image"}},
+			wantErr: true,
+		},
+		{
+			name:  "host path",
+			mount: &types.Mount{ContainerPath: "/image", HostPath: "/host", Image: &types.ImageSpec{Image: "image"}},
+			imageMounts: map[string]*imageMount{
+				"/image": {mountPoint: "/storage/image", imageID: "id"},
+			},
+			wantErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctr, err := container.New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := ctr.SetConfig(&types.ContainerConfig{
+				Mounts: []*types.Mount{tc.mount},
+				Metadata: &types.ContainerMetadata{
+					Name: "testctr",
+				},
+			}, &types.PodSandboxConfig{
+				Metadata: &types.PodSandboxMetadata{
+					Name: "testpod",
+				},
+			}); err != nil {
+				t.Fatal(err)
+			}
+
+			volumes, binds, err := addOCIBindMounts(context.Background(), ctr, "", "", nil, false, false, false, false, false, "", tc.imageMounts)
+			if tc.wantErr {
+				if err == nil {
+					t.Error("Should have failed to add the image mount")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			mount := tc.imageMounts["/image"]
+			if len(volumes) != 1 || volumes[0].HostPath != mount.mountPoint || !volumes[0].Readonly ||
+				volumes[0].Image != tc.mount.Image || volumes[0].ImageID != mount.imageID {
+				t.Errorf("Unexpected image volumes %+v", volumes)
+			}
+			if len(binds) != 1 || binds[0].Source != mount.mountPoint || binds[0].Destination != tc.mount.ContainerPath {
+				t.Fatalf("Unexpected image mounts %+v", binds)
+			}
+			if !slices.Contains(binds[0].Options, "ro") {
+				t.Errorf("Image mount is not read-only: %v", binds[0].Options)
+			}
+		})
+	}
+}
+
+func TestArtifactFileName(t *testing.T) {
+	const dgst = digest.Digest("sha256:039058c6f2c0cb492c533b0a4d14ef77cc0f78abccced5287d84a1a2011cfb81")
+	for _, tc := range []struct {
+		title    string
+		expected string
+	}{
+		{"config.yaml", "config.yaml"},
+		{"", dgst.Encoded()},
+		{"../config.yaml", dgst.Encoded()},
+		{"..", dgst.Encoded()},
+	} {
+		artifact := &ociartifact.Artifact{
+			Digest:      dgst,
+			Annotations: map[string]string{v1.AnnotationTitle: tc.title},
+		}
+		if name := artifactFileName(artifact); name != tc.expected {
+			t.Errorf("title %q: expected %q, got %q", tc.title, tc.expected, name)
+		}
+	}
+}
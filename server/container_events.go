@@ -1,42 +1,154 @@
 package server
 
 import (
+	"context"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 
+	"github.com/cri-o/cri-o/internal/eventjournal"
+	"github.com/cri-o/cri-o/internal/log"
+	"github.com/cri-o/cri-o/internal/oci"
+	"github.com/cri-o/cri-o/server/metrics"
+	rspec "github.com/opencontainers/runtime-spec/specs-go"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 	types "k8s.io/cri-api/pkg/apis/runtime/v1"
 )
 
+const (
+	// ContainerEventsResumeKey is the gRPC metadata key a reconnecting
+	// client can set on GetContainerEvents to the sequence of the last event
+	// it has received. All journaled events after that sequence are replayed
+	// before new events are sent.
+	ContainerEventsResumeKey = "crio-events-resume-from"
+
+	// ContainerEventsSequenceKey is the gRPC header metadata key which
+	// contains the sequence of the event preceding the first event sent on
+	// the stream. Every following event increments the sequence by one.
+	ContainerEventsSequenceKey = "crio-events-sequence"
+
+	// ContainerEventsRelistKey is the gRPC header metadata key which is set
+	// to "true" if the events after the sequence requested via
+	// ContainerEventsResumeKey are no longer journaled. No events are
+	// replayed in that case, and the client has to relist all containers.
+	ContainerEventsRelistKey = "crio-events-relist"
+
+	// containerEventsChanSize is the buffer size of the ContainerEventsChan.
+	containerEventsChanSize = 1000
+
+	// containerEventsJournalMaxEntries is the maximum number of events kept
+	// in the container events journal.
+	containerEventsJournalMaxEntries = 1000
+)
+
 type containerEventConn struct {
 	wg  sync.WaitGroup
 	err error
 }
 
+// containerEventsJournalDir returns the directory of the container events
+// journal, which lives next to the container exits directory.
+func containerEventsJournalDir(containerExitsDir string) string {
+	return filepath.Join(filepath.Dir(filepath.Clean(containerExitsDir)), "events")
+}
+
 // GetContainerEvents sends the stream of container events to clients
 func (s *Server) GetContainerEvents(_ *types.GetEventsRequest, ces types.RuntimeService_GetContainerEventsServer) error {
 	if !s.Config().EnablePodEvents {
 		return nil
 	}
 
-	s.containerEventStreamBroadcaster.Do(func() {
-		// note that this function will run indefinitely until ContainerEventsChan is closed
-		go s.broadcastEvents()
-	})
-
 	conn := &containerEventConn{
 		wg: sync.WaitGroup{},
 	}
-
-	s.containerEventClients.Store(ces, conn)
 	conn.wg.Add(1)
 
+	// register the client before starting the broadcaster, so that the
+	// events recovered during the server restore reach the first client
+	if err := s.registerContainerEventClient(ces, conn); err != nil {
+		return err
+	}
+
+	s.containerEventStreamBroadcaster.Do(func() {
+		// note that this function will run indefinitely until ContainerEventsChan is closed
+		go s.broadcastEvents()
+	})
+
 	// wait here until we don't want to send events to this client anymore
 	conn.wg.Wait()
 	s.containerEventClients.Delete(ces)
 	return conn.err
 }
 
+// registerContainerEventClient replays the journaled events a resuming client
+// has missed and adds it to the clients receiving the broadcasted events.
+func (s *Server) registerContainerEventClient(ces types.RuntimeService_GetContainerEventsServer, conn *containerEventConn) error {
+	s.containerEventsLock.Lock()
+	defer s.containerEventsLock.Unlock()
+
+	var (
+		sequence uint64
+		entries  []*eventjournal.Entry
+		relist   bool
+	)
+	if s.containerEventsJournal != nil {
+		sequence = s.containerEventsJournal.LastSequence()
+		if resumeFrom, ok := containerEventsResumeSequence(ces.Context()); ok {
+			var err error
+			entries, err = s.containerEventsJournal.Resume(resumeFrom)
+			if err != nil {
+				log.Warnf(ces.Context(), "Unable to replay container events, client has to relist: %v", err)
+				relist = true
+			} else if len(entries) > 0 {
+				sequence = entries[0].Sequence - 1
+			}
+		}
+	}
+
+	header := metadata.Pairs(ContainerEventsSequenceKey, strconv.FormatUint(sequence, 10))
+	if relist {
+		header.Set(ContainerEventsRelistKey, "true")
+	}
+	if err := ces.SendHeader(header); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := ces.Send(entry.Event); err != nil {
+			return err
+		}
+	}
+
+	s.containerEventClients.Store(ces, conn)
+	return nil
+}
+
+// containerEventsResumeSequence returns the sequence provided by the client
+// via the ContainerEventsResumeKey metadata, if any.
+func containerEventsResumeSequence(ctx context.Context) (uint64, bool) {
+	if ctx == nil {
+		return 0, false
+	}
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return 0, false
+	}
+	values := md.Get(ContainerEventsResumeKey)
+	if len(values) == 0 {
+		return 0, false
+	}
+	sequence, err := strconv.ParseUint(values[0], 10, 64)
+	if err != nil {
+		log.Warnf(ctx, "Ignoring invalid container events resume sequence %q: %v", values[0], err)
+		return 0, false
+	}
+	return sequence, true
+}
+
 func (s *Server) broadcastEvents() {
 	// notify all connections that ContainerEventsChan has been closed
 	defer s.containerEventClients.Range(func(_, value any) bool { // nolint: unparam
@@ -49,28 +161,180 @@ func (s *Server) broadcastEvents() {
 	})
 
 	for containerEvent := range s.ContainerEventsChan {
-		s.containerEventClients.Range(func(key, value any) bool {
-			stream, ok := key.(types.RuntimeService_GetContainerEventsServer)
-			if !ok {
-				return true
-			}
+		s.broadcastEvent(&containerEvent)
+	}
+}
 
-			conn, ok := value.(*containerEventConn)
-			if !ok {
-				return true
-			}
+// containerEventClient is a client connected to the container events stream.
+type containerEventClient struct {
+	stream types.RuntimeService_GetContainerEventsServer
+	conn   *containerEventConn
+}
 
-			if err := stream.Send(&containerEvent); err != nil {
-				code, _ := status.FromError(err)
-				// when the client closes the connection this error is expected
-				// so only log non transport closing errors
-				if code.Code() != codes.Unavailable && code.Message() != "transport is closing" {
-					conn.err = err
-				}
-				// notify our waiting client connection that we are done
-				conn.wg.Done()
+// broadcastEvent journals the provided event and sends it to all connected
+// clients.
+func (s *Server) broadcastEvent(containerEvent *types.ContainerEventResponse) {
+	for _, client := range s.journalContainerEvent(containerEvent) {
+		if err := client.stream.Send(containerEvent); err != nil {
+			code, _ := status.FromError(err)
+			// when the client closes the connection this error is expected
+			// so only log non transport closing errors
+			if code.Code() != codes.Unavailable && code.Message() != "transport is closing" {
+				client.conn.err = err
 			}
+			// notify our waiting client connection that we are done
+			client.conn.wg.Done()
+		}
+	}
+}
+
+// journalContainerEvent journals the provided event and returns the clients
+// it has to be sent to. Clients registering afterwards receive the event
+// when the journal gets replayed to them. The event is sent outside of the
+// lock, which therefore does not block the registration of clients on slow
+// ones.
+func (s *Server) journalContainerEvent(containerEvent *types.ContainerEventResponse) []containerEventClient {
+	s.containerEventsLock.Lock()
+	defer s.containerEventsLock.Unlock()
+
+	if s.containerEventsJournal != nil {
+		if _, err := s.containerEventsJournal.Append(containerEvent); err != nil {
+			log.Errorf(context.Background(), "Unable to journal event %s for container %s: %v", containerEvent.ContainerEventType, containerEvent.ContainerId, err)
+		}
+	}
+
+	var clients []containerEventClient
+	s.containerEventClients.Range(func(key, value any) bool {
+		stream, ok := key.(types.RuntimeService_GetContainerEventsServer)
+		if !ok {
 			return true
+		}
+
+		conn, ok := value.(*containerEventConn)
+		if !ok {
+			return true
+		}
+
+		clients = append(clients, containerEventClient{stream: stream, conn: conn})
+		return true
+	})
+	return clients
+}
+
+// recoverContainerEvents compares the state of the containers recovered by
+// LoadContainer with their last journaled event and generates the events
+// which have been missed while CRI-O was not running. The generated events
+// get broadcasted once the first client connects.
+func (s *Server) recoverContainerEvents(ctx context.Context) {
+	if !s.config.EnablePodEvents || s.containerEventsJournal == nil {
+		return
+	}
+	ctx, span := log.StartSpan(ctx)
+	defer span.End()
+
+	lastEvents := s.containerEventsJournal.LastEvents()
+	if len(lastEvents) == 0 {
+		return
+	}
+
+	containers, err := s.ContainerServer.ListContainers()
+	if err != nil {
+		log.Warnf(ctx, "Unable to list containers to recover events: %v", err)
+		return
+	}
+	for _, sb := range s.ContainerServer.ListSandboxes() {
+		if infra := sb.InfraContainer(); infra != nil {
+			containers = append(containers, infra)
+		}
+	}
+
+	for _, missed := range missedContainerEvents(containers, lastEvents) {
+		log.Infof(ctx, "Recovering missed event %s for container %s", missed.eventType, missed.container.ID())
+		s.generateCRIEvent(ctx, missed.container, missed.eventType)
+	}
+	for _, deleted := range deletedContainerEvents(containers, lastEvents) {
+		log.Infof(ctx, "Recovering missed event %s for container %s", deleted.ContainerEventType, deleted.ContainerId)
+		select {
+		case s.ContainerEventsChan <- *deleted:
+		default:
+			log.Errorf(ctx, "Failed to recover event %s for container %s", deleted.ContainerEventType, deleted.ContainerId)
+			metrics.Instance().MetricContainersEventsDroppedInc()
+		}
+	}
+}
+
+// containerEvent is an event of a container which has not been generated yet.
+type containerEvent struct {
+	container *oci.Container
+	eventType types.ContainerEventType
+}
+
+// missedContainerEvents returns the events which have been missed for the
+// containers, based on their last journaled events. Containers without
+// journaled event are skipped.
+func missedContainerEvents(containers []*oci.Container, lastEvents map[string]*types.ContainerEventResponse) []containerEvent {
+	var res []containerEvent
+	for _, c := range containers {
+		lastEvent, ok := lastEvents[c.ID()]
+		if !ok {
+			continue
+		}
+		if eventType, missed := missedContainerEvent(c.State().Status, lastEvent.ContainerEventType); missed {
+			res = append(res, containerEvent{container: c, eventType: eventType})
+		}
+	}
+	return res
+}
+
+// deletedContainerEvents returns the DELETED events which have been missed
+// for the journaled containers which no longer exist, ordered by container ID.
+// The pod sandbox status of the events is taken from the last journaled event
+// of the container, since the pod might not exist anymore either.
+func deletedContainerEvents(containers []*oci.Container, lastEvents map[string]*types.ContainerEventResponse) []*types.ContainerEventResponse {
+	exists := make(map[string]bool, len(containers))
+	for _, c := range containers {
+		exists[c.ID()] = true
+	}
+
+	var res []*types.ContainerEventResponse
+	for id, lastEvent := range lastEvents {
+		if exists[id] || lastEvent.ContainerEventType == types.ContainerEventType_CONTAINER_DELETED_EVENT {
+			continue
+		}
+		containersStatuses := make([]*types.ContainerStatus, 0, len(lastEvent.ContainersStatuses))
+		for _, containerStatus := range lastEvent.ContainersStatuses {
+			if containerStatus.GetId() != id {
+				containersStatuses = append(containersStatuses, containerStatus)
+			}
+		}
+		res = append(res, &types.ContainerEventResponse{
+			ContainerId:        id,
+			ContainerEventType: types.ContainerEventType_CONTAINER_DELETED_EVENT,
+			CreatedAt:          time.Now().UnixNano(),
+			PodSandboxStatus:   lastEvent.PodSandboxStatus,
+			ContainersStatuses: containersStatuses,
 		})
 	}
+	slices.SortFunc(res, func(a, b *types.ContainerEventResponse) int {
+		return strings.Compare(a.ContainerId, b.ContainerId)
+	})
+	return res
+}
+
+// missedContainerEvent returns the event type which has to be generated for a
+// container in the provided state if the last journaled event was
+// lastEventType.
+func missedContainerEvent(state rspec.ContainerState, lastEventType types.ContainerEventType) (types.ContainerEventType, bool) {
+	switch state {
+	case oci.ContainerStateRunning:
+		if lastEventType == types.ContainerEventType_CONTAINER_CREATED_EVENT {
+			return types.ContainerEventType_CONTAINER_STARTED_EVENT, true
+		}
+	case oci.ContainerStateStopped:
+		if lastEventType != types.ContainerEventType_CONTAINER_STOPPED_EVENT &&
+			lastEventType != types.ContainerEventType_CONTAINER_DELETED_EVENT {
+			return types.ContainerEventType_CONTAINER_STOPPED_EVENT, true
+		}
+	}
+	return 0, false
 }
@@ -0,0 +1,233 @@
+package server
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/cri-o/cri-o/internal/eventjournal"
+	"github.com/cri-o/cri-o/internal/oci"
+	containereventservermock "github.com/cri-o/cri-o/test/mocks/containereventserver"
+	"github.com/golang/mock/gomock"
+	rspec "github.com/opencontainers/runtime-spec/specs-go"
+	"google.golang.org/grpc/metadata"
+	types "k8s.io/cri-api/pkg/apis/runtime/v1"
+)
+
+func TestMissedContainerEvent(t *testing.T) {
+	for _, tc := range []struct {
+		state     rspec.ContainerState
+		lastEvent types.ContainerEventType
+		expected  types.ContainerEventType
+		missed    bool
+	}{
+		{oci.ContainerStateCreated, types.ContainerEventType_CONTAINER_CREATED_EVENT, 0, false},
+		{oci.ContainerStateRunning, types.ContainerEventType_CONTAINER_CREATED_EVENT, types.ContainerEventType_CONTAINER_STARTED_EVENT, true},
+		{oci.ContainerStateRunning, types.ContainerEventType_CONTAINER_STARTED_EVENT, 0, false},
+		{oci.ContainerStateStopped, types.ContainerEventType_CONTAINER_CREATED_EVENT, types.ContainerEventType_CONTAINER_STOPPED_EVENT, true},
+		{oci.ContainerStateStopped, types.ContainerEventType_CONTAINER_STARTED_EVENT, types.ContainerEventType_CONTAINER_STOPPED_EVENT, true},
+		{oci.ContainerStateStopped, types.ContainerEventType_CONTAINER_STOPPED_EVENT, 0, false},
+		{oci.ContainerStateStopped, types.ContainerEventType_CONTAINER_DELETED_EVENT, 0, false},
+	} {
+		eventType, missed := missedContainerEvent(tc.state, tc.lastEvent)
+		if missed != tc.missed || eventType != tc.expected {
+			t.Errorf("state %s after %s: expected %s (%v), got %s (%v)",
+				tc.state, tc.lastEvent, tc.expected, tc.missed, eventType, missed)
+		}
+	}
+}
+
+func TestMissedContainerEvents(t *testing.T) {
+	newContainer := func(id string, state rspec.ContainerState) *oci.Container {
+		c, err := oci.NewContainer(id, id, "", "", map[string]string{}, map[string]string{}, map[string]string{},
+			"", nil, nil, "", &types.ContainerMetadata{}, "sandbox", false, false, false, "", "", time.Now(), "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.State().Status = state
+		return c
+	}
+	started := newContainer("started", oci.ContainerStateRunning)
+	exited := newContainer("exited", oci.ContainerStateStopped)
+	containers := []*oci.Container{
+		started,
+		exited,
+		newContainer("unchanged", oci.ContainerStateRunning),
+		newContainer("unjournaled", oci.ContainerStateStopped),
+	}
+	lastEvents := map[string]*types.ContainerEventResponse{
+		"started":   {ContainerId: "started", ContainerEventType: types.ContainerEventType_CONTAINER_CREATED_EVENT},
+		"exited":    {ContainerId: "exited", ContainerEventType: types.ContainerEventType_CONTAINER_STARTED_EVENT},
+		"unchanged": {ContainerId: "unchanged", ContainerEventType: types.ContainerEventType_CONTAINER_STARTED_EVENT},
+		"removed":   {ContainerId: "removed", ContainerEventType: types.ContainerEventType_CONTAINER_STOPPED_EVENT},
+	}
+
+	missed := missedContainerEvents(containers, lastEvents)
+
+	expected := []containerEvent{
+		{container: started, eventType: types.ContainerEventType_CONTAINER_STARTED_EVENT},
+		{container: exited, eventType: types.ContainerEventType_CONTAINER_STOPPED_EVENT},
+	}
+	if !reflect.DeepEqual(missed, expected) {
+		t.Fatalf("expected missed events %v, got %v", expected, missed)
+	}
+}
+
+func TestDeletedContainerEvents(t *testing.T) {
+	c, err := oci.NewContainer("existing", "existing", "", "", map[string]string{}, map[string]string{}, map[string]string{},
+		"", nil, nil, "", &types.ContainerMetadata{}, "sandbox", false, false, false, "", "", time.Now(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	podStatus := &types.PodSandboxStatus{Id: "sandbox", Metadata: &types.PodSandboxMetadata{Uid: "uid"}}
+	lastEvents := map[string]*types.ContainerEventResponse{
+		"existing": {
+			ContainerId:        "existing",
+			ContainerEventType: types.ContainerEventType_CONTAINER_STOPPED_EVENT,
+			PodSandboxStatus:   podStatus,
+		},
+		"stopped": {
+			ContainerId:        "stopped",
+			ContainerEventType: types.ContainerEventType_CONTAINER_STOPPED_EVENT,
+			PodSandboxStatus:   podStatus,
+			ContainersStatuses: []*types.ContainerStatus{{Id: "stopped"}, {Id: "existing"}},
+		},
+		"created": {
+			ContainerId:        "created",
+			ContainerEventType: types.ContainerEventType_CONTAINER_CREATED_EVENT,
+			PodSandboxStatus:   podStatus,
+		},
+		"deleted": {
+			ContainerId:        "deleted",
+			ContainerEventType: types.ContainerEventType_CONTAINER_DELETED_EVENT,
+			PodSandboxStatus:   podStatus,
+		},
+	}
+
+	deleted := deletedContainerEvents([]*oci.Container{c}, lastEvents)
+
+	if len(deleted) != 2 {
+		t.Fatalf("expected 2 deleted events, got %v", deleted)
+	}
+	for i, id := range []string{"created", "stopped"} {
+		event := deleted[i]
+		if event.ContainerId != id || event.ContainerEventType != types.ContainerEventType_CONTAINER_DELETED_EVENT {
+			t.Errorf("expected DELETED event for %s, got %s for %s", id, event.ContainerEventType, event.ContainerId)
+		}
+		if event.PodSandboxStatus != podStatus {
+			t.Errorf("expected the journaled pod sandbox status for %s, got %v", id, event.PodSandboxStatus)
+		}
+		if event.CreatedAt == 0 {
+			t.Errorf("expected a creation time for %s", id)
+		}
+	}
+	if statuses := deleted[1].ContainersStatuses; len(statuses) != 1 || statuses[0].Id != "existing" {
+		t.Errorf("expected only the status of the existing container, got %v", statuses)
+	}
+}
+
+func TestBroadcastEventSendsOutsideOfLock(t *testing.T) {
+	journal, err := eventjournal.New(t.TempDir(), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &Server{containerEventsJournal: journal}
+	event := &types.ContainerEventResponse{ContainerId: "1"}
+
+	ces := containereventservermock.NewMockRuntimeService_GetContainerEventsServer(gomock.NewController(t))
+	ces.EXPECT().Send(event).Do(func(*types.ContainerEventResponse) {
+		if !s.containerEventsLock.TryLock() {
+			t.Error("expected the event to be sent without holding the lock")
+			return
+		}
+		s.containerEventsLock.Unlock()
+	}).Return(nil)
+	s.containerEventClients.Store(ces, &containerEventConn{})
+
+	s.broadcastEvent(event)
+
+	if sequence := journal.LastSequence(); sequence != 1 {
+		t.Errorf("expected the event to be journaled, got sequence %d", sequence)
+	}
+}
+
+func TestRegisterContainerEventClient(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		maxEntries int
+		resumeFrom string
+		header     metadata.MD
+		replayed   []string
+	}{
+		{
+			name:       "without resume",
+			maxEntries: 10,
+			header:     metadata.Pairs(ContainerEventsSequenceKey, "3"),
+		},
+		{
+			name:       "resume from sequence",
+			maxEntries: 10,
+			resumeFrom: "1",
+			header:     metadata.Pairs(ContainerEventsSequenceKey, "1"),
+			replayed:   []string{"2", "3"},
+		},
+		{
+			name:       "resume from the last sequence",
+			maxEntries: 10,
+			resumeFrom: "3",
+			header:     metadata.Pairs(ContainerEventsSequenceKey, "3"),
+		},
+		{
+			name:       "replay from the bounded journal",
+			maxEntries: 2,
+			resumeFrom: "1",
+			header:     metadata.Pairs(ContainerEventsSequenceKey, "1"),
+			replayed:   []string{"2", "3"},
+		},
+		{
+			name:       "relist if resuming before the journal",
+			maxEntries: 2,
+			resumeFrom: "0",
+			header:     metadata.Pairs(ContainerEventsSequenceKey, "3", ContainerEventsRelistKey, "true"),
+		},
+		{
+			name:       "relist if resuming after the journal",
+			maxEntries: 10,
+			resumeFrom: "4",
+			header:     metadata.Pairs(ContainerEventsSequenceKey, "3", ContainerEventsRelistKey, "true"),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			journal, err := eventjournal.New(t.TempDir(), tc.maxEntries)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, id := range []string{"1", "2", "3"} {
+				if _, err := journal.Append(&types.ContainerEventResponse{ContainerId: id}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			s := &Server{containerEventsJournal: journal}
+
+			ctx := context.Background()
+			if tc.resumeFrom != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(ContainerEventsResumeKey, tc.resumeFrom))
+			}
+			ces := containereventservermock.NewMockRuntimeService_GetContainerEventsServer(gomock.NewController(t))
+			ces.EXPECT().Context().Return(ctx).AnyTimes()
+			calls := []*gomock.Call{ces.EXPECT().SendHeader(tc.header).Return(nil)}
+			for _, id := range tc.replayed {
+				calls = append(calls, ces.EXPECT().Send(&types.ContainerEventResponse{ContainerId: id}).Return(nil))
+			}
+			gomock.InOrder(calls...)
+
+			if err := s.registerContainerEventClient(ces, &containerEventConn{}); err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := s.containerEventClients.Load(ces); !ok {
+				t.Fatal("expected the client to be registered")
+			}
+		})
+	}
+}
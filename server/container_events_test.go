@@ -1,9 +1,11 @@
 package server_test
 
 import (
+	"context"
 	"time"
 
 	containereventservermock "github.com/cri-o/cri-o/test/mocks/containereventserver"
+	"github.com/golang/mock/gomock"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	types "k8s.io/cri-api/pkg/apis/runtime/v1"
@@ -21,6 +23,11 @@ var events = []types.ContainerEventResponse{
 	},
 }
 
+func expectStreamSetup(ces *containereventservermock.MockRuntimeService_GetContainerEventsServer) {
+	ces.EXPECT().Context().Return(context.Background()).AnyTimes()
+	ces.EXPECT().SendHeader(gomock.Any()).Return(nil)
+}
+
 var _ = t.Describe("ContainerEvents", func() {
 	BeforeEach(func() {
 		beforeEach()
@@ -39,6 +46,7 @@ var _ = t.Describe("ContainerEvents", func() {
 	t.Describe("ContainerEvents", func() {
 		It("should send events to single client", func() {
 			cesMock := containereventservermock.NewMockRuntimeService_GetContainerEventsServer(mockCtrl)
+			expectStreamSetup(cesMock)
 			// EXPECT expects the exact object, so we can't use the copy range gives us
 			for i := range events {
 				cesMock.EXPECT().Send(&events[i]).Return(nil)
@@ -55,6 +63,8 @@ var _ = t.Describe("ContainerEvents", func() {
 		It("should send events all events to both clients", func() {
 			client1 := containereventservermock.NewMockRuntimeService_GetContainerEventsServer(mockCtrl)
 			client2 := containereventservermock.NewMockRuntimeService_GetContainerEventsServer(mockCtrl)
+			expectStreamSetup(client1)
+			expectStreamSetup(client2)
 
 			for i := range events {
 				client1.EXPECT().Send(&events[i]).Return(nil)
@@ -0,0 +1,127 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/cri-o/cri-o/internal/config/ociartifact"
+	ctrfactory "github.com/cri-o/cri-o/internal/factory/container"
+	"github.com/cri-o/cri-o/internal/log"
+	"github.com/cri-o/cri-o/internal/oci"
+	v1 "github.com/opencontainers/image-spec/specs-go/v1"
+	types "k8s.io/cri-api/pkg/apis/runtime/v1"
+)
+
+// imageMountArtifactMaxSize is the maximum size of an artifact which can be
+// mounted as an image volume.
+const imageMountArtifactMaxSize = 16 * 1024 * 1024 // 16 MiB
+
+// imageMount is an image or artifact mounted for an image volume.
+type imageMount struct {
+	// mountPoint is the directory containing the image or artifact.
+	mountPoint string
+
+	// imageID is the ID of the mounted storage image. It is empty if an
+	// artifact has been mounted.
+	imageID string
+}
+
+// mountImages mounts the images and artifacts referenced by the image volumes
+// of the container, pulling them if needed. The mounts are keyed by the clean
+// container path of their volumes. Artifacts are written into containerDir.
+func (s *Server) mountImages(ctx context.Context, ctr ctrfactory.Container, containerDir, mountLabel string) (mounts map[string]*imageMount, retErr error) {
+	ctx, span := log.StartSpan(ctx)
+	defer span.End()
+
+	mounts = make(map[string]*imageMount)
+	defer func() {
+		if retErr != nil {
+			for _, mount := range mounts {
+				s.unmountImage(ctx, mount.imageID)
+			}
+		}
+	}()
+
+	for _, m := range ctr.Config().Mounts {
+		if m.GetImage().GetImage() == "" {
+			continue
+		}
+		artifactDir := filepath.Join(containerDir, "image-mounts", strconv.Itoa(len(mounts)))
+		mount, err := s.mountImage(ctx, m.Image.Image, artifactDir, mountLabel)
+		if err != nil {
+			return nil, fmt.Errorf("mount image %s for volume %s: %w", m.Image.Image, m.ContainerPath, err)
+		}
+		mounts[filepath.Clean(m.ContainerPath)] = mount
+	}
+	return mounts, nil
+}
+
+// mountImage mounts the top layer of the image read-only via the storage,
+// labeled with mountLabel. References which cannot be pulled as image are
+// pulled as artifact, whose layer is written into artifactDir.
+func (s *Server) mountImage(ctx context.Context, ref, artifactDir, mountLabel string) (*imageMount, error) {
+	imageErr := s.pullImageIfNotPresent(ctx, ref)
+	if imageErr == nil {
+		status, err := s.storageImageStatus(ctx, types.ImageSpec{Image: ref})
+		if err != nil {
+			return nil, fmt.Errorf("get image status: %w", err)
+		}
+		if status == nil {
+			return nil, errors.New("image does not exist after pulling it")
+		}
+		imageID := status.ID.IDStringForOutOfProcessConsumptionOnly()
+		mountPoint, err := s.Store().MountImage(imageID, nil, mountLabel)
+		if err != nil {
+			return nil, fmt.Errorf("mount image %s: %w", imageID, err)
+		}
+		log.Infof(ctx, "Mounted image %s to %s", imageID, mountPoint)
+		return &imageMount{mountPoint: mountPoint, imageID: imageID}, nil
+	}
+
+	log.Infof(ctx, "Unable to pull %s as image, pulling it as artifact: %v", ref, imageErr)
+	artifact, err := ociartifact.New().Pull(ctx, ref, &ociartifact.PullOptions{
+		SystemContext: s.config.SystemContext,
+		MaxSize:       imageMountArtifactMaxSize,
+	})
+	if err != nil {
+		return nil, errors.Join(imageErr, fmt.Errorf("pull artifact: %w", err))
+	}
+	if err := os.MkdirAll(artifactDir, 0o755); err != nil {
+		return nil, fmt.Errorf("create artifact directory: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(artifactDir, artifactFileName(artifact)), artifact.Data, 0o644); err != nil {
+		return nil, fmt.Errorf("write artifact: %w", err)
+	}
+	return &imageMount{mountPoint: artifactDir}, nil
+}
+
+// artifactFileName returns the name of the file containing the layer of the
+// artifact, which is its title if set, or the encoded digest of the layer.
+func artifactFileName(artifact *ociartifact.Artifact) string {
+	title := artifact.Annotations[v1.AnnotationTitle]
+	if title != "" && title == filepath.Base(title) && title != "." && title != ".." {
+		return title
+	}
+	return artifact.Digest.Encoded()
+}
+
+// unmountImages unmounts the storage images of the image volumes, which
+// releases the references of the container to them.
+func (s *Server) unmountImages(ctx context.Context, volumes []oci.ContainerVolume) {
+	for i := range volumes {
+		s.unmountImage(ctx, volumes[i].ImageID)
+	}
+}
+
+func (s *Server) unmountImage(ctx context.Context, imageID string) {
+	if imageID == "" {
+		return
+	}
+	if _, err := s.Store().UnmountImage(imageID, false); err != nil {
+		log.Warnf(ctx, "Unable to unmount image %s: %v", imageID, err)
+	}
+}
@@ -74,6 +74,7 @@ func (s *Server) removeContainerInPod(ctx context.Context, sb *sandbox.Sandbox,
 	if err := s.StorageRuntimeServer().DeleteContainer(ctx, c.ID()); err != nil && !errors.Is(err, storage.ErrContainerUnknown) {
 		return fmt.Errorf("failed to delete container %s in pod sandbox %s: %w", c.Name(), sb.ID(), err)
 	}
+	s.unmountImages(ctx, c.Volumes())
 
 	s.ReleaseContainerName(ctx, c.Name())
 	s.removeContainer(ctx, c)
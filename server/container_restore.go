@@ -9,6 +9,7 @@ import (
 	metadata "github.com/checkpoint-restore/checkpointctl/lib"
 	"github.com/containers/storage/pkg/archive"
 	"github.com/cri-o/cri-o/internal/factory/container"
+	"github.com/cri-o/cri-o/internal/lib"
 	"github.com/cri-o/cri-o/internal/lib/sandbox"
 	"github.com/cri-o/cri-o/internal/log"
 	"github.com/cri-o/cri-o/internal/storage"
@@ -113,6 +114,7 @@ func (s *Server) CRImportCheckpoint(
 				metadata.NetworkStatusFile,
 				metadata.DeletedFilesFile,
 				metadata.CheckpointDirectory,
+				lib.CheckpointParentsDirectory,
 			},
 		}
 		mountPoint, err = os.MkdirTemp("", "checkpoint")
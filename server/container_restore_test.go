@@ -546,6 +546,9 @@ var _ = t.Describe("ContainerRestore", func() {
 				mockutils.InOrder(
 					imageLookup,
 
+					imageServerMock.EXPECT().RecordImageUsage(imageID).
+						Return(nil),
+
 					runtimeServerMock.EXPECT().CreateContainer(gomock.Any(), gomock.Any(),
 						gomock.Any(), gomock.Any(), imageID, gomock.Any(),
 						gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(),
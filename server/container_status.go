@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/cri-o/cri-o/internal/config/cgmgr"
 	"github.com/cri-o/cri-o/internal/log"
 	oci "github.com/cri-o/cri-o/internal/oci"
 	"github.com/cri-o/cri-o/internal/storage"
@@ -64,6 +65,7 @@ func (s *Server) ContainerStatus(ctx context.Context, req *types.ContainerStatus
 			RecursiveReadOnly: cv.RecursiveReadOnly,
 			Propagation:       cv.Propagation,
 			SelinuxRelabel:    cv.SelinuxRelabel,
+			Image:             cv.Image,
 		})
 	}
 	resp.Status.Mounts = mounts
@@ -123,7 +125,7 @@ func (s *Server) ContainerStatus(ctx context.Context, req *types.ContainerStatus
 	resp.Status.LogPath = c.LogPath()
 
 	if req.Verbose {
-		info, err := s.createContainerInfo(c)
+		info, err := s.createContainerInfo(ctx, c)
 		if err != nil {
 			return nil, fmt.Errorf("creating container info: %w", err)
 		}
@@ -134,10 +136,18 @@ func (s *Server) ContainerStatus(ctx context.Context, req *types.ContainerStatus
 }
 
 type containerInfo struct {
-	SandboxID   string    `json:"sandboxID"`
-	Pid         int       `json:"pid"`
-	RuntimeSpec spec.Spec `json:"runtimeSpec"`
-	Privileged  bool      `json:"privileged"`
+	SandboxID   string             `json:"sandboxID"`
+	Pid         int                `json:"pid"`
+	RuntimeSpec spec.Spec          `json:"runtimeSpec"`
+	Privileged  bool               `json:"privileged"`
+	Pressure    *containerPressure `json:"pressure,omitempty"`
+}
+
+// containerPressure is the pressure stall information of a running container.
+type containerPressure struct {
+	CPU    *cgmgr.PSIStats `json:"cpu,omitempty"`
+	Memory *cgmgr.PSIStats `json:"memory,omitempty"`
+	IO     *cgmgr.PSIStats `json:"io,omitempty"`
 }
 
 type containerInfoCheckpointRestore struct {
@@ -145,11 +155,12 @@ type containerInfoCheckpointRestore struct {
 	Restored       bool      `json:"restored"`
 }
 
-func (s *Server) createContainerInfo(container *oci.Container) (map[string]string, error) {
+func (s *Server) createContainerInfo(ctx context.Context, container *oci.Container) (map[string]string, error) {
 	metadata, err := s.StorageRuntimeServer().GetContainerMetadata(container.ID())
 	if err != nil {
 		return nil, fmt.Errorf("getting container metadata: %w", err)
 	}
+	pressure := s.containerPressure(ctx, container)
 
 	bytes, err := func(metadata *storage.RuntimeContainerMetadata) ([]byte, error) {
 		localContainerInfo := containerInfo{
@@ -157,6 +168,7 @@ func (s *Server) createContainerInfo(container *oci.Container) (map[string]strin
 			Pid:         container.StateNoLock().InitPid,
 			RuntimeSpec: container.Spec(),
 			Privileged:  metadata.Privileged,
+			Pressure:    pressure,
 		}
 
 		if s.config.CheckpointRestore() {
@@ -181,3 +193,34 @@ func (s *Server) createContainerInfo(container *oci.Container) (map[string]strin
 	}
 	return map[string]string{"info": string(bytes)}, nil
 }
+
+// containerPressure returns the pressure stall information of the container,
+// or nil if it is not running or the information is not available.
+func (s *Server) containerPressure(ctx context.Context, container *oci.Container) *containerPressure {
+	if container.StateNoLock().Status != oci.ContainerStateRunning {
+		return nil
+	}
+	sb := s.GetSandbox(container.Sandbox())
+	if sb == nil {
+		return nil
+	}
+	stats, err := s.Runtime().ContainerStats(ctx, container, sb.CgroupParent())
+	if err != nil {
+		log.Warnf(ctx, "Unable to get pressure stall information of container %s: %v", container.ID(), err)
+		return nil
+	}
+	pressure := &containerPressure{}
+	if stats.CPU != nil {
+		pressure.CPU = stats.CPU.PSI
+	}
+	if stats.Memory != nil {
+		pressure.Memory = stats.Memory.PSI
+	}
+	if stats.DiskIO != nil {
+		pressure.IO = stats.DiskIO.PSI
+	}
+	if pressure.CPU == nil && pressure.Memory == nil && pressure.IO == nil {
+		return nil
+	}
+	return pressure
+}
@@ -0,0 +1,201 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cri-o/cri-o/internal/opentelemetry"
+	libconfig "github.com/cri-o/cri-o/pkg/config"
+	"github.com/cri-o/cri-o/pkg/types"
+	"github.com/sirupsen/logrus"
+)
+
+// Debug settings which can be changed temporarily via the inspect API.
+const (
+	DebugSettingLogFilter           = "log-filter"
+	DebugSettingLogLevel            = "log-level"
+	DebugSettingTracingSamplingRate = "tracing-sampling-rate"
+)
+
+const (
+	// DefaultDebugOverrideDuration is the duration of a debug setting
+	// override if none is provided.
+	DefaultDebugOverrideDuration = 10 * time.Minute
+
+	// maxDebugOverrideDuration bounds the duration of a debug setting
+	// override to not accidentally keep it forever.
+	maxDebugOverrideDuration = 24 * time.Hour
+
+	// maxTracingSamplingRate is the sampling rate to always sample.
+	maxTracingSamplingRate = 1000000
+)
+
+var (
+	errUnknownDebugSetting  = errors.New("unknown debug setting")
+	errInvalidDebugOverride = errors.New("invalid debug setting override")
+	errNoDebugOverride      = errors.New("debug setting is not overridden")
+)
+
+// debugOverride is a temporary change of a debug setting, which gets reverted
+// once it expires.
+type debugOverride struct {
+	value    string
+	previous string
+	expires  time.Time
+	timer    *time.Timer
+}
+
+// debugOverrides tracks the active debug setting overrides.
+type debugOverrides struct {
+	sync.Mutex
+	overrides map[string]*debugOverride
+}
+
+// debugSetting returns the current value of the debug setting.
+func (s *Server) debugSetting(setting string) (string, error) {
+	switch setting {
+	case DebugSettingLogFilter:
+		return s.config.LogFilter, nil
+	case DebugSettingLogLevel:
+		return s.config.LogLevel, nil
+	case DebugSettingTracingSamplingRate:
+		return strconv.Itoa(s.config.TracingSamplingRatePerMillion), nil
+	}
+	return "", fmt.Errorf("%w: %s", errUnknownDebugSetting, setting)
+}
+
+// setDebugSetting applies the value to the debug setting.
+func (s *Server) setDebugSetting(setting, value string) error {
+	switch setting {
+	case DebugSettingLogFilter:
+		newConfig := &libconfig.Config{RuntimeConfig: libconfig.RuntimeConfig{LogFilter: value}}
+		if err := s.config.ReloadLogFilter(newConfig); err != nil {
+			return fmt.Errorf("%w: %w", errInvalidDebugOverride, err)
+		}
+		return nil
+
+	case DebugSettingLogLevel:
+		newConfig := &libconfig.Config{RuntimeConfig: libconfig.RuntimeConfig{LogLevel: value}}
+		if err := s.config.ReloadLogLevel(newConfig); err != nil {
+			return fmt.Errorf("%w: %w", errInvalidDebugOverride, err)
+		}
+		return nil
+
+	case DebugSettingTracingSamplingRate:
+		if !s.config.EnableTracing {
+			return fmt.Errorf("%w: tracing is not enabled", errInvalidDebugOverride)
+		}
+		rate, err := strconv.Atoi(value)
+		if err != nil || rate < 0 || rate > maxTracingSamplingRate {
+			return fmt.Errorf("%w: sampling rate has to be between 0 and %d, got %q", errInvalidDebugOverride, maxTracingSamplingRate, value)
+		}
+		opentelemetry.SetSamplingRate(rate)
+		s.config.TracingSamplingRatePerMillion = rate
+		logrus.Infof("Set config tracing_sampling_rate_per_million to %q", value)
+		return nil
+	}
+	return fmt.Errorf("%w: %s", errUnknownDebugSetting, setting)
+}
+
+// overrideDebugSetting changes the debug setting to the value and reverts it
+// after the duration. Overriding an already overridden setting extends the
+// override, which still reverts to the initial value.
+func (s *Server) overrideDebugSetting(setting, value string, duration time.Duration) error {
+	if duration <= 0 || duration > maxDebugOverrideDuration {
+		return fmt.Errorf("%w: duration has to be between 0 and %s, got %s", errInvalidDebugOverride, maxDebugOverrideDuration, duration)
+	}
+
+	s.debugOverrides.Lock()
+	defer s.debugOverrides.Unlock()
+
+	previous, err := s.debugSetting(setting)
+	if err != nil {
+		return err
+	}
+	if o, ok := s.debugOverrides.overrides[setting]; ok {
+		previous = o.previous
+	}
+	if err := s.setDebugSetting(setting, value); err != nil {
+		return err
+	}
+	if o, ok := s.debugOverrides.overrides[setting]; ok {
+		o.timer.Stop()
+	}
+	if s.debugOverrides.overrides == nil {
+		s.debugOverrides.overrides = make(map[string]*debugOverride)
+	}
+
+	o := &debugOverride{
+		value:    value,
+		previous: previous,
+		expires:  time.Now().Add(duration),
+	}
+	o.timer = time.AfterFunc(duration, func() {
+		s.debugOverrides.Lock()
+		defer s.debugOverrides.Unlock()
+		// The override may have been replaced in the meantime.
+		if s.debugOverrides.overrides[setting] != o {
+			return
+		}
+		logrus.Infof("Debug setting %s override expired, reverting to %q", setting, o.previous)
+		if err := s.revertDebugSettingLocked(setting); err != nil {
+			logrus.Errorf("Unable to revert debug setting %s: %v", setting, err)
+		}
+	})
+	s.debugOverrides.overrides[setting] = o
+	logrus.Infof("Overriding debug setting %s with %q for %s", setting, value, duration)
+
+	return nil
+}
+
+// revertDebugSetting reverts an overridden debug setting to its previous
+// value.
+func (s *Server) revertDebugSetting(setting string) error {
+	s.debugOverrides.Lock()
+	defer s.debugOverrides.Unlock()
+	return s.revertDebugSettingLocked(setting)
+}
+
+func (s *Server) revertDebugSettingLocked(setting string) error {
+	if _, err := s.debugSetting(setting); err != nil {
+		return err
+	}
+	o, ok := s.debugOverrides.overrides[setting]
+	if !ok {
+		return fmt.Errorf("%w: %s", errNoDebugOverride, setting)
+	}
+	o.timer.Stop()
+	delete(s.debugOverrides.overrides, setting)
+	return s.setDebugSetting(setting, o.previous)
+}
+
+// getDebugInfo returns the current debug settings including their active
+// overrides.
+func (s *Server) getDebugInfo() types.DebugInfo {
+	s.debugOverrides.Lock()
+	defer s.debugOverrides.Unlock()
+
+	info := types.DebugInfo{
+		LogLevel:                      s.config.LogLevel,
+		LogFilter:                     s.config.LogFilter,
+		TracingEnabled:                s.config.EnableTracing,
+		TracingSamplingRatePerMillion: s.config.TracingSamplingRatePerMillion,
+		Overrides:                     []types.DebugOverride{},
+	}
+	for setting, o := range s.debugOverrides.overrides {
+		info.Overrides = append(info.Overrides, types.DebugOverride{
+			Setting:  setting,
+			Value:    o.value,
+			Previous: o.previous,
+			Expires:  o.expires.UnixNano(),
+		})
+	}
+	sort.Slice(info.Overrides, func(i, j int) bool {
+		return info.Overrides[i].Setting < info.Overrides[j].Setting
+	})
+	return info
+}
@@ -0,0 +1,86 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/cri-o/cri-o/internal/imagegc"
+	"github.com/cri-o/cri-o/internal/log"
+	"golang.org/x/sys/unix"
+)
+
+// newImageGC creates the built-in image garbage collector from the server
+// configuration.
+func (s *Server) newImageGC() (*imagegc.GarbageCollector, error) {
+	maxUnusedAge, err := s.config.ParseImageGCMaxUnusedAge()
+	if err != nil {
+		return nil, fmt.Errorf("parse image gc max unused age: %w", err)
+	}
+	return imagegc.New(imagegc.Policy{
+		MaxUnusedAge:         maxUnusedAge,
+		MaxImages:            s.config.ImageGCMaxImages,
+		HighThresholdPercent: s.config.ImageGCHighThresholdPercent,
+		LowThresholdPercent:  s.config.ImageGCLowThresholdPercent,
+	}, &imagegc.Options{
+		ImageServer:     s.StorageImageServer(),
+		SystemContext:   s.config.SystemContext,
+		ProtectedImages: []string{s.config.PauseImage},
+		ImagesInUse:     s.imagesInUse,
+		FsUsage:         s.imageFsUsage,
+	}), nil
+}
+
+// startImageGC runs the image garbage collection in the background until the
+// server shuts down.
+func (s *Server) startImageGC(ctx context.Context) error {
+	interval, err := s.config.ParseImageGCInterval()
+	if err != nil {
+		return fmt.Errorf("parse image gc interval: %w", err)
+	}
+	go s.imageGC.Start(ctx, interval, s.monitorsChan)
+	return nil
+}
+
+// imagesInUse returns the IDs of all images referenced by a container,
+// including the infra containers.
+func (s *Server) imagesInUse() map[string]bool {
+	res := make(map[string]bool)
+
+	containers, err := s.ContainerServer.ListContainers()
+	if err != nil {
+		log.Warnf(context.Background(), "Unable to list containers for image garbage collection: %v", err)
+	}
+	for _, sb := range s.ContainerServer.ListSandboxes() {
+		if infra := sb.InfraContainer(); infra != nil {
+			containers = append(containers, infra)
+		}
+	}
+	for _, c := range containers {
+		if id := c.ImageID(); id != nil {
+			res[id.IDStringForOutOfProcessConsumptionOnly()] = true
+		}
+	}
+	return res
+}
+
+// imageFsUsage returns the bytes used by the images as reported by
+// ImageFsInfo and the total bytes of the filesystem they are stored on.
+func (s *Server) imageFsUsage() (used, capacity uint64, err error) {
+	fsInfo, err := getStorageFsInfo(s.StorageImageServer().GetStore())
+	if err != nil {
+		return 0, 0, fmt.Errorf("get image fs info: %w", err)
+	}
+	if len(fsInfo.ImageFilesystems) == 0 {
+		return 0, 0, errors.New("no image filesystem reported")
+	}
+	imageFs := fsInfo.ImageFilesystems[0]
+
+	// ImageFsInfo does not report the capacity of the filesystem.
+	path := imageFs.GetFsId().GetMountpoint()
+	var stat unix.Statfs_t
+	if err := unix.Statfs(path, &stat); err != nil {
+		return 0, 0, fmt.Errorf("statfs %s: %w", path, err)
+	}
+	return imageFs.GetUsedBytes().GetValue(), stat.Blocks * uint64(stat.Bsize), nil
+}
@@ -0,0 +1,184 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cri-o/cri-o/internal/log"
+	types "k8s.io/cri-api/pkg/apis/runtime/v1"
+)
+
+const (
+	// pinnedImagesReadyCondition is the runtime condition type reported if
+	// the pre-pull of the pinned images is enabled.
+	pinnedImagesReadyCondition = "PinnedImagesReady"
+
+	// pinnedImagesNotPulledReason is the reason reported if pinned images
+	// have not been pulled yet.
+	pinnedImagesNotPulledReason = "PinnedImagesNotPulled"
+
+	// prePullMinBackoff and prePullMaxBackoff limit the exponential backoff
+	// between the pull attempts of a pinned image.
+	prePullMinBackoff = 5 * time.Second
+	prePullMaxBackoff = 5 * time.Minute
+)
+
+// prePullState holds the pinned images which are pre-pulled in the
+// background.
+type prePullState struct {
+	sync.Mutex
+	// enabled is true if the pinned images get pre-pulled.
+	enabled bool
+	// pending maps the names of the pinned images which have not been pulled
+	// yet to their pull goroutine.
+	pending map[string]*pendingPrePull
+}
+
+// pendingPrePull is a pinned image which has not been pulled yet.
+type pendingPrePull struct {
+	// cancel stops the pull goroutine, for example if the image got removed
+	// from the configuration.
+	cancel context.CancelFunc
+	// err is the error of the latest pull attempt.
+	err error
+}
+
+// prePullPinnedImages pulls all pinned images without glob or keyword pattern
+// and the pause image in the background, if enabled. Images which are already
+// being pulled are skipped, while images which are no longer configured stop
+// being pulled.
+func (s *Server) prePullPinnedImages(ctx context.Context) {
+	if !s.config.PrePullPinnedImages {
+		return
+	}
+	names := s.prePullImageNames()
+
+	s.prePull.Lock()
+	defer s.prePull.Unlock()
+	s.prePull.enabled = true
+	if s.prePull.pending == nil {
+		s.prePull.pending = make(map[string]*pendingPrePull)
+	}
+
+	for name, pending := range s.prePull.pending {
+		if !slices.Contains(names, name) {
+			log.Infof(ctx, "Stopping the pre-pull of image %s", name)
+			pending.cancel()
+			delete(s.prePull.pending, name)
+		}
+	}
+	for _, name := range names {
+		if _, ok := s.prePull.pending[name]; ok {
+			continue
+		}
+		pullCtx, cancel := context.WithCancel(ctx)
+		pending := &pendingPrePull{cancel: cancel}
+		s.prePull.pending[name] = pending
+		go s.prePullImage(pullCtx, name, pending)
+	}
+}
+
+// prePullImageNames returns the deduplicated names of the pinned images
+// without glob or keyword pattern and the pause image.
+func (s *Server) prePullImageNames() []string {
+	names := []string{}
+	for _, name := range append(slices.Clone(s.config.PinnedImages), s.config.PauseImage) {
+		if name == "" || strings.Contains(name, "*") || slices.Contains(names, name) {
+			continue
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// prePullImage pulls the image if it does not exist, retrying with an
+// exponential backoff until the pull succeeds or ctx is done.
+func (s *Server) prePullImage(ctx context.Context, name string, pending *pendingPrePull) {
+	defer pending.cancel()
+
+	backoff := prePullMinBackoff
+	for {
+		err := s.pullImageIfNotPresent(ctx, name)
+
+		s.prePull.Lock()
+		if s.prePull.pending[name] != pending {
+			s.prePull.Unlock()
+			return
+		}
+		if err == nil {
+			delete(s.prePull.pending, name)
+			s.prePull.Unlock()
+			log.Infof(ctx, "Pinned image %s is available", name)
+			return
+		}
+		pending.err = err
+		s.prePull.Unlock()
+
+		log.Warnf(ctx, "Unable to pre-pull image %s, retrying in %v: %v", name, backoff, err)
+		timer := time.NewTimer(backoff)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-s.monitorsChan:
+			timer.Stop()
+			return
+		}
+		backoff = min(2*backoff, prePullMaxBackoff)
+	}
+}
+
+// pullImageIfNotPresent pulls the image via the same code path as PullImage,
+// unless it already exists in the storage.
+func (s *Server) pullImageIfNotPresent(ctx context.Context, name string) error {
+	status, err := s.storageImageStatus(ctx, types.ImageSpec{Image: name})
+	if err != nil {
+		return err
+	}
+	if status != nil {
+		return nil
+	}
+
+	log.Infof(ctx, "Pre-pulling image: %s", name)
+	pullArgs := pullArguments{image: name}
+	if name == s.config.PauseImage {
+		pullArgs.authFile = s.config.PauseImageAuthFile
+	}
+	_, err = s.deduplicatedPullImage(ctx, &pullArgs, nil)
+	return err
+}
+
+// prePullConditions returns the runtime condition of the pinned images, which
+// is false until all of them have been pulled. No condition is returned if
+// the pre-pull is disabled.
+func (s *Server) prePullConditions() []*types.RuntimeCondition {
+	s.prePull.Lock()
+	defer s.prePull.Unlock()
+	if !s.prePull.enabled {
+		return nil
+	}
+
+	condition := &types.RuntimeCondition{
+		Type:   pinnedImagesReadyCondition,
+		Status: true,
+	}
+	if len(s.prePull.pending) > 0 {
+		images := make([]string, 0, len(s.prePull.pending))
+		for name, pending := range s.prePull.pending {
+			if pending.err != nil {
+				name = fmt.Sprintf("%s (%v)", name, pending.err)
+			}
+			images = append(images, name)
+		}
+		slices.Sort(images)
+		condition.Status = false
+		condition.Reason = pinnedImagesNotPulledReason
+		condition.Message = "Waiting for the pull of the pinned images: " + strings.Join(images, ", ")
+	}
+	return []*types.RuntimeCondition{condition}
+}
@@ -0,0 +1,47 @@
+package server
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	libconfig "github.com/cri-o/cri-o/pkg/config"
+)
+
+func TestPrePullImageNames(t *testing.T) {
+	s := &Server{config: libconfig.Config{ImageConfig: libconfig.ImageConfig{
+		PauseImage:   "registry.k8s.io/pause:3.9",
+		PinnedImages: []string{"quay.io/crio/fedora-crio-ci:latest", "quay.io/crio/*", "*busybox*", "registry.k8s.io/pause:3.9", ""},
+	}}}
+
+	names := s.prePullImageNames()
+	if expected := []string{"quay.io/crio/fedora-crio-ci:latest", "registry.k8s.io/pause:3.9"}; !reflect.DeepEqual(names, expected) {
+		t.Fatalf("expected images %v, got %v", expected, names)
+	}
+}
+
+func TestPrePullConditions(t *testing.T) {
+	s := &Server{}
+	if conditions := s.prePullConditions(); len(conditions) != 0 {
+		t.Fatalf("expected no conditions if disabled, got %v", conditions)
+	}
+
+	s.prePull.enabled = true
+	s.prePull.pending = map[string]*pendingPrePull{
+		"second": {err: errors.New("connection refused")},
+		"first":  {},
+	}
+	conditions := s.prePullConditions()
+	if len(conditions) != 1 || conditions[0].Type != pinnedImagesReadyCondition || conditions[0].Status {
+		t.Fatalf("expected a false %s condition, got %v", pinnedImagesReadyCondition, conditions)
+	}
+	if expected := "Waiting for the pull of the pinned images: first, second (connection refused)"; conditions[0].Message != expected {
+		t.Fatalf("expected message %q, got %q", expected, conditions[0].Message)
+	}
+
+	s.prePull.pending = map[string]*pendingPrePull{}
+	conditions = s.prePullConditions()
+	if len(conditions) != 1 || !conditions[0].Status {
+		t.Fatalf("expected a true %s condition, got %v", pinnedImagesReadyCondition, conditions)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/containers/image/v5/pkg/sysregistriesv2"
 	"github.com/containers/image/v5/signature"
 	imageTypes "github.com/containers/image/v5/types"
 	encconfig "github.com/containers/ocicrypt/config"
@@ -70,6 +71,31 @@ func (s *Server) PullImage(ctx context.Context, req *types.PullImageRequest) (*t
 		}
 	}
 
+	imageRef, err := s.deduplicatedPullImage(ctx, &pullArgs, sc)
+	if err != nil {
+		wrap := func(e error) error { return fmt.Errorf("%w: %w", e, err) }
+
+		if errors.Is(err, syscall.ECONNREFUSED) {
+			return nil, wrap(crierrors.ErrRegistryUnavailable)
+		}
+
+		var policyErr signature.PolicyRequirementError
+		if errors.As(err, &policyErr) {
+			return nil, wrap(crierrors.ErrSignatureValidationFailed)
+		}
+
+		return nil, err
+	}
+
+	log.Infof(ctx, "Pulled image: %v", imageRef)
+	return &types.PullImageResponse{
+		ImageRef: imageRef,
+	}, nil
+}
+
+// deduplicatedPullImage pulls the image of pullArgs, where callers pulling
+// the same image in parallel share a single pull operation.
+func (s *Server) deduplicatedPullImage(ctx context.Context, pullArgs *pullArguments, sc *types.PodSandboxConfig) (string, error) {
 	// We use the server's pullOperationsInProgress to record which images are
 	// currently being pulled. This allows for avoiding pulling the same image
 	// in parallel. Hence, if a given image is currently being pulled, we queue
@@ -78,62 +104,48 @@ func (s *Server) PullImage(ctx context.Context, req *types.PullImageRequest) (*t
 	pullOp, pullInProcess := func() (pullOp *pullOperation, inProgress bool) {
 		s.pullOperationsLock.Lock()
 		defer s.pullOperationsLock.Unlock()
-		pullOp, inProgress = s.pullOperationsInProgress[pullArgs]
+		pullOp, inProgress = s.pullOperationsInProgress[*pullArgs]
 		if !inProgress {
-			pullOp = &pullOperation{}
-			s.pullOperationsInProgress[pullArgs] = pullOp
+			pullOp = &pullOperation{progress: newPullProgress(pullArgs.image)}
+			s.pullOperationsInProgress[*pullArgs] = pullOp
 			storage.ImageBeingPulled.Store(pullArgs.image, true)
 			pullOp.wg.Add(1)
 		}
 		return pullOp, inProgress
 	}()
+	pullOp.progress.addPod(sc)
 
 	if !pullInProcess {
 		pullOp.err = errors.New("pullImage was aborted by a Go panic")
 		defer func() {
 			s.pullOperationsLock.Lock()
-			delete(s.pullOperationsInProgress, pullArgs)
+			delete(s.pullOperationsInProgress, *pullArgs)
 			storage.ImageBeingPulled.Delete(pullArgs.image)
 			pullOp.wg.Done()
 			s.pullOperationsLock.Unlock()
 		}()
-		pullOp.imageRef, pullOp.err = s.pullImage(ctx, &pullArgs)
+		pullOp.imageRef, pullOp.err = s.pullImage(ctx, pullArgs, pullOp.progress)
 	} else {
 		// Wait for the pull operation to finish.
 		pullOp.wg.Wait()
 	}
 
-	if pullOp.err != nil {
-		wrap := func(e error) error { return fmt.Errorf("%w: %w", e, pullOp.err) }
-
-		if errors.Is(pullOp.err, syscall.ECONNREFUSED) {
-			return nil, wrap(crierrors.ErrRegistryUnavailable)
-		}
-
-		var policyErr signature.PolicyRequirementError
-		if errors.As(pullOp.err, &policyErr) {
-			return nil, wrap(crierrors.ErrSignatureValidationFailed)
-		}
-
-		return nil, pullOp.err
-	}
-
-	log.Infof(ctx, "Pulled image: %v", pullOp.imageRef)
-	return &types.PullImageResponse{
-		ImageRef: pullOp.imageRef,
-	}, nil
+	return pullOp.imageRef, pullOp.err
 }
 
 // pullImage performs the actual pull operation of PullImage. Used to separate
 // the pull implementation from the pullCache logic in PullImage and improve
 // readability and maintainability.
-func (s *Server) pullImage(ctx context.Context, pullArgs *pullArguments) (string, error) {
+func (s *Server) pullImage(ctx context.Context, pullArgs *pullArguments, pullProgress *pullProgress) (string, error) {
 	var err error
 	ctx, span := log.StartSpan(ctx)
 	defer span.End()
 
 	sourceCtx := *s.config.SystemContext   // A shallow copy we can modify
 	sourceCtx.DockerLogMirrorChoice = true // Add info level log of the pull source
+	if pullArgs.authFile != "" {
+		sourceCtx.AuthFilePath = pullArgs.authFile
+	}
 	if pullArgs.credentials.Username != "" {
 		sourceCtx.DockerAuthConfig = &pullArgs.credentials
 	}
@@ -148,6 +160,11 @@ func (s *Server) pullImage(ctx context.Context, pullArgs *pullArguments) (string
 	}
 	log.Debugf(ctx, "Using pull policy path for image %s: %s", pullArgs.image, sourceCtx.SignaturePolicyPath)
 
+	if err := s.config.ApplyNamespacedRegistries(&sourceCtx, pullArgs.namespace); err != nil {
+		return "", err
+	}
+	log.Debugf(ctx, "Using registries configuration for image %s: %s", pullArgs.image, sysregistriesv2.ConfigurationSourceDescription(&sourceCtx))
+
 	decryptConfig, err := getDecryptionKeys(s.config.DecryptionKeysPath)
 	if err != nil {
 		return "", err
@@ -168,7 +185,7 @@ func (s *Server) pullImage(ctx context.Context, pullArgs *pullArguments) (string
 		}
 	}
 
-	remoteCandidates, err := s.StorageImageServer().CandidatesForPotentiallyShortImageName(s.config.SystemContext, pullArgs.image)
+	remoteCandidates, err := s.StorageImageServer().CandidatesForPotentiallyShortImageName(&sourceCtx, pullArgs.image)
 	if err != nil {
 		return "", err
 	}
@@ -176,7 +193,8 @@ func (s *Server) pullImage(ctx context.Context, pullArgs *pullArguments) (string
 	// and they all fail, this error value should be overwritten by a real failure.
 	lastErr := errors.New("internal error: pullImage failed but reported no error reason")
 	for _, remoteCandidateName := range remoteCandidates {
-		err := s.pullImageCandidate(ctx, &sourceCtx, remoteCandidateName, decryptConfig, cgroup)
+		pullProgress.setCandidate(remoteCandidateName.StringForOutOfProcessConsumptionOnly())
+		err := s.pullImageCandidate(ctx, &sourceCtx, remoteCandidateName, decryptConfig, cgroup, pullProgress)
 		if err == nil {
 			// Update metric for successful image pulls
 			metrics.Instance().MetricImagePullsSuccessesInc(remoteCandidateName)
@@ -197,7 +215,7 @@ func (s *Server) pullImage(ctx context.Context, pullArgs *pullArguments) (string
 	return "", lastErr
 }
 
-func (s *Server) pullImageCandidate(ctx context.Context, sourceCtx *imageTypes.SystemContext, remoteCandidateName storage.RegistryImageReference, decryptConfig *encconfig.DecryptConfig, cgroup string) error {
+func (s *Server) pullImageCandidate(ctx context.Context, sourceCtx *imageTypes.SystemContext, remoteCandidateName storage.RegistryImageReference, decryptConfig *encconfig.DecryptConfig, cgroup string, pullProgress *pullProgress) error {
 	tmpImg, err := s.StorageImageServer().PrepareImage(sourceCtx, remoteCandidateName)
 	if err != nil {
 		// We're not able to find the image remotely, check if it's
@@ -239,13 +257,18 @@ func (s *Server) pullImageCandidate(ctx context.Context, sourceCtx *imageTypes.S
 		log.Debugf(ctx, "Image in store has different ID, re-pulling %s", remoteCandidateName)
 	}
 
-	// Collect pull progress metrics
+	progressTimeout, err := s.config.ParsePullProgressTimeout()
+	if err != nil {
+		return err
+	}
+
+	// Collect pull progress metrics and record the progress for the inspect API
 	progress := make(chan imageTypes.ProgressProperties)
 	defer close(progress) // nolint:gocritic
 
 	// Cancel the pull if no progress is made
 	pullCtx, cancel := context.WithCancel(context.Background())
-	go consumeImagePullProgress(ctx, cancel, progress, remoteCandidateName)
+	go consumeImagePullProgress(ctx, cancel, progress, remoteCandidateName, pullProgress, progressTimeout)
 
 	_, err = s.StorageImageServer().PullImage(pullCtx, remoteCandidateName, &storage.ImageCopyOptions{
 		SourceCtx:        sourceCtx,
@@ -266,23 +289,29 @@ func (s *Server) pullImageCandidate(ctx context.Context, sourceCtx *imageTypes.S
 	return nil
 }
 
-// consumeImagePullProgress consumes progress and turns it into metrics updates.
-// It also checks if progress is being made within a constant timeout.
+// consumeImagePullProgress consumes progress and turns it into metrics updates
+// and updates of the pull progress. It also checks if progress is being made within the timeout.
 // If the timeout is reached because no progress updates have been made, then
-// the cancel function will be called.
-func consumeImagePullProgress(ctx context.Context, cancel context.CancelFunc, progress <-chan imageTypes.ProgressProperties, remoteCandidateName storage.RegistryImageReference) {
-	// The progress interval is 1s, but we give it a bit more time just in case
-	// that the connection revives.
-	const timeout = 10 * time.Second
-	timer := time.AfterFunc(timeout, func() {
-		log.Warnf(ctx, "Timed out on waiting up to %s for image pull progress updates", timeout)
-		cancel()
-	})
-	timer.Stop()       // don't start the timer immediately
-	defer timer.Stop() // ensure that the timer is stopped when we exit the progress loop
+// the cancel function will be called. A timeout of 0 disables the check.
+func consumeImagePullProgress(ctx context.Context, cancel context.CancelFunc, progress <-chan imageTypes.ProgressProperties, remoteCandidateName storage.RegistryImageReference, pullProgress *pullProgress, timeout time.Duration) {
+	// The progress interval is 1s, but the timeout should give it a bit more
+	// time just in case that the connection revives.
+	// A zero timeout disables the timer.
+	var timer *time.Timer
+	if timeout > 0 {
+		timer = time.AfterFunc(timeout, func() {
+			log.Warnf(ctx, "Timed out on waiting up to %s for image pull progress updates", timeout)
+			cancel()
+		})
+		timer.Stop()       // don't start the timer immediately
+		defer timer.Stop() // ensure that the timer is stopped when we exit the progress loop
+	}
 
 	for p := range progress {
-		timer.Reset(timeout)
+		if timer != nil {
+			timer.Reset(timeout)
+		}
+		pullProgress.update(&p)
 
 		if p.Event == imageTypes.ProgressEventSkipped {
 			// Skipped digests metrics
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	istorage "github.com/containers/image/v5/storage"
 	"github.com/cri-o/cri-o/internal/log"
@@ -143,5 +144,12 @@ func createImageInfo(result *pkgstorage.ImageResult) (map[string]string, error)
 	if err != nil {
 		return nil, fmt.Errorf("marshal data: %v: %w", info, err)
 	}
-	return map[string]string{"info": string(bytes)}, nil
+	res := map[string]string{
+		"info":       string(bytes),
+		"usageCount": strconv.FormatUint(result.UsageCount, 10),
+	}
+	if !result.LastUsed.IsZero() {
+		res["lastUsed"] = result.LastUsed.Format(time.RFC3339Nano)
+	}
+	return res, nil
 }
@@ -2,6 +2,7 @@ package server_test
 
 import (
 	"context"
+	"time"
 
 	istorage "github.com/containers/image/v5/storage"
 	"github.com/cri-o/cri-o/internal/storage"
@@ -76,6 +77,8 @@ var _ = t.Describe("ImageStatus", func() {
 								OS:           "os",
 							},
 						},
+						LastUsed:   time.Unix(1700000000, 0).UTC(),
+						UsageCount: 3,
 					},
 					nil,
 				),
@@ -95,6 +98,8 @@ var _ = t.Describe("ImageStatus", func() {
 			Expect(response.Info["info"]).To(ContainSubstring(
 				`{"imageSpec":{"architecture":"arch","os":"os","config":{}`,
 			))
+			Expect(response.Info).To(HaveKeyWithValue("lastUsed", "2023-11-14T22:13:20Z"))
+			Expect(response.Info).To(HaveKeyWithValue("usageCount", "3"))
 		})
 
 		It("should succeed with a full image ID", func() {
@@ -6,16 +6,25 @@ import (
 	"math"
 	"net/http"
 	"net/http/pprof"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/containers/storage/pkg/idtools"
 	"github.com/cri-o/cri-o/internal/lib/sandbox"
 	"github.com/cri-o/cri-o/internal/log"
 	"github.com/cri-o/cri-o/internal/oci"
+	"github.com/cri-o/cri-o/internal/storage"
+	"github.com/cri-o/cri-o/pkg/annotations"
 	"github.com/cri-o/cri-o/pkg/types"
 	"github.com/go-chi/chi/v5"
 	json "github.com/json-iterator/go"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/net/context"
+	"k8s.io/apimachinery/pkg/labels"
 )
 
 func (s *Server) getIDMappingsInfo() types.IDMappings {
@@ -48,9 +57,110 @@ func (s *Server) getInfo() types.CrioInfo {
 		StorageImage:      s.config.ImageStore,
 		CgroupDriver:      s.config.CgroupManager().Name(),
 		DefaultIDMappings: s.getIDMappingsInfo(),
+		RuntimeHandlers:   s.config.RuntimeHandlerCapabilities(),
 	}
 }
 
+func (s *Server) getImagesInfo() ([]types.ImageInfo, error) {
+	images, err := s.StorageImageServer().ListImages(s.config.SystemContext)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]types.ImageInfo, 0, len(images))
+	for i := range images {
+		image := &images[i]
+		info := types.ImageInfo{
+			ID:          image.ID.IDStringForOutOfProcessConsumptionOnly(),
+			RepoTags:    image.RepoTags,
+			RepoDigests: image.RepoDigests,
+			Pinned:      image.Pinned,
+			UsageCount:  image.UsageCount,
+		}
+		if image.Size != nil {
+			info.Size = *image.Size
+		}
+		if !image.LastUsed.IsZero() {
+			info.LastUsed = image.LastUsed.UnixNano()
+		}
+		res = append(res, info)
+	}
+	return res, nil
+}
+
+// getCheckpointsInfo returns all checkpoint images of the local storage.
+func (s *Server) getCheckpointsInfo() ([]types.CheckpointInfo, error) {
+	images, err := s.StorageImageServer().ListImages(s.config.SystemContext)
+	if err != nil {
+		return nil, err
+	}
+	res := []types.CheckpointInfo{}
+	for i := range images {
+		image := &images[i]
+		name, ok := image.Annotations[annotations.CheckpointAnnotationName]
+		if !ok {
+			continue
+		}
+		info := types.CheckpointInfo{
+			ID:              image.ID.IDStringForOutOfProcessConsumptionOnly(),
+			RepoTags:        image.RepoTags,
+			Container:       name,
+			RootfsImageName: image.Annotations[annotations.CheckpointAnnotationRootfsImageName],
+			CRIOVersion:     image.Annotations[annotations.CheckpointAnnotationCRIOVersion],
+			CriuVersion:     image.Annotations[annotations.CheckpointAnnotationCriuVersion],
+		}
+		if image.Size != nil {
+			info.Size = *image.Size
+		}
+		if image.OCIConfig != nil && image.OCIConfig.Created != nil {
+			info.Created = image.OCIConfig.Created.UnixNano()
+		}
+		res = append(res, info)
+	}
+	return res, nil
+}
+
+// minCheckpointIDPrefixLength is the minimum length of checkpoint ID
+// prefixes, to not accidentally match an arbitrary checkpoint.
+const minCheckpointIDPrefixLength = 3
+
+var (
+	errCheckpointNotFound  = errors.New("checkpoint not found")
+	errCheckpointInvalidID = errors.New("invalid checkpoint ID")
+	errCheckpointAmbiguous = errors.New("checkpoint is ambiguous")
+)
+
+// deleteCheckpoint removes the checkpoint image matching the ID, ID prefix or
+// name.
+func (s *Server) deleteCheckpoint(id string) error {
+	if len(id) < minCheckpointIDPrefixLength {
+		return fmt.Errorf("%w %q: requires at least %d characters", errCheckpointInvalidID, id, minCheckpointIDPrefixLength)
+	}
+	checkpoints, err := s.getCheckpointsInfo()
+	if err != nil {
+		return err
+	}
+	var matches []string
+	for i := range checkpoints {
+		checkpoint := &checkpoints[i]
+		if strings.HasPrefix(checkpoint.ID, id) || slices.Contains(checkpoint.RepoTags, id) {
+			matches = append(matches, checkpoint.ID)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return fmt.Errorf("%w: %s", errCheckpointNotFound, id)
+	case 1:
+	default:
+		return fmt.Errorf("%w: %s matches %s", errCheckpointAmbiguous, id, strings.Join(matches, ", "))
+	}
+
+	imageID, err := storage.ParseStorageImageIDFromOutOfProcessData(matches[0])
+	if err != nil {
+		return err
+	}
+	return s.StorageImageServer().DeleteImage(s.config.SystemContext, imageID)
+}
+
 var (
 	errCtrNotFound     = errors.New("container not found")
 	errCtrStateNil     = errors.New("container state is nil")
@@ -114,17 +224,211 @@ func (s *Server) getContainerInfo(ctx context.Context, id string, getContainerFu
 		LogPath:         ctr.LogPath(),
 		Sandbox:         ctr.Sandbox(),
 		IPs:             sb.IPs(),
+		State:           string(ctrState.Status),
+		RuntimeHandler:  sb.RuntimeHandler(),
+		NRIAudit:        ctrState.NRIAudit,
 	}, nil
 }
 
+// inspectFilter is the parsed representation of the query filters of the
+// pods and containers list endpoints.
+type inspectFilter struct {
+	state          string
+	labelSelector  labels.Selector
+	runtimeHandler string
+}
+
+// parseInspectFilter parses the filters from the request query.
+func parseInspectFilter(query url.Values) (*inspectFilter, error) {
+	selector, err := labels.Parse(query.Get(InspectFilterLabel))
+	if err != nil {
+		return nil, fmt.Errorf("invalid label selector: %w", err)
+	}
+	return &inspectFilter{
+		state:          query.Get(InspectFilterState),
+		labelSelector:  selector,
+		runtimeHandler: query.Get(InspectFilterRuntimeHandler),
+	}, nil
+}
+
+func (f *inspectFilter) matches(state, runtimeHandler string, l map[string]string) bool {
+	if f.state != "" && f.state != state {
+		return false
+	}
+	if f.runtimeHandler != "" && f.runtimeHandler != runtimeHandler {
+		return false
+	}
+	return f.labelSelector.Matches(labels.Set(l))
+}
+
+// getContainersInfo returns the information about all containers matching the
+// filter, excluding the infra containers.
+func (s *Server) getContainersInfo(ctx context.Context, filter *inspectFilter) ([]types.ContainerInfo, error) {
+	ctx, span := log.StartSpan(ctx)
+	defer span.End()
+	ctrs, err := s.ContainerServer.ListContainers()
+	if err != nil {
+		return nil, err
+	}
+	res := []types.ContainerInfo{}
+	for _, ctr := range ctrs {
+		ci, err := s.getContainerInfo(ctx, ctr.ID(), s.GetContainer, s.getInfraContainer, s.getSandbox)
+		if err != nil {
+			// The container may have been removed in the meantime.
+			log.Debugf(ctx, "Skipping container %s: %v", ctr.ID(), err)
+			continue
+		}
+		if filter.matches(ci.State, ci.RuntimeHandler, ci.Labels) {
+			res = append(res, ci)
+		}
+	}
+	return res, nil
+}
+
+var errPodNotFound = errors.New("pod not found")
+
+// getPodInfo returns the information about the pod sandbox referenced by its
+// ID or unique ID prefix.
+func (s *Server) getPodInfo(ctx context.Context, id string) (types.PodInfo, error) {
+	ctx, span := log.StartSpan(ctx)
+	defer span.End()
+	podID, err := s.PodIDIndex().Get(id)
+	if err != nil {
+		return types.PodInfo{}, fmt.Errorf("%w: %s: %v", errPodNotFound, id, err)
+	}
+	sb := s.getSandbox(ctx, podID)
+	if sb == nil || !sb.Created() {
+		return types.PodInfo{}, fmt.Errorf("%w: %s", errPodNotFound, id)
+	}
+	return podInfo(sb), nil
+}
+
+// getPodsInfo returns the information about all pod sandboxes matching the
+// filter.
+func (s *Server) getPodsInfo(filter *inspectFilter) []types.PodInfo {
+	res := []types.PodInfo{}
+	for _, sb := range s.ContainerServer.ListSandboxes() {
+		// Skip sandboxes that aren't created yet
+		if !sb.Created() {
+			continue
+		}
+		pi := podInfo(sb)
+		if filter.matches(pi.State, pi.RuntimeHandler, pi.Labels) {
+			res = append(res, pi)
+		}
+	}
+	return res
+}
+
+func podInfo(sb *sandbox.Sandbox) types.PodInfo {
+	state := "notready"
+	if sb.Ready(true) {
+		state = "ready"
+	}
+	ctrs := sb.Containers().List()
+	ctrIDs := make([]string, 0, len(ctrs))
+	for _, ctr := range ctrs {
+		ctrIDs = append(ctrIDs, ctr.ID())
+	}
+	slices.Sort(ctrIDs)
+	info := types.PodInfo{
+		ID:             sb.ID(),
+		Name:           sb.Metadata().Name,
+		Namespace:      sb.Namespace(),
+		UID:            sb.Metadata().Uid,
+		State:          state,
+		CreatedTime:    sb.CreatedAt(),
+		Labels:         sb.Labels(),
+		Annotations:    sb.Annotations(),
+		RuntimeHandler: sb.RuntimeHandler(),
+		IPs:            sb.IPs(),
+		Containers:     ctrIDs,
+	}
+	if infra := sb.InfraContainer(); infra != nil {
+		info.InfraContainer = infra.ID()
+	}
+	return info
+}
+
+const (
+	InspectCheckpointsEndpoint   = "/checkpoints"
+	InspectConfigEndpoint        = "/config"
+	InspectContainersEndpoint    = "/containers"
+	InspectDebugEndpoint         = "/debug"
+	InspectImagesEndpoint        = "/images"
+	InspectImageGCEndpoint       = "/images/gc"
+	InspectInfoEndpoint          = "/info"
+	InspectPauseEndpoint         = "/pause"
+	InspectPodsEndpoint          = "/pods"
+	InspectPodCheckpointEndpoint = "/pods/checkpoint"
+	InspectPodRestoreEndpoint    = "/pods/restore"
+	InspectPullsEndpoint         = "/pulls"
+	InspectUnpauseEndpoint       = "/unpause"
+)
+
+// Query parameters to filter the results of the pods and containers list
+// endpoints.
+const (
+	InspectFilterLabel          = "label"
+	InspectFilterRuntimeHandler = "runtime_handler"
+	InspectFilterState          = "state"
+)
+
+// Query parameters of the pod checkpoint and restore endpoints.
 const (
-	InspectConfigEndpoint     = "/config"
-	InspectContainersEndpoint = "/containers"
-	InspectInfoEndpoint       = "/info"
-	InspectPauseEndpoint      = "/pause"
-	InspectUnpauseEndpoint    = "/unpause"
+	InspectCheckpointPath         = "path"
+	InspectCheckpointLeaveRunning = "leave_running"
 )
 
+// Query parameters to override a debug setting.
+const (
+	InspectDebugDuration = "duration"
+	InspectDebugValue    = "value"
+)
+
+// writeDebugOverrideError writes the error of a debug setting change with the
+// matching status code.
+func writeDebugOverrideError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, errUnknownDebugSetting), errors.Is(err, errNoDebugOverride):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, errInvalidDebugOverride):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// checkpointPath returns the absolute archive path of a pod checkpoint or
+// restore request.
+func checkpointPath(query url.Values) (string, error) {
+	path := query.Get(InspectCheckpointPath)
+	if path == "" {
+		return "", errors.New("missing query parameter " + InspectCheckpointPath)
+	}
+	if !filepath.IsAbs(path) {
+		return "", fmt.Errorf("checkpoint path %q is not absolute", path)
+	}
+	return path, nil
+}
+
+// writePodCheckpointError writes the error of a pod checkpoint or restore with
+// the matching status code.
+func writePodCheckpointError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, errPodNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, errInvalidPodCheckpoint):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, errPodCheckpointConflict):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, errCheckpointRestoreNotAvailable):
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
 // GetExtendInterfaceMux returns the mux used to serve extend interface requests
 func (s *Server) GetExtendInterfaceMux(enableProfile bool) *chi.Mux {
 	mux := chi.NewMux()
@@ -155,6 +459,172 @@ func (s *Server) GetExtendInterfaceMux(enableProfile bool) *chi.Mux {
 		}
 	}))
 
+	mux.Get(InspectDebugEndpoint, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		js, err := json.Marshal(s.getDebugInfo())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write(js); err != nil {
+			logrus.Errorf("Unable to write response JSON: %v", err)
+		}
+	}))
+
+	mux.Put(InspectDebugEndpoint+"/{setting}", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		query := req.URL.Query()
+		if !query.Has(InspectDebugValue) {
+			http.Error(w, "missing query parameter "+InspectDebugValue, http.StatusBadRequest)
+			return
+		}
+		duration := DefaultDebugOverrideDuration
+		if d := query.Get(InspectDebugDuration); d != "" {
+			var err error
+			if duration, err = time.ParseDuration(d); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		if err := s.overrideDebugSetting(chi.URLParam(req, "setting"), query.Get(InspectDebugValue), duration); err != nil {
+			writeDebugOverrideError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		if _, err := w.Write([]byte("200 OK")); err != nil {
+			logrus.Errorf("Unable to write response: %v", err)
+		}
+	}))
+
+	mux.Delete(InspectDebugEndpoint+"/{setting}", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if err := s.revertDebugSetting(chi.URLParam(req, "setting")); err != nil {
+			writeDebugOverrideError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		if _, err := w.Write([]byte("200 OK")); err != nil {
+			logrus.Errorf("Unable to write response: %v", err)
+		}
+	}))
+
+	mux.Get(InspectImagesEndpoint, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		images, err := s.getImagesInfo()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		js, err := json.Marshal(images)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write(js); err != nil {
+			logrus.Errorf("Unable to write response JSON: %v", err)
+		}
+	}))
+
+	imageGC := func(dryRun bool) http.HandlerFunc {
+		return func(w http.ResponseWriter, req *http.Request) {
+			if s.imageGC == nil {
+				http.Error(w, "image garbage collection is not available", http.StatusServiceUnavailable)
+				return
+			}
+			res, err := s.imageGC.Run(req.Context(), dryRun)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			js, err := json.Marshal(res)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			if _, err := w.Write(js); err != nil {
+				logrus.Errorf("Unable to write response JSON: %v", err)
+			}
+		}
+	}
+	// Only report the images which would be removed by the next run, the
+	// state of the garbage collection is only changed by a POST request.
+	mux.Get(InspectImageGCEndpoint, imageGC(true))
+	mux.Post(InspectImageGCEndpoint, imageGC(false))
+
+	mux.Get(InspectPullsEndpoint, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		js, err := json.Marshal(s.getPullsInfo())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write(js); err != nil {
+			logrus.Errorf("Unable to write response JSON: %v", err)
+		}
+	}))
+
+	mux.Get(InspectCheckpointsEndpoint, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		checkpoints, err := s.getCheckpointsInfo()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		js, err := json.Marshal(checkpoints)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write(js); err != nil {
+			logrus.Errorf("Unable to write response JSON: %v", err)
+		}
+	}))
+
+	mux.Delete(InspectCheckpointsEndpoint+"/{id}", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		// The checkpoint can be referenced by name, which has to be escaped.
+		id, err := url.PathUnescape(chi.URLParam(req, "id"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := s.deleteCheckpoint(id); err != nil {
+			switch {
+			case errors.Is(err, errCheckpointNotFound):
+				http.Error(w, err.Error(), http.StatusNotFound)
+			case errors.Is(err, errCheckpointInvalidID), errors.Is(err, errCheckpointAmbiguous):
+				http.Error(w, err.Error(), http.StatusBadRequest)
+			default:
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		if _, err := w.Write([]byte("200 OK")); err != nil {
+			logrus.Errorf("Unable to write response: %v", err)
+		}
+	}))
+
+	mux.Get(InspectContainersEndpoint, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		filter, err := parseInspectFilter(req.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		containers, err := s.getContainersInfo(req.Context(), filter)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		js, err := json.Marshal(containers)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write(js); err != nil {
+			logrus.Errorf("Unable to write response JSON: %v", err)
+		}
+	}))
+
 	mux.Get(InspectContainersEndpoint+"/{id}", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 		ctx := context.TODO()
 		containerID := chi.URLParam(req, "id")
@@ -183,6 +653,97 @@ func (s *Server) GetExtendInterfaceMux(enableProfile bool) *chi.Mux {
 		}
 	}))
 
+	mux.Get(InspectPodsEndpoint, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		filter, err := parseInspectFilter(req.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		js, err := json.Marshal(s.getPodsInfo(filter))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write(js); err != nil {
+			logrus.Errorf("Unable to write response JSON: %v", err)
+		}
+	}))
+
+	mux.Get(InspectPodsEndpoint+"/{id}", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		pi, err := s.getPodInfo(req.Context(), chi.URLParam(req, "id"))
+		if err != nil {
+			if errors.Is(err, errPodNotFound) {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		js, err := json.Marshal(pi)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write(js); err != nil {
+			logrus.Errorf("Unable to write response JSON: %v", err)
+		}
+	}))
+
+	mux.Post(InspectPodCheckpointEndpoint+"/{id}", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		query := req.URL.Query()
+		path, err := checkpointPath(query)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		leaveRunning := false
+		if value := query.Get(InspectCheckpointLeaveRunning); value != "" {
+			leaveRunning, err = strconv.ParseBool(value)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		info, err := s.checkpointPod(req.Context(), chi.URLParam(req, "id"), path, leaveRunning)
+		if err != nil {
+			writePodCheckpointError(w, err)
+			return
+		}
+		js, err := json.Marshal(info)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write(js); err != nil {
+			logrus.Errorf("Unable to write response JSON: %v", err)
+		}
+	}))
+
+	mux.Post(InspectPodRestoreEndpoint, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		path, err := checkpointPath(req.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		info, err := s.restorePod(req.Context(), path)
+		if err != nil {
+			writePodCheckpointError(w, err)
+			return
+		}
+		js, err := json.Marshal(info)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write(js); err != nil {
+			logrus.Errorf("Unable to write response JSON: %v", err)
+		}
+	}))
+
 	mux.Get(InspectPauseEndpoint+"/{id}", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 		containerID := chi.URLParam(req, "id")
 		ctx := context.TODO()
@@ -4,12 +4,22 @@ import (
 	"context"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
+	"time"
 
+	"github.com/cri-o/cri-o/internal/imagegc"
 	"github.com/cri-o/cri-o/internal/oci"
+	"github.com/cri-o/cri-o/internal/storage"
+	"github.com/cri-o/cri-o/pkg/annotations"
+	"github.com/cri-o/cri-o/pkg/types"
 	"github.com/go-chi/chi/v5"
+	"github.com/golang/mock/gomock"
+	json "github.com/json-iterator/go"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/sirupsen/logrus"
 )
 
 var _ = t.Describe("Inspect", func() {
@@ -103,7 +113,7 @@ var _ = t.Describe("Inspect", func() {
 				To(BeEquivalentTo(http.StatusInternalServerError))
 		})
 
-		It("should fail with empty with /containers route", func() {
+		It("should succeed with empty list on /containers route", func() {
 			// Given
 			// When
 			request, err := http.NewRequest(http.MethodGet, "/containers", http.NoBody)
@@ -112,6 +122,96 @@ var _ = t.Describe("Inspect", func() {
 			// Then
 			Expect(err).ToNot(HaveOccurred())
 			Expect(request).NotTo(BeNil())
+			Expect(recorder.Code).To(BeEquivalentTo(http.StatusOK))
+			Expect(recorder.Body.String()).To(Equal("[]"))
+		})
+
+		It("should list containers filtered by state on /containers route", func() {
+			// Given
+			testContainer.SetState(&oci.ContainerState{
+				State: specs.State{Status: oci.ContainerStateRunning},
+			})
+			addContainerAndSandbox()
+
+			for state, expected := range map[string]int{
+				oci.ContainerStateRunning: 1,
+				oci.ContainerStatePaused:  0,
+			} {
+				// When
+				recorder = httptest.NewRecorder()
+				request, err := http.NewRequest(http.MethodGet, "/containers?state="+state, http.NoBody)
+				mux.ServeHTTP(recorder, request)
+
+				// Then
+				Expect(err).ToNot(HaveOccurred())
+				Expect(recorder.Code).To(BeEquivalentTo(http.StatusOK))
+				containers := []types.ContainerInfo{}
+				Expect(json.Unmarshal(recorder.Body.Bytes(), &containers)).To(Succeed())
+				Expect(containers).To(HaveLen(expected))
+			}
+		})
+
+		It("should fail with invalid label selector on /containers route", func() {
+			// Given
+			// When
+			request, err := http.NewRequest(http.MethodGet,
+				"/containers?label="+url.QueryEscape("app in ("), http.NoBody)
+			mux.ServeHTTP(recorder, request)
+
+			// Then
+			Expect(err).ToNot(HaveOccurred())
+			Expect(recorder.Code).To(BeEquivalentTo(http.StatusBadRequest))
+		})
+
+		It("should list pods filtered by label on /pods route", func() {
+			// Given
+			addContainerAndSandbox()
+
+			for selector, expected := range map[string]int{
+				"!app":      1,
+				"app=nginx": 0,
+			} {
+				// When
+				recorder = httptest.NewRecorder()
+				request, err := http.NewRequest(http.MethodGet,
+					"/pods?label="+url.QueryEscape(selector), http.NoBody)
+				mux.ServeHTTP(recorder, request)
+
+				// Then
+				Expect(err).ToNot(HaveOccurred())
+				Expect(recorder.Code).To(BeEquivalentTo(http.StatusOK))
+				pods := []types.PodInfo{}
+				Expect(json.Unmarshal(recorder.Body.Bytes(), &pods)).To(Succeed())
+				Expect(pods).To(HaveLen(expected))
+			}
+		})
+
+		It("should succeed with pod ID prefix on /pods route", func() {
+			// Given
+			addContainerAndSandbox()
+
+			// When
+			request, err := http.NewRequest(http.MethodGet,
+				"/pods/"+testSandbox.ID()[:6], http.NoBody)
+			mux.ServeHTTP(recorder, request)
+
+			// Then
+			Expect(err).ToNot(HaveOccurred())
+			Expect(recorder.Code).To(BeEquivalentTo(http.StatusOK))
+			pod := types.PodInfo{}
+			Expect(json.Unmarshal(recorder.Body.Bytes(), &pod)).To(Succeed())
+			Expect(pod.ID).To(Equal(testSandbox.ID()))
+			Expect(pod.InfraContainer).To(Equal(testContainer.ID()))
+		})
+
+		It("should fail with invalid pod ID on /pods route", func() {
+			// Given
+			// When
+			request, err := http.NewRequest(http.MethodGet, "/pods/123", http.NoBody)
+			mux.ServeHTTP(recorder, request)
+
+			// Then
+			Expect(err).ToNot(HaveOccurred())
 			Expect(recorder.Code).To(BeEquivalentTo(http.StatusNotFound))
 		})
 
@@ -254,5 +354,255 @@ var _ = t.Describe("Inspect", func() {
 			Expect(request).NotTo(BeNil())
 			Expect(recorder.Code).To(BeEquivalentTo(http.StatusConflict))
 		})
+
+		It("should override and revert the log level on /debug route", func() {
+			// Given
+			previous := logrus.GetLevel()
+			defer logrus.SetLevel(previous)
+
+			// When
+			request, err := http.NewRequest(http.MethodPut,
+				"/debug/log-level?value=trace&duration=1h", http.NoBody)
+			mux.ServeHTTP(recorder, request)
+
+			// Then
+			Expect(err).ToNot(HaveOccurred())
+			Expect(recorder.Code).To(BeEquivalentTo(http.StatusOK))
+			Expect(logrus.GetLevel()).To(Equal(logrus.TraceLevel))
+
+			// When
+			recorder = httptest.NewRecorder()
+			request, err = http.NewRequest(http.MethodGet, "/debug", http.NoBody)
+			mux.ServeHTTP(recorder, request)
+
+			// Then
+			Expect(err).ToNot(HaveOccurred())
+			Expect(recorder.Code).To(BeEquivalentTo(http.StatusOK))
+			info := types.DebugInfo{}
+			Expect(json.Unmarshal(recorder.Body.Bytes(), &info)).To(Succeed())
+			Expect(info.LogLevel).To(Equal("trace"))
+			Expect(info.Overrides).To(HaveLen(1))
+			Expect(info.Overrides[0].Setting).To(Equal("log-level"))
+			Expect(info.Overrides[0].Previous).To(Equal(serverConfig.LogLevel))
+
+			// When
+			recorder = httptest.NewRecorder()
+			request, err = http.NewRequest(http.MethodDelete, "/debug/log-level", http.NoBody)
+			mux.ServeHTTP(recorder, request)
+
+			// Then
+			Expect(err).ToNot(HaveOccurred())
+			Expect(recorder.Code).To(BeEquivalentTo(http.StatusOK))
+			Expect(logrus.GetLevel().String()).To(Equal(serverConfig.LogLevel))
+		})
+
+		It("should fail with invalid log level on /debug route", func() {
+			// Given
+			// When
+			request, err := http.NewRequest(http.MethodPut, "/debug/log-level?value=wrong", http.NoBody)
+			mux.ServeHTTP(recorder, request)
+
+			// Then
+			Expect(err).ToNot(HaveOccurred())
+			Expect(recorder.Code).To(BeEquivalentTo(http.StatusBadRequest))
+		})
+
+		It("should fail with too long duration on /debug route", func() {
+			// Given
+			// When
+			request, err := http.NewRequest(http.MethodPut,
+				"/debug/log-level?value=debug&duration=48h", http.NoBody)
+			mux.ServeHTTP(recorder, request)
+
+			// Then
+			Expect(err).ToNot(HaveOccurred())
+			Expect(recorder.Code).To(BeEquivalentTo(http.StatusBadRequest))
+		})
+
+		It("should fail to change the sampling rate without tracing on /debug route", func() {
+			// Given
+			// When
+			request, err := http.NewRequest(http.MethodPut,
+				"/debug/tracing-sampling-rate?value=1000000", http.NoBody)
+			mux.ServeHTTP(recorder, request)
+
+			// Then
+			Expect(err).ToNot(HaveOccurred())
+			Expect(recorder.Code).To(BeEquivalentTo(http.StatusBadRequest))
+		})
+
+		It("should fail to revert a not overridden setting on /debug route", func() {
+			// Given
+			// When
+			request, err := http.NewRequest(http.MethodDelete, "/debug/log-filter", http.NoBody)
+			mux.ServeHTTP(recorder, request)
+
+			// Then
+			Expect(err).ToNot(HaveOccurred())
+			Expect(recorder.Code).To(BeEquivalentTo(http.StatusNotFound))
+		})
+
+		It("should only report the images to remove on GET /images/gc route", func() {
+			// Given
+			images := imageGCTestImages()
+			imageServerMock.EXPECT().ListImages(gomock.Any()).Return(images, nil)
+			sut.SetImageGC(imagegc.New(imagegc.Policy{MaxImages: 1}, &imagegc.Options{
+				ImageServer: imageServerMock,
+			}))
+
+			// When
+			request, err := http.NewRequest(http.MethodGet, "/images/gc", http.NoBody)
+			mux.ServeHTTP(recorder, request)
+
+			// Then
+			Expect(err).ToNot(HaveOccurred())
+			Expect(recorder.Code).To(BeEquivalentTo(http.StatusOK))
+			res := types.ImageGCResult{}
+			Expect(json.Unmarshal(recorder.Body.Bytes(), &res)).To(Succeed())
+			Expect(res.DryRun).To(BeTrue())
+			Expect(res.Images).To(HaveLen(1))
+			Expect(res.Images[0].ID).To(Equal(strings.Repeat("a", 64)))
+		})
+
+		It("should remove the selected images on POST /images/gc route", func() {
+			// Given
+			images := imageGCTestImages()
+			gomock.InOrder(
+				imageServerMock.EXPECT().ListImages(gomock.Any()).Return(images, nil),
+				imageServerMock.EXPECT().DeleteImage(gomock.Any(), images[0].ID).Return(nil),
+			)
+			sut.SetImageGC(imagegc.New(imagegc.Policy{MaxImages: 1}, &imagegc.Options{
+				ImageServer: imageServerMock,
+			}))
+
+			// When
+			request, err := http.NewRequest(http.MethodPost, "/images/gc", http.NoBody)
+			mux.ServeHTTP(recorder, request)
+
+			// Then
+			Expect(err).ToNot(HaveOccurred())
+			Expect(recorder.Code).To(BeEquivalentTo(http.StatusOK))
+			res := types.ImageGCResult{}
+			Expect(json.Unmarshal(recorder.Body.Bytes(), &res)).To(Succeed())
+			Expect(res.DryRun).To(BeFalse())
+			Expect(res.Images).To(HaveLen(1))
+			Expect(res.Images[0].ID).To(Equal(strings.Repeat("a", 64)))
+		})
+
+		It("should list only checkpoint images on /checkpoints route", func() {
+			// Given
+			checkpointID, err := storage.ParseStorageImageIDFromOutOfProcessData(strings.Repeat("a", 64))
+			Expect(err).ToNot(HaveOccurred())
+			imageID, err := storage.ParseStorageImageIDFromOutOfProcessData(strings.Repeat("b", 64))
+			Expect(err).ToNot(HaveOccurred())
+			imageServerMock.EXPECT().ListImages(gomock.Any()).Return([]storage.ImageResult{
+				{
+					ID:          checkpointID,
+					RepoTags:    []string{"localhost/checkpoint:latest"},
+					Annotations: map[string]string{annotations.CheckpointAnnotationName: "ctr"},
+				},
+				{ID: imageID, RepoTags: []string{"quay.io/image:latest"}},
+			}, nil)
+
+			// When
+			request, err := http.NewRequest(http.MethodGet, "/checkpoints", http.NoBody)
+			mux.ServeHTTP(recorder, request)
+
+			// Then
+			Expect(err).ToNot(HaveOccurred())
+			Expect(recorder.Code).To(BeEquivalentTo(http.StatusOK))
+			checkpoints := []types.CheckpointInfo{}
+			Expect(json.Unmarshal(recorder.Body.Bytes(), &checkpoints)).To(Succeed())
+			Expect(checkpoints).To(HaveLen(1))
+			Expect(checkpoints[0].ID).To(Equal(strings.Repeat("a", 64)))
+			Expect(checkpoints[0].Container).To(Equal("ctr"))
+		})
+
+		It("should delete a checkpoint image by name on /checkpoints route", func() {
+			// Given
+			checkpointID, err := storage.ParseStorageImageIDFromOutOfProcessData(strings.Repeat("a", 64))
+			Expect(err).ToNot(HaveOccurred())
+			gomock.InOrder(
+				imageServerMock.EXPECT().ListImages(gomock.Any()).Return([]storage.ImageResult{{
+					ID:          checkpointID,
+					RepoTags:    []string{"localhost/checkpoint:latest"},
+					Annotations: map[string]string{annotations.CheckpointAnnotationName: "ctr"},
+				}}, nil),
+				imageServerMock.EXPECT().DeleteImage(gomock.Any(), checkpointID).Return(nil),
+			)
+
+			// When
+			request, err := http.NewRequest(http.MethodDelete,
+				"/checkpoints/"+url.PathEscape("localhost/checkpoint:latest"), http.NoBody)
+			mux.ServeHTTP(recorder, request)
+
+			// Then
+			Expect(err).ToNot(HaveOccurred())
+			Expect(recorder.Code).To(BeEquivalentTo(http.StatusOK))
+		})
+
+		It("should fail to delete a regular image on /checkpoints route", func() {
+			// Given
+			imageID, err := storage.ParseStorageImageIDFromOutOfProcessData(strings.Repeat("b", 64))
+			Expect(err).ToNot(HaveOccurred())
+			imageServerMock.EXPECT().ListImages(gomock.Any()).Return([]storage.ImageResult{
+				{ID: imageID, RepoTags: []string{"quay.io/image:latest"}},
+			}, nil)
+
+			// When
+			request, err := http.NewRequest(http.MethodDelete, "/checkpoints/bbbb", http.NoBody)
+			mux.ServeHTTP(recorder, request)
+
+			// Then
+			Expect(err).ToNot(HaveOccurred())
+			Expect(recorder.Code).To(BeEquivalentTo(http.StatusNotFound))
+		})
+
+		It("should fail to delete a checkpoint with too short ID on /checkpoints route", func() {
+			// Given
+			// When
+			request, err := http.NewRequest(http.MethodDelete, "/checkpoints/a", http.NoBody)
+			mux.ServeHTTP(recorder, request)
+
+			// Then
+			Expect(err).ToNot(HaveOccurred())
+			Expect(recorder.Code).To(BeEquivalentTo(http.StatusBadRequest))
+		})
+
+		It("should fail to delete an ambiguous checkpoint on /checkpoints route", func() {
+			// Given
+			firstID, err := storage.ParseStorageImageIDFromOutOfProcessData("abc" + strings.Repeat("a", 61))
+			Expect(err).ToNot(HaveOccurred())
+			secondID, err := storage.ParseStorageImageIDFromOutOfProcessData("abc" + strings.Repeat("b", 61))
+			Expect(err).ToNot(HaveOccurred())
+			imageServerMock.EXPECT().ListImages(gomock.Any()).Return([]storage.ImageResult{
+				{ID: firstID, Annotations: map[string]string{annotations.CheckpointAnnotationName: "ctr"}},
+				{ID: secondID, Annotations: map[string]string{annotations.CheckpointAnnotationName: "ctr"}},
+			}, nil)
+
+			// When
+			request, err := http.NewRequest(http.MethodDelete, "/checkpoints/abc", http.NoBody)
+			mux.ServeHTTP(recorder, request)
+
+			// Then
+			Expect(err).ToNot(HaveOccurred())
+			Expect(recorder.Code).To(BeEquivalentTo(http.StatusBadRequest))
+		})
 	})
 })
+
+// imageGCTestImages returns two images, of which the first one has been used
+// least recently.
+func imageGCTestImages() []storage.ImageResult {
+	images := []storage.ImageResult{}
+	for i, idChar := range []string{"a", "b"} {
+		id, err := storage.ParseStorageImageIDFromOutOfProcessData(strings.Repeat(idChar, 64))
+		Expect(err).ToNot(HaveOccurred())
+		images = append(images, storage.ImageResult{
+			ID:       id,
+			RepoTags: []string{"quay.io/" + idChar + ":latest"},
+			LastUsed: time.Now().Add(time.Duration(i-2) * time.Hour),
+		})
+	}
+	return images
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/cri-o/cri-o/internal/storage"
 	"github.com/cri-o/cri-o/internal/storage/references"
 	"github.com/cri-o/cri-o/pkg/config"
+	crioTypes "github.com/cri-o/cri-o/pkg/types"
 	specs "github.com/opencontainers/runtime-spec/specs-go"
 	types "k8s.io/cri-api/pkg/apis/runtime/v1"
 )
@@ -70,6 +71,7 @@ func TestGetContainerInfo(t *testing.T) {
 		cstate.State = specs.State{}
 		cstate.Created = created
 		container.SetStateAndSpoofPid(cstate)
+		container.AddNRIAudit(crioTypes.NRIAuditEntry{Plugin: "external", Event: "CREATE_CONTAINER"})
 		return container
 	}
 	getInfraContainerFunc := func(ctx context.Context, id string) *oci.Container {
@@ -111,6 +113,9 @@ func TestGetContainerInfo(t *testing.T) {
 	if ci.IPs[0] != "1.1.1.42" {
 		t.Fatalf("expected ip 1.1.1.42, got %s", ci.IPs[0])
 	}
+	if len(ci.NRIAudit) != 1 || ci.NRIAudit[0].Plugin != "external" {
+		t.Fatalf("expected NRI audit entry of plugin external, got %v", ci.NRIAudit)
+	}
 	if len(ci.Annotations) == 0 {
 		t.Fatal("annotations are empty")
 	}
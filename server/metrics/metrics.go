@@ -82,6 +82,41 @@ type Metrics struct {
 	metricContainersOOMCountTotal             *prometheus.CounterVec
 	metricContainersSeccompNotifierCountTotal *prometheus.CounterVec
 	metricResourcesStalledAtStage             *prometheus.CounterVec
+	metricImagePullsQueueDepth                *prometheus.GaugeVec
+	metricImagePullsQueueWaitSeconds          *prometheus.HistogramVec
+	metricContainersCheckpointDuration        prometheus.Histogram
+	metricContainersRestoreDuration           prometheus.Histogram
+	metricContainersCheckpointArchiveSize     prometheus.Histogram
+	metricContainersCheckpointRootfsDiffSize  prometheus.Histogram
+	metricContainersCheckpointRestoreFailures *prometheus.CounterVec
+}
+
+const (
+	// CheckpointRestorePhaseCRIUDump is the phase of dumping the container
+	// processes using CRIU.
+	CheckpointRestorePhaseCRIUDump = "criu_dump"
+	// CheckpointRestorePhaseExport is the phase of exporting the checkpoint
+	// into an archive.
+	CheckpointRestorePhaseExport = "export"
+	// CheckpointRestorePhaseImageCreation is the phase of creating an OCI
+	// image from the checkpoint.
+	CheckpointRestorePhaseImageCreation = "image_creation"
+	// CheckpointRestorePhaseCRIURestore is the phase of restoring the
+	// container processes using CRIU.
+	CheckpointRestorePhaseCRIURestore = "criu_restore"
+)
+
+// checkpointSizeBuckets are the histogram buckets in bytes for the checkpoint
+// sizes.
+var checkpointSizeBuckets = []float64{
+	1 << 10,   //   1 KiB
+	1 << 20,   //   1 MiB
+	10 << 20,  //  10 MiB
+	100 << 20, // 100 MiB
+	500 << 20, // 500 MiB
+	1 << 30,   //   1 GiB
+	5 << 30,   //   5 GiB
+	10 << 30,  //  10 GiB
 }
 
 var instance *Metrics
@@ -238,6 +273,63 @@ func New(config *libconfig.MetricsConfig) *Metrics {
 			},
 			[]string{"stage"},
 		),
+		metricImagePullsQueueDepth: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Subsystem: collectors.Subsystem,
+				Name:      collectors.ImagePullsQueueDepth.String(),
+				Help:      "Number of image pulls waiting for a free pull slot by registry.",
+			},
+			[]string{"registry"},
+		),
+		metricImagePullsQueueWaitSeconds: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Subsystem: collectors.Subsystem,
+				Name:      collectors.ImagePullsQueueWaitSeconds.String(),
+				Help:      "Time in seconds image pulls waited for a free pull slot by registry.",
+				Buckets:   []float64{0, 0.1, 0.5, 1, 5, 10, 30, 60, 120, 300},
+			},
+			[]string{"registry"},
+		),
+		metricContainersCheckpointDuration: prometheus.NewHistogram(
+			prometheus.HistogramOpts{
+				Subsystem: collectors.Subsystem,
+				Name:      collectors.ContainersCheckpointDurationSeconds.String(),
+				Help:      "Duration in seconds of successful container checkpoints.",
+				Buckets:   []float64{0.1, 0.5, 1, 5, 10, 30, 60, 120, 300, 600},
+			},
+		),
+		metricContainersRestoreDuration: prometheus.NewHistogram(
+			prometheus.HistogramOpts{
+				Subsystem: collectors.Subsystem,
+				Name:      collectors.ContainersRestoreDurationSeconds.String(),
+				Help:      "Duration in seconds of successful container restores.",
+				Buckets:   []float64{0.1, 0.5, 1, 5, 10, 30, 60, 120, 300, 600},
+			},
+		),
+		metricContainersCheckpointArchiveSize: prometheus.NewHistogram(
+			prometheus.HistogramOpts{
+				Subsystem: collectors.Subsystem,
+				Name:      collectors.ContainersCheckpointArchiveSizeBytes.String(),
+				Help:      "Size in bytes of exported container checkpoint archives.",
+				Buckets:   checkpointSizeBuckets,
+			},
+		),
+		metricContainersCheckpointRootfsDiffSize: prometheus.NewHistogram(
+			prometheus.HistogramOpts{
+				Subsystem: collectors.Subsystem,
+				Name:      collectors.ContainersCheckpointRootfsDiffSizeBytes.String(),
+				Help:      "Size in bytes of the root file system changes of checkpointed containers.",
+				Buckets:   checkpointSizeBuckets,
+			},
+		),
+		metricContainersCheckpointRestoreFailures: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Subsystem: collectors.Subsystem,
+				Name:      collectors.ContainersCheckpointRestoreFailuresTotal.String(),
+				Help:      "Cumulative number of container checkpoint and restore failures by phase.",
+			},
+			[]string{"phase"},
+		),
 	}
 	return Instance()
 }
@@ -400,6 +492,49 @@ func (m *Metrics) MetricResourcesStalledAtStage(stage string) {
 	c.Inc()
 }
 
+func (m *Metrics) MetricImagePullsQueueDepthSet(registry string, depth int) {
+	g, err := m.metricImagePullsQueueDepth.GetMetricWithLabelValues(registry)
+	if err != nil {
+		logrus.Warnf("Unable to write image pulls queue depth metric: %v", err)
+		return
+	}
+	g.Set(float64(depth))
+}
+
+func (m *Metrics) MetricImagePullsQueueWaitObserve(registry string, wait time.Duration) {
+	o, err := m.metricImagePullsQueueWaitSeconds.GetMetricWithLabelValues(registry)
+	if err != nil {
+		logrus.Warnf("Unable to write image pulls queue wait metric: %v", err)
+		return
+	}
+	o.Observe(wait.Seconds())
+}
+
+func (m *Metrics) MetricContainersCheckpointDurationObserve(start time.Time) {
+	m.metricContainersCheckpointDuration.Observe(SinceInSeconds(start))
+}
+
+func (m *Metrics) MetricContainersRestoreDurationObserve(start time.Time) {
+	m.metricContainersRestoreDuration.Observe(SinceInSeconds(start))
+}
+
+func (m *Metrics) MetricContainersCheckpointArchiveSizeObserve(size int64) {
+	m.metricContainersCheckpointArchiveSize.Observe(float64(size))
+}
+
+func (m *Metrics) MetricContainersCheckpointRootfsDiffSizeObserve(size int64) {
+	m.metricContainersCheckpointRootfsDiffSize.Observe(float64(size))
+}
+
+func (m *Metrics) MetricContainersCheckpointRestoreFailuresInc(phase string) {
+	c, err := m.metricContainersCheckpointRestoreFailures.GetMetricWithLabelValues(phase)
+	if err != nil {
+		logrus.Warnf("Unable to write checkpoint restore failures metric: %v", err)
+		return
+	}
+	c.Inc()
+}
+
 // createEndpoint creates a /metrics endpoint for prometheus monitoring.
 func (m *Metrics) createEndpoint() (*http.ServeMux, error) {
 	for collector, metric := range map[collectors.Collector]prometheus.Collector{
@@ -411,6 +546,8 @@ func (m *Metrics) createEndpoint() (*http.ServeMux, error) {
 		collectors.ImagePullsBytesTotal:                m.metricImagePullsBytesTotal,
 		collectors.ImagePullsFailureTotal:              m.metricImagePullsFailureTotal,
 		collectors.ImagePullsLayerSize:                 m.metricImagePullsLayerSize,
+		collectors.ImagePullsQueueDepth:                m.metricImagePullsQueueDepth,
+		collectors.ImagePullsQueueWaitSeconds:          m.metricImagePullsQueueWaitSeconds,
 		collectors.ImagePullsSkippedBytesTotal:         m.metricImagePullsSkippedBytesTotal,
 		collectors.ImagePullsSuccessTotal:              m.metricImagePullsSuccessTotal,
 		collectors.OperationsErrorsTotal:               m.metricOperationsErrorsTotal,
@@ -419,6 +556,12 @@ func (m *Metrics) createEndpoint() (*http.ServeMux, error) {
 		collectors.OperationsTotal:                     m.metricOperationsTotal,
 		collectors.ProcessesDefunct:                    m.metricProcessesDefunct,
 		collectors.ResourcesStalledAtStage:             m.metricResourcesStalledAtStage,
+
+		collectors.ContainersCheckpointArchiveSizeBytes:     m.metricContainersCheckpointArchiveSize,
+		collectors.ContainersCheckpointDurationSeconds:      m.metricContainersCheckpointDuration,
+		collectors.ContainersCheckpointRestoreFailuresTotal: m.metricContainersCheckpointRestoreFailures,
+		collectors.ContainersCheckpointRootfsDiffSizeBytes:  m.metricContainersCheckpointRootfsDiffSize,
+		collectors.ContainersRestoreDurationSeconds:         m.metricContainersRestoreDuration,
 	} {
 		if m.config.MetricsCollectors.Contains(collector) {
 			logrus.Debugf("Enabling metric: %s", collector.Stripped())
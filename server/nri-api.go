@@ -100,12 +100,23 @@ func (a *nriAPI) createContainer(ctx context.Context, specgen *generate.Generato
 		spec: specgen.Config,
 	}
 
-	adjust, err := a.nri.CreateContainer(ctx, pod, ctr)
+	adjusts, err := a.nri.CreateContainer(ctx, pod, ctr)
 	if err != nil {
 		return err
 	}
 
-	wrapgen := nrigen.SpecGenerator(specgen,
+	// A dry run adjusts a copy of the spec, to only record the changes.
+	target := specgen
+	if a.cri.config.NRI.DryRun {
+		spec := &rspec.Spec{}
+		if err := copyNRIAuditSpec(specgen.Config, spec); err != nil {
+			return fmt.Errorf("failed to copy spec of container %s: %w", ctr.GetID(), err)
+		}
+		gen := generate.NewFromSpec(spec)
+		target = &gen
+	}
+
+	wrapgen := nrigen.SpecGenerator(target,
 		nrigen.WithAnnotationFilter(
 			func(values map[string]string) (map[string]string, error) {
 				annotations, handler := criPod.Annotations(), criPod.RuntimeHandler()
@@ -163,8 +174,15 @@ func (a *nriAPI) createContainer(ctx context.Context, specgen *generate.Generato
 		),
 	)
 
-	if err := wrapgen.Adjust(adjust); err != nil {
-		return fmt.Errorf("failed to adjust container %s: %w", ctr.GetID(), err)
+	for _, adjust := range adjusts {
+		before := &rspec.Spec{}
+		if err := copyNRIAuditSpec(target.Config, before); err != nil {
+			return fmt.Errorf("failed to copy spec of container %s: %w", ctr.GetID(), err)
+		}
+		if err := wrapgen.Adjust(adjust.Adjust); err != nil {
+			return fmt.Errorf("failed to adjust container %s: %w", ctr.GetID(), err)
+		}
+		a.auditNRI(ctx, criCtr, adjust.Plugin, api.Event_CREATE_CONTAINER.String(), before, target.Config)
 	}
 
 	return nil
@@ -226,12 +244,31 @@ func (a *nriAPI) updateContainer(ctx context.Context, criCtr *oci.Container, req
 		ctr: criCtr,
 	}
 
-	r, err := a.nri.UpdateContainer(ctx, pod, ctr, api.FromCRILinuxResources(req))
+	changes, err := a.nri.UpdateContainer(ctx, pod, ctr, api.FromCRILinuxResources(req))
 	if err != nil {
 		return nil, err
 	}
+	if len(changes) == 0 {
+		return nil, nil
+	}
+
+	resources, audited := req, false
+	for _, change := range changes {
+		updated := change.Resources.ToCRI(noOomAdj)
+		if a.auditNRI(ctx, criCtr, change.Plugin, api.Event_UPDATE_CONTAINER.String(), resources, updated) {
+			audited = true
+		}
+		resources = updated
+	}
+	if audited {
+		a.persistNRIAudit(ctx, criCtr)
+	}
 
-	return r.ToCRI(noOomAdj), nil
+	if a.cri.config.NRI.DryRun {
+		return nil, nil
+	}
+
+	return resources, nil
 }
 
 func (a *nriAPI) postUpdateContainer(ctx context.Context, criCtr *oci.Container) error {
@@ -397,6 +434,17 @@ func (a *nriAPI) UpdateContainer(ctx context.Context, u *api.ContainerUpdate) er
 	}
 
 	resources := u.Linux.Resources.ToOCI()
+	var current *rspec.LinuxResources
+	if spec := ctr.Spec(); spec.Linux != nil {
+		current = spec.Linux.Resources
+	}
+	if a.auditNRI(ctx, ctr, nri.PluginFromContext(ctx), api.Event_UPDATE_CONTAINER.String(), current, resources) {
+		a.persistNRIAudit(ctx, ctr)
+	}
+	if a.cri.config.NRI.DryRun {
+		return nil
+	}
+
 	if err = a.cri.Runtime().UpdateContainer(ctx, ctr, resources); err != nil {
 		log.Errorf(ctx, "Failed to update CRI container %q: %v", u.ContainerId, err)
 		if u.IgnoreFailure {
@@ -417,6 +465,11 @@ func (a *nriAPI) EvictContainer(ctx context.Context, e *api.ContainerEviction) e
 		log.Errorf(ctx, "Failed to evict CRI container %q: %v", e.ContainerId, err)
 		return nil
 	}
+	a.auditNRI(ctx, ctr, nri.PluginFromContext(ctx), nriEventEvictContainer, nil, nil)
+	if a.cri.config.NRI.DryRun {
+		a.persistNRIAudit(ctx, ctr)
+		return nil
+	}
 	if err = a.cri.stopContainer(ctx, ctr, 0); err != nil {
 		log.Errorf(ctx, "Failed to evict CRI container %q: %v", e.ContainerId, err)
 		return err
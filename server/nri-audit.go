@@ -0,0 +1,153 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/cri-o/cri-o/internal/log"
+	"github.com/cri-o/cri-o/internal/oci"
+	"github.com/cri-o/cri-o/pkg/types"
+	rspec "github.com/opencontainers/runtime-spec/specs-go"
+)
+
+// nriEventEvictContainer is the audit event of a container eviction, which has
+// no corresponding NRI event.
+const nriEventEvictContainer = "EVICT_CONTAINER"
+
+// auditNRI records the changes of the plugin from before to after in the NRI
+// audit trail of the container and logs them. Nothing is recorded for a plugin
+// without changes, unless it evicts the container. It returns whether an entry
+// got recorded.
+func (a *nriAPI) auditNRI(ctx context.Context, ctr *oci.Container, plugin, event string, before, after any) bool {
+	changes, err := nriAuditChanges(before, after)
+	if err != nil {
+		log.Warnf(ctx, "Failed to record the changes of NRI plugin %s to container %s: %v", plugin, ctr.ID(), err)
+		return false
+	}
+	if len(changes) == 0 && event != nriEventEvictContainer {
+		return false
+	}
+
+	entry := types.NRIAuditEntry{
+		Plugin:  plugin,
+		Event:   event,
+		Time:    time.Now().UnixNano(),
+		DryRun:  a.cri.config.NRI.DryRun,
+		Changes: changes,
+	}
+	ctr.AddNRIAudit(entry)
+
+	dryRun := ""
+	if entry.DryRun {
+		dryRun = " (dry run)"
+	}
+	changesJSON, err := json.Marshal(changes)
+	if err != nil {
+		changesJSON = []byte(err.Error())
+	}
+	log.Infof(ctx, "NRI plugin %s changed container %s on %s%s: %s",
+		plugin, ctr.ID(), event, dryRun, changesJSON)
+	return true
+}
+
+// persistNRIAudit writes the container state including the NRI audit trail to
+// disk, for changes after the creation of the container.
+func (a *nriAPI) persistNRIAudit(ctx context.Context, ctr *oci.Container) {
+	if err := a.cri.ContainerStateToDisk(ctx, ctr); err != nil {
+		log.Warnf(ctx, "Failed to write the NRI audit trail of container %s to disk: %v", ctr.ID(), err)
+	}
+}
+
+// copyNRIAuditSpec deep copies the spec from in to out.
+func copyNRIAuditSpec(in, out *rspec.Spec) error {
+	data, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+// nriAuditChanges returns the changed fields between the JSON encodings of
+// before and after, sorted by their path.
+func nriAuditChanges(before, after any) ([]types.NRIAuditChange, error) {
+	beforeValue, err := nriAuditValue(before)
+	if err != nil {
+		return nil, err
+	}
+	afterValue, err := nriAuditValue(after)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []types.NRIAuditChange
+	diffNRIAuditValue("", beforeValue, afterValue, &changes)
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+	return changes, nil
+}
+
+func nriAuditValue(v any) (any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("encode %T: %w", v, err)
+	}
+	var res any
+	if err := json.Unmarshal(data, &res); err != nil {
+		return nil, fmt.Errorf("decode %T: %w", v, err)
+	}
+	return res, nil
+}
+
+// diffNRIAuditValue appends the changes between the decoded JSON values to
+// changes, descending into objects and arrays.
+func diffNRIAuditValue(path string, before, after any, changes *[]types.NRIAuditChange) {
+	join := func(key string) string {
+		if path == "" {
+			return key
+		}
+		return path + "." + key
+	}
+
+	switch b := before.(type) {
+	case map[string]any:
+		if a, ok := after.(map[string]any); ok {
+			for key, value := range b {
+				diffNRIAuditValue(join(key), value, a[key], changes)
+			}
+			for key, value := range a {
+				if _, ok := b[key]; !ok {
+					diffNRIAuditValue(join(key), nil, value, changes)
+				}
+			}
+			return
+		}
+	case []any:
+		if a, ok := after.([]any); ok {
+			for i := range max(len(b), len(a)) {
+				var beforeItem, afterItem any
+				if i < len(b) {
+					beforeItem = b[i]
+				}
+				if i < len(a) {
+					afterItem = a[i]
+				}
+				diffNRIAuditValue(join(strconv.Itoa(i)), beforeItem, afterItem, changes)
+			}
+			return
+		}
+	}
+
+	if !reflect.DeepEqual(before, after) {
+		*changes = append(*changes, types.NRIAuditChange{
+			Path: path,
+			Old:  before,
+			New:  after,
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package server
+
+import (
+	"reflect"
+	"testing"
+
+	crioTypes "github.com/cri-o/cri-o/pkg/types"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+)
+
+func TestNRIAuditChanges(t *testing.T) {
+	before := &specs.Spec{
+		Process: &specs.Process{
+			Env: []string{"A=1"},
+		},
+		Annotations: map[string]string{"a": "1"},
+	}
+	after := &specs.Spec{
+		Process: &specs.Process{
+			Env: []string{"A=2", "B=1"},
+		},
+		Annotations: map[string]string{"a": "1", "b": "2"},
+	}
+
+	changes, err := nriAuditChanges(before, after)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []crioTypes.NRIAuditChange{
+		{Path: "annotations.b", New: "2"},
+		{Path: "process.env.0", Old: "A=1", New: "A=2"},
+		{Path: "process.env.1", New: "B=1"},
+	}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Fatalf("expected changes %v, got %v", expected, changes)
+	}
+
+	changes, err = nriAuditChanges(before, before)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 0 {
+		t.Fatalf("expected no changes, got %v", changes)
+	}
+}
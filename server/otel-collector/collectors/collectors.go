@@ -63,6 +63,27 @@ const (
 
 	// ResourcesStalledAtStage is the key for the resources stalled at different stages in container and pod creation.
 	ResourcesStalledAtStage Collector = crioPrefix + "resources_stalled_at_stage"
+
+	// ImagePullsQueueDepth is the key for the number of image pulls waiting for a free slot per registry.
+	ImagePullsQueueDepth Collector = crioPrefix + "image_pulls_queue_depth"
+
+	// ImagePullsQueueWaitSeconds is the key for the time image pulls waited for a free slot per registry.
+	ImagePullsQueueWaitSeconds Collector = crioPrefix + "image_pulls_queue_wait_seconds"
+
+	// ContainersCheckpointDurationSeconds is the key for the duration of successful container checkpoints.
+	ContainersCheckpointDurationSeconds Collector = crioPrefix + "containers_checkpoint_duration_seconds"
+
+	// ContainersRestoreDurationSeconds is the key for the duration of successful container restores.
+	ContainersRestoreDurationSeconds Collector = crioPrefix + "containers_restore_duration_seconds"
+
+	// ContainersCheckpointArchiveSizeBytes is the key for the size of exported container checkpoint archives.
+	ContainersCheckpointArchiveSizeBytes Collector = crioPrefix + "containers_checkpoint_archive_size_bytes"
+
+	// ContainersCheckpointRootfsDiffSizeBytes is the key for the size of the root file system changes of checkpointed containers.
+	ContainersCheckpointRootfsDiffSizeBytes Collector = crioPrefix + "containers_checkpoint_rootfs_diff_size_bytes"
+
+	// ContainersCheckpointRestoreFailuresTotal is the key for the container checkpoint and restore failures per phase.
+	ContainersCheckpointRestoreFailuresTotal Collector = crioPrefix + "containers_checkpoint_restore_failures_total"
 )
 
 // FromSlice converts a string slice to a Collectors type.
@@ -101,6 +122,13 @@ func All() Collectors {
 		ContainersOOMCountTotal.Stripped(),
 		ContainersSeccompNotifierCountTotal.Stripped(),
 		ResourcesStalledAtStage.Stripped(),
+		ImagePullsQueueDepth.Stripped(),
+		ImagePullsQueueWaitSeconds.Stripped(),
+		ContainersCheckpointDurationSeconds.Stripped(),
+		ContainersRestoreDurationSeconds.Stripped(),
+		ContainersCheckpointArchiveSizeBytes.Stripped(),
+		ContainersCheckpointRootfsDiffSizeBytes.Stripped(),
+		ContainersCheckpointRestoreFailuresTotal.Stripped(),
 	}
 }
 
@@ -52,11 +52,18 @@ var _ = t.Describe("Collectors", func() {
 				collectors.ContainersOOMCountTotal,
 				collectors.ContainersSeccompNotifierCountTotal,
 				collectors.ResourcesStalledAtStage,
+				collectors.ImagePullsQueueDepth,
+				collectors.ImagePullsQueueWaitSeconds,
+				collectors.ContainersCheckpointDurationSeconds,
+				collectors.ContainersRestoreDurationSeconds,
+				collectors.ContainersCheckpointArchiveSizeBytes,
+				collectors.ContainersCheckpointRootfsDiffSizeBytes,
+				collectors.ContainersCheckpointRestoreFailuresTotal,
 			} {
 				Expect(all.Contains(collector)).To(BeTrue())
 			}
 
-			Expect(all).To(HaveLen(16))
+			Expect(all).To(HaveLen(23))
 		})
 	})
 
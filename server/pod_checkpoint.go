@@ -0,0 +1,355 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	metadata "github.com/checkpoint-restore/checkpointctl/lib"
+	"github.com/containers/storage/pkg/archive"
+	"github.com/cri-o/cri-o/internal/hostport"
+	"github.com/cri-o/cri-o/internal/lib"
+	"github.com/cri-o/cri-o/internal/lib/sandbox"
+	"github.com/cri-o/cri-o/internal/log"
+	"github.com/cri-o/cri-o/internal/oci"
+	crioTypes "github.com/cri-o/cri-o/pkg/types"
+	"golang.org/x/net/context"
+	types "k8s.io/cri-api/pkg/apis/runtime/v1"
+)
+
+// podCheckpointContainersDirectory is the directory of a pod checkpoint
+// archive containing the checkpoint archives of the containers.
+const podCheckpointContainersDirectory = "containers"
+
+var (
+	errCheckpointRestoreNotAvailable = errors.New("checkpoint/restore support not available")
+	errInvalidPodCheckpoint          = errors.New("invalid pod checkpoint")
+	errPodCheckpointConflict         = errors.New("conflicting pod")
+)
+
+// podCheckpointDump is the content of the pod.dump file of a pod checkpoint
+// archive. It contains everything required to recreate the sandbox.
+type podCheckpointDump struct {
+	// ID is the ID of the checkpointed pod sandbox.
+	ID             string                  `json:"id"`
+	RuntimeHandler string                  `json:"runtimeHandler"`
+	Config         *types.PodSandboxConfig `json:"config"`
+	// Containers are the IDs of the checkpointed containers in the order
+	// of their creation, which is the order they get restored in.
+	Containers     []string  `json:"containers"`
+	CheckpointedAt time.Time `json:"checkpointedAt"`
+}
+
+// podSandboxConfig returns the CRI configuration recreating the sandbox.
+// Settings which are not kept by the sandbox, like the SELinux options, fall
+// back to their defaults.
+func podSandboxConfig(sb *sandbox.Sandbox) *types.PodSandboxConfig {
+	portMappings := make([]*types.PortMapping, 0, len(sb.PortMappings()))
+	for _, pm := range sb.PortMappings() {
+		portMappings = append(portMappings, criPortMapping(pm))
+	}
+	return &types.PodSandboxConfig{
+		Metadata:     sb.Metadata(),
+		Hostname:     sb.Hostname(),
+		LogDirectory: sb.LogDir(),
+		DnsConfig:    sb.DNSConfig(),
+		PortMappings: portMappings,
+		Labels:       sb.Labels(),
+		Annotations:  sb.Annotations(),
+		Linux: &types.LinuxPodSandboxConfig{
+			CgroupParent: sb.CgroupParent(),
+			SecurityContext: &types.LinuxSandboxSecurityContext{
+				NamespaceOptions: sb.NamespaceOptions(),
+				Privileged:       sb.Privileged(),
+			},
+			Overhead:  sb.PodLinuxOverhead(),
+			Resources: sb.PodLinuxResources(),
+		},
+	}
+}
+
+// criPortMapping is the reverse of convertPortMappings.
+func criPortMapping(pm *hostport.PortMapping) *types.PortMapping {
+	return &types.PortMapping{
+		Protocol:      types.Protocol(types.Protocol_value[string(pm.Protocol)]),
+		ContainerPort: pm.ContainerPort,
+		HostPort:      pm.HostPort,
+		HostIp:        pm.HostIP,
+	}
+}
+
+// checkpointPod checkpoints all running containers of the pod sandbox
+// referenced by its ID or unique ID prefix into a single archive at the
+// target path. The containers get paused together before the first one is
+// dumped, which keeps the checkpoint consistent across them. They are only
+// stopped once the archive has been written, unless leaveRunning is set, which
+// keeps them running if the checkpoint fails.
+func (s *Server) checkpointPod(ctx context.Context, id, target string, leaveRunning bool) (*crioTypes.PodCheckpointInfo, error) {
+	ctx, span := log.StartSpan(ctx)
+	defer span.End()
+	if !s.config.RuntimeConfig.CheckpointRestore() {
+		return nil, errCheckpointRestoreNotAvailable
+	}
+	podID, err := s.PodIDIndex().Get(id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", errPodNotFound, id, err)
+	}
+	sb := s.getSandbox(ctx, podID)
+	if sb == nil || !sb.Created() {
+		return nil, fmt.Errorf("%w: %s", errPodNotFound, id)
+	}
+
+	stopMutex := sb.StopMutex()
+	stopMutex.RLock()
+	defer stopMutex.RUnlock()
+	if sb.Stopped() {
+		return nil, fmt.Errorf("pod %s is stopped", sb.ID())
+	}
+
+	ctrs := []*oci.Container{}
+	for _, ctr := range sb.Containers().List() {
+		if ctr.State().Status == oci.ContainerStateRunning {
+			ctrs = append(ctrs, ctr)
+		}
+	}
+	slices.SortFunc(ctrs, func(a, b *oci.Container) int {
+		return a.CreatedAt().Compare(b.CreatedAt())
+	})
+
+	workDir, err := os.MkdirTemp("", "pod-checkpoint")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := os.RemoveAll(workDir); err != nil {
+			log.Errorf(ctx, "Could not recursively remove %s: %q", workDir, err)
+		}
+	}()
+	ctrsDir := filepath.Join(workDir, podCheckpointContainersDirectory)
+	if err := os.Mkdir(ctrsDir, 0o700); err != nil {
+		return nil, err
+	}
+
+	log.Infof(ctx, "Checkpointing pod: %s", sb.ID())
+	// Containers paused so far get resumed in any case, which also updates
+	// the state of the stopped ones.
+	paused := make([]*oci.Container, 0, len(ctrs))
+	defer func() {
+		for _, ctr := range paused {
+			s.ContainerServer.ResumeCheckpointedContainer(ctx, ctr)
+		}
+	}()
+	for _, ctr := range ctrs {
+		if err := s.Runtime().PauseContainer(ctx, ctr); err != nil {
+			return nil, fmt.Errorf("failed to pause container %s before checkpointing: %w", ctr.ID(), err)
+		}
+		paused = append(paused, ctr)
+		if err := s.Runtime().UpdateContainerStatus(ctx, ctr); err != nil {
+			return nil, fmt.Errorf("failed to update status of container %s: %w", ctr.ID(), err)
+		}
+	}
+
+	dump := &podCheckpointDump{
+		ID:             sb.ID(),
+		RuntimeHandler: sb.RuntimeHandler(),
+		Config:         podSandboxConfig(sb),
+		Containers:     make([]string, 0, len(ctrs)),
+		CheckpointedAt: time.Now(),
+	}
+	// The containers keep running until the archive has been written.
+	for _, ctr := range ctrs {
+		if _, err := s.ContainerServer.ContainerCheckpoint(
+			ctx,
+			&metadata.ContainerConfig{ID: ctr.ID()},
+			&lib.ContainerCheckpointOptions{
+				TargetFile:  filepath.Join(ctrsDir, ctr.ID()+".tar"),
+				KeepRunning: true,
+				Paused:      true,
+			},
+		); err != nil {
+			return nil, err
+		}
+		dump.Containers = append(dump.Containers, ctr.ID())
+	}
+	if _, err := metadata.WriteJSONFile(dump, workDir, metadata.PodDumpFile); err != nil {
+		return nil, err
+	}
+
+	if err := writePodCheckpointArchive(workDir, target); err != nil {
+		return nil, err
+	}
+	log.Infof(ctx, "Checkpointed pod %s to %s", sb.ID(), target)
+
+	if !leaveRunning {
+		for _, ctr := range ctrs {
+			if err := s.stopContainer(ctx, ctr, 0); err != nil {
+				return nil, fmt.Errorf("failed to stop container %s after checkpointing: %w", ctr.ID(), err)
+			}
+		}
+	}
+
+	return &crioTypes.PodCheckpointInfo{
+		ID:         sb.ID(),
+		Path:       target,
+		Containers: dump.Containers,
+	}, nil
+}
+
+// writePodCheckpointArchive writes the content of the directory as
+// uncompressed tar archive to the target path.
+func writePodCheckpointArchive(dir, target string) error {
+	input, err := archive.TarWithOptions(dir, &archive.TarOptions{
+		Compression: archive.Uncompressed,
+	})
+	if err != nil {
+		return fmt.Errorf("error reading pod checkpoint directory %q: %w", dir, err)
+	}
+	defer input.Close()
+
+	outFile, err := os.OpenFile(target, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return fmt.Errorf("error creating pod checkpoint archive %q: %w", target, err)
+	}
+	defer outFile.Close()
+
+	if _, err := io.Copy(outFile, input); err != nil {
+		return fmt.Errorf("error writing pod checkpoint archive %q: %w", target, err)
+	}
+	return nil
+}
+
+// readPodCheckpointDump unpacks the pod checkpoint archive into the directory
+// and returns its pod dump.
+func readPodCheckpointDump(path, dir string) (*podCheckpointDump, error) {
+	archiveFile, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open pod checkpoint archive %s: %w", path, err)
+	}
+	defer archiveFile.Close()
+
+	if err := archive.Untar(archiveFile, dir, &archive.TarOptions{}); err != nil {
+		return nil, fmt.Errorf("unpacking of pod checkpoint archive %s failed: %w", path, err)
+	}
+
+	dump := &podCheckpointDump{}
+	if _, err := metadata.ReadJSONFile(dump, dir, metadata.PodDumpFile); err != nil {
+		return nil, fmt.Errorf("%w: failed to read %q: %v", errInvalidPodCheckpoint, metadata.PodDumpFile, err)
+	}
+	if dump.Config == nil || dump.Config.Metadata == nil {
+		return nil, fmt.Errorf("%w: missing pod configuration", errInvalidPodCheckpoint)
+	}
+	for _, ctrID := range dump.Containers {
+		if filepath.Base(ctrID) != ctrID {
+			return nil, fmt.Errorf("%w: invalid container ID %q", errInvalidPodCheckpoint, ctrID)
+		}
+	}
+	return dump, nil
+}
+
+// podRestoreRuntime contains the CRI methods of the Server used to restore a
+// pod checkpoint.
+type podRestoreRuntime interface {
+	RunPodSandbox(context.Context, *types.RunPodSandboxRequest) (*types.RunPodSandboxResponse, error)
+	StopPodSandbox(context.Context, *types.StopPodSandboxRequest) (*types.StopPodSandboxResponse, error)
+	RemovePodSandbox(context.Context, *types.RemovePodSandboxRequest) (*types.RemovePodSandboxResponse, error)
+	CRImportCheckpoint(ctx context.Context, createConfig *types.ContainerConfig, sbID, sandboxUID string) (string, error)
+	StartContainer(context.Context, *types.StartContainerRequest) (*types.StartContainerResponse, error)
+}
+
+// restorePod recreates the pod sandbox of the pod checkpoint archive at the
+// path and restores all of its containers into it. The sandbox is recreated
+// with the metadata of the checkpointed one, which therefore has to be removed
+// before, for example when restoring a pod on the same node.
+func (s *Server) restorePod(ctx context.Context, path string) (*crioTypes.PodInfo, error) {
+	ctx, span := log.StartSpan(ctx)
+	defer span.End()
+	if !s.config.RuntimeConfig.CheckpointRestore() {
+		return nil, errCheckpointRestoreNotAvailable
+	}
+
+	workDir, err := os.MkdirTemp("", "pod-restore")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := os.RemoveAll(workDir); err != nil {
+			log.Errorf(ctx, "Could not recursively remove %s: %q", workDir, err)
+		}
+	}()
+	dump, err := readPodCheckpointDump(path, workDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, sb := range s.ContainerServer.ListSandboxes() {
+		if samePodMetadata(sb.Metadata(), dump.Config.Metadata) {
+			return nil, fmt.Errorf("%w: pod %s has the metadata of the checkpointed pod, remove it before restoring", errPodCheckpointConflict, sb.ID())
+		}
+	}
+
+	log.Infof(ctx, "Restoring pod %s from %s", dump.ID, path)
+	sbID, err := restorePodCheckpoint(ctx, s, dump, workDir)
+	if err != nil {
+		return nil, err
+	}
+
+	sb := s.getSandbox(ctx, sbID)
+	if sb == nil {
+		return nil, fmt.Errorf("%w: %s", errPodNotFound, sbID)
+	}
+	log.Infof(ctx, "Restored pod %s as %s", dump.ID, sbID)
+	info := podInfo(sb)
+	return &info, nil
+}
+
+// samePodMetadata returns true if both metadata result in the same sandbox
+// name.
+func samePodMetadata(a, b *types.PodSandboxMetadata) bool {
+	return a.GetName() == b.GetName() &&
+		a.GetNamespace() == b.GetNamespace() &&
+		a.GetUid() == b.GetUid() &&
+		a.GetAttempt() == b.GetAttempt()
+}
+
+// restorePodCheckpoint recreates the sandbox of the pod dump and imports and
+// starts the containers unpacked to dir in the order of the dump. The sandbox
+// gets removed again if one of the containers cannot be restored.
+func restorePodCheckpoint(ctx context.Context, rt podRestoreRuntime, dump *podCheckpointDump, dir string) (_ string, retErr error) {
+	resp, err := rt.RunPodSandbox(ctx, &types.RunPodSandboxRequest{
+		Config:         dump.Config,
+		RuntimeHandler: dump.RuntimeHandler,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to recreate pod %s: %w", dump.ID, err)
+	}
+	sbID := resp.PodSandboxId
+	defer func() {
+		if retErr == nil {
+			return
+		}
+		log.Infof(ctx, "RestorePod: removing pod %s", sbID)
+		if _, err := rt.StopPodSandbox(ctx, &types.StopPodSandboxRequest{PodSandboxId: sbID}); err != nil {
+			log.Warnf(ctx, "Failed to stop pod %s: %v", sbID, err)
+		}
+		if _, err := rt.RemovePodSandbox(ctx, &types.RemovePodSandboxRequest{PodSandboxId: sbID}); err != nil {
+			log.Warnf(ctx, "Failed to remove pod %s: %v", sbID, err)
+		}
+	}()
+
+	for _, ctrID := range dump.Containers {
+		ctrArchive := filepath.Join(dir, podCheckpointContainersDirectory, ctrID+".tar")
+		newID, err := rt.CRImportCheckpoint(ctx, &types.ContainerConfig{
+			Image: &types.ImageSpec{Image: ctrArchive},
+		}, sbID, dump.Config.Metadata.Uid)
+		if err != nil {
+			return "", fmt.Errorf("failed to import checkpoint of container %s: %w", ctrID, err)
+		}
+		if _, err := rt.StartContainer(ctx, &types.StartContainerRequest{ContainerId: newID}); err != nil {
+			return "", fmt.Errorf("failed to restore container %s: %w", ctrID, err)
+		}
+	}
+	return sbID, nil
+}
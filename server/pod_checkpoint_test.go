@@ -0,0 +1,414 @@
+package server_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	metadata "github.com/checkpoint-restore/checkpointctl/lib"
+	cstorage "github.com/containers/storage"
+	"github.com/containers/storage/pkg/archive"
+	"github.com/cri-o/cri-o/internal/oci"
+	"github.com/cri-o/cri-o/pkg/types"
+	"github.com/cri-o/cri-o/server"
+	"github.com/go-chi/chi/v5"
+	"github.com/golang/mock/gomock"
+	json "github.com/json-iterator/go"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	criTypes "k8s.io/cri-api/pkg/apis/runtime/v1"
+)
+
+var _ = t.Describe("PodCheckpoint", func() {
+	var (
+		recorder *httptest.ResponseRecorder
+		mux      *chi.Mux
+		dir      string
+	)
+
+	// Prepare the sut
+	BeforeEach(func() {
+		beforeEach()
+		mockRuncInLibConfig()
+		serverConfig.SetCheckpointRestore(true)
+		setupSUT()
+
+		recorder = httptest.NewRecorder()
+		mux = sut.GetExtendInterfaceMux(false)
+		dir = t.MustTempDir("pod-checkpoint")
+	})
+	AfterEach(afterEach)
+
+	pathQuery := func(path string) string {
+		return "?" + url.Values{"path": []string{path}}.Encode()
+	}
+
+	// addRunningContainer adds a running container of the test sandbox,
+	// which uses the mocked runtime implementation.
+	addRunningContainer := func(id string, created time.Time) *oci.Container {
+		ctrDir := filepath.Join(t.MustTempDir("pod-checkpoint-container"), id)
+		Expect(os.Mkdir(ctrDir, 0o700)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(ctrDir, "config.json"),
+			[]byte(`{"linux":{},"process":{}}`), 0o644)).To(Succeed())
+		ctr, err := oci.NewContainer(id, id, ctrDir, "",
+			make(map[string]string), make(map[string]string),
+			make(map[string]string), "image", nil, nil, "",
+			&criTypes.ContainerMetadata{Name: id}, sandboxID, false, false,
+			false, "", ctrDir, created, "")
+		Expect(err).ToNot(HaveOccurred())
+		ctr.State().Status = oci.ContainerStateRunning
+		ctr.SetCreated()
+		sut.AddContainer(context.Background(), ctr)
+		Expect(sut.CtrIDIndex().Add(id)).To(Succeed())
+		sut.Runtime().SetRuntimeImpl(ctr, ociRuntimeMock)
+		return ctr
+	}
+
+	expectPause := func(ctr *oci.Container) *gomock.Call {
+		return ociRuntimeMock.EXPECT().PauseContainer(gomock.Any(), ctr).
+			Do(func(context.Context, *oci.Container) { ctr.State().Status = oci.ContainerStatePaused }).
+			Return(nil)
+	}
+
+	expectStatusUpdate := func(ctr *oci.Container) *gomock.Call {
+		return ociRuntimeMock.EXPECT().UpdateContainerStatus(gomock.Any(), ctr).
+			Return(nil)
+	}
+
+	expectUnpause := func(ctr *oci.Container) *gomock.Call {
+		return ociRuntimeMock.EXPECT().UnpauseContainer(gomock.Any(), ctr).
+			Do(func(context.Context, *oci.Container) { ctr.State().Status = oci.ContainerStateRunning }).
+			Return(nil)
+	}
+
+	// expectCheckpoint expects the container to be checkpointed into the pod
+	// archive while it keeps running.
+	expectCheckpoint := func(ctr *oci.Container, mountPoint string) []*gomock.Call {
+		return []*gomock.Call{
+			ociRuntimeMock.EXPECT().CheckpointContainer(gomock.Any(), ctr, gomock.Any(), gomock.Any()).
+				Do(func(_ context.Context, _ *oci.Container, _ *specs.Spec, opts *oci.CheckpointOptions) {
+					Expect(opts.LeaveRunning).To(BeTrue())
+				}).
+				Return(nil),
+			storeMock.EXPECT().Container(ctr.ID()).
+				Return(&cstorage.Container{ID: ctr.ID(), LayerID: "layer"}, nil),
+			storeMock.EXPECT().Changes("", "layer").Return(nil, nil),
+			imageServerMock.EXPECT().GetStore().Return(storeMock),
+			storeMock.EXPECT().Mount(ctr.ID(), gomock.Any()).Return(mountPoint, nil),
+		}
+	}
+
+	t.Describe("checkpoint", func() {
+		It("should write the pod dump into the archive", func() {
+			// Given
+			addContainerAndSandbox()
+			target := filepath.Join(dir, "pod.tar")
+
+			// When
+			request, err := http.NewRequest(http.MethodPost,
+				"/pods/checkpoint/"+testSandbox.ID()+pathQuery(target), http.NoBody)
+			mux.ServeHTTP(recorder, request)
+
+			// Then
+			Expect(err).ToNot(HaveOccurred())
+			Expect(recorder.Code).To(BeEquivalentTo(http.StatusOK))
+			info := types.PodCheckpointInfo{}
+			Expect(json.Unmarshal(recorder.Body.Bytes(), &info)).To(Succeed())
+			Expect(info.ID).To(Equal(testSandbox.ID()))
+			Expect(info.Path).To(Equal(target))
+			Expect(info.Containers).To(BeEmpty())
+
+			archiveFile, err := os.Open(target)
+			Expect(err).ToNot(HaveOccurred())
+			defer archiveFile.Close()
+			unpacked := filepath.Join(dir, "unpacked")
+			Expect(archive.Untar(archiveFile, unpacked, &archive.TarOptions{})).To(Succeed())
+			dump := struct {
+				ID     string `json:"id"`
+				Config struct {
+					Metadata struct {
+						Name string `json:"name"`
+					} `json:"metadata"`
+				} `json:"config"`
+			}{}
+			_, err = metadata.ReadJSONFile(&dump, unpacked, metadata.PodDumpFile)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(dump.ID).To(Equal(testSandbox.ID()))
+			Expect(dump.Config.Metadata.Name).To(Equal(testSandbox.Metadata().Name))
+		})
+
+		It("should checkpoint paused containers in the order of their creation and stop them afterwards", func() {
+			// Given
+			addContainerAndSandbox()
+			now := time.Now()
+			second := addRunningContainer("second", now)
+			first := addRunningContainer("first", now.Add(-time.Minute))
+			target := filepath.Join(dir, "pod.tar")
+			mountPoint := t.MustTempDir("pod-checkpoint-rootfs")
+
+			expectStop := func(ctr *oci.Container) []*gomock.Call {
+				return []*gomock.Call{
+					expectUnpause(ctr),
+					expectStatusUpdate(ctr),
+					ociRuntimeMock.EXPECT().StopContainer(gomock.Any(), ctr, int64(0)).
+						Do(func(context.Context, *oci.Container, int64) {
+							// The archive has been written before
+							Expect(target).To(BeARegularFile())
+							ctr.State().Status = oci.ContainerStateStopped
+						}).
+						Return(nil),
+					runtimeServerMock.EXPECT().StopContainer(gomock.Any(), ctr.ID()).Return(nil),
+					expectStatusUpdate(ctr),
+				}
+			}
+			calls := []*gomock.Call{
+				expectPause(first),
+				expectStatusUpdate(first),
+				expectPause(second),
+				expectStatusUpdate(second),
+			}
+			// The containers are checkpointed paused without pausing them again
+			calls = append(calls, expectCheckpoint(first, mountPoint)...)
+			calls = append(calls, expectCheckpoint(second, mountPoint)...)
+			calls = append(calls, expectStop(first)...)
+			calls = append(calls, expectStop(second)...)
+			// The stopped containers do not get unpaused
+			calls = append(calls, expectStatusUpdate(first).Times(2), expectStatusUpdate(second).Times(2))
+			gomock.InOrder(calls...)
+
+			// When
+			request, err := http.NewRequest(http.MethodPost,
+				"/pods/checkpoint/"+testSandbox.ID()+pathQuery(target), http.NoBody)
+			mux.ServeHTTP(recorder, request)
+
+			// Then
+			Expect(err).ToNot(HaveOccurred())
+			Expect(recorder.Code).To(BeEquivalentTo(http.StatusOK))
+			info := types.PodCheckpointInfo{}
+			Expect(json.Unmarshal(recorder.Body.Bytes(), &info)).To(Succeed())
+			Expect(info.Containers).To(Equal([]string{first.ID(), second.ID()}))
+			Expect(first.State().Status).To(BeEquivalentTo(oci.ContainerStateStopped))
+			Expect(second.State().Status).To(BeEquivalentTo(oci.ContainerStateStopped))
+
+			archiveFile, err := os.Open(target)
+			Expect(err).ToNot(HaveOccurred())
+			defer archiveFile.Close()
+			unpacked := filepath.Join(dir, "unpacked")
+			Expect(archive.Untar(archiveFile, unpacked, &archive.TarOptions{})).To(Succeed())
+			Expect(filepath.Join(unpacked, "containers", first.ID()+".tar")).To(BeARegularFile())
+			Expect(filepath.Join(unpacked, "containers", second.ID()+".tar")).To(BeARegularFile())
+		})
+
+		It("should leave the containers running if requested", func() {
+			// Given
+			addContainerAndSandbox()
+			ctr := addRunningContainer("ctr", time.Now())
+			target := filepath.Join(dir, "pod.tar")
+
+			calls := []*gomock.Call{expectPause(ctr), expectStatusUpdate(ctr)}
+			calls = append(calls, expectCheckpoint(ctr, t.MustTempDir("pod-checkpoint-rootfs"))...)
+			calls = append(calls, expectStatusUpdate(ctr), expectUnpause(ctr), expectStatusUpdate(ctr))
+			gomock.InOrder(calls...)
+
+			// When
+			request, err := http.NewRequest(http.MethodPost,
+				"/pods/checkpoint/"+testSandbox.ID()+pathQuery(target)+"&"+server.InspectCheckpointLeaveRunning+"=true", http.NoBody)
+			mux.ServeHTTP(recorder, request)
+
+			// Then
+			Expect(err).ToNot(HaveOccurred())
+			Expect(recorder.Code).To(BeEquivalentTo(http.StatusOK))
+			Expect(target).To(BeARegularFile())
+			Expect(ctr.State().Status).To(BeEquivalentTo(oci.ContainerStateRunning))
+		})
+
+		It("should keep the containers running if the archive cannot be written", func() {
+			// Given
+			addContainerAndSandbox()
+			ctr := addRunningContainer("ctr", time.Now())
+			target := filepath.Join(dir, "missing", "pod.tar")
+
+			calls := []*gomock.Call{expectPause(ctr), expectStatusUpdate(ctr)}
+			calls = append(calls, expectCheckpoint(ctr, t.MustTempDir("pod-checkpoint-rootfs"))...)
+			calls = append(calls, expectStatusUpdate(ctr), expectUnpause(ctr), expectStatusUpdate(ctr))
+			gomock.InOrder(calls...)
+
+			// When
+			request, err := http.NewRequest(http.MethodPost,
+				"/pods/checkpoint/"+testSandbox.ID()+pathQuery(target), http.NoBody)
+			mux.ServeHTTP(recorder, request)
+
+			// Then
+			Expect(err).ToNot(HaveOccurred())
+			Expect(recorder.Code).To(BeEquivalentTo(http.StatusInternalServerError))
+			Expect(target).NotTo(BeAnExistingFile())
+			Expect(ctr.State().Status).To(BeEquivalentTo(oci.ContainerStateRunning))
+		})
+
+		It("should unpause all containers if a checkpoint fails", func() {
+			// Given
+			addContainerAndSandbox()
+			now := time.Now()
+			first := addRunningContainer("first", now.Add(-time.Minute))
+			second := addRunningContainer("second", now)
+			target := filepath.Join(dir, "pod.tar")
+
+			gomock.InOrder(
+				expectPause(first),
+				expectStatusUpdate(first),
+				expectPause(second),
+				expectStatusUpdate(second),
+				ociRuntimeMock.EXPECT().CheckpointContainer(gomock.Any(), first, gomock.Any(), gomock.Any()).
+					Return(errors.New("checkpoint failed")),
+				expectStatusUpdate(first),
+				expectUnpause(first),
+				expectStatusUpdate(first),
+				expectStatusUpdate(second),
+				expectUnpause(second),
+				expectStatusUpdate(second),
+			)
+
+			// When
+			request, err := http.NewRequest(http.MethodPost,
+				"/pods/checkpoint/"+testSandbox.ID()+pathQuery(target), http.NoBody)
+			mux.ServeHTTP(recorder, request)
+
+			// Then
+			Expect(err).ToNot(HaveOccurred())
+			Expect(recorder.Code).To(BeEquivalentTo(http.StatusInternalServerError))
+			Expect(target).NotTo(BeAnExistingFile())
+			Expect(first.State().Status).To(BeEquivalentTo(oci.ContainerStateRunning))
+			Expect(second.State().Status).To(BeEquivalentTo(oci.ContainerStateRunning))
+		})
+
+		It("should fail without path", func() {
+			// Given
+			addContainerAndSandbox()
+
+			// When
+			request, err := http.NewRequest(http.MethodPost,
+				"/pods/checkpoint/"+testSandbox.ID(), http.NoBody)
+			mux.ServeHTTP(recorder, request)
+
+			// Then
+			Expect(err).ToNot(HaveOccurred())
+			Expect(recorder.Code).To(BeEquivalentTo(http.StatusBadRequest))
+		})
+
+		It("should fail with relative path", func() {
+			// Given
+			addContainerAndSandbox()
+
+			// When
+			request, err := http.NewRequest(http.MethodPost,
+				"/pods/checkpoint/"+testSandbox.ID()+pathQuery("pod.tar"), http.NoBody)
+			mux.ServeHTTP(recorder, request)
+
+			// Then
+			Expect(err).ToNot(HaveOccurred())
+			Expect(recorder.Code).To(BeEquivalentTo(http.StatusBadRequest))
+		})
+
+		It("should fail with invalid pod ID", func() {
+			// Given
+			// When
+			request, err := http.NewRequest(http.MethodPost,
+				"/pods/checkpoint/123"+pathQuery(filepath.Join(dir, "pod.tar")), http.NoBody)
+			mux.ServeHTTP(recorder, request)
+
+			// Then
+			Expect(err).ToNot(HaveOccurred())
+			Expect(recorder.Code).To(BeEquivalentTo(http.StatusNotFound))
+		})
+	})
+
+	t.Describe("restore", func() {
+		// writeArchive writes an archive with the file and its content
+		writeArchive := func(file, content string) string {
+			Expect(os.WriteFile(filepath.Join(dir, file), []byte(content), 0o644)).To(Succeed())
+			input, err := archive.TarWithOptions(dir, &archive.TarOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			defer input.Close()
+			target := filepath.Join(t.MustTempDir("pod-restore"), "pod.tar")
+			outFile, err := os.Create(target)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = outFile.ReadFrom(input)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(outFile.Close()).To(Succeed())
+			return target
+		}
+
+		It("should fail with archive without pod dump", func() {
+			// Given
+			target := writeArchive("file", "content")
+
+			// When
+			request, err := http.NewRequest(http.MethodPost,
+				"/pods/restore"+pathQuery(target), http.NoBody)
+			mux.ServeHTTP(recorder, request)
+
+			// Then
+			Expect(err).ToNot(HaveOccurred())
+			Expect(recorder.Code).To(BeEquivalentTo(http.StatusBadRequest))
+		})
+
+		It("should fail while the checkpointed pod exists", func() {
+			// Given
+			addContainerAndSandbox()
+			target := writeArchive(metadata.PodDumpFile,
+				`{"id":"`+testSandbox.ID()+`","config":{"metadata":{}}}`)
+
+			// When
+			request, err := http.NewRequest(http.MethodPost,
+				"/pods/restore"+pathQuery(target), http.NoBody)
+			mux.ServeHTTP(recorder, request)
+
+			// Then
+			Expect(err).ToNot(HaveOccurred())
+			Expect(recorder.Code).To(BeEquivalentTo(http.StatusConflict))
+		})
+	})
+})
+
+var _ = t.Describe("PodCheckpoint with CheckpointRestore set to false", func() {
+	var (
+		recorder *httptest.ResponseRecorder
+		mux      *chi.Mux
+	)
+
+	// Prepare the sut
+	BeforeEach(func() {
+		beforeEach()
+		mockRuncInLibConfig()
+		serverConfig.SetCheckpointRestore(false)
+		setupSUT()
+
+		recorder = httptest.NewRecorder()
+		mux = sut.GetExtendInterfaceMux(false)
+	})
+	AfterEach(afterEach)
+
+	t.Describe("checkpoint", func() {
+		It("should fail with checkpoint/restore support not available", func() {
+			// Given
+			addContainerAndSandbox()
+			target := filepath.Join(t.MustTempDir("pod-checkpoint"), "pod.tar")
+
+			// When
+			request, err := http.NewRequest(http.MethodPost,
+				"/pods/checkpoint/"+testSandbox.ID()+"?path="+url.QueryEscape(target), http.NoBody)
+			mux.ServeHTTP(recorder, request)
+
+			// Then
+			Expect(err).ToNot(HaveOccurred())
+			Expect(recorder.Code).To(BeEquivalentTo(http.StatusServiceUnavailable))
+			Expect(target).NotTo(BeAnExistingFile())
+		})
+	})
+})
@@ -0,0 +1,95 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	types "k8s.io/cri-api/pkg/apis/runtime/v1"
+)
+
+// fakePodRestoreRuntime records the calls of a pod restore.
+type fakePodRestoreRuntime struct {
+	calls    []string
+	startErr error
+}
+
+func (f *fakePodRestoreRuntime) RunPodSandbox(_ context.Context, req *types.RunPodSandboxRequest) (*types.RunPodSandboxResponse, error) {
+	f.calls = append(f.calls, "run "+req.Config.Metadata.Name+" "+req.RuntimeHandler)
+	return &types.RunPodSandboxResponse{PodSandboxId: "new-pod"}, nil
+}
+
+func (f *fakePodRestoreRuntime) StopPodSandbox(_ context.Context, req *types.StopPodSandboxRequest) (*types.StopPodSandboxResponse, error) {
+	f.calls = append(f.calls, "stop "+req.PodSandboxId)
+	return &types.StopPodSandboxResponse{}, nil
+}
+
+func (f *fakePodRestoreRuntime) RemovePodSandbox(_ context.Context, req *types.RemovePodSandboxRequest) (*types.RemovePodSandboxResponse, error) {
+	f.calls = append(f.calls, "remove "+req.PodSandboxId)
+	return &types.RemovePodSandboxResponse{}, nil
+}
+
+func (f *fakePodRestoreRuntime) CRImportCheckpoint(_ context.Context, createConfig *types.ContainerConfig, sbID, sandboxUID string) (string, error) {
+	name := filepath.Base(createConfig.Image.Image)
+	f.calls = append(f.calls, "import "+name+" "+sbID+" "+sandboxUID)
+	return "new-" + name, nil
+}
+
+func (f *fakePodRestoreRuntime) StartContainer(_ context.Context, req *types.StartContainerRequest) (*types.StartContainerResponse, error) {
+	f.calls = append(f.calls, "start "+req.ContainerId)
+	return &types.StartContainerResponse{}, f.startErr
+}
+
+func testPodCheckpointDump() *podCheckpointDump {
+	return &podCheckpointDump{
+		ID:             "pod",
+		RuntimeHandler: "runc",
+		Config: &types.PodSandboxConfig{
+			Metadata: &types.PodSandboxMetadata{Name: "name", Uid: "uid"},
+		},
+		Containers: []string{"first", "second"},
+	}
+}
+
+func TestRestorePodCheckpoint(t *testing.T) {
+	rt := &fakePodRestoreRuntime{}
+
+	sbID, err := restorePodCheckpoint(context.Background(), rt, testPodCheckpointDump(), "/dir")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sbID != "new-pod" {
+		t.Fatalf("expected sandbox new-pod, got %q", sbID)
+	}
+	expected := []string{
+		"run name runc",
+		"import first.tar new-pod uid",
+		"start new-first.tar",
+		"import second.tar new-pod uid",
+		"start new-second.tar",
+	}
+	if !reflect.DeepEqual(rt.calls, expected) {
+		t.Fatalf("expected calls %q, got %q", expected, rt.calls)
+	}
+}
+
+func TestRestorePodCheckpointFailure(t *testing.T) {
+	rt := &fakePodRestoreRuntime{startErr: errors.New("restore failed")}
+
+	_, err := restorePodCheckpoint(context.Background(), rt, testPodCheckpointDump(), "/dir")
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	expected := []string{
+		"run name runc",
+		"import first.tar new-pod uid",
+		"start new-first.tar",
+		"stop new-pod",
+		"remove new-pod",
+	}
+	if !reflect.DeepEqual(rt.calls, expected) {
+		t.Fatalf("expected calls %q, got %q", expected, rt.calls)
+	}
+}
@@ -0,0 +1,147 @@
+package server
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+	"time"
+
+	imageTypes "github.com/containers/image/v5/types"
+	crioTypes "github.com/cri-o/cri-o/pkg/types"
+	"github.com/opencontainers/go-digest"
+	types "k8s.io/cri-api/pkg/apis/runtime/v1"
+)
+
+// States of a blob of an image pull as reported by the inspect API.
+const (
+	pullLayerStateDownloading = "downloading"
+	pullLayerStateDone        = "done"
+	pullLayerStateSkipped     = "skipped"
+)
+
+// pullProgress records the progress of a pull operation, which is updated
+// from the progress channel of the image copy and exposed via the inspect
+// API.
+type pullProgress struct {
+	sync.Mutex
+	image     string
+	candidate string
+	started   time.Time
+	// layers are the blobs of the current candidate in the order of their
+	// first progress event.
+	layers []*crioTypes.PullLayerInfo
+	// pods are the namespace/name of the pods waiting on the pull.
+	pods []string
+}
+
+func newPullProgress(image string) *pullProgress {
+	return &pullProgress{
+		image:   image,
+		started: time.Now(),
+	}
+}
+
+// addPod records that the pod of the sandbox config waits on the pull. Pulls
+// without a sandbox config, for example from crictl, are not recorded.
+func (p *pullProgress) addPod(sc *types.PodSandboxConfig) {
+	if sc == nil || sc.Metadata == nil {
+		return
+	}
+	pod := sc.Metadata.Namespace + "/" + sc.Metadata.Name
+
+	p.Lock()
+	defer p.Unlock()
+	if !slices.Contains(p.pods, pod) {
+		p.pods = append(p.pods, pod)
+	}
+}
+
+// setCandidate records the image name currently being pulled and drops the
+// layers of a previously tried candidate.
+func (p *pullProgress) setCandidate(candidate string) {
+	p.Lock()
+	defer p.Unlock()
+	p.candidate = candidate
+	p.layers = nil
+}
+
+// update applies an event of the progress channel.
+func (p *pullProgress) update(props *imageTypes.ProgressProperties) {
+	p.Lock()
+	defer p.Unlock()
+
+	layer := p.layer(props.Artifact.Digest)
+	if layer == nil {
+		layer = &crioTypes.PullLayerInfo{
+			Digest: props.Artifact.Digest.String(),
+		}
+		p.layers = append(p.layers, layer)
+	}
+	layer.MediaType = props.Artifact.MediaType
+	layer.Size = props.Artifact.Size
+
+	switch props.Event {
+	case imageTypes.ProgressEventSkipped:
+		layer.State = pullLayerStateSkipped
+	case imageTypes.ProgressEventDone:
+		layer.State = pullLayerStateDone
+		layer.DownloadedBytes = props.Offset
+	default:
+		layer.State = pullLayerStateDownloading
+		layer.DownloadedBytes = props.Offset
+	}
+}
+
+func (p *pullProgress) layer(dgst digest.Digest) *crioTypes.PullLayerInfo {
+	for _, layer := range p.layers {
+		if layer.Digest == dgst.String() {
+			return layer
+		}
+	}
+	return nil
+}
+
+// info returns a snapshot of the progress.
+func (p *pullProgress) info() crioTypes.PullInfo {
+	p.Lock()
+	defer p.Unlock()
+
+	info := crioTypes.PullInfo{
+		Image:     p.image,
+		Candidate: p.candidate,
+		StartTime: p.started.UnixNano(),
+		Layers:    make([]crioTypes.PullLayerInfo, 0, len(p.layers)),
+		Pods:      slices.Clone(p.pods),
+	}
+	if info.Pods == nil {
+		info.Pods = []string{}
+	}
+	for _, layer := range p.layers {
+		info.Layers = append(info.Layers, *layer)
+		info.DownloadedBytes += layer.DownloadedBytes
+		if layer.Size > 0 {
+			info.TotalBytes += layer.Size
+		}
+	}
+	return info
+}
+
+// getPullsInfo returns the progress of all image pulls in progress, sorted by
+// their start time.
+func (s *Server) getPullsInfo() []crioTypes.PullInfo {
+	s.pullOperationsLock.Lock()
+	progresses := make([]*pullProgress, 0, len(s.pullOperationsInProgress))
+	for _, pullOp := range s.pullOperationsInProgress {
+		progresses = append(progresses, pullOp.progress)
+	}
+	s.pullOperationsLock.Unlock()
+
+	res := make([]crioTypes.PullInfo, 0, len(progresses))
+	for _, progress := range progresses {
+		res = append(res, progress.info())
+	}
+	slices.SortFunc(res, func(a, b crioTypes.PullInfo) int {
+		return cmp.Compare(a.StartTime, b.StartTime)
+	})
+	return res
+}
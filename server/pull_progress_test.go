@@ -0,0 +1,121 @@
+package server
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	imageTypes "github.com/containers/image/v5/types"
+	"github.com/cri-o/cri-o/internal/storage/references"
+	crioTypes "github.com/cri-o/cri-o/pkg/types"
+	"github.com/opencontainers/go-digest"
+	types "k8s.io/cri-api/pkg/apis/runtime/v1"
+)
+
+func TestPullProgress(t *testing.T) {
+	const (
+		layer1 = digest.Digest("sha256:1111111111111111111111111111111111111111111111111111111111111111")
+		layer2 = digest.Digest("sha256:2222222222222222222222222222222222222222222222222222222222222222")
+		layer3 = digest.Digest("sha256:3333333333333333333333333333333333333333333333333333333333333333")
+	)
+	sandboxConfig := func(name string) *types.PodSandboxConfig {
+		return &types.PodSandboxConfig{
+			Metadata: &types.PodSandboxMetadata{Name: name, Namespace: "default"},
+		}
+	}
+
+	p := newPullProgress("alpine")
+	p.addPod(sandboxConfig("first"))
+	p.addPod(sandboxConfig("second"))
+	p.addPod(sandboxConfig("first"))
+	p.addPod(nil)
+
+	p.setCandidate("docker.io/library/alpine:latest")
+	p.update(&imageTypes.ProgressProperties{
+		Event:    imageTypes.ProgressEventRead,
+		Artifact: imageTypes.BlobInfo{Digest: layer3, Size: 10},
+		Offset:   5,
+	})
+	p.setCandidate("quay.io/library/alpine:latest")
+	for _, props := range []imageTypes.ProgressProperties{{
+		Event:    imageTypes.ProgressEventNewArtifact,
+		Artifact: imageTypes.BlobInfo{Digest: layer1, Size: 100, MediaType: "layer"},
+	}, {
+		Event:    imageTypes.ProgressEventRead,
+		Artifact: imageTypes.BlobInfo{Digest: layer1, Size: 100, MediaType: "layer"},
+		Offset:   40,
+	}, {
+		Event:    imageTypes.ProgressEventSkipped,
+		Artifact: imageTypes.BlobInfo{Digest: layer2, Size: 50, MediaType: "layer"},
+	}, {
+		Event:    imageTypes.ProgressEventDone,
+		Artifact: imageTypes.BlobInfo{Digest: layer3, Size: -1, MediaType: "config"},
+		Offset:   20,
+	}} {
+		p.update(&props)
+	}
+
+	info := p.info()
+	if info.Image != "alpine" || info.Candidate != "quay.io/library/alpine:latest" {
+		t.Fatalf("unexpected image %q and candidate %q", info.Image, info.Candidate)
+	}
+	if expected := []string{"default/first", "default/second"}; !reflect.DeepEqual(info.Pods, expected) {
+		t.Fatalf("expected pods %v, got %v", expected, info.Pods)
+	}
+	expected := []crioTypes.PullLayerInfo{
+		{Digest: layer1.String(), MediaType: "layer", Size: 100, DownloadedBytes: 40, State: pullLayerStateDownloading},
+		{Digest: layer2.String(), MediaType: "layer", Size: 50, State: pullLayerStateSkipped},
+		{Digest: layer3.String(), MediaType: "config", Size: -1, DownloadedBytes: 20, State: pullLayerStateDone},
+	}
+	if !reflect.DeepEqual(info.Layers, expected) {
+		t.Fatalf("expected layers %v, got %v", expected, info.Layers)
+	}
+	if info.DownloadedBytes != 60 || info.TotalBytes != 150 {
+		t.Fatalf("expected 60/150 bytes, got %d/%d", info.DownloadedBytes, info.TotalBytes)
+	}
+}
+
+func TestGetPullsInfo(t *testing.T) {
+	s := &Server{pullOperationsInProgress: make(map[pullArguments]*pullOperation)}
+	if pulls := s.getPullsInfo(); len(pulls) != 0 {
+		t.Fatalf("expected no pulls, got %v", pulls)
+	}
+
+	first := newPullProgress("first")
+	second := newPullProgress("second")
+	second.started = first.started.Add(1)
+	s.pullOperationsInProgress[pullArguments{image: "second"}] = &pullOperation{progress: second}
+	s.pullOperationsInProgress[pullArguments{image: "first"}] = &pullOperation{progress: first}
+
+	pulls := s.getPullsInfo()
+	if len(pulls) != 2 || pulls[0].Image != "first" || pulls[1].Image != "second" {
+		t.Fatalf("expected the pulls sorted by start time, got %v", pulls)
+	}
+}
+
+func TestConsumeImagePullProgressWithoutTimeout(t *testing.T) {
+	ref, err := references.ParseRegistryImageReferenceFromOutOfProcessData("docker.io/library/alpine:latest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	progress := make(chan imageTypes.ProgressProperties)
+	done := make(chan struct{})
+	go func() {
+		consumeImagePullProgress(ctx, cancel, progress, ref, newPullProgress("alpine"), 0)
+		close(done)
+	}()
+
+	for range 3 {
+		progress <- imageTypes.ProgressProperties{Event: imageTypes.ProgressEventRead}
+		time.Sleep(10 * time.Millisecond)
+	}
+	close(progress)
+	<-done
+
+	if err := ctx.Err(); err != nil {
+		t.Fatalf("expected the pull not to be cancelled without timeout, got %v", err)
+	}
+}
@@ -0,0 +1,194 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/cri-o/cri-o/internal/lib/sandbox"
+	"github.com/cri-o/cri-o/internal/log"
+	"github.com/cri-o/cri-o/internal/oci"
+	types "k8s.io/cri-api/pkg/apis/runtime/v1"
+)
+
+const (
+	// runtimeHandlerReadyCondition is the prefix of the runtime condition
+	// type reported for every runtime handler, which is followed by a slash
+	// and the name of the runtime handler.
+	runtimeHandlerReadyCondition = "RuntimeHandlerReady"
+
+	// runtimeHandlerUnhealthyReason is the reason reported for an unhealthy
+	// runtime handler.
+	runtimeHandlerUnhealthyReason = "RuntimeHandlerUnhealthy"
+)
+
+// runtimeHealth holds the results of the latest runtime handler health
+// checks. Runtime handlers which have not been checked yet are considered to
+// be healthy.
+type runtimeHealth struct {
+	sync.RWMutex
+	// enabled is true if the health checks are running.
+	enabled bool
+	// unhealthy maps the names of unhealthy runtime handlers to the error of
+	// their latest health check.
+	unhealthy map[string]error
+}
+
+// startRuntimeHealthChecks checks the health of all runtime handlers in the
+// background until the server shuts down.
+func (s *Server) startRuntimeHealthChecks(ctx context.Context) error {
+	interval, err := s.config.ParseRuntimeHealthCheckInterval()
+	if err != nil {
+		return fmt.Errorf("parse runtime health check interval: %w", err)
+	}
+	if interval <= 0 {
+		log.Debugf(ctx, "Runtime handler health checks are disabled")
+		return nil
+	}
+	log.Infof(ctx, "Starting runtime handler health checks every %v", interval)
+
+	s.runtimeHealth.Lock()
+	s.runtimeHealth.enabled = true
+	s.runtimeHealth.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			s.checkRuntimeHandlers(ctx)
+			select {
+			case <-ticker.C:
+			case <-s.monitorsChan:
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// checkRuntimeHandlers runs the health checks of all runtime handlers and
+// records their results.
+func (s *Server) checkRuntimeHandlers(ctx context.Context) {
+	containers := s.runningContainersByRuntimeHandler()
+
+	unhealthy := make(map[string]error)
+	for name := range s.config.Runtimes {
+		if err := s.checkRuntimeHandler(ctx, name, containers[name]); err != nil {
+			unhealthy[name] = err
+		}
+	}
+
+	s.runtimeHealth.Lock()
+	defer s.runtimeHealth.Unlock()
+	for name, err := range unhealthy {
+		if _, ok := s.runtimeHealth.unhealthy[name]; !ok {
+			log.Warnf(ctx, "Runtime handler %s became unhealthy: %v", name, err)
+		}
+	}
+	for name := range s.runtimeHealth.unhealthy {
+		if _, ok := unhealthy[name]; !ok {
+			log.Infof(ctx, "Runtime handler %s recovered", name)
+		}
+	}
+	s.runtimeHealth.unhealthy = unhealthy
+}
+
+// checkRuntimeHandler checks the binaries of the runtime handler and the
+// connections to the processes serving its running containers. A single
+// container with a broken connection, for example because it is just exiting,
+// does not render the runtime handler unhealthy. Only if none of the
+// connections work the runtime handler is considered to be broken.
+func (s *Server) checkRuntimeHandler(ctx context.Context, name string, containers []*oci.Container) error {
+	if err := s.Runtime().CheckRuntimeHandlerHealth(ctx, name); err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, c := range containers {
+		err := s.Runtime().CheckContainerRuntimeHealth(ctx, c)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// runningContainersByRuntimeHandler returns the running containers, including
+// the infra containers, grouped by the name of their runtime handler.
+func (s *Server) runningContainersByRuntimeHandler() map[string][]*oci.Container {
+	res := make(map[string][]*oci.Container)
+	for _, sb := range s.ContainerServer.ListSandboxes() {
+		name := s.runtimeHandlerName(sb)
+		containers := sb.Containers().List()
+		if infra := sb.InfraContainer(); infra != nil {
+			containers = append(containers, infra)
+		}
+		for _, c := range containers {
+			if c.State().Status == oci.ContainerStateRunning {
+				res[name] = append(res[name], c)
+			}
+		}
+	}
+	return res
+}
+
+// runtimeHandlerName returns the name of the runtime handler of the sandbox,
+// which is the default runtime handler if the sandbox does not request one.
+func (s *Server) runtimeHandlerName(sb *sandbox.Sandbox) string {
+	if name := sb.RuntimeHandler(); name != "" {
+		return name
+	}
+	return s.config.DefaultRuntime
+}
+
+// runtimeHandlerHealthy returns an error if the latest health check of the
+// runtime handler failed. An empty name refers to the default runtime
+// handler.
+func (s *Server) runtimeHandlerHealthy(name string) error {
+	if name == "" {
+		name = s.config.DefaultRuntime
+	}
+
+	s.runtimeHealth.RLock()
+	defer s.runtimeHealth.RUnlock()
+	if err, ok := s.runtimeHealth.unhealthy[name]; ok {
+		return fmt.Errorf("runtime handler %s is unhealthy, new sandboxes are rejected until it recovers: %w", name, err)
+	}
+	return nil
+}
+
+// runtimeHandlerConditions returns a runtime condition for every runtime
+// handler, sorted by name. No conditions are returned if the health checks are
+// disabled.
+func (s *Server) runtimeHandlerConditions() []*types.RuntimeCondition {
+	s.runtimeHealth.RLock()
+	defer s.runtimeHealth.RUnlock()
+	if !s.runtimeHealth.enabled {
+		return nil
+	}
+
+	names := make([]string, 0, len(s.config.Runtimes))
+	for name := range s.config.Runtimes {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	conditions := make([]*types.RuntimeCondition, 0, len(names))
+	for _, name := range names {
+		condition := &types.RuntimeCondition{
+			Type:   runtimeHandlerReadyCondition + "/" + name,
+			Status: true,
+		}
+		if err, ok := s.runtimeHealth.unhealthy[name]; ok {
+			condition.Status = false
+			condition.Reason = runtimeHandlerUnhealthyReason
+			condition.Message = err.Error()
+		}
+		conditions = append(conditions, condition)
+	}
+	return conditions
+}
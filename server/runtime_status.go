@@ -28,12 +28,18 @@ func (s *Server) Status(ctx context.Context, req *types.StatusRequest) (*types.S
 		networkCondition.Message = fmt.Sprintf("Network plugin returns error: %v", err)
 	}
 
+	// The health of the runtime handlers is only reported by their own
+	// conditions, because the kubelet marks the node as not ready if the
+	// runtime is not ready.
+	conditions := append([]*types.RuntimeCondition{
+		runtimeCondition,
+		networkCondition,
+	}, s.runtimeHandlerConditions()...)
+	conditions = append(conditions, s.prePullConditions()...)
+
 	resp := &types.StatusResponse{
 		Status: &types.RuntimeStatus{
-			Conditions: []*types.RuntimeCondition{
-				runtimeCondition,
-				networkCondition,
-			},
+			Conditions: conditions,
 		},
 	}
 
@@ -78,5 +84,12 @@ func (s *Server) createRuntimeInfo() (map[string]string, error) {
 	if err != nil {
 		return nil, fmt.Errorf("marshal data: %w", err)
 	}
-	return map[string]string{"config": string(bytes)}, nil
+	runtimeHandlers, err := json.Marshal(s.config.RuntimeHandlerCapabilities())
+	if err != nil {
+		return nil, fmt.Errorf("marshal runtime handlers: %w", err)
+	}
+	return map[string]string{
+		"config":          string(bytes),
+		"runtimeHandlers": string(runtimeHandlers),
+	}, nil
 }
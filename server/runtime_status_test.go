@@ -3,6 +3,7 @@ package server_test
 import (
 	"context"
 
+	"github.com/cri-o/cri-o/pkg/config"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	types "k8s.io/cri-api/pkg/apis/runtime/v1"
@@ -47,6 +48,44 @@ var _ = t.Describe("Status", func() {
 			}
 		})
 
+		It("should report unhealthy runtime handlers", func() {
+			// Given
+			afterEach()
+			beforeEach()
+			mockRuncInLibConfig()
+			serverConfig.RuntimeHealthCheckInterval = "1h"
+			serverConfig.Runtimes["broken"] = &config.RuntimeHandler{
+				RuntimePath: "/not/existing",
+			}
+			setupSUT()
+
+			// When
+			var conditions []*types.RuntimeCondition
+			Eventually(func() bool {
+				response, err := sut.Status(context.Background(),
+					&types.StatusRequest{})
+				Expect(err).ToNot(HaveOccurred())
+				conditions = response.Status.Conditions
+				for _, condition := range conditions {
+					if condition.Type == "RuntimeHandlerReady/broken" {
+						return condition.Status
+					}
+				}
+				return true
+			}).Should(BeFalse())
+
+			// Then
+			Expect(conditions).To(HaveLen(4))
+			for _, condition := range conditions {
+				if condition.Type == "RuntimeHandlerReady/broken" {
+					Expect(condition.Reason).To(Equal("RuntimeHandlerUnhealthy"))
+					Expect(condition.Message).To(ContainSubstring("/not/existing"))
+				} else {
+					Expect(condition.Status).To(BeTrue())
+				}
+			}
+		})
+
 		It("should return info as part of a verbose response", func() {
 			// When
 			response, err := sut.Status(context.Background(),
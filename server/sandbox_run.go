@@ -47,16 +47,19 @@ func (s *Server) privilegedSandbox(req *types.RunPodSandboxRequest) bool {
 }
 
 // runtimeHandler returns the runtime handler key provided by CRI if the key
-// does exist and the associated data are valid. If the key is empty, there
-// is nothing to do, and the empty key is returned. For every other case, this
-// function will return an empty string with the error associated.
+// does exist, the associated data are valid and the runtime handler is
+// healthy. If the key is empty, only the health of the default runtime
+// handler is checked, and the empty key is returned. For every other case,
+// this function will return an empty string with the error associated.
 func (s *Server) runtimeHandler(req *types.RunPodSandboxRequest) (string, error) {
 	handler := req.RuntimeHandler
-	if handler == "" {
-		return handler, nil
+	if handler != "" {
+		if _, err := s.Runtime().ValidateRuntimeHandler(handler); err != nil {
+			return "", err
+		}
 	}
 
-	if _, err := s.Runtime().ValidateRuntimeHandler(handler); err != nil {
+	if err := s.runtimeHandlerHealthy(handler); err != nil {
 		return "", err
 	}
 
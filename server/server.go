@@ -22,7 +22,9 @@ import (
 	"github.com/containers/storage/pkg/idtools"
 	storageTypes "github.com/containers/storage/types"
 	"github.com/cri-o/cri-o/internal/config/seccomp"
+	"github.com/cri-o/cri-o/internal/eventjournal"
 	"github.com/cri-o/cri-o/internal/hostport"
+	"github.com/cri-o/cri-o/internal/imagegc"
 	"github.com/cri-o/cri-o/internal/lib"
 	"github.com/cri-o/cri-o/internal/lib/sandbox"
 	"github.com/cri-o/cri-o/internal/log"
@@ -90,6 +92,25 @@ type Server struct {
 
 	containerEventClients           sync.Map
 	containerEventStreamBroadcaster sync.Once
+	// containerEventsJournal persists the broadcasted container events to
+	// be able to replay them to reconnecting clients.
+	containerEventsJournal *eventjournal.Journal
+	// containerEventsLock serializes journaling events and collecting the
+	// clients to send them to with the registration of new event clients.
+	containerEventsLock sync.Mutex
+
+	// imageGC is the built-in image garbage collector.
+	imageGC *imagegc.GarbageCollector
+
+	// debugOverrides are the temporary debug setting changes done via the
+	// inspect API.
+	debugOverrides debugOverrides
+
+	// runtimeHealth are the results of the runtime handler health checks.
+	runtimeHealth runtimeHealth
+
+	// prePull are the pinned images which are pulled in the background.
+	prePull prePullState
 
 	// NRI runtime interface
 	nri *nriAPI
@@ -102,6 +123,9 @@ type pullArguments struct {
 	sandboxCgroup string
 	credentials   imageTypes.DockerAuthConfig
 	namespace     string
+	// authFile overrides the global_auth_file, for example for the
+	// pause_image_auth_file.
+	authFile string
 }
 
 // pullOperation is used to synchronize parallel pull operations via the
@@ -116,6 +140,8 @@ type pullOperation struct {
 	imageRef string
 	// err is the error indicating if the pull operation has succeeded or not.
 	err error
+	// progress is the progress of the pull operation.
+	progress *pullProgress
 }
 
 type certConfigCache struct {
@@ -453,7 +479,14 @@ func New(
 	}
 	if s.config.EnablePodEvents {
 		// creating a container events channel only if the evented pleg is enabled
-		s.ContainerEventsChan = make(chan types.ContainerEventResponse, 1000)
+		s.ContainerEventsChan = make(chan types.ContainerEventResponse, containerEventsChanSize)
+		s.containerEventsJournal, err = eventjournal.New(
+			containerEventsJournalDir(config.ContainerExitsDir),
+			containerEventsJournalMaxEntries,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("open container events journal: %w", err)
+		}
 	}
 	if err := configureMaxThreads(); err != nil {
 		return nil, err
@@ -472,6 +505,7 @@ func New(
 
 	deletedImages := s.restore(ctx)
 	s.wipeIfAppropriate(ctx, deletedImages)
+	s.recoverContainerEvents(ctx)
 
 	var bindAddressStr string
 	bindAddress := net.ParseIP(config.StreamAddress)
@@ -544,6 +578,20 @@ func New(
 		logrus.Debug("Metrics are disabled")
 	}
 
+	s.imageGC, err = s.newImageGC()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.startImageGC(ctx); err != nil {
+		return nil, err
+	}
+
+	if err := s.startRuntimeHealthChecks(ctx); err != nil {
+		return nil, err
+	}
+
+	s.prePullPinnedImages(ctx)
+
 	if err := s.startSeccompNotifierWatcher(ctx); err != nil {
 		return nil, fmt.Errorf("start seccomp notifier watcher: %w", err)
 	}
@@ -583,6 +631,7 @@ func (s *Server) startReloadWatcher(ctx context.Context) {
 			// ImageServer compiles the list with regex for both
 			// pinned and sandbox/pause images, we need to update them
 			s.StorageImageServer().UpdatePinnedImagesList(append(s.config.PinnedImages, s.config.PauseImage))
+			s.prePullPinnedImages(ctx)
 			logrus.Info("Configuration reload completed")
 			// Print the current configuration.
 			tomlConfig, err := s.config.ToString()
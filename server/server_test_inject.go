@@ -7,6 +7,7 @@
 package server
 
 import (
+	"github.com/cri-o/cri-o/internal/imagegc"
 	"github.com/cri-o/ocicni/pkg/ocicni"
 )
 
@@ -20,3 +21,8 @@ func (s *StreamService) SetRuntimeServer(server *Server) {
 func (s *Server) SetCNIPlugin(plugin ocicni.CNIPlugin) error {
 	return s.config.SetCNIPlugin(plugin)
 }
+
+// SetImageGC sets the image garbage collector of the server.
+func (s *Server) SetImageGC(gc *imagegc.GarbageCollector) {
+	s.imageGC = gc
+}
@@ -145,6 +145,7 @@ var beforeEach = func() {
 	serverConfig.LogDir = path.Join(testPath, "log")
 	serverConfig.CleanShutdownFile = path.Join(testPath, "clean.shutdown")
 	serverConfig.EnablePodEvents = true
+	serverConfig.RuntimeHealthCheckInterval = ""
 
 	// We want a directory that is guaranteed to exist, but it must
 	// be empty so we don't erroneously load anything and make tests
@@ -143,3 +143,23 @@ function teardown() {
 	[[ "$container_name" == "restored-sleep-container" ]]
 	[[ "$pod_name" == "restoresandbox2" ]]
 }
+
+@test "checkpoint and restore a pod with crio status" {
+	CONTAINER_ENABLE_CRIU_SUPPORT=true start_crio
+	pod_id=$(crictl runp "$TESTDATA"/sandbox_config.json)
+	ctr_id=$(crictl create "$pod_id" "$TESTDATA"/container_sleep.json "$TESTDATA"/sandbox_config.json)
+	crictl start "$ctr_id"
+	run -0 "${CRIO_BINARY_PATH}" status --socket="${CRIO_SOCKET}" pods --id "$pod_id" --checkpoint "$TESTDIR"/pod.tar
+	[[ "$output" == *"containers: $ctr_id"* ]]
+	[[ $(crictl inspect -o go-template --template '{{.status.state}}' "$ctr_id") == "CONTAINER_EXITED" ]]
+	# the checkpointed pod has to be removed before restoring it
+	run ! "${CRIO_BINARY_PATH}" status --socket="${CRIO_SOCKET}" pods --restore "$TESTDIR"/pod.tar
+	crictl rmp -f "$pod_id"
+	run -0 "${CRIO_BINARY_PATH}" status --socket="${CRIO_SOCKET}" pods --restore "$TESTDIR"/pod.tar
+	new_pod_id=$(echo "$output" | sed -n 's/^id: //p')
+	[[ "$new_pod_id" != "$pod_id" ]]
+	new_ctr_id=$(crictl ps -q --pod "$new_pod_id")
+	[[ -n "$new_ctr_id" ]]
+	restored=$(crictl inspect --output go-template --template "{{(index .info.restored)}}" "$new_ctr_id")
+	[[ "$restored" == "true" ]]
+}
@@ -156,3 +156,111 @@ EOF
 
 	stop_crio
 }
+
+@test "container disk metrics" {
+	CONTAINER_ENABLE_METRICS="true" setup_crio
+	cat << EOF > "$CRIO_CONFIG"
+[crio.stats]
+collection_period = 0
+included_pod_metrics = [
+    "network",
+    "disk",
+    "diskio",
+]
+EOF
+	start_crio_no_setup
+	check_images
+
+	metrics_setup
+
+	cmd='dd if=/dev/zero of=/testfile bs=1M count=10 && sync'
+	crictl exec --sync "$CONTAINER_ID" /bin/sh -c "$cmd"
+
+	metrics=$(crictl metricsp)
+
+	# assert the writable layer usage includes the written file
+	metrics_fs_usage=$(echo "$metrics" | jq '.podMetrics[0].containerMetrics[0].metrics[] | select(.name == "container_fs_usage_bytes") | .value.value | tonumber')
+	[[ $metrics_fs_usage -ge $((10 * 1024 * 1024)) ]]
+
+	# assert the filesystem capacity is reported
+	metrics_fs_limit=$(echo "$metrics" | jq '.podMetrics[0].containerMetrics[0].metrics[] | select(.name == "container_fs_limit_bytes") | .value.value | tonumber')
+	[[ $metrics_fs_limit -ge $metrics_fs_usage ]]
+	metrics_inodes_total=$(echo "$metrics" | jq '.podMetrics[0].containerMetrics[0].metrics[] | select(.name == "container_fs_inodes_total") | .value.value | tonumber')
+	[[ $metrics_inodes_total -gt 0 ]]
+
+	# assert block I/O metrics are present if the container did block I/O
+	set_container_pod_cgroup_root "" "$CONTAINER_ID"
+	if is_cgroup_v2 && [ -s "$CTR_CGROUP"/io.stat ]; then
+		echo "$metrics" | jq -e '.podMetrics[0].containerMetrics[0].metrics[] | select(.name == "container_blkio_device_usage_total")'
+		echo "$metrics" | jq -e '.podMetrics[0].containerMetrics[0].metrics[] | select(.name == "container_fs_sector_writes_total")'
+	fi
+
+	stop_crio
+}
+
+@test "container pressure metrics" {
+	if ! is_cgroup_v2; then
+		skip "pressure stall information requires cgroup v2"
+	fi
+	CONTAINER_ENABLE_METRICS="true" setup_crio
+	cat << EOF > "$CRIO_CONFIG"
+[crio.stats]
+collection_period = 0
+included_pod_metrics = [
+    "network",
+    "pressure",
+]
+EOF
+	start_crio_no_setup
+	check_images
+
+	metrics_setup
+
+	set_container_pod_cgroup_root "" "$CONTAINER_ID"
+	if [ ! -f "$CTR_CGROUP"/cpu.pressure ]; then
+		skip "pressure stall information is not enabled"
+	fi
+
+	metrics=$(crictl metricsp)
+	echo "$metrics" | jq -e '.podMetrics[0].containerMetrics[0].metrics[] | select(.name == "container_pressure_cpu_waiting_seconds_total")'
+	echo "$metrics" | jq -e '.podMetrics[0].containerMetrics[0].metrics[] | select(.name == "container_pressure_memory_stalled_seconds_total")'
+
+	# assert the pressure stall information is part of the verbose status
+	crictl inspect "$CONTAINER_ID" | jq -e '.info.pressure.cpu.some.total'
+
+	stop_crio
+}
+
+@test "container task state metrics" {
+	CONTAINER_ENABLE_METRICS="true" setup_crio
+	cat << EOF > "$CRIO_CONFIG"
+[crio.stats]
+collection_period = 0
+included_pod_metrics = [
+    "network",
+    "cpuLoad",
+    "hugetlb",
+]
+EOF
+	start_crio_no_setup
+	check_images
+
+	metrics_setup
+
+	metrics=$(crictl metricsp)
+
+	# assert the container process is counted as sleeping or running
+	tasks=$(echo "$metrics" | jq '[.podMetrics[0].containerMetrics[0].metrics[] | select(.name == "container_tasks_state") | .value.value | tonumber] | add')
+	[[ $tasks -ge 1 ]]
+	echo "$metrics" | jq -e '.podMetrics[0].containerMetrics[0].metrics[] | select(.name == "container_tasks_state" and .labelValues[-1] == "uninterruptible")'
+
+	# assert hugepage metrics are reported if the hugetlb controller is enabled
+	if [ -n "$(find /sys/kernel/mm/hugepages -mindepth 1 -maxdepth 1 2> /dev/null)" ]; then
+		set_container_pod_cgroup_root "hugetlb" "$CONTAINER_ID"
+		if compgen -G "$CTR_CGROUP/hugetlb.*" > /dev/null; then
+			echo "$metrics" | jq -e '.podMetrics[0].containerMetrics[0].metrics[] | select(.name == "container_hugetlb_usage_bytes")'
+		fi
+	fi
+
+	stop_crio
+}
@@ -105,6 +105,27 @@ function create_test_rro_mounts() {
 	[ "$output" == "2048" ]
 }
 
+@test "ulimits with builtin NRI plugin" {
+	cat << EOF > "$CRIO_CONFIG_DIR/01-nri-builtin.conf"
+[crio.nri.builtin_plugins.default_ulimits]
+config = "nofile=42:42, nproc=1024:2048"
+EOF
+	OVERRIDE_OPTIONS="--default-ulimits nofile=24:24" start_crio
+
+	jq '	  .command = ["/bin/sh", "-c", "sleep 600"]' \
+		"$TESTDATA"/container_config.json > "$newconfig"
+	ctr_id=$(crictl run "$newconfig" "$TESTDATA"/sandbox_config.json)
+
+	output=$(crictl exec --sync "$ctr_id" sh -c "ulimit -n")
+	[ "$output" == "42" ]
+
+	output=$(crictl exec --sync "$ctr_id" sh -c "ulimit -u")
+	[ "$output" == "1024" ]
+
+	output=$(crictl exec --sync "$ctr_id" sh -c "ulimit -Hu")
+	[ "$output" == "2048" ]
+}
+
 @test "ctr remove" {
 	start_crio
 	ctr_id=$(crictl run "$TESTDATA"/container_redis.json "$TESTDATA"/sandbox_config.json)
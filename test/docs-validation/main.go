@@ -30,6 +30,7 @@ var (
 		"plugin_dir",                  // deprecated
 		"runtimes",                    // printed as separate table
 		"workloads",                   // printed as separate table
+		"builtin_plugins",             // printed as separate table
 		"manage_network_ns_lifecycle", // deprecated
 	}
 
@@ -43,7 +44,8 @@ var (
 
 	// Tags where it should not validate the values
 	excludedCLI = []string{
-		"workloads", // too complex an option for a CLI flag
+		"workloads",       // too complex an option for a CLI flag
+		"builtin_plugins", // too complex an option for a CLI flag
 	}
 
 	// Mapping for inconsistencies between tags and CLI arguments
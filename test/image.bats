@@ -98,6 +98,65 @@ function teardown() {
 	cleanup_images
 }
 
+@test "image pull with resumable pulls" {
+	CONTAINER_RESUMABLE_PULLS=true start_crio
+	crictl pull "$IMAGE"
+	imageid=$(crictl images --quiet "$IMAGE")
+	[ "$imageid" != "" ]
+
+	# the partial blobs are removed after a successful pull
+	[ -d "$TESTDIR/crio/crio-partial-blobs" ]
+	[ -z "$(ls -A "$TESTDIR/crio/crio-partial-blobs")" ]
+	cleanup_images
+}
+
+@test "image pull with namespaced registries configuration" {
+	mkdir -p "$TESTDIR/registries/blocked" "$TESTDIR/registries/allowed"
+	cat << EOF > "$TESTDIR/registries/blocked/registries.conf"
+[[registry]]
+location = "quay.io/crio"
+blocked = true
+EOF
+	BLOCKED_SANDBOX_CONFIG="$TESTDIR/blocked.json"
+	jq '.metadata.namespace = "blocked"' "$TESTDATA/sandbox_config.json" > "$BLOCKED_SANDBOX_CONFIG"
+	ALLOWED_SANDBOX_CONFIG="$TESTDIR/allowed.json"
+	jq '.metadata.namespace = "allowed"' "$TESTDATA/sandbox_config.json" > "$ALLOWED_SANDBOX_CONFIG"
+	CONTAINER_NAMESPACED_REGISTRIES_DIR="$TESTDIR/registries" start_crio
+
+	run ! crictl pull --pod-config "$BLOCKED_SANDBOX_CONFIG" "$IMAGE"
+	crictl pull --pod-config "$ALLOWED_SANDBOX_CONFIG" "$IMAGE"
+	crictl rmi "$IMAGE"
+
+	# the namespaced configurations are reloaded with the system wide one
+	cp "$TESTDIR/registries/blocked/registries.conf" "$TESTDIR/registries/allowed/registries.conf"
+	reload_crio
+	wait_for_log "Applied new registry configuration of namespace allowed"
+	run ! crictl pull --pod-config "$ALLOWED_SANDBOX_CONFIG" "$IMAGE"
+}
+
+@test "image pull of pinned images at startup" {
+	cat << EOF > "$CRIO_CONFIG_DIR"/99-pinned-images.conf
+[crio.image]
+pinned_images = ["$IMAGE", "quay.io/crio/*"]
+EOF
+	CONTAINER_PRE_PULL_PINNED_IMAGES=true start_crio
+	wait_for_log "Pinned image $IMAGE is available"
+	crictl info | jq -e '.status.conditions[] | select(.type == "PinnedImagesReady") | .status == true'
+	imageid=$(crictl images --quiet "$IMAGE")
+	[ "$imageid" != "" ]
+
+	# new pinned images are pulled after a reload
+	cat << EOF > "$CRIO_CONFIG_DIR"/99-pinned-images.conf
+[crio.image]
+pinned_images = ["$IMAGE", "$IMAGE_LIST_TAG"]
+EOF
+	reload_crio
+	wait_for_log "Pinned image $IMAGE_LIST_TAG is available"
+	imageid=$(crictl images --quiet "$IMAGE_LIST_TAG")
+	[ "$imageid" != "" ]
+	cleanup_images
+}
+
 @test "image pull and list using imagestore" {
 	# Start crio with imagestore
 	mkdir -p "$TESTDIR/imagestore"
@@ -16,6 +16,7 @@ function teardown() {
 	echo "$out"
 	[[ "$out" == *"\"cgroup_driver\":\"$CONTAINER_CGROUP_MANAGER\""* ]]
 	[[ "$out" == *"\"storage_root\":\"$TESTDIR/crio\""* ]]
+	[[ "$out" == *"\"runtime_handlers\":[{\"name\":"* ]]
 }
 
 @test "ctr inspect" {
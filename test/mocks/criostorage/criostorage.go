@@ -171,6 +171,20 @@ func (mr *MockImageServerMockRecorder) PullImage(arg0, arg1, arg2 interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PullImage", reflect.TypeOf((*MockImageServer)(nil).PullImage), arg0, arg1, arg2)
 }
 
+// RecordImageUsage mocks base method.
+func (m *MockImageServer) RecordImageUsage(arg0 storage0.StorageImageID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordImageUsage", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordImageUsage indicates an expected call of RecordImageUsage.
+func (mr *MockImageServerMockRecorder) RecordImageUsage(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordImageUsage", reflect.TypeOf((*MockImageServer)(nil).RecordImageUsage), arg0)
+}
+
 // UntagImage mocks base method.
 func (m *MockImageServer) UntagImage(arg0 *types.SystemContext, arg1 references.RegistryImageReference) error {
 	m.ctrl.T.Helper()
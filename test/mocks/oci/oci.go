@@ -56,7 +56,7 @@ func (mr *MockRuntimeImplMockRecorder) AttachContainer(arg0, arg1, arg2, arg3, a
 }
 
 // CheckpointContainer mocks base method.
-func (m *MockRuntimeImpl) CheckpointContainer(arg0 context.Context, arg1 *oci.Container, arg2 *specs.Spec, arg3 bool) error {
+func (m *MockRuntimeImpl) CheckpointContainer(arg0 context.Context, arg1 *oci.Container, arg2 *specs.Spec, arg3 *oci.CheckpointOptions) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "CheckpointContainer", arg0, arg1, arg2, arg3)
 	ret0, _ := ret[0].(error)
@@ -390,3 +390,34 @@ function teardown() {
 	output=$(crictl exec --sync "$ctr_id" ls -ld /etc)
 	[[ "$output" == *"test test"* ]]
 }
+
+@test "pod should be rejected on unhealthy runtime handler" {
+	BROKEN_RUNTIME_BINARY_PATH="$TESTDIR"/broken-runtime
+	cat << EOF > "$BROKEN_RUNTIME_BINARY_PATH"
+#!/usr/bin/env bash
+exec $RUNTIME_BINARY_PATH "\$@"
+EOF
+	chmod 755 "$BROKEN_RUNTIME_BINARY_PATH"
+
+	cat << EOF > "$CRIO_CONFIG_DIR"/99-broken-runtime.conf
+[crio.runtime]
+runtime_health_check_interval = "1s"
+[crio.runtime.runtimes.broken]
+runtime_path = "$BROKEN_RUNTIME_BINARY_PATH"
+EOF
+	start_crio
+
+	# a healthy runtime handler is reported as ready
+	crictl info | jq -e '.status.conditions[] | select(.type == "RuntimeHandlerReady/broken") | .status == true'
+
+	# break the runtime handler
+	rm "$BROKEN_RUNTIME_BINARY_PATH"
+	wait_for_log "Runtime handler broken became unhealthy"
+
+	crictl info | jq -e '.status.conditions[] | select(.type == "RuntimeHandlerReady/broken") | .status == false'
+	run ! crictl runp -r broken "$TESTDATA"/sandbox_config.json
+	[[ "$output" == *"runtime handler broken is unhealthy"* ]]
+
+	# other runtime handlers are not affected
+	crictl runp "$TESTDATA"/sandbox_config.json
+}
@@ -33,12 +33,24 @@ function teardown() {
 
 	# then
 	[[ "$output" == *"storage driver"* ]]
+	[[ "$output" == *"runtime handlers:"* ]]
+	[[ "$output" == *"checkpoint/restore:"* ]]
 }
 
 @test "status should fail to retrieve the info with invalid socket" {
 	run -1 "${CRIO_BINARY_PATH}" status --socket wrong.sock i
 }
 
+@test "status should succeed to retrieve the pulls" {
+	# when
+	run -0 curl --silent --fail --show-error --unix-socket "$CRIO_SOCKET" http://localhost/pulls
+
+	# then
+	[[ "$output" == "[]" ]]
+	run -0 "${CRIO_BINARY_PATH}" status --socket="${CRIO_SOCKET}" pulls
+	[[ "$output" == "" ]]
+}
+
 @test "succeed to retrieve the container info" {
 	# given
 	pod=$(crictl runp "$TESTDATA"/sandbox_config.json)
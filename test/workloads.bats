@@ -431,3 +431,33 @@ function check_conmon_fields() {
 	ctr_id=$(crictl run "$ctrconfig" "$sboxconfig")
 	[[ $(systemctl show --property CollectMode crio-"$ctr_id".scope) == "CollectMode=inactive-or-failed" ]]
 }
+
+@test "test workload can override memory and pids defaults" {
+	name=helloctr
+	cat << EOF > "$CRIO_CONFIG_DIR/01-workload.conf"
+[crio.runtime.workloads.management]
+activation_annotation = "$activation"
+annotation_prefix = "$prefix"
+[crio.runtime.workloads.management.resources]
+memorylimit = 536870912
+pidslimit = 1024
+EOF
+
+	start_crio
+
+	jq --arg act "$activation" --arg set '{"memorylimit": 268435456}' --arg setkey "$prefix/$name" \
+		'   .annotations[$act] = "true"
+		|   .annotations[$setkey] = $set' \
+		"$TESTDATA"/sandbox_config.json > "$sboxconfig"
+
+	jq --arg act "$activation" --arg name "$name" \
+		'   .annotations[$act] = "true"
+		|   .metadata.name = $name' \
+		"$TESTDATA"/container_sleep.json > "$ctrconfig"
+
+	ctr_id=$(crictl run "$ctrconfig" "$sboxconfig")
+	config=$(runtime state "$ctr_id" | jq -r .bundle)/config.json
+
+	[[ $(jq .linux.resources.memory.limit < "$config") == 268435456 ]]
+	[[ $(jq .linux.resources.pids.limit < "$config") == 1024 ]]
+}